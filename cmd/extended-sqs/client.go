@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging"
+	logging_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging/constants"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_session "github.com/aws/aws-sdk-go/aws/session"
+	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// Flags shared by the commands talking to aws
+type awsFlags struct {
+	region   string
+	endpoint string
+	bucket   string
+	prefix   string
+	verbose  bool
+}
+
+func (f *awsFlags) register(flags *flag.FlagSet) {
+	flags.StringVar(&f.region, "region", "", "Region of the queue and the bucket, taken from the environment by default")
+	flags.StringVar(&f.endpoint, "endpoint", "", "Endpoint of sqs and s3, e.g. of localstack")
+	flags.StringVar(&f.bucket, "bucket", "", "Bucket to offload the payloads to, payloads are fetched from the bucket of their pointers")
+	flags.StringVar(&f.prefix, "prefix", "", "Key prefix of the offloaded payloads")
+	flags.BoolVar(&f.verbose, "verbose", false, "Log the routine of the client")
+}
+
+func (f *awsFlags) newSession() (*aws_session.Session, error) {
+	awsConfig := &aws.Config{}
+	if f.region != "" {
+		awsConfig.Region = aws.String(f.region)
+	}
+	if f.endpoint != "" {
+		awsConfig.Endpoint = aws.String(f.endpoint)
+		awsConfig.S3ForcePathStyle = aws.Bool(true)
+	}
+
+	return aws_session.NewSession(awsConfig)
+}
+
+// Offloads to the bucket given by -bucket, payloads are kept inline without it
+func (f *awsFlags) newClient(opts ...aws_extended_sqs_client.AwsExtendedSQSClientOption) (*aws_extended_sqs_client.AwsExtendedSQSClient, error) {
+	return f.buildClient(f.bucket != "", opts...)
+}
+
+// Payloads are fetched from the bucket of their pointers, so -bucket is not needed to receive
+func (f *awsFlags) newReceiveClient(opts ...aws_extended_sqs_client.AwsExtendedSQSClientOption) (*aws_extended_sqs_client.AwsExtendedSQSClient, error) {
+	return f.buildClient(true, opts...)
+}
+
+func (f *awsFlags) buildClient(payloadSupport bool, opts ...aws_extended_sqs_client.AwsExtendedSQSClientOption) (*aws_extended_sqs_client.AwsExtendedSQSClient, error) {
+	session, err := f.newSession()
+	if err != nil {
+		return nil, err
+	}
+
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	config.SetS3KeyPrefix(f.prefix)
+	if payloadSupport {
+		config.WithPayloadSupportEnabled(aws_s3.New(session), f.bucket)
+	}
+
+	logLevel := logging_constants.LOG_LEVEL_WARN
+	if f.verbose {
+		logLevel = logging_constants.LOG_LEVEL_DEBUG
+	}
+
+	opts = append([]aws_extended_sqs_client.AwsExtendedSQSClientOption{
+		aws_extended_sqs_client.WithLogger(logging.NewStdLogger(log.New(os.Stderr, "", log.LstdFlags), logLevel)),
+	}, opts...)
+
+	return aws_extended_sqs_client.NewExtendedSQSClient(aws_sqs.New(session), config, opts...), nil
+}
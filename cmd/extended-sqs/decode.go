@@ -0,0 +1,31 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/cmd/extended-sqs/inspect"
+)
+
+func runDecode(args []string) error {
+	// Not parsed as flags, since a modified receipt handle starts with a dash
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	}
+
+	if len(args) != 1 || args[0] == "-h" || args[0] == "-help" {
+		fmt.Fprintln(os.Stderr, "Usage: decode <message pointer or modified receipt handle>")
+		os.Exit(2)
+	}
+
+	decoded, err := inspect.DecodeReference(args[0])
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(decoded)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/cmd/extended-sqs/inspect"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
+)
+
+func runDump(args []string) error {
+	flags := flag.NewFlagSet("dump", flag.ExitOnError)
+
+	var awsFlags awsFlags
+	awsFlags.register(flags)
+
+	pointer := flags.String("pointer", "", "Message pointer or modified receipt handle of the message (required)")
+	flags.Parse(args)
+
+	if *pointer == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	decoded, err := inspect.DecodeReference(*pointer)
+	if err != nil {
+		return err
+	}
+
+	session, err := awsFlags.newSession()
+	if err != nil {
+		return err
+	}
+
+	// The raw object, i.e. still enveloped when the attributes are offloaded along with the body
+	output, err := aws_s3.New(session).GetObjectWithContext(context.Background(), &aws_s3.GetObjectInput{
+		Bucket: aws.String(decoded.S3BucketName),
+		Key:    aws.String(decoded.S3Key),
+	})
+	if err != nil {
+		return err
+	}
+	defer output.Body.Close()
+
+	_, err = io.Copy(os.Stdout, output.Body)
+	return err
+}
@@ -0,0 +1,92 @@
+// Decodes the references and the messages of the extended client for the commands of extended-sqs
+package inspect
+
+import (
+	"strings"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/internal/payload_store"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// Location of a payload, from a message pointer or a modified receipt handle
+type Reference struct {
+	S3BucketName string `json:"s3BucketName"`
+	S3Key        string `json:"s3Key"`
+	// Set when decoded from a modified receipt handle, the one given by sqs
+	ReceiptHandle string `json:"receiptHandle,omitempty"`
+	// Set when the receipt handle is signed
+	Signature string `json:"signature,omitempty"`
+}
+
+type PeekedAttribute struct {
+	DataType    string `json:"dataType"`
+	StringValue string `json:"stringValue,omitempty"`
+	BinaryValue []byte `json:"binaryValue,omitempty"`
+}
+
+type PeekedMessage struct {
+	MessageId         string                             `json:"messageId"`
+	ReceiptHandle     string                             `json:"receiptHandle"`
+	S3Pointer         *aws_extended_sqs_client.S3Pointer `json:"s3Pointer,omitempty"`
+	Attributes        map[string]string                  `json:"attributes,omitempty"`
+	MessageAttributes map[string]*PeekedAttribute        `json:"messageAttributes,omitempty"`
+	Body              string                             `json:"body"`
+}
+
+// Accepts the body of an offloaded message, i.e. a message pointer, or a receipt handle returned by the extended client
+func DecodeReference(reference string) (*Reference, error) {
+	reference = strings.TrimSpace(reference)
+
+	if strings.HasPrefix(reference, "[") {
+		pointer, err := payload_store.FromJson(reference)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Reference{S3BucketName: pointer.S3BucketName, S3Key: pointer.S3Key}, nil
+	}
+
+	handle, err := aws_extended_sqs_client.ParseReceiptHandle(reference)
+	if err != nil {
+		return nil, err
+	}
+
+	if handle.S3Pointer == nil {
+		return nil, errors.PointerFormatError{Message: "Neither a message pointer nor a modified receipt handle"}
+	}
+
+	return &Reference{
+		S3BucketName:  handle.S3Pointer.S3BucketName,
+		S3Key:         handle.S3Pointer.S3Key,
+		ReceiptHandle: handle.Original,
+		Signature:     handle.Signature,
+	}, nil
+}
+
+// s3Pointer is the pointer the payload of the message was fetched from, nil when the message was not offloaded
+func NewPeekedMessage(message *aws_sqs.Message, s3Pointer *aws_extended_sqs_client.S3Pointer) *PeekedMessage {
+	peeked := &PeekedMessage{
+		MessageId:     aws.StringValue(message.MessageId),
+		ReceiptHandle: aws.StringValue(message.ReceiptHandle),
+		S3Pointer:     s3Pointer,
+		Attributes:    aws.StringValueMap(message.Attributes),
+		Body:          aws.StringValue(message.Body),
+	}
+
+	if len(message.MessageAttributes) > 0 {
+		peeked.MessageAttributes = map[string]*PeekedAttribute{}
+		for name, value := range message.MessageAttributes {
+			peeked.MessageAttributes[name] = &PeekedAttribute{
+				DataType:    aws.StringValue(value.DataType),
+				StringValue: aws.StringValue(value.StringValue),
+				BinaryValue: value.BinaryValue,
+			}
+		}
+	}
+
+	return peeked
+}
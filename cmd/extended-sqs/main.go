@@ -0,0 +1,55 @@
+// Sends, peeks and inspects the messages of the extended client, resolving the payloads offloaded to s3.
+//
+//	extended-sqs send -queue-url URL -bucket sample-bucket -file body.json
+//	extended-sqs peek -queue-url URL
+//	extended-sqs decode '-..s3BucketName..-sample-bucket-..s3BucketName..--..s3Key..-xxx-..s3Key..-receipt-handle'
+//	extended-sqs dump -pointer '["software.amazon.payloadoffloading.PayloadS3Pointer",{"s3BucketName":"sample-bucket","s3Key":"xxx"}]'
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+type command struct {
+	name        string
+	description string
+	run         func(args []string) error
+}
+
+var commands = []*command{
+	{name: "send", description: "Send a file as a message, offloaded to s3 when too large", run: runSend},
+	{name: "peek", description: "Receive messages with their payloads resolved, without deleting them", run: runPeek},
+	{name: "decode", description: "Decode a message pointer or a modified receipt handle", run: runDecode},
+	{name: "dump", description: "Write the s3 object of a message to stdout", run: runDump},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	for _, command := range commands {
+		if command.name != os.Args[1] {
+			continue
+		}
+
+		if err := command.run(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %+v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags]\n\nCommands:\n", os.Args[0])
+	for _, command := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s%s\n", command.name, command.description)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun %s <command> -h for the flags of a command.\n", os.Args[0])
+}
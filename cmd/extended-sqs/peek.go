@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/cmd/extended-sqs/inspect"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+)
+
+func runPeek(args []string) error {
+	flags := flag.NewFlagSet("peek", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: peek -queue-url URL [flags]")
+		fmt.Fprintln(flags.Output(), "\nA peek is a receive: it increments the ApproximateReceiveCount of the messages,")
+		fmt.Fprintln(flags.Output(), "which moves them to the dead-letter queue once the maxReceiveCount of the redrive policy is reached.")
+		fmt.Fprintln(flags.Output())
+		flags.PrintDefaults()
+	}
+
+	var awsFlags awsFlags
+	awsFlags.register(flags)
+
+	queueUrl := flags.String("queue-url", "", "Url of the queue (required)")
+	maxNumberOfMessages := flags.Int64("max", 1, "Messages to receive, from 1 to 10")
+	waitTimeSeconds := flags.Int64("wait-time-seconds", 0, "Long polling duration, from 0 to 20")
+	visibilityTimeout := flags.Int64("visibility-timeout", 0, "Seconds the messages stay invisible, 0 to have them redelivered right away")
+	flags.Parse(args)
+
+	if *queueUrl == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	fmt.Fprintln(os.Stderr, "Warning: peeking increments the receive count of the messages, see peek -h")
+
+	s3Pointers := map[string]*aws_extended_sqs_client.S3Pointer{}
+	client, err := awsFlags.newReceiveClient(aws_extended_sqs_client.WithAfterReceiveInterceptor(
+		func(ctx context.Context, info *aws_extended_sqs_client.ReceivedMessageInfo) error {
+			s3Pointers[aws.StringValue(info.Message.MessageId)] = info.S3Pointer
+			return nil
+		},
+	))
+	if err != nil {
+		return err
+	}
+
+	output, err := client.ReceiveMessageWithContext(context.Background(), &aws_sqs.ReceiveMessageInput{
+		QueueUrl:              queueUrl,
+		MaxNumberOfMessages:   maxNumberOfMessages,
+		WaitTimeSeconds:       waitTimeSeconds,
+		VisibilityTimeout:     visibilityTimeout,
+		AttributeNames:        []*string{aws.String(aws_sqs.QueueAttributeNameAll)},
+		MessageAttributeNames: []*string{aws.String(aws_sqs.QueueAttributeNameAll)},
+	})
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	for _, message := range output.Messages {
+		if err := encoder.Encode(inspect.NewPeekedMessage(message, s3Pointers[aws.StringValue(message.MessageId)])); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
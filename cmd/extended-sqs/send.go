@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// Repeatable name=value flag of string message attributes
+type attributeFlags map[string]*aws_sqs.MessageAttributeValue
+
+func (a attributeFlags) String() string {
+	return fmt.Sprintf("%d attributes", len(a))
+}
+
+func (a attributeFlags) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return fmt.Errorf("Attribute [%s] is not in the form name=value.", value)
+	}
+
+	a[parts[0]] = &aws_sqs.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(parts[1])}
+	return nil
+}
+
+func runSend(args []string) error {
+	flags := flag.NewFlagSet("send", flag.ExitOnError)
+
+	var awsFlags awsFlags
+	awsFlags.register(flags)
+
+	attributes := attributeFlags{}
+	queueUrl := flags.String("queue-url", "", "Url of the queue (required)")
+	file := flags.String("file", "-", "File of the message body, - for stdin")
+	groupId := flags.String("group-id", "", "Message group id of a fifo queue")
+	deduplicationId := flags.String("deduplication-id", "", "Message deduplication id of a fifo queue")
+	delaySeconds := flags.Int64("delay-seconds", 0, "Delay of the message")
+	flags.Var(attributes, "attribute", "String message attribute as name=value, repeatable")
+	flags.Parse(args)
+
+	if *queueUrl == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	body, err := readBody(*file)
+	if err != nil {
+		return err
+	}
+
+	var info *aws_extended_sqs_client.SendMessageInfo
+	client, err := awsFlags.newClient(aws_extended_sqs_client.WithAfterSendInterceptor(
+		func(ctx context.Context, sendInfo *aws_extended_sqs_client.SendMessageInfo, output *aws_sqs.SendMessageOutput, err error) {
+			info = sendInfo
+		},
+	))
+	if err != nil {
+		return err
+	}
+
+	input := &aws_sqs.SendMessageInput{
+		QueueUrl:    queueUrl,
+		MessageBody: aws.String(body),
+	}
+	if len(attributes) > 0 {
+		input.MessageAttributes = attributes
+	}
+	if *groupId != "" {
+		input.MessageGroupId = groupId
+	}
+	if *deduplicationId != "" {
+		input.MessageDeduplicationId = deduplicationId
+	}
+	if *delaySeconds > 0 {
+		input.DelaySeconds = delaySeconds
+	}
+
+	output, err := client.SendMessageWithContext(context.Background(), input)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("message_id=%s\n", aws.StringValue(output.MessageId))
+	if info != nil && info.S3Pointer != nil {
+		fmt.Printf("offloaded=s3://%s/%s\n", info.S3Pointer.S3BucketName, info.S3Pointer.S3Key)
+	}
+
+	return nil
+}
+
+func readBody(file string) (string, error) {
+	var body []byte
+	var err error
+	if file == "-" {
+		body, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		body, err = ioutil.ReadFile(file)
+	}
+
+	return string(body), err
+}
@@ -0,0 +1,133 @@
+// Lists the offloaded payloads of a bucket and deletes the ones no message references anymore.
+//
+//	payload-gc -bucket sample-bucket -prefix payloads/ -min-age 360h
+//	payload-gc -bucket sample-bucket -prefix payloads/ -pointers-file pointers.txt -dry-run=false
+//
+// Nothing is deleted unless -dry-run=false is given, and a bucket holding nothing but the payloads
+// is only listed as a whole with -whole-bucket.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_payload_gc"
+	payload_gc_configs_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_payload_gc/constants"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_session "github.com/aws/aws-sdk-go/aws/session"
+	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
+)
+
+func main() {
+	bucket := flag.String("bucket", "", "Bucket of the offloaded payloads (required)")
+	prefix := flag.String("prefix", "", "Key prefix of the offloaded payloads, as given to SetS3KeyPrefix (required unless -whole-bucket)")
+	wholeBucket := flag.Bool("whole-bucket", false, "Lists the whole bucket when no prefix is given")
+	minAge := flag.Duration("min-age", payload_gc_configs_constants.DEFAULT_MIN_AGE, "Objects modified more recently are kept")
+	dryRun := flag.Bool("dry-run", payload_gc_configs_constants.DEFAULT_DRY_RUN, "Only report the orphans")
+	pointersFile := flag.String("pointers-file", "", "File of message pointers still in flight, one per line, whose payloads are kept")
+	region := flag.String("region", "", "Region of the bucket, taken from the environment by default")
+	endpoint := flag.String("endpoint", "", "Endpoint of s3, e.g. of localstack")
+	flag.Parse()
+
+	if *bucket == "" || (*prefix == "" && !*wholeBucket) {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	awsConfig := &aws.Config{}
+	if *region != "" {
+		awsConfig.Region = aws.String(*region)
+	}
+	if *endpoint != "" {
+		awsConfig.Endpoint = aws.String(*endpoint)
+		awsConfig.S3ForcePathStyle = aws.Bool(true)
+	}
+
+	session, err := aws_session.NewSession(awsConfig)
+	if err != nil {
+		log.Fatalf("Error: %+v", err)
+	}
+
+	opts := []aws_extended_sqs_payload_gc.AwsExtendedSQSPayloadGCOption{
+		aws_extended_sqs_payload_gc.WithLogger(logging.NewDefaultLogger()),
+		aws_extended_sqs_payload_gc.WithS3KeyPrefix(*prefix),
+		aws_extended_sqs_payload_gc.WithMinAge(*minAge),
+		aws_extended_sqs_payload_gc.WithDryRun(*dryRun),
+		aws_extended_sqs_payload_gc.WithOrphanHandler(func(orphan *aws_extended_sqs_payload_gc.Orphan) {
+			printOrphan(orphan, *dryRun)
+		}),
+	}
+	if *wholeBucket {
+		opts = append(opts, aws_extended_sqs_payload_gc.WithWholeBucket())
+	}
+	if *pointersFile != "" {
+		opts = append(opts, aws_extended_sqs_payload_gc.WithInFlightPointers(func(ctx context.Context) ([]string, error) {
+			return readPointers(*pointersFile)
+		}))
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		stop()
+	}()
+
+	gc := aws_extended_sqs_payload_gc.NewExtendedSQSPayloadGC(aws_s3.New(session), *bucket, opts...)
+
+	report, err := gc.Run(ctx)
+	printReport(report)
+	if err != nil {
+		log.Fatalf("Error: %+v", err)
+	}
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func readPointers(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var pointers []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			pointers = append(pointers, line)
+		}
+	}
+
+	return pointers, scanner.Err()
+}
+
+func printOrphan(orphan *aws_extended_sqs_payload_gc.Orphan, dryRun bool) {
+	status := "orphan"
+	switch {
+	case orphan.Err != nil:
+		status = fmt.Sprintf("failed: %v", orphan.Err)
+	case !dryRun:
+		status = "deleted"
+	}
+
+	fmt.Printf("%s\t%s\t%d\t%s\n", orphan.S3Key, orphan.LastModified.Format("2006-01-02T15:04:05Z07:00"), orphan.Size, status)
+}
+
+func printReport(report *aws_extended_sqs_payload_gc.Report) {
+	fmt.Printf("scanned=%d recent=%d in_flight=%d orphans=%d deleted=%d failed=%d dry_run=%t\n",
+		report.Scanned, report.Recent, report.InFlight, report.Orphaned, report.Deleted, report.Failed, report.DryRun)
+}
@@ -0,0 +1,28 @@
+package errors_constants
+
+const (
+	// Operations on the s3 payload
+	OPERATION_STORE  = "store"
+	OPERATION_FETCH  = "fetch"
+	OPERATION_DELETE = "delete"
+	OPERATION_LIST   = "list"
+	OPERATION_COPY   = "copy"
+	// Looking up whether a content addressed payload is already stored
+	OPERATION_CHECK = "check"
+	// Refreshing the last modified time of a content addressed payload
+	OPERATION_TOUCH = "touch"
+
+	// Operations on the sqs message
+	OPERATION_SEND    = "send"
+	OPERATION_RECEIVE = "receive"
+	// Operation on the visibility of the sqs message
+	OPERATION_CHANGE_VISIBILITY = "change visibility"
+)
+
+// What an operation on the sqs message does, as described by SQSError
+var SQS_OPERATION_PHRASES = map[string]string{
+	OPERATION_SEND:              "send message",
+	OPERATION_RECEIVE:           "receive message",
+	OPERATION_DELETE:            "delete message",
+	OPERATION_CHANGE_VISIBILITY: "change message visibility",
+}
@@ -6,8 +6,9 @@ import (
 	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
 )
 
+var _ aws_extended_sqsiface.RetryableErrorInterface = OversizeBreakError{}
+
 type OversizeBreakError struct {
-	aws_extended_sqsiface.ErrorInterface
 	Message string
 	Size    int
 }
@@ -19,3 +20,7 @@ func (e OversizeBreakError) Code() string {
 func (e OversizeBreakError) Error() string {
 	return fmt.Sprintf("%s - %s", e.Code(), e.Message)
 }
+
+func (e OversizeBreakError) Retryable() bool {
+	return false
+}
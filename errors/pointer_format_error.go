@@ -6,9 +6,11 @@ import (
 	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
 )
 
+var _ aws_extended_sqsiface.RetryableErrorInterface = PointerFormatError{}
+
 type PointerFormatError struct {
-	aws_extended_sqsiface.ErrorInterface
 	Message string
+	Err     error
 }
 
 func (e PointerFormatError) Code() string {
@@ -16,5 +18,17 @@ func (e PointerFormatError) Code() string {
 }
 
 func (e PointerFormatError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s - %s: %v", e.Code(), e.Message, e.Err)
+	}
+
 	return fmt.Sprintf("%s - %s", e.Code(), e.Message)
 }
+
+func (e PointerFormatError) Unwrap() error {
+	return e.Err
+}
+
+func (e PointerFormatError) Retryable() bool {
+	return false
+}
@@ -0,0 +1,35 @@
+package errors
+
+import (
+	"fmt"
+
+	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
+)
+
+var _ aws_extended_sqsiface.RetryableErrorInterface = ReceiptHandleFormatError{}
+
+// Returned for a receipt handle with the markers of the extended client which cannot be parsed
+type ReceiptHandleFormatError struct {
+	Message string
+	Err     error
+}
+
+func (e ReceiptHandleFormatError) Code() string {
+	return "ReceiptHandleFormatError"
+}
+
+func (e ReceiptHandleFormatError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s - %s: %v", e.Code(), e.Message, e.Err)
+	}
+
+	return fmt.Sprintf("%s - %s", e.Code(), e.Message)
+}
+
+func (e ReceiptHandleFormatError) Unwrap() error {
+	return e.Err
+}
+
+func (e ReceiptHandleFormatError) Retryable() bool {
+	return false
+}
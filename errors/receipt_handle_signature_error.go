@@ -0,0 +1,35 @@
+package errors
+
+import (
+	"fmt"
+
+	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
+)
+
+var _ aws_extended_sqsiface.RetryableErrorInterface = ReceiptHandleSignatureError{}
+
+// Returned for a receipt handle whose s3 pointer is not signed with a signing key of the configuration
+type ReceiptHandleSignatureError struct {
+	Message string
+	Err     error
+}
+
+func (e ReceiptHandleSignatureError) Code() string {
+	return "ReceiptHandleSignatureError"
+}
+
+func (e ReceiptHandleSignatureError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s - %s: %v", e.Code(), e.Message, e.Err)
+	}
+
+	return fmt.Sprintf("%s - %s", e.Code(), e.Message)
+}
+
+func (e ReceiptHandleSignatureError) Unwrap() error {
+	return e.Err
+}
+
+func (e ReceiptHandleSignatureError) Retryable() bool {
+	return false
+}
@@ -0,0 +1,46 @@
+package errors
+
+import (
+	"context"
+	std_errors "errors"
+
+	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// Reports whether retrying the failed call may succeed, following the classification of the aws sdk retryer
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var retryableErr aws_extended_sqsiface.RetryableErrorInterface
+	if std_errors.As(err, &retryableErr) {
+		return retryableErr.Retryable()
+	}
+
+	if std_errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	if std_errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var requestFailure awserr.RequestFailure
+	if std_errors.As(err, &requestFailure) {
+		statusCode := requestFailure.StatusCode()
+		if statusCode == 429 || (statusCode >= 500 && statusCode != 501) {
+			return true
+		}
+	}
+
+	var awsErr awserr.Error
+	if std_errors.As(err, &awsErr) {
+		return request.IsErrorRetryable(awsErr) || request.IsErrorThrottle(awsErr)
+	}
+
+	return false
+}
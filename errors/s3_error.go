@@ -0,0 +1,44 @@
+package errors
+
+import (
+	"fmt"
+
+	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
+)
+
+var _ aws_extended_sqsiface.RetryableErrorInterface = S3Error{}
+
+// Failure of an operation on the s3 payload, Operation is one of errors_constants.OPERATION_STORE, OPERATION_FETCH, OPERATION_DELETE,
+// OPERATION_LIST and OPERATION_COPY of the payload garbage collector and the redrive, or OPERATION_CHECK and OPERATION_TOUCH of content addressed payloads
+type S3Error struct {
+	Operation    string
+	S3BucketName string
+	S3Key        string
+	Err          error
+}
+
+func (e S3Error) Code() string {
+	return "AwsSqsGoExtendedClientS3Error"
+}
+
+func (e S3Error) Error() string {
+	return fmt.Sprintf("%s - Failed to %s payload s3://%s/%s: %v", e.Code(), e.Operation, e.S3BucketName, e.S3Key, e.Err)
+}
+
+func (e S3Error) Unwrap() error {
+	return e.Err
+}
+
+// Matches any S3Error with the same operation, e.g. errors.Is(err, S3Error{Operation: errors_constants.OPERATION_STORE})
+func (e S3Error) Is(target error) bool {
+	t, ok := target.(S3Error)
+	if !ok {
+		return false
+	}
+
+	return t.Operation == "" || t.Operation == e.Operation
+}
+
+func (e S3Error) Retryable() bool {
+	return IsRetryable(e.Err)
+}
@@ -6,9 +6,11 @@ import (
 	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
 )
 
+var _ aws_extended_sqsiface.RetryableErrorInterface = SDKError{}
+
 type SDKError struct {
-	aws_extended_sqsiface.ErrorInterface
 	Message string
+	Err     error
 }
 
 func (e SDKError) Code() string {
@@ -16,5 +18,17 @@ func (e SDKError) Code() string {
 }
 
 func (e SDKError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s - %s: %v", e.Code(), e.Message, e.Err)
+	}
+
 	return fmt.Sprintf("%s - %s", e.Code(), e.Message)
 }
+
+func (e SDKError) Unwrap() error {
+	return e.Err
+}
+
+func (e SDKError) Retryable() bool {
+	return IsRetryable(e.Err)
+}
@@ -0,0 +1,48 @@
+package errors
+
+import (
+	"fmt"
+
+	errors_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors/constants"
+	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
+)
+
+var _ aws_extended_sqsiface.RetryableErrorInterface = SQSError{}
+
+// Failure of an operation on the sqs message, Operation is one of errors_constants.OPERATION_SEND, OPERATION_RECEIVE, OPERATION_DELETE or OPERATION_CHANGE_VISIBILITY
+type SQSError struct {
+	Operation string
+	QueueUrl  string
+	Err       error
+}
+
+func (e SQSError) Code() string {
+	return "AwsSqsGoExtendedClientSQSError"
+}
+
+func (e SQSError) Error() string {
+	operation, ok := errors_constants.SQS_OPERATION_PHRASES[e.Operation]
+	if !ok {
+		operation = e.Operation
+	}
+
+	return fmt.Sprintf("%s - Failed to %s on queue %s: %v", e.Code(), operation, e.QueueUrl, e.Err)
+}
+
+func (e SQSError) Unwrap() error {
+	return e.Err
+}
+
+// Matches any SQSError with the same operation, e.g. errors.Is(err, SQSError{Operation: errors_constants.OPERATION_SEND})
+func (e SQSError) Is(target error) bool {
+	t, ok := target.(SQSError)
+	if !ok {
+		return false
+	}
+
+	return t.Operation == "" || t.Operation == e.Operation
+}
+
+func (e SQSError) Retryable() bool {
+	return IsRetryable(e.Err)
+}
@@ -0,0 +1,30 @@
+package fakes_constants
+
+import "time"
+
+const (
+	QUEUE_URL_PREFIX           = "https://sqs.fake.amazonaws.com/000000000000/"
+	DEFAULT_VISIBILITY_TIMEOUT = 30
+	// Longest a visibility timeout can be set to, 12 hours
+	MAX_VISIBILITY_TIMEOUT = 43200
+	MAX_MESSAGE_SIZE       = 262144
+	// Limit of the sum of the message sizes of a batch
+	MAX_BATCH_SIZE         = 262144
+	MAX_MESSAGE_ATTRIBUTES = 10
+	MAX_BATCH_ENTRIES      = 10
+	MAX_RECEIVE_MESSAGES   = 10
+	// Interval of checking for visible messages while long polling
+	LONG_POLLING_INTERVAL = 10 * time.Millisecond
+	// Fifo queues only accept one message per deduplication id within the interval
+	DEDUPLICATION_INTERVAL = 5 * time.Minute
+	FIFO_QUEUE_SUFFIX      = ".fifo"
+)
+
+const (
+	MAX_LIST_KEYS   = 1000
+	MAX_DELETE_KEYS = 1000
+	// Error code of HeadObject on a missing key, which has no constant in the sdk
+	ERR_CODE_NOT_FOUND = "NotFound"
+	// Error code of a request with an invalid parameter, which has no constant in the sdk
+	ERR_CODE_INVALID_PARAMETER_VALUE = "InvalidParameterValue"
+)
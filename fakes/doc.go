@@ -0,0 +1,15 @@
+// In-memory sqs and s3 for tests of the extended client, without localstack or aws credentials.
+//
+// FakeSQS follows the limits of sqs on message size, message attributes, batch entries and the 256 KB total of a
+// send batch, and the visibility of received messages. Queues named with the .fifo suffix are fifo queues: a message
+// group id is required, per message delays are rejected, messages are deduplicated for 5 minutes by their
+// deduplication id or, with ContentBasedDeduplication, their body, are given sequence numbers, and a message group
+// is locked while one of its messages is in flight.
+//
+// Not modelled: redrive policies and dead-letter queues, message retention, the receive request attempt id of fifo
+// queues, deduplication scoped to a message group, throughput quotas and throttling, and queue attributes other than
+// the ones given on creation and the approximate numbers of messages.
+//
+// FakeS3 keeps the objects of its buckets in memory, with the operations used by the payload store, the payload
+// garbage collector and the redrive.
+package fakes
@@ -0,0 +1,445 @@
+package fakes
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	fakes_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/fakes/constants"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
+	aws_s3iface "github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// In-memory s3 for tests, safe for concurrent use. Objects keep their body, content type and metadata,
+// and are listed in the order of their keys. Methods which are not implemented panic.
+type FakeS3 struct {
+	aws_s3iface.S3API
+
+	mu      sync.Mutex
+	buckets map[string]map[string]*fakeObject
+	// Added to the current time by Advance
+	offset time.Duration
+}
+
+type fakeObject struct {
+	body         []byte
+	contentType  *string
+	metadata     map[string]*string
+	lastModified time.Time
+	eTag         string
+}
+
+// Creates the buckets of the names, more can be created with CreateBucket
+func NewFakeS3(bucketNames ...string) *FakeS3 {
+	fake := &FakeS3{buckets: map[string]map[string]*fakeObject{}}
+	for _, name := range bucketNames {
+		fake.buckets[name] = map[string]*fakeObject{}
+	}
+
+	return fake
+}
+
+// Moves the clock of the fake forward, which ages the objects, e.g. for the payload garbage collector
+func (f *FakeS3) Advance(duration time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.offset += duration
+}
+
+// Body of the object, false when it does not exist
+func (f *FakeS3) Object(bucket string, key string) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	object, ok := f.buckets[bucket][key]
+	if !ok {
+		return nil, false
+	}
+
+	return append([]byte(nil), object.body...), true
+}
+
+// Keys of the objects of the bucket, in order
+func (f *FakeS3) Keys(bucket string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return sortedKeys(f.buckets[bucket])
+}
+
+func (f *FakeS3) CreateBucket(input *aws_s3.CreateBucketInput) (*aws_s3.CreateBucketOutput, error) {
+	return f.CreateBucketWithContext(context.Background(), input)
+}
+
+func (f *FakeS3) CreateBucketWithContext(ctx aws.Context, input *aws_s3.CreateBucketInput, opts ...request.Option) (*aws_s3.CreateBucketOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return &aws_s3.CreateBucketOutput{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bucket := aws.StringValue(input.Bucket)
+	if _, ok := f.buckets[bucket]; ok {
+		return &aws_s3.CreateBucketOutput{}, awserr.New(aws_s3.ErrCodeBucketAlreadyOwnedByYou, "Your previous request to create the named bucket succeeded and you already own it.", nil)
+	}
+
+	f.buckets[bucket] = map[string]*fakeObject{}
+
+	return &aws_s3.CreateBucketOutput{Location: aws.String("/" + bucket)}, nil
+}
+
+func (f *FakeS3) PutObject(input *aws_s3.PutObjectInput) (*aws_s3.PutObjectOutput, error) {
+	return f.PutObjectWithContext(context.Background(), input)
+}
+
+func (f *FakeS3) PutObjectWithContext(ctx aws.Context, input *aws_s3.PutObjectInput, opts ...request.Option) (*aws_s3.PutObjectOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return &aws_s3.PutObjectOutput{}, err
+	}
+
+	var body []byte
+	if input.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(input.Body)
+		if err != nil {
+			return &aws_s3.PutObjectOutput{}, err
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	objects, err := f.getBucket(input.Bucket)
+	if err != nil {
+		return &aws_s3.PutObjectOutput{}, err
+	}
+
+	object := f.newObject(body, input.ContentType, input.Metadata)
+	objects[aws.StringValue(input.Key)] = object
+
+	return &aws_s3.PutObjectOutput{ETag: aws.String(object.eTag)}, nil
+}
+
+func (f *FakeS3) GetObject(input *aws_s3.GetObjectInput) (*aws_s3.GetObjectOutput, error) {
+	return f.GetObjectWithContext(context.Background(), input)
+}
+
+func (f *FakeS3) GetObjectWithContext(ctx aws.Context, input *aws_s3.GetObjectInput, opts ...request.Option) (*aws_s3.GetObjectOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return &aws_s3.GetObjectOutput{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	object, err := f.getObject(input.Bucket, input.Key, aws_s3.ErrCodeNoSuchKey)
+	if err != nil {
+		return &aws_s3.GetObjectOutput{}, err
+	}
+
+	return &aws_s3.GetObjectOutput{
+		Body:          ioutil.NopCloser(bytes.NewReader(append([]byte(nil), object.body...))),
+		ContentLength: aws.Int64(int64(len(object.body))),
+		ContentType:   object.contentType,
+		ETag:          aws.String(object.eTag),
+		LastModified:  aws.Time(object.lastModified),
+		Metadata:      copyMetadata(object.metadata),
+	}, nil
+}
+
+func (f *FakeS3) HeadObject(input *aws_s3.HeadObjectInput) (*aws_s3.HeadObjectOutput, error) {
+	return f.HeadObjectWithContext(context.Background(), input)
+}
+
+func (f *FakeS3) HeadObjectWithContext(ctx aws.Context, input *aws_s3.HeadObjectInput, opts ...request.Option) (*aws_s3.HeadObjectOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return &aws_s3.HeadObjectOutput{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// A head request has no body, so s3 answers a missing key with a plain not found
+	object, err := f.getObject(input.Bucket, input.Key, fakes_constants.ERR_CODE_NOT_FOUND)
+	if err != nil {
+		return &aws_s3.HeadObjectOutput{}, err
+	}
+
+	return &aws_s3.HeadObjectOutput{
+		ContentLength: aws.Int64(int64(len(object.body))),
+		ContentType:   object.contentType,
+		ETag:          aws.String(object.eTag),
+		LastModified:  aws.Time(object.lastModified),
+		Metadata:      copyMetadata(object.metadata),
+	}, nil
+}
+
+func (f *FakeS3) CopyObject(input *aws_s3.CopyObjectInput) (*aws_s3.CopyObjectOutput, error) {
+	return f.CopyObjectWithContext(context.Background(), input)
+}
+
+// Copies the metadata unless MetadataDirective is REPLACE. An object can be copied onto itself to refresh its last modified time.
+func (f *FakeS3) CopyObjectWithContext(ctx aws.Context, input *aws_s3.CopyObjectInput, opts ...request.Option) (*aws_s3.CopyObjectOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return &aws_s3.CopyObjectOutput{}, err
+	}
+
+	copySource, err := url.PathUnescape(strings.TrimPrefix(aws.StringValue(input.CopySource), "/"))
+	if err != nil {
+		return &aws_s3.CopyObjectOutput{}, invalidArgumentError(fmt.Sprintf("Invalid copy source [%s].", aws.StringValue(input.CopySource)))
+	}
+
+	parts := strings.SplitN(copySource, "/", 2)
+	if len(parts) != 2 {
+		return &aws_s3.CopyObjectOutput{}, invalidArgumentError(fmt.Sprintf("Invalid copy source [%s].", aws.StringValue(input.CopySource)))
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	source, err := f.getObject(aws.String(parts[0]), aws.String(parts[1]), aws_s3.ErrCodeNoSuchKey)
+	if err != nil {
+		return &aws_s3.CopyObjectOutput{}, err
+	}
+
+	objects, err := f.getBucket(input.Bucket)
+	if err != nil {
+		return &aws_s3.CopyObjectOutput{}, err
+	}
+
+	contentType, metadata := source.contentType, source.metadata
+	if aws.StringValue(input.MetadataDirective) == aws_s3.MetadataDirectiveReplace {
+		contentType, metadata = input.ContentType, input.Metadata
+	}
+
+	object := f.newObject(source.body, contentType, metadata)
+	objects[aws.StringValue(input.Key)] = object
+
+	return &aws_s3.CopyObjectOutput{
+		CopyObjectResult: &aws_s3.CopyObjectResult{
+			ETag:         aws.String(object.eTag),
+			LastModified: aws.Time(object.lastModified),
+		},
+	}, nil
+}
+
+func (f *FakeS3) DeleteObject(input *aws_s3.DeleteObjectInput) (*aws_s3.DeleteObjectOutput, error) {
+	return f.DeleteObjectWithContext(context.Background(), input)
+}
+
+// Deleting a missing key succeeds, as in s3
+func (f *FakeS3) DeleteObjectWithContext(ctx aws.Context, input *aws_s3.DeleteObjectInput, opts ...request.Option) (*aws_s3.DeleteObjectOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return &aws_s3.DeleteObjectOutput{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	objects, err := f.getBucket(input.Bucket)
+	if err != nil {
+		return &aws_s3.DeleteObjectOutput{}, err
+	}
+
+	delete(objects, aws.StringValue(input.Key))
+
+	return &aws_s3.DeleteObjectOutput{}, nil
+}
+
+func (f *FakeS3) DeleteObjects(input *aws_s3.DeleteObjectsInput) (*aws_s3.DeleteObjectsOutput, error) {
+	return f.DeleteObjectsWithContext(context.Background(), input)
+}
+
+func (f *FakeS3) DeleteObjectsWithContext(ctx aws.Context, input *aws_s3.DeleteObjectsInput, opts ...request.Option) (*aws_s3.DeleteObjectsOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return &aws_s3.DeleteObjectsOutput{}, err
+	}
+
+	if input.Delete == nil || len(input.Delete.Objects) == 0 || len(input.Delete.Objects) > fakes_constants.MAX_DELETE_KEYS {
+		return &aws_s3.DeleteObjectsOutput{}, awserr.New("MalformedXML", fmt.Sprintf("A delete request must have from 1 to %d keys.", fakes_constants.MAX_DELETE_KEYS), nil)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	objects, err := f.getBucket(input.Bucket)
+	if err != nil {
+		return &aws_s3.DeleteObjectsOutput{}, err
+	}
+
+	output := &aws_s3.DeleteObjectsOutput{}
+	for _, identifier := range input.Delete.Objects {
+		delete(objects, aws.StringValue(identifier.Key))
+
+		// In quiet mode only the keys which failed are returned
+		if !aws.BoolValue(input.Delete.Quiet) {
+			output.Deleted = append(output.Deleted, &aws_s3.DeletedObject{Key: identifier.Key})
+		}
+	}
+
+	return output, nil
+}
+
+func (f *FakeS3) ListObjectsV2(input *aws_s3.ListObjectsV2Input) (*aws_s3.ListObjectsV2Output, error) {
+	return f.ListObjectsV2WithContext(context.Background(), input)
+}
+
+// Supports Prefix, StartAfter, MaxKeys and ContinuationToken, which is the last key of the previous page
+func (f *FakeS3) ListObjectsV2WithContext(ctx aws.Context, input *aws_s3.ListObjectsV2Input, opts ...request.Option) (*aws_s3.ListObjectsV2Output, error) {
+	if err := ctx.Err(); err != nil {
+		return &aws_s3.ListObjectsV2Output{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	objects, err := f.getBucket(input.Bucket)
+	if err != nil {
+		return &aws_s3.ListObjectsV2Output{}, err
+	}
+
+	maxKeys := int(aws.Int64Value(input.MaxKeys))
+	if maxKeys <= 0 || maxKeys > fakes_constants.MAX_LIST_KEYS {
+		maxKeys = fakes_constants.MAX_LIST_KEYS
+	}
+
+	after := aws.StringValue(input.StartAfter)
+	if input.ContinuationToken != nil {
+		after = *input.ContinuationToken
+	}
+
+	output := &aws_s3.ListObjectsV2Output{
+		Name:              input.Bucket,
+		Prefix:            input.Prefix,
+		MaxKeys:           aws.Int64(int64(maxKeys)),
+		ContinuationToken: input.ContinuationToken,
+		IsTruncated:       aws.Bool(false),
+	}
+
+	for _, key := range sortedKeys(objects) {
+		if key <= after || !strings.HasPrefix(key, aws.StringValue(input.Prefix)) {
+			continue
+		}
+
+		if len(output.Contents) == maxKeys {
+			output.IsTruncated = aws.Bool(true)
+			output.NextContinuationToken = output.Contents[len(output.Contents)-1].Key
+			break
+		}
+
+		object := objects[key]
+		output.Contents = append(output.Contents, &aws_s3.Object{
+			Key:          aws.String(key),
+			Size:         aws.Int64(int64(len(object.body))),
+			ETag:         aws.String(object.eTag),
+			LastModified: aws.Time(object.lastModified),
+			StorageClass: aws.String(aws_s3.ObjectStorageClassStandard),
+		})
+	}
+	output.KeyCount = aws.Int64(int64(len(output.Contents)))
+
+	return output, nil
+}
+
+func (f *FakeS3) ListObjectsV2Pages(input *aws_s3.ListObjectsV2Input, fn func(*aws_s3.ListObjectsV2Output, bool) bool) error {
+	return f.ListObjectsV2PagesWithContext(context.Background(), input, fn)
+}
+
+func (f *FakeS3) ListObjectsV2PagesWithContext(ctx aws.Context, input *aws_s3.ListObjectsV2Input, fn func(*aws_s3.ListObjectsV2Output, bool) bool, opts ...request.Option) error {
+	pageInput := *input
+
+	for {
+		output, err := f.ListObjectsV2WithContext(ctx, &pageInput)
+		if err != nil {
+			return err
+		}
+
+		lastPage := !aws.BoolValue(output.IsTruncated)
+		if !fn(output, lastPage) || lastPage {
+			return nil
+		}
+
+		pageInput.ContinuationToken = output.NextContinuationToken
+	}
+}
+
+func (f *FakeS3) now() time.Time {
+	return time.Now().Add(f.offset)
+}
+
+func (f *FakeS3) newObject(body []byte, contentType *string, metadata map[string]*string) *fakeObject {
+	hash := md5.Sum(body)
+
+	return &fakeObject{
+		body:         append([]byte(nil), body...),
+		contentType:  contentType,
+		metadata:     copyMetadata(metadata),
+		lastModified: f.now(),
+		eTag:         fmt.Sprintf("\"%s\"", hex.EncodeToString(hash[:])),
+	}
+}
+
+func (f *FakeS3) getBucket(bucket *string) (map[string]*fakeObject, error) {
+	objects, ok := f.buckets[aws.StringValue(bucket)]
+	if !ok {
+		return nil, awserr.New(aws_s3.ErrCodeNoSuchBucket, "The specified bucket does not exist", nil)
+	}
+
+	return objects, nil
+}
+
+func (f *FakeS3) getObject(bucket *string, key *string, notFoundCode string) (*fakeObject, error) {
+	objects, err := f.getBucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	object, ok := objects[aws.StringValue(key)]
+	if !ok {
+		return nil, awserr.New(notFoundCode, "The specified key does not exist.", nil)
+	}
+
+	return object, nil
+}
+
+func copyMetadata(metadata map[string]*string) map[string]*string {
+	if metadata == nil {
+		return nil
+	}
+
+	copied := make(map[string]*string, len(metadata))
+	for name, value := range metadata {
+		copied[name] = aws.String(aws.StringValue(value))
+	}
+
+	return copied
+}
+
+func invalidArgumentError(message string) error {
+	return awserr.New("InvalidArgument", message, nil)
+}
+
+// Keys of the objects in order, as s3 lists them
+func sortedKeys(objects map[string]*fakeObject) []string {
+	keys := make([]string, 0, len(objects))
+	for key := range objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,798 @@
+package fakes
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	fakes_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/fakes/constants"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+	aws_sqsiface "github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/google/uuid"
+)
+
+// In-memory sqs for tests, safe for concurrent use. Messages are kept in the order they are sent,
+// become invisible for the visibility timeout once received and are redelivered with a new receipt handle.
+// Only the latest receipt handle of a message is valid. Queues named with the .fifo suffix are fifo queues, see the package doc.
+// Methods which are not implemented panic.
+type FakeSQS struct {
+	aws_sqsiface.SQSAPI
+
+	mu     sync.Mutex
+	queues map[string]*fakeQueue
+	// Added to the current time by Advance
+	offset time.Duration
+}
+
+type fakeQueue struct {
+	name       string
+	url        string
+	attributes map[string]string
+	messages   []*fakeMessage
+	fifo       bool
+	// Last sequence number given to a message of a fifo queue
+	sequenceNumber int64
+	// Messages of a fifo queue by deduplication id
+	deduplicated map[string]*fakeMessage
+}
+
+type fakeMessage struct {
+	message       *aws_sqs.Message
+	sentAt        time.Time
+	visibleAt     time.Time
+	receiveCount  int
+	firstReceived time.Time
+	receiptHandle string
+}
+
+// Creates the queues of the names, more can be created with CreateQueue
+func NewFakeSQS(queueNames ...string) *FakeSQS {
+	fake := &FakeSQS{queues: map[string]*fakeQueue{}}
+	for _, name := range queueNames {
+		fake.createQueue(name, nil)
+	}
+
+	return fake
+}
+
+// Url of the queue of the name, whether it exists or not
+func (f *FakeSQS) QueueUrl(name string) string {
+	return fakes_constants.QUEUE_URL_PREFIX + name
+}
+
+// Moves the clock of the fake forward, e.g. to make received messages visible again without waiting for their timeout
+func (f *FakeSQS) Advance(duration time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.offset += duration
+}
+
+// All the messages of the queue, visible or not, in the order they are sent
+func (f *FakeSQS) Messages(queueUrl string) []*aws_sqs.Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	queue, ok := f.queues[queueUrl]
+	if !ok {
+		return nil
+	}
+
+	messages := make([]*aws_sqs.Message, len(queue.messages))
+	for index, message := range queue.messages {
+		messages[index] = copyMessage(message.message)
+	}
+
+	return messages
+}
+
+func (f *FakeSQS) CreateQueue(input *aws_sqs.CreateQueueInput) (*aws_sqs.CreateQueueOutput, error) {
+	return f.CreateQueueWithContext(context.Background(), input)
+}
+
+func (f *FakeSQS) CreateQueueWithContext(ctx aws.Context, input *aws_sqs.CreateQueueInput, opts ...request.Option) (*aws_sqs.CreateQueueOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return &aws_sqs.CreateQueueOutput{}, err
+	}
+
+	if aws.StringValue(input.QueueName) == "" {
+		return &aws_sqs.CreateQueueOutput{}, invalidParameterError("Queue name is required.")
+	}
+
+	fifo := strings.HasSuffix(aws.StringValue(input.QueueName), fakes_constants.FIFO_QUEUE_SUFFIX)
+	if aws.StringValue(input.Attributes[aws_sqs.QueueAttributeNameFifoQueue]) == "true" && !fifo {
+		return &aws_sqs.CreateQueueOutput{}, invalidParameterError("The name of a FIFO queue can only include alphanumeric characters, hyphens, or underscores, must end with .fifo suffix.")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	queue := f.createQueue(aws.StringValue(input.QueueName), input.Attributes)
+
+	return &aws_sqs.CreateQueueOutput{QueueUrl: aws.String(queue.url)}, nil
+}
+
+func (f *FakeSQS) GetQueueUrl(input *aws_sqs.GetQueueUrlInput) (*aws_sqs.GetQueueUrlOutput, error) {
+	return f.GetQueueUrlWithContext(context.Background(), input)
+}
+
+func (f *FakeSQS) GetQueueUrlWithContext(ctx aws.Context, input *aws_sqs.GetQueueUrlInput, opts ...request.Option) (*aws_sqs.GetQueueUrlOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return &aws_sqs.GetQueueUrlOutput{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	queue, err := f.getQueue(aws.String(f.QueueUrl(aws.StringValue(input.QueueName))))
+	if err != nil {
+		return &aws_sqs.GetQueueUrlOutput{}, err
+	}
+
+	return &aws_sqs.GetQueueUrlOutput{QueueUrl: aws.String(queue.url)}, nil
+}
+
+// Supports ApproximateNumberOfMessages, ApproximateNumberOfMessagesNotVisible and the attributes given on creation
+func (f *FakeSQS) GetQueueAttributes(input *aws_sqs.GetQueueAttributesInput) (*aws_sqs.GetQueueAttributesOutput, error) {
+	return f.GetQueueAttributesWithContext(context.Background(), input)
+}
+
+func (f *FakeSQS) GetQueueAttributesWithContext(ctx aws.Context, input *aws_sqs.GetQueueAttributesInput, opts ...request.Option) (*aws_sqs.GetQueueAttributesOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return &aws_sqs.GetQueueAttributesOutput{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	queue, err := f.getQueue(input.QueueUrl)
+	if err != nil {
+		return &aws_sqs.GetQueueAttributesOutput{}, err
+	}
+
+	now := f.now()
+	visible, notVisible := 0, 0
+	for _, message := range queue.messages {
+		if message.visibleAt.After(now) {
+			notVisible++
+		} else {
+			visible++
+		}
+	}
+
+	attributes := map[string]string{}
+	for name, value := range queue.attributes {
+		attributes[name] = value
+	}
+	attributes[aws_sqs.QueueAttributeNameApproximateNumberOfMessages] = strconv.Itoa(visible)
+	attributes[aws_sqs.QueueAttributeNameApproximateNumberOfMessagesNotVisible] = strconv.Itoa(notVisible)
+
+	output := &aws_sqs.GetQueueAttributesOutput{Attributes: map[string]*string{}}
+	for name, value := range attributes {
+		if matchesName(name, input.AttributeNames) {
+			output.Attributes[name] = aws.String(value)
+		}
+	}
+
+	return output, nil
+}
+
+func (f *FakeSQS) PurgeQueue(input *aws_sqs.PurgeQueueInput) (*aws_sqs.PurgeQueueOutput, error) {
+	return f.PurgeQueueWithContext(context.Background(), input)
+}
+
+func (f *FakeSQS) PurgeQueueWithContext(ctx aws.Context, input *aws_sqs.PurgeQueueInput, opts ...request.Option) (*aws_sqs.PurgeQueueOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return &aws_sqs.PurgeQueueOutput{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	queue, err := f.getQueue(input.QueueUrl)
+	if err != nil {
+		return &aws_sqs.PurgeQueueOutput{}, err
+	}
+
+	queue.messages = nil
+
+	return &aws_sqs.PurgeQueueOutput{}, nil
+}
+
+func (f *FakeSQS) SendMessage(input *aws_sqs.SendMessageInput) (*aws_sqs.SendMessageOutput, error) {
+	return f.SendMessageWithContext(context.Background(), input)
+}
+
+func (f *FakeSQS) SendMessageWithContext(ctx aws.Context, input *aws_sqs.SendMessageInput, opts ...request.Option) (*aws_sqs.SendMessageOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return &aws_sqs.SendMessageOutput{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	queue, err := f.getQueue(input.QueueUrl)
+	if err != nil {
+		return &aws_sqs.SendMessageOutput{}, err
+	}
+
+	message, err := f.sendMessage(queue, input.MessageBody, input.MessageAttributes, input.MessageSystemAttributes,
+		input.DelaySeconds, input.MessageGroupId, input.MessageDeduplicationId)
+	if err != nil {
+		return &aws_sqs.SendMessageOutput{}, err
+	}
+
+	return &aws_sqs.SendMessageOutput{
+		MessageId:        message.MessageId,
+		MD5OfMessageBody: message.MD5OfBody,
+		SequenceNumber:   message.Attributes[aws_sqs.MessageSystemAttributeNameSequenceNumber],
+	}, nil
+}
+
+func (f *FakeSQS) SendMessageBatch(input *aws_sqs.SendMessageBatchInput) (*aws_sqs.SendMessageBatchOutput, error) {
+	return f.SendMessageBatchWithContext(context.Background(), input)
+}
+
+func (f *FakeSQS) SendMessageBatchWithContext(ctx aws.Context, input *aws_sqs.SendMessageBatchInput, opts ...request.Option) (*aws_sqs.SendMessageBatchOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return &aws_sqs.SendMessageBatchOutput{}, err
+	}
+
+	ids := make([]*string, len(input.Entries))
+	for index, entry := range input.Entries {
+		ids[index] = entry.Id
+	}
+	if err := validateBatch(ids); err != nil {
+		return &aws_sqs.SendMessageBatchOutput{}, err
+	}
+
+	size := 0
+	for _, entry := range input.Entries {
+		size += getMessageSize(entry.MessageBody, entry.MessageAttributes)
+	}
+	if size > fakes_constants.MAX_BATCH_SIZE {
+		return &aws_sqs.SendMessageBatchOutput{}, awserr.New(aws_sqs.ErrCodeBatchRequestTooLong,
+			fmt.Sprintf("Batch requests cannot be longer than %d bytes. You have sent %d bytes.", fakes_constants.MAX_BATCH_SIZE, size), nil)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	queue, err := f.getQueue(input.QueueUrl)
+	if err != nil {
+		return &aws_sqs.SendMessageBatchOutput{}, err
+	}
+
+	output := &aws_sqs.SendMessageBatchOutput{}
+	for _, entry := range input.Entries {
+		message, err := f.sendMessage(queue, entry.MessageBody, entry.MessageAttributes, entry.MessageSystemAttributes,
+			entry.DelaySeconds, entry.MessageGroupId, entry.MessageDeduplicationId)
+		if err != nil {
+			output.Failed = append(output.Failed, newBatchResultErrorEntry(entry.Id, err))
+			continue
+		}
+
+		output.Successful = append(output.Successful, &aws_sqs.SendMessageBatchResultEntry{
+			Id:               entry.Id,
+			MessageId:        message.MessageId,
+			MD5OfMessageBody: message.MD5OfBody,
+			SequenceNumber:   message.Attributes[aws_sqs.MessageSystemAttributeNameSequenceNumber],
+		})
+	}
+
+	return output, nil
+}
+
+func (f *FakeSQS) ReceiveMessage(input *aws_sqs.ReceiveMessageInput) (*aws_sqs.ReceiveMessageOutput, error) {
+	return f.ReceiveMessageWithContext(context.Background(), input)
+}
+
+// Long polls in real time for WaitTimeSeconds, or until ctx is done
+func (f *FakeSQS) ReceiveMessageWithContext(ctx aws.Context, input *aws_sqs.ReceiveMessageInput, opts ...request.Option) (*aws_sqs.ReceiveMessageOutput, error) {
+	maxNumberOfMessages := aws.Int64Value(input.MaxNumberOfMessages)
+	if maxNumberOfMessages == 0 {
+		maxNumberOfMessages = 1
+	}
+	if maxNumberOfMessages < 1 || maxNumberOfMessages > fakes_constants.MAX_RECEIVE_MESSAGES {
+		return &aws_sqs.ReceiveMessageOutput{}, invalidParameterError(fmt.Sprintf("MaxNumberOfMessages [%d] must be from 1 to %d.", maxNumberOfMessages, fakes_constants.MAX_RECEIVE_MESSAGES))
+	}
+
+	deadline := time.Now().Add(time.Duration(aws.Int64Value(input.WaitTimeSeconds)) * time.Second)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return &aws_sqs.ReceiveMessageOutput{}, err
+		}
+
+		messages, err := f.receiveMessages(input, int(maxNumberOfMessages))
+		if err != nil || len(messages) > 0 || !time.Now().Before(deadline) {
+			return &aws_sqs.ReceiveMessageOutput{Messages: messages}, err
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(fakes_constants.LONG_POLLING_INTERVAL):
+		}
+	}
+}
+
+func (f *FakeSQS) DeleteMessage(input *aws_sqs.DeleteMessageInput) (*aws_sqs.DeleteMessageOutput, error) {
+	return f.DeleteMessageWithContext(context.Background(), input)
+}
+
+func (f *FakeSQS) DeleteMessageWithContext(ctx aws.Context, input *aws_sqs.DeleteMessageInput, opts ...request.Option) (*aws_sqs.DeleteMessageOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return &aws_sqs.DeleteMessageOutput{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	queue, err := f.getQueue(input.QueueUrl)
+	if err != nil {
+		return &aws_sqs.DeleteMessageOutput{}, err
+	}
+
+	return &aws_sqs.DeleteMessageOutput{}, f.deleteMessage(queue, input.ReceiptHandle)
+}
+
+func (f *FakeSQS) DeleteMessageBatch(input *aws_sqs.DeleteMessageBatchInput) (*aws_sqs.DeleteMessageBatchOutput, error) {
+	return f.DeleteMessageBatchWithContext(context.Background(), input)
+}
+
+func (f *FakeSQS) DeleteMessageBatchWithContext(ctx aws.Context, input *aws_sqs.DeleteMessageBatchInput, opts ...request.Option) (*aws_sqs.DeleteMessageBatchOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return &aws_sqs.DeleteMessageBatchOutput{}, err
+	}
+
+	ids := make([]*string, len(input.Entries))
+	for index, entry := range input.Entries {
+		ids[index] = entry.Id
+	}
+	if err := validateBatch(ids); err != nil {
+		return &aws_sqs.DeleteMessageBatchOutput{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	queue, err := f.getQueue(input.QueueUrl)
+	if err != nil {
+		return &aws_sqs.DeleteMessageBatchOutput{}, err
+	}
+
+	output := &aws_sqs.DeleteMessageBatchOutput{}
+	for _, entry := range input.Entries {
+		if err := f.deleteMessage(queue, entry.ReceiptHandle); err != nil {
+			output.Failed = append(output.Failed, newBatchResultErrorEntry(entry.Id, err))
+			continue
+		}
+
+		output.Successful = append(output.Successful, &aws_sqs.DeleteMessageBatchResultEntry{Id: entry.Id})
+	}
+
+	return output, nil
+}
+
+func (f *FakeSQS) ChangeMessageVisibility(input *aws_sqs.ChangeMessageVisibilityInput) (*aws_sqs.ChangeMessageVisibilityOutput, error) {
+	return f.ChangeMessageVisibilityWithContext(context.Background(), input)
+}
+
+func (f *FakeSQS) ChangeMessageVisibilityWithContext(ctx aws.Context, input *aws_sqs.ChangeMessageVisibilityInput, opts ...request.Option) (*aws_sqs.ChangeMessageVisibilityOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return &aws_sqs.ChangeMessageVisibilityOutput{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	queue, err := f.getQueue(input.QueueUrl)
+	if err != nil {
+		return &aws_sqs.ChangeMessageVisibilityOutput{}, err
+	}
+
+	return &aws_sqs.ChangeMessageVisibilityOutput{}, f.changeMessageVisibility(queue, input.ReceiptHandle, input.VisibilityTimeout)
+}
+
+func (f *FakeSQS) ChangeMessageVisibilityBatch(input *aws_sqs.ChangeMessageVisibilityBatchInput) (*aws_sqs.ChangeMessageVisibilityBatchOutput, error) {
+	return f.ChangeMessageVisibilityBatchWithContext(context.Background(), input)
+}
+
+func (f *FakeSQS) ChangeMessageVisibilityBatchWithContext(ctx aws.Context, input *aws_sqs.ChangeMessageVisibilityBatchInput, opts ...request.Option) (*aws_sqs.ChangeMessageVisibilityBatchOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return &aws_sqs.ChangeMessageVisibilityBatchOutput{}, err
+	}
+
+	ids := make([]*string, len(input.Entries))
+	for index, entry := range input.Entries {
+		ids[index] = entry.Id
+	}
+	if err := validateBatch(ids); err != nil {
+		return &aws_sqs.ChangeMessageVisibilityBatchOutput{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	queue, err := f.getQueue(input.QueueUrl)
+	if err != nil {
+		return &aws_sqs.ChangeMessageVisibilityBatchOutput{}, err
+	}
+
+	output := &aws_sqs.ChangeMessageVisibilityBatchOutput{}
+	for _, entry := range input.Entries {
+		if err := f.changeMessageVisibility(queue, entry.ReceiptHandle, entry.VisibilityTimeout); err != nil {
+			output.Failed = append(output.Failed, newBatchResultErrorEntry(entry.Id, err))
+			continue
+		}
+
+		output.Successful = append(output.Successful, &aws_sqs.ChangeMessageVisibilityBatchResultEntry{Id: entry.Id})
+	}
+
+	return output, nil
+}
+
+func (f *FakeSQS) now() time.Time {
+	return time.Now().Add(f.offset)
+}
+
+// Returns the existing queue of the name, as sqs does for the same attributes
+func (f *FakeSQS) createQueue(name string, attributes map[string]*string) *fakeQueue {
+	url := f.QueueUrl(name)
+	if queue, ok := f.queues[url]; ok {
+		return queue
+	}
+
+	queue := &fakeQueue{
+		name: name,
+		url:  url,
+		attributes: map[string]string{
+			aws_sqs.QueueAttributeNameVisibilityTimeout: strconv.Itoa(fakes_constants.DEFAULT_VISIBILITY_TIMEOUT),
+			aws_sqs.QueueAttributeNameDelaySeconds:      "0",
+		},
+		fifo:         strings.HasSuffix(name, fakes_constants.FIFO_QUEUE_SUFFIX),
+		deduplicated: map[string]*fakeMessage{},
+	}
+	if queue.fifo {
+		queue.attributes[aws_sqs.QueueAttributeNameFifoQueue] = "true"
+		queue.attributes[aws_sqs.QueueAttributeNameContentBasedDeduplication] = "false"
+	}
+	for name, value := range attributes {
+		queue.attributes[name] = aws.StringValue(value)
+	}
+
+	f.queues[url] = queue
+
+	return queue
+}
+
+func (f *FakeSQS) getQueue(queueUrl *string) (*fakeQueue, error) {
+	queue, ok := f.queues[aws.StringValue(queueUrl)]
+	if !ok {
+		return nil, awserr.New(aws_sqs.ErrCodeQueueDoesNotExist, "The specified queue does not exist for this wsdl version.", nil)
+	}
+
+	return queue, nil
+}
+
+func (f *FakeSQS) sendMessage(
+	queue *fakeQueue,
+	body *string,
+	messageAttributes map[string]*aws_sqs.MessageAttributeValue,
+	systemAttributes map[string]*aws_sqs.MessageSystemAttributeValue,
+	delaySeconds *int64,
+	groupId *string,
+	deduplicationId *string,
+) (*aws_sqs.Message, error) {
+	if aws.StringValue(body) == "" {
+		return nil, awserr.New("MissingParameter", "The request must contain the parameter MessageBody.", nil)
+	}
+
+	if len(messageAttributes) > fakes_constants.MAX_MESSAGE_ATTRIBUTES {
+		return nil, invalidParameterError(fmt.Sprintf("Number of message attributes [%d] exceeds the allowed maximum [%d].", len(messageAttributes), fakes_constants.MAX_MESSAGE_ATTRIBUTES))
+	}
+
+	if size := getMessageSize(body, messageAttributes); size > fakes_constants.MAX_MESSAGE_SIZE {
+		return nil, invalidParameterError(fmt.Sprintf("One or more parameters are invalid. Reason: Message must be shorter than %d bytes.", fakes_constants.MAX_MESSAGE_SIZE))
+	}
+
+	now := f.now()
+
+	if queue.fifo {
+		if aws.StringValue(groupId) == "" {
+			return nil, awserr.New("MissingParameter", "The request must contain the parameter MessageGroupId.", nil)
+		}
+
+		if delaySeconds != nil {
+			return nil, invalidParameterError(fmt.Sprintf("Value %d for parameter DelaySeconds is invalid. Reason: The request include parameter that is not valid for this queue type.", *delaySeconds))
+		}
+
+		if aws.StringValue(deduplicationId) == "" {
+			if queue.attributes[aws_sqs.QueueAttributeNameContentBasedDeduplication] != "true" {
+				return nil, invalidParameterError("The queue should either have ContentBasedDeduplication enabled or MessageDeduplicationId provided explicitly")
+			}
+
+			contentHash := sha256.Sum256([]byte(aws.StringValue(body)))
+			deduplicationId = aws.String(hex.EncodeToString(contentHash[:]))
+		}
+
+		// Accepted but not enqueued again, as sqs does
+		if sent, ok := queue.deduplicated[*deduplicationId]; ok && now.Sub(sent.sentAt) < fakes_constants.DEDUPLICATION_INTERVAL {
+			return sent.message, nil
+		}
+	}
+
+	hash := md5.Sum([]byte(aws.StringValue(body)))
+
+	message := &aws_sqs.Message{
+		MessageId: aws.String(uuid.NewString()),
+		Body:      aws.String(aws.StringValue(body)),
+		MD5OfBody: aws.String(hex.EncodeToString(hash[:])),
+		Attributes: map[string]*string{
+			aws_sqs.MessageSystemAttributeNameSentTimestamp: aws.String(strconv.FormatInt(now.UnixNano()/int64(time.Millisecond), 10)),
+		},
+	}
+
+	if len(messageAttributes) > 0 {
+		message.MessageAttributes = map[string]*aws_sqs.MessageAttributeValue{}
+		for name, value := range messageAttributes {
+			copied := *value
+			message.MessageAttributes[name] = &copied
+		}
+	}
+
+	for name, value := range systemAttributes {
+		message.Attributes[name] = aws.String(aws.StringValue(value.StringValue))
+	}
+	if groupId != nil {
+		message.Attributes[aws_sqs.MessageSystemAttributeNameMessageGroupId] = aws.String(*groupId)
+	}
+	if deduplicationId != nil {
+		message.Attributes[aws_sqs.MessageSystemAttributeNameMessageDeduplicationId] = aws.String(*deduplicationId)
+	}
+	if queue.fifo {
+		queue.sequenceNumber++
+		message.Attributes[aws_sqs.MessageSystemAttributeNameSequenceNumber] = aws.String(fmt.Sprintf("%020d", queue.sequenceNumber))
+	}
+
+	delay, _ := strconv.Atoi(queue.attributes[aws_sqs.QueueAttributeNameDelaySeconds])
+	if delaySeconds != nil {
+		delay = int(*delaySeconds)
+	}
+
+	sent := &fakeMessage{
+		message:   message,
+		sentAt:    now,
+		visibleAt: now.Add(time.Duration(delay) * time.Second),
+	}
+	queue.messages = append(queue.messages, sent)
+
+	if queue.fifo {
+		queue.deduplicated[*deduplicationId] = sent
+	}
+
+	return message, nil
+}
+
+func (f *FakeSQS) receiveMessages(input *aws_sqs.ReceiveMessageInput, maxNumberOfMessages int) ([]*aws_sqs.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	queue, err := f.getQueue(input.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	visibilityTimeout, _ := strconv.Atoi(queue.attributes[aws_sqs.QueueAttributeNameVisibilityTimeout])
+	if input.VisibilityTimeout != nil {
+		visibilityTimeout = int(*input.VisibilityTimeout)
+	}
+
+	now := f.now()
+
+	// Groups of a fifo queue with a message in flight or delayed, whose later messages wait for it
+	lockedGroups := map[string]bool{}
+
+	var messages []*aws_sqs.Message
+	for _, message := range queue.messages {
+		if len(messages) >= maxNumberOfMessages {
+			break
+		}
+
+		groupId := aws.StringValue(message.message.Attributes[aws_sqs.MessageSystemAttributeNameMessageGroupId])
+		if message.visibleAt.After(now) {
+			if queue.fifo {
+				lockedGroups[groupId] = true
+			}
+			continue
+		}
+
+		if queue.fifo && lockedGroups[groupId] {
+			continue
+		}
+
+		message.receiveCount++
+		if message.receiveCount == 1 {
+			message.firstReceived = now
+		}
+		message.visibleAt = now.Add(time.Duration(visibilityTimeout) * time.Second)
+		message.receiptHandle = uuid.NewString()
+
+		messages = append(messages, message.received(input.AttributeNames, input.MessageAttributeNames))
+	}
+
+	return messages, nil
+}
+
+func (f *FakeSQS) deleteMessage(queue *fakeQueue, receiptHandle *string) error {
+	for index, message := range queue.messages {
+		if message.receiptHandle != "" && message.receiptHandle == aws.StringValue(receiptHandle) {
+			queue.messages = append(queue.messages[:index], queue.messages[index+1:]...)
+			return nil
+		}
+	}
+
+	return receiptHandleError(receiptHandle)
+}
+
+func (f *FakeSQS) changeMessageVisibility(queue *fakeQueue, receiptHandle *string, visibilityTimeout *int64) error {
+	timeout := aws.Int64Value(visibilityTimeout)
+	if timeout < 0 || timeout > fakes_constants.MAX_VISIBILITY_TIMEOUT {
+		return invalidParameterError(fmt.Sprintf("VisibilityTimeout [%d] must be from 0 to %d.", timeout, fakes_constants.MAX_VISIBILITY_TIMEOUT))
+	}
+
+	now := f.now()
+	for _, message := range queue.messages {
+		if message.receiptHandle == "" || message.receiptHandle != aws.StringValue(receiptHandle) {
+			continue
+		}
+
+		if !message.visibleAt.After(now) {
+			return awserr.New(aws_sqs.ErrCodeMessageNotInflight, "The message referred to isn't in flight.", nil)
+		}
+
+		message.visibleAt = now.Add(time.Duration(timeout) * time.Second)
+		return nil
+	}
+
+	return receiptHandleError(receiptHandle)
+}
+
+// Copy of the message as received, with the requested attributes only
+func (m *fakeMessage) received(attributeNames []*string, messageAttributeNames []*string) *aws_sqs.Message {
+	received := copyMessage(m.message)
+	received.ReceiptHandle = aws.String(m.receiptHandle)
+
+	attributes := map[string]*string{
+		aws_sqs.MessageSystemAttributeNameApproximateReceiveCount:          aws.String(strconv.Itoa(m.receiveCount)),
+		aws_sqs.MessageSystemAttributeNameApproximateFirstReceiveTimestamp: aws.String(strconv.FormatInt(m.firstReceived.UnixNano()/int64(time.Millisecond), 10)),
+	}
+	for name, value := range m.message.Attributes {
+		attributes[name] = value
+	}
+
+	received.Attributes = nil
+	for name, value := range attributes {
+		if matchesName(name, attributeNames) {
+			if received.Attributes == nil {
+				received.Attributes = map[string]*string{}
+			}
+			received.Attributes[name] = value
+		}
+	}
+
+	received.MessageAttributes = nil
+	for name, value := range m.message.MessageAttributes {
+		if matchesName(name, messageAttributeNames) {
+			if received.MessageAttributes == nil {
+				received.MessageAttributes = map[string]*aws_sqs.MessageAttributeValue{}
+			}
+			received.MessageAttributes[name] = value
+		}
+	}
+
+	return received
+}
+
+func copyMessage(message *aws_sqs.Message) *aws_sqs.Message {
+	copied := *message
+
+	if message.Attributes != nil {
+		copied.Attributes = map[string]*string{}
+		for name, value := range message.Attributes {
+			copied.Attributes[name] = value
+		}
+	}
+
+	if message.MessageAttributes != nil {
+		copied.MessageAttributes = map[string]*aws_sqs.MessageAttributeValue{}
+		for name, value := range message.MessageAttributes {
+			copied.MessageAttributes[name] = value
+		}
+	}
+
+	return &copied
+}
+
+// Follows the matching of the attribute names of sqs, i.e. "All", ".*", "prefix.*" or an exact name
+func matchesName(name string, requestedNames []*string) bool {
+	for _, requestedName := range requestedNames {
+		switch requested := aws.StringValue(requestedName); {
+		case requested == aws_sqs.QueueAttributeNameAll || requested == ".*":
+			return true
+		case strings.HasSuffix(requested, ".*") && strings.HasPrefix(name, strings.TrimSuffix(requested, "*")):
+			return true
+		case requested == name:
+			return true
+		}
+	}
+
+	return false
+}
+
+// Counted as sqs does, the body along with the name, type and value of each attribute
+func getMessageSize(body *string, messageAttributes map[string]*aws_sqs.MessageAttributeValue) int {
+	size := len(aws.StringValue(body))
+	for name, value := range messageAttributes {
+		size += len(name) + len(aws.StringValue(value.DataType)) + len(aws.StringValue(value.StringValue)) + len(value.BinaryValue)
+	}
+
+	return size
+}
+
+func validateBatch(ids []*string) error {
+	if len(ids) == 0 {
+		return awserr.New(aws_sqs.ErrCodeEmptyBatchRequest, "There should be at least one entry in the request.", nil)
+	}
+
+	if len(ids) > fakes_constants.MAX_BATCH_ENTRIES {
+		return awserr.New(aws_sqs.ErrCodeTooManyEntriesInBatchRequest, fmt.Sprintf("Maximum number of entries per request are %d.", fakes_constants.MAX_BATCH_ENTRIES), nil)
+	}
+
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if aws.StringValue(id) == "" {
+			return awserr.New(aws_sqs.ErrCodeInvalidBatchEntryId, "A batch entry id is required.", nil)
+		}
+
+		if seen[*id] {
+			return awserr.New(aws_sqs.ErrCodeBatchEntryIdsNotDistinct, fmt.Sprintf("Id %s repeated.", *id), nil)
+		}
+		seen[*id] = true
+	}
+
+	return nil
+}
+
+func newBatchResultErrorEntry(id *string, err error) *aws_sqs.BatchResultErrorEntry {
+	entry := &aws_sqs.BatchResultErrorEntry{
+		Id:          id,
+		Message:     aws.String(err.Error()),
+		SenderFault: aws.Bool(true),
+	}
+
+	if awsErr, ok := err.(awserr.Error); ok {
+		entry.Code = aws.String(awsErr.Code())
+		entry.Message = aws.String(awsErr.Message())
+	}
+
+	return entry
+}
+
+func receiptHandleError(receiptHandle *string) error {
+	return awserr.New(aws_sqs.ErrCodeReceiptHandleIsInvalid, fmt.Sprintf("The receipt handle \"%s\" is not valid.", aws.StringValue(receiptHandle)), nil)
+}
+
+func invalidParameterError(message string) error {
+	return awserr.New(fakes_constants.ERR_CODE_INVALID_PARAMETER_VALUE, message, nil)
+}
@@ -5,6 +5,5 @@ go 1.12
 require (
 	github.com/aws/aws-sdk-go v1.34.34
 	github.com/google/uuid v1.3.0
-	github.com/sirupsen/logrus v1.9.3
 	github.com/stretchr/testify v1.8.0
 )
@@ -1,20 +1,39 @@
 package aws_extended_sqsiface
 
 import (
+	"time"
+
 	aws_s3iface "github.com/aws/aws-sdk-go/service/s3/s3iface"
 )
 
 type AwsExtendedSqsClientConfigurationInterface interface {
 	WithPayloadSupportEnabled(s3 aws_s3iface.S3API, s3BucketName string)
 	WithBreakSendSupportEnabled()
+	WithBucketRouter(router BucketRouterInterface)
+	WithS3ClientResolver(resolver S3ClientResolverInterface)
+	SetS3KeyPrefix(prefix string)
 	SetPayloadSizeThreshold(threshold int)
 	SetBreakSendPayloadSizeThreshold(threshold int)
 	SetAlwaysThroughS3(alwaysThroughS3 bool)
 	SetCleanupS3Payload(cleanupS3Payload bool)
+	SetAttributeOffloadEnabled(attributeOffload bool)
+	SetContentAddressedPayloads(contentAddressed bool)
+	SetContentAddressedTouchAge(touchAge time.Duration)
+	SetAttributeLimitFallback(fallback string)
+	SetReceiptHandleSigningKey(key []byte, previousKeys ...[]byte)
 	IsPayloadSupportEnabled() bool
 	IsBreakSendSupportEnabled() bool
+	GetS3BucketName() string
+	GetS3KeyPrefix() string
+	GetBucketRouter() BucketRouterInterface
+	GetS3ClientResolver() S3ClientResolverInterface
 	GetPayloadSizeThreshold() int
 	GetBreakSendPayloadSizeThreshold() int
 	IsAlwaysThroughS3() bool
 	DoesCleanupS3Payload() bool
+	IsAttributeOffloadEnabled() bool
+	IsContentAddressedPayloads() bool
+	GetContentAddressedTouchAge() time.Duration
+	GetAttributeLimitFallback() string
+	GetReceiptHandleSigningKeys() [][]byte
 }
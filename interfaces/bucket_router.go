@@ -0,0 +1,9 @@
+package aws_extended_sqsiface
+
+import (
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+)
+
+type BucketRouterInterface interface {
+	RouteBucket(input *aws_sqs.SendMessageInput, payloadSize int) (string, error)
+}
@@ -4,3 +4,8 @@ type ErrorInterface interface {
 	Code() string
 	Error() string
 }
+
+type RetryableErrorInterface interface {
+	ErrorInterface
+	Retryable() bool
+}
@@ -0,0 +1,9 @@
+package aws_extended_sqsiface
+
+// Structured logger the client logs through, see the logging package for the adapters.
+// Levels are the LOG_LEVEL_* of logging_constants.
+type LoggerInterface interface {
+	WithField(key string, value interface{}) LoggerInterface
+	WithFields(fields map[string]interface{}) LoggerInterface
+	Log(level string, message string)
+}
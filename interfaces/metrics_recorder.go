@@ -0,0 +1,8 @@
+package aws_extended_sqsiface
+
+// Receives the metrics of the client, see metrics_constants for the metric and label names.
+// Every metric name is always reported with the same set of label names.
+type MetricsRecorderInterface interface {
+	IncrementCounter(name string, labels map[string]string)
+	ObserveHistogram(name string, value float64, labels map[string]string)
+}
@@ -1,7 +1,16 @@
 package aws_extended_sqsiface
 
+import (
+	"context"
+)
+
 type PayloadStoreInterface interface {
 	StoreOriginalPayload(originalPayload string) (string, error)
+	StoreOriginalPayloadInBucket(originalPayload string, s3BucketName string) (string, error)
+	StoreOriginalPayloadInBucketWithContext(ctx context.Context, originalPayload string, s3BucketName string) (string, error)
 	GetOriginalPayload(messagePointer string) (string, error)
+	GetOriginalPayloadWithContext(ctx context.Context, messagePointer string) (string, error)
 	DeleteOriginalPayload(messagePointer string) error
+	DeleteOriginalPayloadWithContext(ctx context.Context, messagePointer string) error
+	DeleteOriginalPayloadsWithContext(ctx context.Context, messagePointers []string) []error
 }
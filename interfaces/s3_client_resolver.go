@@ -0,0 +1,9 @@
+package aws_extended_sqsiface
+
+import (
+	aws_s3iface "github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+type S3ClientResolverInterface interface {
+	ResolveS3Client(s3BucketName string) (aws_s3iface.S3API, error)
+}
@@ -0,0 +1,27 @@
+package aws_extended_sqsiface
+
+import (
+	"context"
+)
+
+// Creates the spans of the client and propagates the trace context through message attributes,
+// see tracing_constants for the span names, kinds and attribute keys.
+type TracerInterface interface {
+	StartSpan(ctx context.Context, name string, kind string, attributes map[string]string) (context.Context, SpanInterface)
+	Inject(ctx context.Context, carrier TextMapCarrierInterface)
+	Extract(ctx context.Context, carrier TextMapCarrierInterface) context.Context
+	// Names of the message attributes written by Inject, requested along with the ones asked on receive
+	Fields() []string
+}
+
+type SpanInterface interface {
+	RecordError(err error)
+	End()
+}
+
+// Same method set as propagation.TextMapCarrier of OpenTelemetry
+type TextMapCarrierInterface interface {
+	Get(key string) string
+	Set(key string, value string)
+	Keys() []string
+}
@@ -0,0 +1,220 @@
+package batcher
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	batcher_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/internal/batcher/constants"
+)
+
+// Sends a batch of the items added for key, in the order they were added. It is called from a goroutine of its own
+// and owns the items, e.g. to complete the futures of the items with the outcome of the batch.
+type SendFunc func(key string, items []interface{})
+
+// Buffers items per key, e.g. per queue url, and hands them to send once a batch is full or has lingered long enough.
+// Batches of a key are sent concurrently, except for ordered batches which are sent one at a time in the order
+// they are flushed.
+type Batcher struct {
+	send SendFunc
+	opts *batcherOptions
+
+	mu       sync.Mutex
+	batches  map[string]*batch
+	inFlight map[chan struct{}]struct{}
+	// Batches waiting for the one in flight, keyed by the keys with an ordered batch in flight
+	ordered map[string][]*batch
+	closed  bool
+}
+
+type batcherOptions struct {
+	maxBatchSize  int
+	maxBatchBytes int
+	linger        time.Duration
+}
+
+type BatcherOption func(*batcherOptions)
+
+type batch struct {
+	key     string
+	items   []interface{}
+	size    int
+	ordered bool
+	timer   *time.Timer
+	done    chan struct{}
+}
+
+func newBatcherOptions() *batcherOptions {
+	return &batcherOptions{
+		maxBatchSize:  batcher_constants.DEFAULT_MAX_BATCH_SIZE,
+		maxBatchBytes: batcher_constants.DEFAULT_MAX_BATCH_BYTES,
+		linger:        batcher_constants.DEFAULT_LINGER,
+	}
+}
+
+// Items per batch
+func WithMaxBatchSize(maxBatchSize int) BatcherOption {
+	return func(opts *batcherOptions) {
+		opts.maxBatchSize = maxBatchSize
+	}
+}
+
+// Total size of the items of a batch, 0 for no limit
+func WithMaxBatchBytes(maxBatchBytes int) BatcherOption {
+	return func(opts *batcherOptions) {
+		opts.maxBatchBytes = maxBatchBytes
+	}
+}
+
+// Longest an item waits in a batch which is not full before the batch is sent
+func WithLinger(linger time.Duration) BatcherOption {
+	return func(opts *batcherOptions) {
+		opts.linger = linger
+	}
+}
+
+func NewBatcher(send SendFunc, opts ...BatcherOption) *Batcher {
+	batcherOpts := newBatcherOptions()
+	for _, opt := range opts {
+		opt(batcherOpts)
+	}
+
+	return &Batcher{
+		send:     send,
+		opts:     batcherOpts,
+		batches:  map[string]*batch{},
+		inFlight: map[chan struct{}]struct{}{},
+		ordered:  map[string][]*batch{},
+	}
+}
+
+// Adds the item of the given size to the batch of key. A batch with an ordered item is sent only once the ordered
+// batches of key flushed before it are sent. Returns false without adding the item once the batcher is closed.
+func (b *Batcher) Add(key string, item interface{}, size int, ordered bool) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return false
+	}
+
+	current := b.batches[key]
+	if current != nil && b.opts.maxBatchBytes > 0 && current.size+size > b.opts.maxBatchBytes {
+		b.flushBatch(current)
+		current = nil
+	}
+
+	if current == nil {
+		current = b.newBatch(key)
+	}
+
+	current.items = append(current.items, item)
+	current.size += size
+	current.ordered = current.ordered || ordered
+
+	if len(current.items) >= b.opts.maxBatchSize || (b.opts.maxBatchBytes > 0 && current.size >= b.opts.maxBatchBytes) {
+		b.flushBatch(current)
+	}
+
+	return true
+}
+
+// Sends the buffered items right away and waits for all the batches being sent, or until ctx is done
+func (b *Batcher) Flush(ctx context.Context) error {
+	b.mu.Lock()
+
+	for _, current := range b.batches {
+		b.flushBatch(current)
+	}
+
+	inFlight := make([]chan struct{}, 0, len(b.inFlight))
+	for done := range b.inFlight {
+		inFlight = append(inFlight, done)
+	}
+
+	b.mu.Unlock()
+
+	for _, done := range inFlight {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// Stops accepting items, then flushes the buffered ones
+func (b *Batcher) Close(ctx context.Context) error {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+
+	return b.Flush(ctx)
+}
+
+// Must be called with the lock held
+func (b *Batcher) newBatch(key string) *batch {
+	current := &batch{key: key}
+	current.timer = time.AfterFunc(b.opts.linger, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		// the batch may have been flushed for being full in the meantime
+		if b.batches[key] == current {
+			b.flushBatch(current)
+		}
+	})
+
+	b.batches[key] = current
+
+	return current
+}
+
+// Must be called with the lock held
+func (b *Batcher) flushBatch(current *batch) {
+	current.timer.Stop()
+	delete(b.batches, current.key)
+
+	current.done = make(chan struct{})
+	b.inFlight[current.done] = struct{}{}
+
+	if current.ordered {
+		// only one ordered batch of a key is in flight, the next ones are sent by its goroutine
+		if pending, ok := b.ordered[current.key]; ok {
+			b.ordered[current.key] = append(pending, current)
+			return
+		}
+
+		b.ordered[current.key] = nil
+	}
+
+	go b.sendBatches(current)
+}
+
+// Sends the batch, then the ordered batches of its key flushed in the meantime
+func (b *Batcher) sendBatches(current *batch) {
+	for current != nil {
+		b.send(current.key, current.items)
+
+		b.mu.Lock()
+
+		delete(b.inFlight, current.done)
+		close(current.done)
+
+		var next *batch
+		if pending, ok := b.ordered[current.key]; ok && current.ordered {
+			if len(pending) == 0 {
+				delete(b.ordered, current.key)
+			} else {
+				next = pending[0]
+				b.ordered[current.key] = pending[1:]
+			}
+		}
+
+		b.mu.Unlock()
+
+		current = next
+	}
+}
@@ -0,0 +1,10 @@
+package batcher_constants
+
+import "time"
+
+const (
+	DEFAULT_MAX_BATCH_SIZE = 10
+	// Batches are not limited by their size in bytes by default
+	DEFAULT_MAX_BATCH_BYTES = 0
+	DEFAULT_LINGER          = 100 * time.Millisecond
+)
@@ -0,0 +1,17 @@
+package noop
+
+import (
+	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
+)
+
+type MetricsRecorder struct {
+	aws_extended_sqsiface.MetricsRecorderInterface
+}
+
+func NewMetricsRecorder() *MetricsRecorder {
+	return &MetricsRecorder{}
+}
+
+func (r *MetricsRecorder) IncrementCounter(name string, labels map[string]string) {}
+
+func (r *MetricsRecorder) ObserveHistogram(name string, value float64, labels map[string]string) {}
@@ -0,0 +1,37 @@
+package noop
+
+import (
+	"context"
+
+	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
+)
+
+type Tracer struct {
+	aws_extended_sqsiface.TracerInterface
+}
+
+type Span struct {
+	aws_extended_sqsiface.SpanInterface
+}
+
+func NewTracer() *Tracer {
+	return &Tracer{}
+}
+
+func (t *Tracer) StartSpan(ctx context.Context, name string, kind string, attributes map[string]string) (context.Context, aws_extended_sqsiface.SpanInterface) {
+	return ctx, &Span{}
+}
+
+func (t *Tracer) Inject(ctx context.Context, carrier aws_extended_sqsiface.TextMapCarrierInterface) {}
+
+func (t *Tracer) Extract(ctx context.Context, carrier aws_extended_sqsiface.TextMapCarrierInterface) context.Context {
+	return ctx
+}
+
+func (t *Tracer) Fields() []string {
+	return nil
+}
+
+func (s *Span) RecordError(err error) {}
+
+func (s *Span) End() {}
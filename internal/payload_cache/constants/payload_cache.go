@@ -0,0 +1,8 @@
+package payload_cache_constants
+
+import "time"
+
+const (
+	// Payloads are cached for the visibility timeout of most queues, so that redeliveries are served from memory
+	DEFAULT_TTL = 5 * time.Minute
+)
@@ -0,0 +1,147 @@
+package payload_cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	payload_cache_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/internal/payload_cache/constants"
+)
+
+// Least recently used payloads fetched from s3, bounded by the total size of the payloads in bytes.
+// Payloads are never modified once stored, so an entry stays valid until it expires or its payload is deleted.
+type PayloadCache struct {
+	maxBytes int
+	opts     *payloadCacheOptions
+
+	mu      sync.Mutex
+	bytes   int
+	entries map[cacheKey]*list.Element
+	// Most recently used first
+	lru *list.List
+}
+
+type payloadCacheOptions struct {
+	ttl time.Duration
+	now func() time.Time
+}
+
+type PayloadCacheOption func(*payloadCacheOptions)
+
+type cacheKey struct {
+	s3BucketName string
+	s3Key        string
+}
+
+type cacheEntry struct {
+	key       cacheKey
+	payload   string
+	expiresAt time.Time
+}
+
+// Time after which a payload is fetched again from s3, 5 minutes by default. Payloads do not expire when ttl is 0.
+func WithTTL(ttl time.Duration) PayloadCacheOption {
+	return func(opts *payloadCacheOptions) {
+		opts.ttl = ttl
+	}
+}
+
+// Clock of the expiry, time.Now by default
+func WithClock(now func() time.Time) PayloadCacheOption {
+	return func(opts *payloadCacheOptions) {
+		opts.now = now
+	}
+}
+
+// Payloads larger than maxBytes are not cached
+func NewPayloadCache(maxBytes int, opts ...PayloadCacheOption) *PayloadCache {
+	cacheOpts := &payloadCacheOptions{
+		ttl: payload_cache_constants.DEFAULT_TTL,
+		now: time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(cacheOpts)
+	}
+
+	return &PayloadCache{
+		maxBytes: maxBytes,
+		opts:     cacheOpts,
+		entries:  make(map[cacheKey]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+func (c *PayloadCache) Get(s3BucketName string, s3Key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[cacheKey{s3BucketName, s3Key}]
+	if !ok {
+		return "", false
+	}
+
+	entry := element.Value.(*cacheEntry)
+	if c.isExpired(entry) {
+		c.remove(element)
+		return "", false
+	}
+
+	c.lru.MoveToFront(element)
+
+	return entry.payload, true
+}
+
+// Evicts the least recently used payloads until the payload fits
+func (c *PayloadCache) Put(s3BucketName string, s3Key string, payload string) {
+	if len(payload) > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := cacheKey{s3BucketName, s3Key}
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+
+	for c.bytes+len(payload) > c.maxBytes {
+		c.remove(c.lru.Back())
+	}
+
+	var expiresAt time.Time
+	if c.opts.ttl > 0 {
+		expiresAt = c.opts.now().Add(c.opts.ttl)
+	}
+
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, payload: payload, expiresAt: expiresAt})
+	c.bytes += len(payload)
+}
+
+func (c *PayloadCache) Invalidate(s3BucketName string, s3Key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[cacheKey{s3BucketName, s3Key}]; ok {
+		c.remove(element)
+	}
+}
+
+// Total size of the cached payloads in bytes, expired ones included until they are looked up or evicted
+func (c *PayloadCache) Bytes() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.bytes
+}
+
+func (c *PayloadCache) isExpired(entry *cacheEntry) bool {
+	return !entry.expiresAt.IsZero() && !c.opts.now().Before(entry.expiresAt)
+}
+
+func (c *PayloadCache) remove(element *list.Element) {
+	entry := c.lru.Remove(element).(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.bytes -= len(entry.payload)
+}
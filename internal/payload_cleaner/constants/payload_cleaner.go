@@ -0,0 +1,12 @@
+package payload_cleaner_constants
+
+import "time"
+
+const (
+	DEFAULT_WORKERS       = 1
+	DEFAULT_QUEUE_SIZE    = 1000
+	DEFAULT_MAX_RETRIES   = 3
+	DEFAULT_RETRY_BACKOFF = time.Second
+	// Payloads are deleted right after their messages by default
+	DEFAULT_GRACE_PERIOD = 0
+)
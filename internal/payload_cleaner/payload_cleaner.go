@@ -0,0 +1,337 @@
+package payload_cleaner
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors"
+	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
+	payload_cleaner_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/internal/payload_cleaner/constants"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging"
+	logging_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging/constants"
+)
+
+// Called once a payload could not be deleted, after all its attempts or when it could not be enqueued.
+// It is called without any lock of the cleaner held, so it may call Close.
+type FailureHandler func(messagePointer string, err error)
+
+// Deletes offloaded payloads in the background, so that deleting a message does not wait for or fail on s3.
+// Payloads waiting for their grace period or for a retry are kept in a pending list, handed to the workers
+// by a timer once due, so that the workers never sleep on a payload.
+type PayloadCleaner struct {
+	payloadStore aws_extended_sqsiface.PayloadStoreInterface
+	opts         *payloadCleanerOptions
+
+	mu sync.Mutex
+	// Earliest due first
+	pending cleanupTasks
+	closed  bool
+	// Wakes the scheduler up on a new pending task or on closing
+	wake  chan struct{}
+	ready chan *cleanupTask
+	// Closed once every task is handled after Close, which stops the scheduler and the workers
+	stopped  chan struct{}
+	stopOnce sync.Once
+	// Tasks enqueued and not handled yet
+	tasks sync.WaitGroup
+}
+
+type payloadCleanerOptions struct {
+	logger         aws_extended_sqsiface.LoggerInterface
+	workers        int
+	queueSize      int
+	maxRetries     int
+	retryBackoff   time.Duration
+	gracePeriod    time.Duration
+	failureHandler FailureHandler
+}
+
+type PayloadCleanerOption func(*payloadCleanerOptions)
+
+type cleanupTask struct {
+	messagePointer string
+	dueAt          time.Time
+	// Attempts made so far
+	attempts int
+}
+
+// Heap of tasks by due time, see container/heap
+type cleanupTasks []*cleanupTask
+
+func (t cleanupTasks) Len() int           { return len(t) }
+func (t cleanupTasks) Less(i, j int) bool { return t[i].dueAt.Before(t[j].dueAt) }
+func (t cleanupTasks) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
+
+func (t *cleanupTasks) Push(task interface{}) {
+	*t = append(*t, task.(*cleanupTask))
+}
+
+func (t *cleanupTasks) Pop() interface{} {
+	old := *t
+	task := old[len(old)-1]
+	old[len(old)-1] = nil
+	*t = old[:len(old)-1]
+
+	return task
+}
+
+func newPayloadCleanerOptions() *payloadCleanerOptions {
+	return &payloadCleanerOptions{
+		logger:       logging.NewDefaultLogger(),
+		workers:      payload_cleaner_constants.DEFAULT_WORKERS,
+		queueSize:    payload_cleaner_constants.DEFAULT_QUEUE_SIZE,
+		maxRetries:   payload_cleaner_constants.DEFAULT_MAX_RETRIES,
+		retryBackoff: payload_cleaner_constants.DEFAULT_RETRY_BACKOFF,
+		gracePeriod:  payload_cleaner_constants.DEFAULT_GRACE_PERIOD,
+	}
+}
+
+func WithLogger(logger aws_extended_sqsiface.LoggerInterface) PayloadCleanerOption {
+	return func(opts *payloadCleanerOptions) {
+		opts.logger = logger
+	}
+}
+
+// Number of payloads deleted concurrently
+func WithWorkers(workers int) PayloadCleanerOption {
+	return func(opts *payloadCleanerOptions) {
+		opts.workers = workers
+	}
+}
+
+// Payloads waiting for their grace period or a retry, payloads enqueued to a full queue are reported as failed
+func WithQueueSize(queueSize int) PayloadCleanerOption {
+	return func(opts *payloadCleanerOptions) {
+		opts.queueSize = queueSize
+	}
+}
+
+// Attempts after the first failed one
+func WithMaxRetries(maxRetries int) PayloadCleanerOption {
+	return func(opts *payloadCleanerOptions) {
+		opts.maxRetries = maxRetries
+	}
+}
+
+// Wait before the first retry, doubled on each following one
+func WithRetryBackoff(retryBackoff time.Duration) PayloadCleanerOption {
+	return func(opts *payloadCleanerOptions) {
+		opts.retryBackoff = retryBackoff
+	}
+}
+
+// Delay between enqueuing a payload and deleting it, i.e. to let duplicated deliveries of the message still resolve it
+func WithGracePeriod(gracePeriod time.Duration) PayloadCleanerOption {
+	return func(opts *payloadCleanerOptions) {
+		opts.gracePeriod = gracePeriod
+	}
+}
+
+func WithFailureHandler(handler FailureHandler) PayloadCleanerOption {
+	return func(opts *payloadCleanerOptions) {
+		opts.failureHandler = handler
+	}
+}
+
+func NewPayloadCleaner(payloadStore aws_extended_sqsiface.PayloadStoreInterface, opts ...PayloadCleanerOption) *PayloadCleaner {
+	cleanerOpts := newPayloadCleanerOptions()
+	for _, opt := range opts {
+		opt(cleanerOpts)
+	}
+
+	if cleanerOpts.workers < 1 {
+		cleanerOpts.workers = 1
+	}
+
+	if cleanerOpts.queueSize < 0 {
+		cleanerOpts.queueSize = 0
+	}
+
+	cleaner := &PayloadCleaner{
+		payloadStore: payloadStore,
+		opts:         cleanerOpts,
+		wake:         make(chan struct{}, 1),
+		ready:        make(chan *cleanupTask),
+		stopped:      make(chan struct{}),
+	}
+
+	go cleaner.schedule()
+	for i := 0; i < cleanerOpts.workers; i++ {
+		go cleaner.work()
+	}
+
+	return cleaner
+}
+
+// Enqueues the payload to be deleted once the grace period has passed, never blocks
+func (c *PayloadCleaner) Enqueue(messagePointer string) {
+	if err := c.add(&cleanupTask{messagePointer: messagePointer, dueAt: time.Now().Add(c.opts.gracePeriod)}); err != nil {
+		c.fail(messagePointer, err)
+	}
+}
+
+// Stops accepting payloads and deletes the pending ones right away, without waiting for their grace period
+// or between retries. Returns once each of them is deleted or handed to the failure handler, without waiting
+// for the handler to return, or with the error of ctx once it is done.
+func (c *PayloadCleaner) Close(ctx context.Context) error {
+	c.mu.Lock()
+	if !c.closed {
+		c.closed = true
+		c.notify()
+	}
+	c.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		c.tasks.Wait()
+		c.stopOnce.Do(func() {
+			close(c.stopped)
+		})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// The error is returned rather than reported, so that the failure handler is called once the lock is released
+func (c *PayloadCleaner) add(task *cleanupTask) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return errors.SDKError{Message: "Payload cleaner is closed."}
+	}
+
+	if len(c.pending) >= c.opts.queueSize {
+		return errors.SDKError{Message: fmt.Sprintf("Payload cleanup queue is full [%d].", c.opts.queueSize)}
+	}
+
+	c.tasks.Add(1)
+	heap.Push(&c.pending, task)
+	c.notify()
+
+	return nil
+}
+
+// Pends the task again after the backoff, doubled on each retry. Retries are not bound by the queue size.
+func (c *PayloadCleaner) retry(task *cleanupTask) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	task.dueAt = time.Now().Add(c.opts.retryBackoff << uint(task.attempts-1))
+	heap.Push(&c.pending, task)
+	c.notify()
+}
+
+// Must be called with the lock held
+func (c *PayloadCleaner) notify() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Pops the earliest task once it is due, every task being due once the cleaner is closed.
+// Otherwise returns the time until the earliest task is due, 0 when none is pending.
+func (c *PayloadCleaner) next() (*cleanupTask, time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.pending) == 0 {
+		return nil, 0
+	}
+
+	if wait := time.Until(c.pending[0].dueAt); wait > 0 && !c.closed {
+		return nil, wait
+	}
+
+	return heap.Pop(&c.pending).(*cleanupTask), 0
+}
+
+// Hands the due tasks to the workers, sleeping until the earliest pending task is due
+func (c *PayloadCleaner) schedule() {
+	for {
+		task, wait := c.next()
+		if task != nil {
+			select {
+			case c.ready <- task:
+			case <-c.stopped:
+				return
+			}
+			continue
+		}
+
+		var timer *time.Timer
+		var due <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			due = timer.C
+		}
+
+		select {
+		case <-due:
+		case <-c.wake:
+		case <-c.stopped:
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+
+		select {
+		case <-c.stopped:
+			return
+		default:
+		}
+	}
+}
+
+func (c *PayloadCleaner) work() {
+	for {
+		select {
+		case task := <-c.ready:
+			c.clean(task)
+		case <-c.stopped:
+			return
+		}
+	}
+}
+
+func (c *PayloadCleaner) clean(task *cleanupTask) {
+	task.attempts++
+
+	err := c.payloadStore.DeleteOriginalPayloadWithContext(context.Background(), task.messagePointer)
+	if err == nil {
+		c.tasks.Done()
+		return
+	}
+
+	c.opts.logger.WithFields(map[string]interface{}{"method": "clean", "attempt": task.attempts}).
+		Log(logging_constants.LOG_LEVEL_WARN, fmt.Sprintf("Error: %+v", err))
+
+	if task.attempts <= c.opts.maxRetries {
+		c.retry(task)
+		return
+	}
+
+	// Done before the failure handler, which may call Close
+	c.tasks.Done()
+	c.fail(task.messagePointer, err)
+}
+
+func (c *PayloadCleaner) fail(messagePointer string, err error) {
+	c.opts.logger.WithFields(map[string]interface{}{"method": "clean", "message_pointer": messagePointer}).
+		Log(logging_constants.LOG_LEVEL_ERROR, fmt.Sprintf("Error: %+v", err))
+
+	if c.opts.failureHandler != nil {
+		c.opts.failureHandler(messagePointer, err)
+	}
+}
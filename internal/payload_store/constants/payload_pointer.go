@@ -3,4 +3,6 @@ package payload_store_constants
 const (
 	// For serializing & deserializing the message pointer
 	JAVA_CLASS_NAME = "software.amazon.payloadoffloading.PayloadS3Pointer"
+	// Written by the java extended client before 1.1, still accepted when deserializing
+	LEGACY_JAVA_CLASS_NAME = "com.amazon.sqs.javamessaging.MessageS3Pointer"
 )
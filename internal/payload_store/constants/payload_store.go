@@ -4,4 +4,13 @@ import "time"
 
 const (
 	S3_CONTEXT_TIMEOUT = 30 * time.Second
+	// Most keys deleted by a single DeleteObjects request
+	MAX_S3_DELETE_OBJECTS = 1000
+	// Error code of HeadObject on a missing key, which has no constant in the sdk
+	S3_ERR_CODE_NOT_FOUND = "NotFound"
+)
+
+const (
+	// Prepended to the hex sha256 of the payload in content addressed keys, after the s3 key prefix
+	CONTENT_ADDRESSED_KEY_PREFIX = "sha256-"
 )
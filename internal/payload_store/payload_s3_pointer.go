@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 
 	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors"
+	payload_store_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/internal/payload_store/constants"
 )
 
 // Used for serializing & deserializing the string in compatible format with the java sdk
@@ -18,22 +19,50 @@ type PayloadS3Pointer struct {
 	S3Key        string
 }
 
+// Accepts the pointers of the java and python extended clients, i.e. the current and the legacy java class names,
+// insignificant whitespace, extra fields and field names in any case, as long as the bucket and the key are set
 func (p *PayloadS3Pointer) UnmarshalJSON(data []byte) error {
-	arr := []PayloadS3PointerJson{}
-
-	err := json.Unmarshal(data, &arr)
+	arr := []json.RawMessage{}
+	if err := json.Unmarshal(data, &arr); err != nil {
+		return err
+	}
 
 	if len(arr) != 2 {
-		if err != nil {
-			return err
+		return errors.PointerFormatError{
+			Message: "Invalid pointer format",
+		}
+	}
+
+	var className string
+	if err := json.Unmarshal(arr[0], &className); err != nil {
+		return errors.PointerFormatError{
+			Message: "Invalid pointer class name",
+			Err:     err,
 		}
+	}
+
+	if className != payload_store_constants.JAVA_CLASS_NAME && className != payload_store_constants.LEGACY_JAVA_CLASS_NAME {
+		return errors.PointerFormatError{
+			Message: "Unknown pointer class name " + className,
+		}
+	}
+
+	var pointerJson PayloadS3PointerJson
+	if err := json.Unmarshal(arr[1], &pointerJson); err != nil {
 		return errors.PointerFormatError{
 			Message: "Invalid pointer format",
+			Err:     err,
 		}
 	}
 
-	p.S3BucketName = arr[1].S3BucketName
-	p.S3Key = arr[1].S3Key
+	if pointerJson.S3BucketName == "" || pointerJson.S3Key == "" {
+		return errors.PointerFormatError{
+			Message: "Pointer without s3 bucket name or s3 key",
+		}
+	}
+
+	p.S3BucketName = pointerJson.S3BucketName
+	p.S3Key = pointerJson.S3Key
 
 	return nil
 }
@@ -45,7 +74,7 @@ func (p *PayloadS3Pointer) MarshalJSON() ([]byte, error) {
 	}
 
 	arr := []interface{}{}
-	arr = append(arr, "software.amazon.payloadoffloading.PayloadS3Pointer")
+	arr = append(arr, payload_store_constants.JAVA_CLASS_NAME)
 	arr = append(arr, pointerJson)
 
 	return json.Marshal(&arr)
@@ -61,12 +90,30 @@ func (p *PayloadS3Pointer) ToJson() (string, error) {
 	return string(messagePointer), nil
 }
 
+// Message pointer of the payload at s3BucketName and s3Key, as ToJson returns it.
+// Only strings are encoded, so it panics if the encoding ever fails instead of returning an error.
+func NewMessagePointer(s3BucketName string, s3Key string) string {
+	messagePointer, err := (&PayloadS3Pointer{S3BucketName: s3BucketName, S3Key: s3Key}).ToJson()
+	if err != nil {
+		panic(err)
+	}
+
+	return messagePointer
+}
+
 func FromJson(pointerStr string) (*PayloadS3Pointer, error) {
 	var payloadPointer PayloadS3Pointer
 	err := json.Unmarshal([]byte(pointerStr), &payloadPointer)
 
 	if err != nil {
-		return nil, err
+		if _, ok := err.(errors.PointerFormatError); ok {
+			return nil, err
+		}
+
+		return nil, errors.PointerFormatError{
+			Message: "Invalid pointer format",
+			Err:     err,
+		}
 	}
 
 	return &payloadPointer, nil
@@ -3,13 +3,24 @@ package payload_store
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"io"
+	"net/url"
 	"strings"
+	"time"
 
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors"
+	errors_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors/constants"
 	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/internal/noop"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/internal/payload_cache"
 	payload_store_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/internal/payload_store/constants"
+	metrics_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/metrics/constants"
+	tracing_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tracing/constants"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
 	aws_s3iface "github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/google/uuid"
@@ -17,23 +28,93 @@ import (
 
 type PayloadStore struct {
 	aws_extended_sqsiface.PayloadStoreInterface
-	s3           aws_s3iface.S3API
-	s3BucketName string
+	s3               aws_s3iface.S3API
+	s3BucketName     string
+	s3KeyPrefix      string
+	s3ClientResolver aws_extended_sqsiface.S3ClientResolverInterface
+	metricsRecorder  aws_extended_sqsiface.MetricsRecorderInterface
+	tracer           aws_extended_sqsiface.TracerInterface
+	payloadCache     *payload_cache.PayloadCache
+	contentAddressed bool
+	// Age after which a content addressed payload stored again is copied onto itself
+	contentAddressedTouchAge time.Duration
 }
 
-func NewPayloadStore(s3Client aws_s3iface.S3API, s3BucketName string) *PayloadStore {
+type PayloadStoreOption func(*PayloadStore)
+
+func WithS3ClientResolver(resolver aws_extended_sqsiface.S3ClientResolverInterface) PayloadStoreOption {
+	return func(p *PayloadStore) {
+		p.s3ClientResolver = resolver
+	}
+}
+
+func WithS3KeyPrefix(prefix string) PayloadStoreOption {
+	return func(p *PayloadStore) {
+		p.s3KeyPrefix = prefix
+	}
+}
+
+func WithMetricsRecorder(recorder aws_extended_sqsiface.MetricsRecorderInterface) PayloadStoreOption {
+	return func(p *PayloadStore) {
+		p.metricsRecorder = recorder
+	}
+}
+
+func WithTracer(tracer aws_extended_sqsiface.TracerInterface) PayloadStoreOption {
+	return func(p *PayloadStore) {
+		p.tracer = tracer
+	}
+}
+
+// Serves the payloads fetched again, i.e. of redelivered messages, from memory
+func WithPayloadCache(cache *payload_cache.PayloadCache) PayloadStoreOption {
+	return func(p *PayloadStore) {
+		p.payloadCache = cache
+	}
+}
+
+// Stores each payload under the sha256 of its content, so that a payload sent many times is stored once.
+// An existing payload older than touchAge is copied onto itself, so that lifecycle rules expiring objects by age
+// do not delete it while new messages point to it.
+func WithContentAddressing(touchAge time.Duration) PayloadStoreOption {
+	return func(p *PayloadStore) {
+		p.contentAddressed = true
+		p.contentAddressedTouchAge = touchAge
+	}
+}
+
+func NewPayloadStore(s3Client aws_s3iface.S3API, s3BucketName string, opts ...PayloadStoreOption) *PayloadStore {
 	payloadStore := &PayloadStore{
-		s3:           s3Client,
-		s3BucketName: s3BucketName,
+		s3:              s3Client,
+		s3BucketName:    s3BucketName,
+		metricsRecorder: noop.NewMetricsRecorder(),
+		tracer:          noop.NewTracer(),
+	}
+
+	for _, opt := range opts {
+		opt(payloadStore)
 	}
 
 	return payloadStore
 }
 
 func (p *PayloadStore) StoreOriginalPayload(originalPayload string) (string, error) {
-	s3Key := uuid.NewString()
+	return p.StoreOriginalPayloadInBucket(originalPayload, p.s3BucketName)
+}
+
+func (p *PayloadStore) StoreOriginalPayloadInBucket(originalPayload string, s3BucketName string) (string, error) {
+	return p.StoreOriginalPayloadInBucketWithContext(context.Background(), originalPayload, s3BucketName)
+}
 
-	payloadPointer, err := p.storeTextInS3(originalPayload, p.s3BucketName, s3Key)
+func (p *PayloadStore) StoreOriginalPayloadInBucketWithContext(ctx context.Context, originalPayload string, s3BucketName string) (string, error) {
+	var payloadPointer *PayloadS3Pointer
+	var err error
+
+	if p.contentAddressed {
+		payloadPointer, err = p.storeContentAddressedTextInS3(ctx, originalPayload, s3BucketName, p.s3KeyPrefix+ContentAddressedKey(originalPayload))
+	} else {
+		payloadPointer, err = p.storeTextInS3(ctx, originalPayload, s3BucketName, p.s3KeyPrefix+uuid.NewString())
+	}
 
 	if err != nil {
 		return "", err
@@ -49,85 +130,422 @@ func (p *PayloadStore) StoreOriginalPayload(originalPayload string) (string, err
 }
 
 func (p *PayloadStore) GetOriginalPayload(messagePointer string) (string, error) {
+	return p.GetOriginalPayloadWithContext(context.Background(), messagePointer)
+}
+
+func (p *PayloadStore) GetOriginalPayloadWithContext(ctx context.Context, messagePointer string) (string, error) {
 	payloadPointer, err := FromJson(messagePointer)
 	if err != nil {
 		return "", err
 	}
 
-	payload, err := p.getTextFromS3(payloadPointer.S3BucketName, payloadPointer.S3Key)
+	if payload, ok := p.getCachedPayload(payloadPointer.S3BucketName, payloadPointer.S3Key); ok {
+		return payload, nil
+	}
+
+	payload, err := p.getTextFromS3(ctx, payloadPointer.S3BucketName, payloadPointer.S3Key)
 
 	if err != nil {
 		return "", err
 	}
 
+	if p.payloadCache != nil {
+		p.payloadCache.Put(payloadPointer.S3BucketName, payloadPointer.S3Key, payload)
+	}
+
 	return payload, err
 }
 
 func (p *PayloadStore) DeleteOriginalPayload(messagePointer string) error {
+	return p.DeleteOriginalPayloadWithContext(context.Background(), messagePointer)
+}
+
+func (p *PayloadStore) DeleteOriginalPayloadWithContext(ctx context.Context, messagePointer string) error {
 	payloadPointer, err := FromJson(messagePointer)
 
 	if err != nil {
 		return err
 	}
 
-	return p.deletePayloadFromS3(payloadPointer.S3BucketName, payloadPointer.S3Key)
+	p.invalidateCachedPayload(payloadPointer.S3BucketName, payloadPointer.S3Key)
+
+	return p.deletePayloadFromS3(ctx, payloadPointer.S3BucketName, payloadPointer.S3Key)
 }
 
-func (p *PayloadStore) storeTextInS3(payload string, s3BucketName string, s3Key string) (*PayloadS3Pointer, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), payload_store_constants.S3_CONTEXT_TIMEOUT)
+// Deletes the payloads with a DeleteObjects request per bucket, returns the error of each payload at the same index, nil when deleted
+func (p *PayloadStore) DeleteOriginalPayloadsWithContext(ctx context.Context, messagePointers []string) []error {
+	errs := make([]error, len(messagePointers))
+	indexesByBucket := map[string][]int{}
+	s3Keys := make([]string, len(messagePointers))
+
+	var s3BucketNames []string
+	for index, messagePointer := range messagePointers {
+		payloadPointer, err := FromJson(messagePointer)
+		if err != nil {
+			errs[index] = err
+			continue
+		}
+
+		if _, ok := indexesByBucket[payloadPointer.S3BucketName]; !ok {
+			s3BucketNames = append(s3BucketNames, payloadPointer.S3BucketName)
+		}
+
+		indexesByBucket[payloadPointer.S3BucketName] = append(indexesByBucket[payloadPointer.S3BucketName], index)
+		s3Keys[index] = payloadPointer.S3Key
+
+		p.invalidateCachedPayload(payloadPointer.S3BucketName, payloadPointer.S3Key)
+	}
+
+	for _, s3BucketName := range s3BucketNames {
+		indexes := indexesByBucket[s3BucketName]
+
+		for start := 0; start < len(indexes); start += payload_store_constants.MAX_S3_DELETE_OBJECTS {
+			end := start + payload_store_constants.MAX_S3_DELETE_OBJECTS
+			if end > len(indexes) {
+				end = len(indexes)
+			}
+
+			keys := make([]string, 0, end-start)
+			for _, index := range indexes[start:end] {
+				keys = append(keys, s3Keys[index])
+			}
+
+			keyErrs := p.deletePayloadsFromS3(ctx, s3BucketName, keys)
+			for i, index := range indexes[start:end] {
+				errs[index] = keyErrs[i]
+			}
+		}
+	}
+
+	return errs
+}
+
+// Always a miss without a payload cache, nothing is recorded then
+func (p *PayloadStore) getCachedPayload(s3BucketName string, s3Key string) (string, bool) {
+	if p.payloadCache == nil {
+		return "", false
+	}
+
+	payload, ok := p.payloadCache.Get(s3BucketName, s3Key)
+
+	result := metrics_constants.RESULT_MISS
+	if ok {
+		result = metrics_constants.RESULT_HIT
+	}
+
+	p.metricsRecorder.IncrementCounter(metrics_constants.METRIC_PAYLOAD_CACHE_LOOKUPS, map[string]string{
+		metrics_constants.LABEL_RESULT: result,
+	})
+
+	return payload, ok
+}
+
+// Before the payload is deleted, so that a failed deletion does not leave a payload cached which may be gone
+func (p *PayloadStore) invalidateCachedPayload(s3BucketName string, s3Key string) {
+	if p.payloadCache != nil {
+		p.payloadCache.Invalidate(s3BucketName, s3Key)
+	}
+}
+
+func (p *PayloadStore) getS3Client(s3BucketName string) (aws_s3iface.S3API, error) {
+	if p.s3ClientResolver == nil {
+		return p.s3, nil
+	}
+
+	return p.s3ClientResolver.ResolveS3Client(s3BucketName)
+}
+
+func (p *PayloadStore) storeTextInS3(ctx context.Context, payload string, s3BucketName string, s3Key string) (*PayloadS3Pointer, error) {
+	s3Client, err := p.getS3Client(s3BucketName)
+	if err != nil {
+		return nil, newS3Error(errors_constants.OPERATION_STORE, s3BucketName, s3Key, err)
+	}
+
+	ctx, span := p.startS3Span(ctx, tracing_constants.SPAN_S3_STORE, s3BucketName, s3Key)
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, payload_store_constants.S3_CONTEXT_TIMEOUT)
 	defer cancel()
 
 	reader := strings.NewReader(payload)
 
-	_, err := p.s3.PutObjectWithContext(ctx, &aws_s3.PutObjectInput{
+	startedAt := time.Now()
+	_, err = s3Client.PutObjectWithContext(ctx, &aws_s3.PutObjectInput{
 		Bucket: aws.String(s3BucketName),
 		Key:    aws.String(s3Key),
 		Body:   reader,
 	})
+	p.recordS3Operation(errors_constants.OPERATION_STORE, startedAt, err)
 
 	if err != nil {
-		return nil, err
+		span.RecordError(err)
+		return nil, newS3Error(errors_constants.OPERATION_STORE, s3BucketName, s3Key, err)
+	}
+
+	return &PayloadS3Pointer{
+		S3BucketName: s3BucketName,
+		S3Key:        s3Key,
+	}, nil
+}
+
+// Skips the upload when the payload is already stored, the key being derived from the content
+func (p *PayloadStore) storeContentAddressedTextInS3(ctx context.Context, payload string, s3BucketName string, s3Key string) (*PayloadS3Pointer, error) {
+	s3Client, err := p.getS3Client(s3BucketName)
+	if err != nil {
+		return nil, newS3Error(errors_constants.OPERATION_CHECK, s3BucketName, s3Key, err)
+	}
+
+	head, err := p.headObjectInS3(ctx, s3Client, s3BucketName, s3Key)
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == payload_store_constants.S3_ERR_CODE_NOT_FOUND {
+			return p.storeTextInS3(ctx, payload, s3BucketName, s3Key)
+		}
+
+		return nil, newS3Error(errors_constants.OPERATION_CHECK, s3BucketName, s3Key, err)
+	}
+
+	// Only a partial upload would not match, which is overwritten
+	if aws.Int64Value(head.ContentLength) != int64(len(payload)) {
+		return p.storeTextInS3(ctx, payload, s3BucketName, s3Key)
+	}
+
+	if time.Since(aws.TimeValue(head.LastModified)) >= p.contentAddressedTouchAge {
+		if err := p.touchObjectInS3(ctx, s3Client, s3BucketName, s3Key, head); err != nil {
+			return nil, newS3Error(errors_constants.OPERATION_TOUCH, s3BucketName, s3Key, err)
+		}
 	}
 
 	return &PayloadS3Pointer{
-		S3BucketName: p.s3BucketName,
+		S3BucketName: s3BucketName,
 		S3Key:        s3Key,
 	}, nil
 }
 
-func (p *PayloadStore) getTextFromS3(s3BucketName string, s3Key string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), payload_store_constants.S3_CONTEXT_TIMEOUT)
+func (p *PayloadStore) headObjectInS3(ctx context.Context, s3Client aws_s3iface.S3API, s3BucketName string, s3Key string) (*aws_s3.HeadObjectOutput, error) {
+	ctx, span := p.startS3Span(ctx, tracing_constants.SPAN_S3_HEAD, s3BucketName, s3Key)
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, payload_store_constants.S3_CONTEXT_TIMEOUT)
+	defer cancel()
+
+	startedAt := time.Now()
+	head, err := s3Client.HeadObjectWithContext(ctx, &aws_s3.HeadObjectInput{
+		Bucket: aws.String(s3BucketName),
+		Key:    aws.String(s3Key),
+	})
+	p.recordS3Operation(errors_constants.OPERATION_CHECK, startedAt, err)
+
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return head, nil
+}
+
+// Copies the object onto itself to refresh its last modified time, keeping its content type and metadata
+func (p *PayloadStore) touchObjectInS3(ctx context.Context, s3Client aws_s3iface.S3API, s3BucketName string, s3Key string, head *aws_s3.HeadObjectOutput) error {
+	ctx, span := p.startS3Span(ctx, tracing_constants.SPAN_S3_COPY, s3BucketName, s3Key)
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, payload_store_constants.S3_CONTEXT_TIMEOUT)
+	defer cancel()
+
+	startedAt := time.Now()
+	_, err := s3Client.CopyObjectWithContext(ctx, &aws_s3.CopyObjectInput{
+		Bucket:            aws.String(s3BucketName),
+		Key:               aws.String(s3Key),
+		CopySource:        aws.String(s3BucketName + "/" + (&url.URL{Path: s3Key}).EscapedPath()),
+		MetadataDirective: aws.String(aws_s3.MetadataDirectiveReplace),
+		ContentType:       head.ContentType,
+		Metadata:          head.Metadata,
+	})
+	p.recordS3Operation(errors_constants.OPERATION_TOUCH, startedAt, err)
+
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+func (p *PayloadStore) getTextFromS3(ctx context.Context, s3BucketName string, s3Key string) (string, error) {
+	s3Client, err := p.getS3Client(s3BucketName)
+	if err != nil {
+		return "", newS3Error(errors_constants.OPERATION_FETCH, s3BucketName, s3Key, err)
+	}
+
+	ctx, span := p.startS3Span(ctx, tracing_constants.SPAN_S3_FETCH, s3BucketName, s3Key)
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, payload_store_constants.S3_CONTEXT_TIMEOUT)
 	defer cancel()
 
-	rawObject, err := p.s3.GetObjectWithContext(ctx, &aws_s3.GetObjectInput{
+	startedAt := time.Now()
+	rawObject, err := s3Client.GetObjectWithContext(ctx, &aws_s3.GetObjectInput{
 		Bucket: aws.String(s3BucketName),
 		Key:    aws.String(s3Key),
 	})
 
 	if err != nil {
-		return "", err
+		p.recordS3Operation(errors_constants.OPERATION_FETCH, startedAt, err)
+		span.RecordError(err)
+		return "", newS3Error(errors_constants.OPERATION_FETCH, s3BucketName, s3Key, err)
 	}
 
 	defer rawObject.Body.Close()
 
 	var objectBuffer bytes.Buffer
 	_, err = io.Copy(&objectBuffer, rawObject.Body)
+	p.recordS3Operation(errors_constants.OPERATION_FETCH, startedAt, err)
 
 	if err != nil {
-		return "", err
+		span.RecordError(err)
+		return "", newS3Error(errors_constants.OPERATION_FETCH, s3BucketName, s3Key, err)
 	}
 
 	return objectBuffer.String(), nil
 }
 
-func (p *PayloadStore) deletePayloadFromS3(s3BucketName string, s3Key string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), payload_store_constants.S3_CONTEXT_TIMEOUT)
+func (p *PayloadStore) deletePayloadFromS3(ctx context.Context, s3BucketName string, s3Key string) error {
+	s3Client, err := p.getS3Client(s3BucketName)
+	if err != nil {
+		return newS3Error(errors_constants.OPERATION_DELETE, s3BucketName, s3Key, err)
+	}
+
+	ctx, span := p.startS3Span(ctx, tracing_constants.SPAN_S3_DELETE, s3BucketName, s3Key)
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, payload_store_constants.S3_CONTEXT_TIMEOUT)
 	defer cancel()
 
-	_, err := p.s3.DeleteObjectWithContext(ctx, &aws_s3.DeleteObjectInput{
+	startedAt := time.Now()
+	_, err = s3Client.DeleteObjectWithContext(ctx, &aws_s3.DeleteObjectInput{
 		Bucket: aws.String(s3BucketName),
 		Key:    aws.String(s3Key),
 	})
+	p.recordS3Operation(errors_constants.OPERATION_DELETE, startedAt, err)
+
+	if err != nil {
+		span.RecordError(err)
+		return newS3Error(errors_constants.OPERATION_DELETE, s3BucketName, s3Key, err)
+	}
+
+	return nil
+}
+
+func (p *PayloadStore) deletePayloadsFromS3(ctx context.Context, s3BucketName string, s3Keys []string) []error {
+	errs := make([]error, len(s3Keys))
+	setErrs := func(err error) []error {
+		for index, s3Key := range s3Keys {
+			errs[index] = newS3Error(errors_constants.OPERATION_DELETE, s3BucketName, s3Key, err)
+		}
+		return errs
+	}
 
-	return err
+	s3Client, err := p.getS3Client(s3BucketName)
+	if err != nil {
+		return setErrs(err)
+	}
+
+	ctx, span := p.tracer.StartSpan(ctx, tracing_constants.SPAN_S3_DELETE_OBJECTS, tracing_constants.SPAN_KIND_CLIENT, map[string]string{
+		tracing_constants.ATTRIBUTE_S3_BUCKET: s3BucketName,
+	})
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, payload_store_constants.S3_CONTEXT_TIMEOUT)
+	defer cancel()
+
+	objects := make([]*aws_s3.ObjectIdentifier, len(s3Keys))
+	for index, s3Key := range s3Keys {
+		objects[index] = &aws_s3.ObjectIdentifier{Key: aws.String(s3Key)}
+	}
+
+	startedAt := time.Now()
+	output, err := s3Client.DeleteObjectsWithContext(ctx, &aws_s3.DeleteObjectsInput{
+		Bucket: aws.String(s3BucketName),
+		Delete: &aws_s3.Delete{
+			Objects: objects,
+			Quiet:   aws.Bool(true),
+		},
+	})
+	p.recordS3Operation(errors_constants.OPERATION_DELETE, startedAt, err)
+
+	if err != nil {
+		span.RecordError(err)
+		return setErrs(err)
+	}
+
+	// In quiet mode only the keys which failed are returned
+	failedKeys := map[string]*aws_s3.Error{}
+	for _, s3Err := range output.Errors {
+		failedKeys[aws.StringValue(s3Err.Key)] = s3Err
+	}
+
+	for index, s3Key := range s3Keys {
+		if s3Err, ok := failedKeys[s3Key]; ok {
+			err := awserr.New(aws.StringValue(s3Err.Code), aws.StringValue(s3Err.Message), nil)
+			span.RecordError(err)
+			errs[index] = newS3Error(errors_constants.OPERATION_DELETE, s3BucketName, s3Key, err)
+		}
+	}
+
+	return errs
+}
+
+func (p *PayloadStore) recordS3Operation(operation string, startedAt time.Time, err error) {
+	status := metrics_constants.STATUS_SUCCESS
+	if err != nil {
+		status = metrics_constants.STATUS_ERROR
+	}
+
+	labels := map[string]string{
+		metrics_constants.LABEL_OPERATION: operation,
+		metrics_constants.LABEL_STATUS:    status,
+	}
+
+	p.metricsRecorder.IncrementCounter(metrics_constants.METRIC_S3_OPERATIONS, labels)
+	p.metricsRecorder.ObserveHistogram(metrics_constants.METRIC_S3_OPERATION_DURATION, time.Since(startedAt).Seconds(), labels)
+}
+
+func (p *PayloadStore) startS3Span(ctx context.Context, name string, s3BucketName string, s3Key string) (context.Context, aws_extended_sqsiface.SpanInterface) {
+	return p.tracer.StartSpan(ctx, name, tracing_constants.SPAN_KIND_CLIENT, map[string]string{
+		tracing_constants.ATTRIBUTE_S3_BUCKET: s3BucketName,
+		tracing_constants.ATTRIBUTE_S3_KEY:    s3Key,
+	})
+}
+
+// Key of a payload stored with WithContentAddressing, without the s3 key prefix
+func ContentAddressedKey(payload string) string {
+	hash := sha256.Sum256([]byte(payload))
+
+	return payload_store_constants.CONTENT_ADDRESSED_KEY_PREFIX + hex.EncodeToString(hash[:])
+}
+
+// Whether the key is one of a content addressed payload, which may be shared by many messages, whatever its s3 key prefix
+func IsContentAddressedKey(s3Key string) bool {
+	length := len(payload_store_constants.CONTENT_ADDRESSED_KEY_PREFIX) + hex.EncodedLen(sha256.Size)
+	if len(s3Key) < length {
+		return false
+	}
+
+	name := s3Key[len(s3Key)-length:]
+	if !strings.HasPrefix(name, payload_store_constants.CONTENT_ADDRESSED_KEY_PREFIX) {
+		return false
+	}
+
+	hash := name[len(payload_store_constants.CONTENT_ADDRESSED_KEY_PREFIX):]
+	_, err := hex.DecodeString(hash)
+
+	return err == nil && strings.ToLower(hash) == hash
+}
+
+func newS3Error(operation string, s3BucketName string, s3Key string, err error) error {
+	return errors.S3Error{
+		Operation:    operation,
+		S3BucketName: s3BucketName,
+		S3Key:        s3Key,
+		Err:          err,
+	}
 }
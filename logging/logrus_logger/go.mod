@@ -0,0 +1,18 @@
+module github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging/logrus_logger
+
+go 1.21
+
+require (
+	github.com/shoplineapp/aws-sqs-golang-extended-client-lib v0.0.0-20261019115548-ffc1e5eb724e
+	github.com/sirupsen/logrus v1.9.3
+	github.com/stretchr/testify v1.8.0
+)
+
+require (
+	github.com/aws/aws-sdk-go v1.34.34 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/jmespath/go-jmespath v0.4.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
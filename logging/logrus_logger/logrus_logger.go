@@ -0,0 +1,38 @@
+package logrus_logger
+
+import (
+	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
+	logging_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging/constants"
+
+	"github.com/sirupsen/logrus"
+)
+
+type LogrusLogger struct {
+	aws_extended_sqsiface.LoggerInterface
+	logger logrus.FieldLogger
+}
+
+func NewLogrusLogger(logger logrus.FieldLogger) *LogrusLogger {
+	return &LogrusLogger{logger: logger}
+}
+
+func (l *LogrusLogger) WithField(key string, value interface{}) aws_extended_sqsiface.LoggerInterface {
+	return &LogrusLogger{logger: l.logger.WithField(key, value)}
+}
+
+func (l *LogrusLogger) WithFields(fields map[string]interface{}) aws_extended_sqsiface.LoggerInterface {
+	return &LogrusLogger{logger: l.logger.WithFields(logrus.Fields(fields))}
+}
+
+func (l *LogrusLogger) Log(level string, message string) {
+	switch level {
+	case logging_constants.LOG_LEVEL_DEBUG:
+		l.logger.Debug(message)
+	case logging_constants.LOG_LEVEL_WARN:
+		l.logger.Warn(message)
+	case logging_constants.LOG_LEVEL_ERROR:
+		l.logger.Error(message)
+	default:
+		l.logger.Info(message)
+	}
+}
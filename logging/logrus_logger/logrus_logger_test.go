@@ -0,0 +1,43 @@
+package logrus_logger
+
+import (
+	"testing"
+
+	logging_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging/constants"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LogrusLogger_Log(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+
+	NewLogrusLogger(logger).
+		WithField("method", "SendMessage").
+		WithFields(map[string]interface{}{"uploaded_to_s3": "false"}).
+		Log(logging_constants.LOG_LEVEL_DEBUG, "Handled by original sqs sdk")
+
+	entry := hook.LastEntry()
+	assert.Equal(t, logrus.DebugLevel, entry.Level)
+	assert.Equal(t, "Handled by original sqs sdk", entry.Message)
+	assert.Equal(t, logrus.Fields{"method": "SendMessage", "uploaded_to_s3": "false"}, entry.Data)
+}
+
+func Test_LogrusLogger_Log_Levels(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+
+	levels := map[string]logrus.Level{
+		logging_constants.LOG_LEVEL_DEBUG: logrus.DebugLevel,
+		logging_constants.LOG_LEVEL_INFO:  logrus.InfoLevel,
+		logging_constants.LOG_LEVEL_WARN:  logrus.WarnLevel,
+		logging_constants.LOG_LEVEL_ERROR: logrus.ErrorLevel,
+	}
+
+	for level, expected := range levels {
+		NewLogrusLogger(logger).Log(level, "test")
+		assert.Equal(t, expected, hook.LastEntry().Level)
+	}
+}
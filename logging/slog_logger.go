@@ -0,0 +1,57 @@
+//go:build go1.21
+
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+
+	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
+	logging_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging/constants"
+)
+
+type SlogLogger struct {
+	aws_extended_sqsiface.LoggerInterface
+	logger *slog.Logger
+}
+
+func NewSlogLogger(logger *slog.Logger) *SlogLogger {
+	return &SlogLogger{logger: logger}
+}
+
+func (l *SlogLogger) WithField(key string, value interface{}) aws_extended_sqsiface.LoggerInterface {
+	return &SlogLogger{logger: l.logger.With(key, value)}
+}
+
+func (l *SlogLogger) WithFields(fields map[string]interface{}) aws_extended_sqsiface.LoggerInterface {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	args := make([]any, 0, len(fields)*2)
+	for _, key := range keys {
+		args = append(args, key, fields[key])
+	}
+
+	return &SlogLogger{logger: l.logger.With(args...)}
+}
+
+func (l *SlogLogger) Log(level string, message string) {
+	l.logger.Log(context.Background(), getSlogLevel(level), message)
+}
+
+func getSlogLevel(level string) slog.Level {
+	switch level {
+	case logging_constants.LOG_LEVEL_DEBUG:
+		return slog.LevelDebug
+	case logging_constants.LOG_LEVEL_WARN:
+		return slog.LevelWarn
+	case logging_constants.LOG_LEVEL_ERROR:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
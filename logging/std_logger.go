@@ -0,0 +1,90 @@
+package logging
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
+	logging_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging/constants"
+)
+
+// Writes logfmt-like lines, i.e. level=info msg="Uploaded to s3" method=SendMessage, to a standard library logger.
+// Used by the client when no logger is given.
+type StdLogger struct {
+	aws_extended_sqsiface.LoggerInterface
+	logger *log.Logger
+	level  string
+	fields map[string]interface{}
+}
+
+// Logs at info and above to stderr, the logger used when none is given
+func NewDefaultLogger() *StdLogger {
+	return NewStdLogger(log.New(os.Stderr, "", log.LstdFlags), logging_constants.LOG_LEVEL_INFO)
+}
+
+// Logs at level and above
+func NewStdLogger(logger *log.Logger, level string) *StdLogger {
+	return &StdLogger{
+		logger: logger,
+		level:  level,
+		fields: map[string]interface{}{},
+	}
+}
+
+func (l *StdLogger) WithField(key string, value interface{}) aws_extended_sqsiface.LoggerInterface {
+	return l.WithFields(map[string]interface{}{key: value})
+}
+
+func (l *StdLogger) WithFields(fields map[string]interface{}) aws_extended_sqsiface.LoggerInterface {
+	newFields := make(map[string]interface{}, len(l.fields)+len(fields))
+	for key, value := range l.fields {
+		newFields[key] = value
+	}
+	for key, value := range fields {
+		newFields[key] = value
+	}
+
+	return &StdLogger{
+		logger: l.logger,
+		level:  l.level,
+		fields: newFields,
+	}
+}
+
+func (l *StdLogger) Log(level string, message string) {
+	if getLevelSeverity(level) < getLevelSeverity(l.level) {
+		return
+	}
+
+	keys := make([]string, 0, len(l.fields))
+	for key := range l.fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "level=%s msg=%q", level, strings.TrimSpace(message))
+	for _, key := range keys {
+		fmt.Fprintf(&builder, " %s=%q", key, fmt.Sprint(l.fields[key]))
+	}
+
+	l.logger.Println(builder.String())
+}
+
+func getLevelSeverity(level string) int {
+	switch level {
+	case logging_constants.LOG_LEVEL_DEBUG:
+		return 0
+	case logging_constants.LOG_LEVEL_INFO:
+		return 1
+	case logging_constants.LOG_LEVEL_WARN:
+		return 2
+	case logging_constants.LOG_LEVEL_ERROR:
+		return 3
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,51 @@
+package zap_logger
+
+import (
+	"sort"
+
+	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
+	logging_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging/constants"
+
+	"go.uber.org/zap"
+)
+
+type ZapLogger struct {
+	aws_extended_sqsiface.LoggerInterface
+	logger *zap.Logger
+}
+
+func NewZapLogger(logger *zap.Logger) *ZapLogger {
+	return &ZapLogger{logger: logger}
+}
+
+func (l *ZapLogger) WithField(key string, value interface{}) aws_extended_sqsiface.LoggerInterface {
+	return &ZapLogger{logger: l.logger.With(zap.Any(key, value))}
+}
+
+func (l *ZapLogger) WithFields(fields map[string]interface{}) aws_extended_sqsiface.LoggerInterface {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	zapFields := make([]zap.Field, 0, len(fields))
+	for _, key := range keys {
+		zapFields = append(zapFields, zap.Any(key, fields[key]))
+	}
+
+	return &ZapLogger{logger: l.logger.With(zapFields...)}
+}
+
+func (l *ZapLogger) Log(level string, message string) {
+	switch level {
+	case logging_constants.LOG_LEVEL_DEBUG:
+		l.logger.Debug(message)
+	case logging_constants.LOG_LEVEL_WARN:
+		l.logger.Warn(message)
+	case logging_constants.LOG_LEVEL_ERROR:
+		l.logger.Error(message)
+	default:
+		l.logger.Info(message)
+	}
+}
@@ -0,0 +1,45 @@
+package zap_logger
+
+import (
+	"testing"
+
+	logging_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging/constants"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func Test_ZapLogger_Log(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	NewZapLogger(zap.New(core)).
+		WithField("method", "SendMessage").
+		WithFields(map[string]interface{}{"uploaded_to_s3": "false"}).
+		Log(logging_constants.LOG_LEVEL_DEBUG, "Handled by original sqs sdk")
+
+	entries := logs.All()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, zapcore.DebugLevel, entries[0].Level)
+	assert.Equal(t, "Handled by original sqs sdk", entries[0].Message)
+	assert.Equal(t, map[string]interface{}{"method": "SendMessage", "uploaded_to_s3": "false"}, entries[0].ContextMap())
+}
+
+func Test_ZapLogger_Log_Levels(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := NewZapLogger(zap.New(core))
+
+	levels := map[string]zapcore.Level{
+		logging_constants.LOG_LEVEL_DEBUG: zapcore.DebugLevel,
+		logging_constants.LOG_LEVEL_INFO:  zapcore.InfoLevel,
+		logging_constants.LOG_LEVEL_WARN:  zapcore.WarnLevel,
+		logging_constants.LOG_LEVEL_ERROR: zapcore.ErrorLevel,
+	}
+
+	for level, expected := range levels {
+		logger.Log(level, "test")
+		entries := logs.TakeAll()
+		assert.Equal(t, expected, entries[0].Level)
+	}
+}
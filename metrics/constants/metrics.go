@@ -0,0 +1,36 @@
+package metrics_constants
+
+const (
+	// Counter of sent messages, labelled by LABEL_DESTINATION
+	METRIC_MESSAGES_SENT = "extended_sqs_messages_sent_total"
+	// Histogram of sent message body sizes in bytes, labelled by LABEL_DESTINATION
+	METRIC_MESSAGE_PAYLOAD_SIZE = "extended_sqs_message_payload_size_bytes"
+	// Counter of received messages, labelled by LABEL_DESTINATION
+	METRIC_MESSAGES_RECEIVED = "extended_sqs_messages_received_total"
+	// Counter of messages rejected by the break send threshold
+	METRIC_BREAK_SEND_REJECTIONS = "extended_sqs_break_send_rejections_total"
+	// Counter of s3 operations on payloads, labelled by LABEL_OPERATION and LABEL_STATUS
+	METRIC_S3_OPERATIONS = "extended_sqs_s3_operations_total"
+	// Histogram of s3 operation latencies in seconds, labelled by LABEL_OPERATION and LABEL_STATUS
+	METRIC_S3_OPERATION_DURATION = "extended_sqs_s3_operation_duration_seconds"
+	// Counter of payload lookups in the payload cache, labelled by LABEL_RESULT
+	METRIC_PAYLOAD_CACHE_LOOKUPS = "extended_sqs_payload_cache_lookups_total"
+)
+
+const (
+	LABEL_DESTINATION = "destination"
+	LABEL_OPERATION   = "operation"
+	LABEL_STATUS      = "status"
+	LABEL_RESULT      = "result"
+)
+
+const (
+	DESTINATION_S3  = "s3"
+	DESTINATION_SQS = "sqs"
+
+	STATUS_SUCCESS = "success"
+	STATUS_ERROR   = "error"
+
+	RESULT_HIT  = "hit"
+	RESULT_MISS = "miss"
+)
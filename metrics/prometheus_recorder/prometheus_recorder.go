@@ -0,0 +1,149 @@
+package prometheus_recorder
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	DEFAULT_DURATION_BUCKETS = prometheus.DefBuckets
+	DEFAULT_SIZE_BUCKETS     = prometheus.ExponentialBuckets(1024, 4, 8)
+)
+
+// Exposes the metrics of the client as prometheus counters and histograms.
+// Collectors are created and registered on first use, with the label names of the first observation.
+type PrometheusRecorder struct {
+	aws_extended_sqsiface.MetricsRecorderInterface
+	registerer      prometheus.Registerer
+	durationBuckets []float64
+	sizeBuckets     []float64
+
+	mu         sync.Mutex
+	counters   map[string]*prometheus.CounterVec
+	histograms map[string]*prometheus.HistogramVec
+}
+
+type PrometheusRecorderOption func(*PrometheusRecorder)
+
+func WithDurationBuckets(buckets []float64) PrometheusRecorderOption {
+	return func(r *PrometheusRecorder) {
+		r.durationBuckets = buckets
+	}
+}
+
+func WithSizeBuckets(buckets []float64) PrometheusRecorderOption {
+	return func(r *PrometheusRecorder) {
+		r.sizeBuckets = buckets
+	}
+}
+
+func NewPrometheusRecorder(registerer prometheus.Registerer, opts ...PrometheusRecorderOption) *PrometheusRecorder {
+	recorder := &PrometheusRecorder{
+		registerer:      registerer,
+		durationBuckets: DEFAULT_DURATION_BUCKETS,
+		sizeBuckets:     DEFAULT_SIZE_BUCKETS,
+		counters:        make(map[string]*prometheus.CounterVec),
+		histograms:      make(map[string]*prometheus.HistogramVec),
+	}
+
+	for _, opt := range opts {
+		opt(recorder)
+	}
+
+	return recorder
+}
+
+func (r *PrometheusRecorder) IncrementCounter(name string, labels map[string]string) {
+	counter, err := r.getCounter(name, labels).GetMetricWith(labels)
+	if err != nil {
+		return
+	}
+
+	counter.Inc()
+}
+
+func (r *PrometheusRecorder) ObserveHistogram(name string, value float64, labels map[string]string) {
+	histogram, err := r.getHistogram(name, labels).GetMetricWith(labels)
+	if err != nil {
+		return
+	}
+
+	histogram.Observe(value)
+}
+
+func (r *PrometheusRecorder) getCounter(name string, labels map[string]string) *prometheus.CounterVec {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if counter, ok := r.counters[name]; ok {
+		return counter
+	}
+
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: name,
+		Help: "Counter " + name + " of the aws sqs extended client",
+	}, getLabelNames(labels))
+
+	if err := r.registerer.Register(counter); err != nil {
+		if registered, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			if existing, ok := registered.ExistingCollector.(*prometheus.CounterVec); ok {
+				counter = existing
+			}
+		}
+	}
+
+	r.counters[name] = counter
+
+	return counter
+}
+
+func (r *PrometheusRecorder) getHistogram(name string, labels map[string]string) *prometheus.HistogramVec {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if histogram, ok := r.histograms[name]; ok {
+		return histogram
+	}
+
+	histogram := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    name,
+		Help:    "Histogram " + name + " of the aws sqs extended client",
+		Buckets: r.getBuckets(name),
+	}, getLabelNames(labels))
+
+	if err := r.registerer.Register(histogram); err != nil {
+		if registered, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			if existing, ok := registered.ExistingCollector.(*prometheus.HistogramVec); ok {
+				histogram = existing
+			}
+		}
+	}
+
+	r.histograms[name] = histogram
+
+	return histogram
+}
+
+func (r *PrometheusRecorder) getBuckets(name string) []float64 {
+	if strings.HasSuffix(name, "_bytes") {
+		return r.sizeBuckets
+	}
+
+	return r.durationBuckets
+}
+
+func getLabelNames(labels map[string]string) []string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
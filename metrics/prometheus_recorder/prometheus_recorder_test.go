@@ -0,0 +1,79 @@
+package prometheus_recorder_test
+
+import (
+	"strings"
+	"testing"
+
+	metrics_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/metrics/constants"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/metrics/prometheus_recorder"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PrometheusRecorder_IncrementCounter(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	recorder := prometheus_recorder.NewPrometheusRecorder(registry)
+
+	recorder.IncrementCounter(metrics_constants.METRIC_MESSAGES_SENT, map[string]string{metrics_constants.LABEL_DESTINATION: metrics_constants.DESTINATION_S3})
+	recorder.IncrementCounter(metrics_constants.METRIC_MESSAGES_SENT, map[string]string{metrics_constants.LABEL_DESTINATION: metrics_constants.DESTINATION_S3})
+	recorder.IncrementCounter(metrics_constants.METRIC_MESSAGES_SENT, map[string]string{metrics_constants.LABEL_DESTINATION: metrics_constants.DESTINATION_SQS})
+
+	expected := `
+# HELP extended_sqs_messages_sent_total Counter extended_sqs_messages_sent_total of the aws sqs extended client
+# TYPE extended_sqs_messages_sent_total counter
+extended_sqs_messages_sent_total{destination="s3"} 2
+extended_sqs_messages_sent_total{destination="sqs"} 1
+`
+	assert.Nil(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), metrics_constants.METRIC_MESSAGES_SENT))
+}
+
+func Test_PrometheusRecorder_ObserveHistogram(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	recorder := prometheus_recorder.NewPrometheusRecorder(registry, prometheus_recorder.WithDurationBuckets([]float64{0.1, 1}))
+
+	labels := map[string]string{
+		metrics_constants.LABEL_OPERATION: "store",
+		metrics_constants.LABEL_STATUS:    metrics_constants.STATUS_SUCCESS,
+	}
+	recorder.ObserveHistogram(metrics_constants.METRIC_S3_OPERATION_DURATION, 0.05, labels)
+	recorder.ObserveHistogram(metrics_constants.METRIC_S3_OPERATION_DURATION, 0.5, labels)
+
+	expected := `
+# HELP extended_sqs_s3_operation_duration_seconds Histogram extended_sqs_s3_operation_duration_seconds of the aws sqs extended client
+# TYPE extended_sqs_s3_operation_duration_seconds histogram
+extended_sqs_s3_operation_duration_seconds_bucket{operation="store",status="success",le="0.1"} 1
+extended_sqs_s3_operation_duration_seconds_bucket{operation="store",status="success",le="1"} 2
+extended_sqs_s3_operation_duration_seconds_bucket{operation="store",status="success",le="+Inf"} 2
+extended_sqs_s3_operation_duration_seconds_sum{operation="store",status="success"} 0.55
+extended_sqs_s3_operation_duration_seconds_count{operation="store",status="success"} 2
+`
+	assert.Nil(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), metrics_constants.METRIC_S3_OPERATION_DURATION))
+}
+
+func Test_PrometheusRecorder_Shares_Registered_Collectors(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	labels := map[string]string{metrics_constants.LABEL_DESTINATION: metrics_constants.DESTINATION_S3}
+
+	prometheus_recorder.NewPrometheusRecorder(registry).IncrementCounter(metrics_constants.METRIC_MESSAGES_RECEIVED, labels)
+	prometheus_recorder.NewPrometheusRecorder(registry).IncrementCounter(metrics_constants.METRIC_MESSAGES_RECEIVED, labels)
+
+	expected := `
+# HELP extended_sqs_messages_received_total Counter extended_sqs_messages_received_total of the aws sqs extended client
+# TYPE extended_sqs_messages_received_total counter
+extended_sqs_messages_received_total{destination="s3"} 2
+`
+	assert.Nil(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), metrics_constants.METRIC_MESSAGES_RECEIVED))
+}
+
+func Test_PrometheusRecorder_Ignores_Inconsistent_Labels(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	recorder := prometheus_recorder.NewPrometheusRecorder(registry)
+
+	recorder.IncrementCounter(metrics_constants.METRIC_BREAK_SEND_REJECTIONS, map[string]string{})
+
+	assert.NotPanics(t, func() {
+		recorder.IncrementCounter(metrics_constants.METRIC_BREAK_SEND_REJECTIONS, map[string]string{"unexpected": "label"})
+	})
+}
@@ -0,0 +1,46 @@
+package aws_extended_sqs_acknowledger
+
+import (
+	"context"
+)
+
+// Called once the message is deleted, with the error of the delete
+type AckCallback func(err error)
+
+// Outcome of a receipt handle buffered by the acknowledger
+type AckFuture struct {
+	done     chan struct{}
+	err      error
+	callback AckCallback
+}
+
+func newAckFuture(callback AckCallback) *AckFuture {
+	return &AckFuture{
+		done:     make(chan struct{}),
+		callback: callback,
+	}
+}
+
+// Closed once the message is deleted or has failed
+func (f *AckFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+// Waits for the message to be deleted and returns the error of the delete, or the error of ctx when it is done first
+func (f *AckFuture) Get(ctx context.Context) error {
+	select {
+	case <-f.done:
+		return f.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (f *AckFuture) complete(err error) {
+	f.err = err
+	close(f.done)
+
+	if f.callback != nil {
+		f.callback(err)
+	}
+}
@@ -0,0 +1,172 @@
+package aws_extended_sqs_acknowledger
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors"
+	errors_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors/constants"
+	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/internal/batcher"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging"
+	logging_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging/constants"
+	acknowledger_configs_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_acknowledger/constants"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+	aws_sqsiface "github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+)
+
+// Collects the receipt handles of handled messages per queue and deletes them with DeleteMessageBatch once a batch is full
+// or the flush interval has passed. client is expected to be an AwsExtendedSQSClient so that the receipt handles of messages
+// received through s3 are unwrapped and their payloads deleted in bulk.
+type AwsExtendedSQSAcknowledger struct {
+	client  aws_sqsiface.SQSAPI
+	opts    *awsExtendedSQSAcknowledgerOptions
+	batcher *batcher.Batcher
+}
+
+type awsExtendedSQSAcknowledgerOptions struct {
+	logger        aws_extended_sqsiface.LoggerInterface
+	maxBatchSize  int
+	flushInterval time.Duration
+}
+
+type AwsExtendedSQSAcknowledgerOption func(*awsExtendedSQSAcknowledgerOptions)
+
+type bufferedAck struct {
+	receiptHandle string
+	future        *AckFuture
+}
+
+func newAcknowledgerOptions() *awsExtendedSQSAcknowledgerOptions {
+	return &awsExtendedSQSAcknowledgerOptions{
+		logger:        logging.NewDefaultLogger(),
+		maxBatchSize:  acknowledger_configs_constants.DEFAULT_MAX_BATCH_SIZE,
+		flushInterval: acknowledger_configs_constants.DEFAULT_FLUSH_INTERVAL,
+	}
+}
+
+func WithLogger(logger aws_extended_sqsiface.LoggerInterface) AwsExtendedSQSAcknowledgerOption {
+	return func(opts *awsExtendedSQSAcknowledgerOptions) {
+		opts.logger = logger
+	}
+}
+
+// Receipt handles per batch, from 1 to 10
+func WithMaxBatchSize(maxBatchSize int) AwsExtendedSQSAcknowledgerOption {
+	return func(opts *awsExtendedSQSAcknowledgerOptions) {
+		opts.maxBatchSize = maxBatchSize
+	}
+}
+
+// Longest a receipt handle waits in a batch which is not full before the batch is deleted
+func WithFlushInterval(flushInterval time.Duration) AwsExtendedSQSAcknowledgerOption {
+	return func(opts *awsExtendedSQSAcknowledgerOptions) {
+		opts.flushInterval = flushInterval
+	}
+}
+
+func NewExtendedSQSAcknowledger(client aws_sqsiface.SQSAPI, opts ...AwsExtendedSQSAcknowledgerOption) *AwsExtendedSQSAcknowledger {
+	acknowledgerOpts := newAcknowledgerOptions()
+	for _, opt := range opts {
+		opt(acknowledgerOpts)
+	}
+
+	acknowledger := &AwsExtendedSQSAcknowledger{
+		client: client,
+		opts:   acknowledgerOpts,
+	}
+	acknowledger.batcher = batcher.NewBatcher(acknowledger.deleteBatch,
+		batcher.WithMaxBatchSize(acknowledgerOpts.maxBatchSize),
+		batcher.WithLinger(acknowledgerOpts.flushInterval),
+	)
+
+	return acknowledger
+}
+
+// Buffers the receipt handle to be deleted from the queue, the future completes once its batch is deleted
+func (a *AwsExtendedSQSAcknowledger) Acknowledge(queueUrl string, receiptHandle string) *AckFuture {
+	return a.AcknowledgeWithCallback(queueUrl, receiptHandle, nil)
+}
+
+// Same as Acknowledge, callback is called from the goroutine deleting the batch once the future completes
+func (a *AwsExtendedSQSAcknowledger) AcknowledgeWithCallback(queueUrl string, receiptHandle string, callback AckCallback) *AckFuture {
+	future := newAckFuture(callback)
+
+	if !a.batcher.Add(queueUrl, &bufferedAck{receiptHandle: receiptHandle, future: future}, 0, false) {
+		future.complete(errors.SDKError{Message: "Acknowledger is closed."})
+	}
+
+	return future
+}
+
+// Deletes the buffered receipt handles right away and waits for all the batches being deleted, or until ctx is done
+func (a *AwsExtendedSQSAcknowledger) Flush(ctx context.Context) error {
+	return a.batcher.Flush(ctx)
+}
+
+// Stops accepting receipt handles, then flushes the buffered ones. Receipt handles acknowledged afterwards fail right away.
+func (a *AwsExtendedSQSAcknowledger) Close(ctx context.Context) error {
+	return a.batcher.Close(ctx)
+}
+
+func (a *AwsExtendedSQSAcknowledger) deleteBatch(queueUrl string, items []interface{}) {
+	logger := a.opts.logger.WithFields(map[string]interface{}{"method": "deleteBatch", "queue_url": queueUrl})
+
+	acks := make([]*bufferedAck, len(items))
+	input := &aws_sqs.DeleteMessageBatchInput{QueueUrl: aws.String(queueUrl)}
+	for index, item := range items {
+		ack := item.(*bufferedAck)
+		acks[index] = ack
+
+		input.Entries = append(input.Entries, &aws_sqs.DeleteMessageBatchRequestEntry{
+			Id:            aws.String(strconv.Itoa(index)),
+			ReceiptHandle: aws.String(ack.receiptHandle),
+		})
+	}
+
+	output, err := a.client.DeleteMessageBatchWithContext(context.Background(), input)
+	if err != nil {
+		logger.Log(logging_constants.LOG_LEVEL_ERROR, fmt.Sprintf("Error: %+v", err))
+
+		for _, ack := range acks {
+			ack.future.complete(err)
+		}
+		return
+	}
+
+	successful := make(map[string]bool, len(output.Successful))
+	for _, result := range output.Successful {
+		successful[aws.StringValue(result.Id)] = true
+	}
+
+	failed := make(map[string]*aws_sqs.BatchResultErrorEntry, len(output.Failed))
+	for _, result := range output.Failed {
+		failed[aws.StringValue(result.Id)] = result
+	}
+
+	for index, ack := range acks {
+		id := strconv.Itoa(index)
+
+		if successful[id] {
+			ack.future.complete(nil)
+		} else if result, ok := failed[id]; ok {
+			err := errors.SQSError{
+				Operation: errors_constants.OPERATION_DELETE,
+				QueueUrl:  queueUrl,
+				Err:       awserr.New(aws.StringValue(result.Code), aws.StringValue(result.Message), nil),
+			}
+
+			logger.WithField("receipt_handle", ack.receiptHandle).Log(logging_constants.LOG_LEVEL_ERROR, fmt.Sprintf("Error: %+v", err))
+			ack.future.complete(err)
+		} else {
+			ack.future.complete(errors.SDKError{Message: fmt.Sprintf("No result for entry [%s] of the batch.", id)})
+		}
+	}
+
+	logger.Log(logging_constants.LOG_LEVEL_DEBUG, fmt.Sprintf("Deleted batch of %d messages, %d failed", len(acks), len(output.Failed)))
+}
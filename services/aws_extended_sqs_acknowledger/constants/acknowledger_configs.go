@@ -0,0 +1,10 @@
+package acknowledger_configs_constants
+
+import (
+	"time"
+)
+
+const (
+	DEFAULT_MAX_BATCH_SIZE = 10
+	DEFAULT_FLUSH_INTERVAL = time.Second
+)
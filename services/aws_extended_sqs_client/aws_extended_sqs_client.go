@@ -1,92 +1,218 @@
 package aws_extended_sqs_client
 
 import (
+	"context"
 	"fmt"
+	"math"
 	"strconv"
-	"strings"
 
 	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/internal/noop"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/internal/payload_cleaner"
 	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/internal/payload_store"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging"
+	logging_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging/constants"
+	metrics_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/metrics/constants"
 	sqs_configs_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client/constants"
-	"github.com/sirupsen/logrus"
+	tracing_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tracing/constants"
 
 	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors"
+	errors_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors/constants"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
 	aws_sqsiface "github.com/aws/aws-sdk-go/service/sqs/sqsiface"
 )
 
+// The sqs call made once the message is handled, i.e. SendMessage or SendMessageWithContext of the wrapped client
+type sendMessageFunc func(*aws_sqs.SendMessageInput) (*aws_sqs.SendMessageOutput, error)
+type receiveMessageFunc func(*aws_sqs.ReceiveMessageInput) (*aws_sqs.ReceiveMessageOutput, error)
+type deleteMessageFunc func(*aws_sqs.DeleteMessageInput) (*aws_sqs.DeleteMessageOutput, error)
+
 type AwsExtendedSQSClient struct {
 	aws_sqsiface.SQSAPI
 	config       aws_extended_sqsiface.AwsExtendedSqsClientConfigurationInterface
 	payloadStore aws_extended_sqsiface.PayloadStoreInterface
 	opts         *awsExtendedSQSClientOptions
+
+	// Set with WithDeferredPayloadCleanup
+	payloadCleaner *payload_cleaner.PayloadCleaner
 }
 
 type awsExtendedSQSClientOptions struct {
-	logger logrus.FieldLogger
+	logger          aws_extended_sqsiface.LoggerInterface
+	metricsRecorder aws_extended_sqsiface.MetricsRecorderInterface
+	tracer          aws_extended_sqsiface.TracerInterface
+
+	loggingAttributeAllowList map[string]bool
+	loggingAttributeDenyList  map[string]bool
+	loggingAttributeRedactor  LoggingAttributeRedactor
+	logLevels                 map[string]string
+
+	beforeSendInterceptors   []BeforeSendInterceptor
+	afterSendInterceptors    []AfterSendInterceptor
+	afterReceiveInterceptors []AfterReceiveInterceptor
+	beforeDeleteInterceptors []BeforeDeleteInterceptor
+
+	payloadCleanup *payloadCleanupOptions
+	payloadCache   *payloadCacheOptions
+
+	lazyPayloadResolution bool
 }
 
 type AwsExtendedSQSClientOption func(*awsExtendedSQSClientOptions)
 
 func newClientOptions() *awsExtendedSQSClientOptions {
 	return &awsExtendedSQSClientOptions{
-		logger: logrus.New(),
+		logger:          logging.NewDefaultLogger(),
+		metricsRecorder: noop.NewMetricsRecorder(),
+		tracer:          noop.NewTracer(),
+		logLevels:       make(map[string]string),
 	}
 }
 
-func WithLogger(logger logrus.FieldLogger) AwsExtendedSQSClientOption {
+func WithLogger(logger aws_extended_sqsiface.LoggerInterface) AwsExtendedSQSClientOption {
 	return func(opts *awsExtendedSQSClientOptions) {
 		opts.logger = logger
 	}
 }
 
+func WithMetricsRecorder(recorder aws_extended_sqsiface.MetricsRecorderInterface) AwsExtendedSQSClientOption {
+	return func(opts *awsExtendedSQSClientOptions) {
+		opts.metricsRecorder = recorder
+	}
+}
+
+func WithTracer(tracer aws_extended_sqsiface.TracerInterface) AwsExtendedSQSClientOption {
+	return func(opts *awsExtendedSQSClientOptions) {
+		opts.tracer = tracer
+	}
+}
+
 func NewExtendedSQSClient(sqs aws_sqsiface.SQSAPI, config *AwsExtendedSQSClientConfiguration, opts ...AwsExtendedSQSClientOption) *AwsExtendedSQSClient {
-	payloadStore := payload_store.NewPayloadStore(config.s3, config.s3BucketName)
+	clientOpts := newClientOptions()
+	for _, opt := range opts {
+		opt(clientOpts)
+	}
+
+	payloadStoreOpts := []payload_store.PayloadStoreOption{
+		payload_store.WithS3ClientResolver(config.s3ClientResolver),
+		payload_store.WithS3KeyPrefix(config.s3KeyPrefix),
+		payload_store.WithMetricsRecorder(clientOpts.metricsRecorder),
+		payload_store.WithTracer(clientOpts.tracer),
+	}
+
+	if config.contentAddressed {
+		payloadStoreOpts = append(payloadStoreOpts, payload_store.WithContentAddressing(config.contentAddressedTouchAge))
+	}
+
+	if clientOpts.payloadCache != nil {
+		payloadStoreOpts = append(payloadStoreOpts, payload_store.WithPayloadCache(clientOpts.payloadCache.newPayloadCache()))
+	}
+
+	payloadStore := payload_store.NewPayloadStore(config.s3, config.s3BucketName, payloadStoreOpts...)
 
 	client := &AwsExtendedSQSClient{
 		SQSAPI:       sqs,
 		config:       config,
 		payloadStore: payloadStore,
-		opts:         newClientOptions(),
+		opts:         clientOpts,
 	}
 
-	for _, opt := range opts {
-		opt(client.opts)
+	if clientOpts.payloadCleanup != nil {
+		client.payloadCleaner = client.newPayloadCleaner()
 	}
 
 	return client
 }
 
 func (c *AwsExtendedSQSClient) SendMessage(input *aws_sqs.SendMessageInput) (*aws_sqs.SendMessageOutput, error) {
-	logger := c.opts.logger.WithField("method", "SendMessage")
+	return c.sendMessageWithContext(context.Background(), input, c.SQSAPI.SendMessage)
+}
+
+func (c *AwsExtendedSQSClient) SendMessageWithContext(ctx aws.Context, input *aws_sqs.SendMessageInput, opts ...request.Option) (*aws_sqs.SendMessageOutput, error) {
+	return c.sendMessageWithContext(ctx, input, func(input *aws_sqs.SendMessageInput) (*aws_sqs.SendMessageOutput, error) {
+		return c.SQSAPI.SendMessageWithContext(ctx, input, opts...)
+	})
+}
+
+func (c *AwsExtendedSQSClient) sendMessageWithContext(ctx context.Context, input *aws_sqs.SendMessageInput, sendCall sendMessageFunc) (*aws_sqs.SendMessageOutput, error) {
+	var queueUrl *string
+	if input != nil {
+		queueUrl = input.QueueUrl
+	}
+
+	ctx, span := c.startMessagingSpan(ctx, tracing_constants.SPAN_SEND_MESSAGE, tracing_constants.SPAN_KIND_PRODUCER, queueUrl)
+	defer span.End()
+
+	output, err := c.handleSendMessage(ctx, input, sendCall)
+	if err != nil {
+		span.RecordError(err)
+	}
+
+	return output, err
+}
+
+func (c *AwsExtendedSQSClient) handleSendMessage(ctx context.Context, input *aws_sqs.SendMessageInput, sendCall sendMessageFunc) (*aws_sqs.SendMessageOutput, error) {
+	logger := c.opts.logger.WithField("method", sqs_configs_constants.LOG_METHOD_SEND_MESSAGE)
+	logLevel := c.getLogLevel(sqs_configs_constants.LOG_METHOD_SEND_MESSAGE)
 
 	if input == nil {
-		logger.WithField("uploaded_to_s3", "false").Infoln("Handled by original sqs sdk")
+		logger.WithField("uploaded_to_s3", "false").Log(logLevel, "Handled by original sqs sdk")
 
 		// let parent handle the error
-		return c.SQSAPI.SendMessage(input)
+		return c.sendMessage(sendCall, input)
+	}
+
+	sqsInput, info, err := c.prepareSendMessage(ctx, input, logger, logLevel)
+	if err != nil {
+		return &aws_sqs.SendMessageOutput{}, err
 	}
 
+	output, err := c.sendMessageAndIntercept(ctx, sendCall, sqsInput, info)
+	if err != nil {
+		return output, err
+	}
+
+	if c.config.IsPayloadSupportEnabled() && info.Input.MessageBody != nil {
+		c.recordMessageSent(info.Destination, len(*info.Input.MessageBody))
+	}
+
+	return output, nil
+}
+
+// Runs the before send interceptors, injects the trace context and offloads the body to s3 when needed.
+// Returns the input to send to sqs along with the info given to the after send interceptors.
+func (c *AwsExtendedSQSClient) prepareSendMessage(ctx context.Context, input *aws_sqs.SendMessageInput, logger aws_extended_sqsiface.LoggerInterface, logLevel string) (*aws_sqs.SendMessageInput, *SendMessageInfo, error) {
+	input, err := c.interceptBeforeSend(ctx, input)
+	if err != nil {
+		logError(logger, "interceptBeforeSend", err)
+		return nil, nil, err
+	}
+
+	// Injected before the attributes are checked, so that the trace context counts against the limits
+	input = c.injectTraceContext(ctx, input)
+
 	logger = logger.WithFields(c.getLoggingFields(input.MessageAttributes))
+	info := &SendMessageInfo{Input: input, Destination: "sqs"}
 
 	if !c.config.IsPayloadSupportEnabled() {
-		logger.WithField("uploaded_to_s3", "false").Infoln("Handled by original sqs sdk")
+		logger.WithField("uploaded_to_s3", "false").Log(logLevel, "Handled by original sqs sdk")
 
-		return c.SQSAPI.SendMessage(input)
+		return input, info, nil
 	}
 
 	if input.MessageBody == nil {
-		logger.WithField("uploaded_to_s3", "false").Infoln("Handled by original sqs sdk")
+		logger.WithField("uploaded_to_s3", "false").Log(logLevel, "Handled by original sqs sdk")
 
 		// let parent handle the error
-		return c.SQSAPI.SendMessage(input)
+		return input, info, nil
 	}
 
 	destination, err := c.getMessageDestination(input, logger)
 	if err != nil {
-		return &aws_sqs.SendMessageOutput{}, err
+		return nil, nil, err
 	}
 
 	var sqsInput *aws_sqs.SendMessageInput
@@ -94,64 +220,116 @@ func (c *AwsExtendedSQSClient) SendMessage(input *aws_sqs.SendMessageInput) (*aw
 	switch destination {
 	case "s3":
 		var err error
-		sqsInput, err = c.storeMessageInS3(input)
+		sqsInput, err = c.storeMessageInS3(ctx, input)
+
+		if err != nil {
+			logError(logger, "storeMessageInS3", err)
+			return nil, nil, err
+		}
 
+		info.S3Pointer, err = newS3Pointer(*sqsInput.MessageBody)
 		if err != nil {
-			logger.WithField("method", "storeMessageInS3").Errorf("Error: %+v\n", err)
-			return &aws_sqs.SendMessageOutput{}, err
+			logError(logger, "newS3Pointer", err)
+			return nil, nil, err
 		}
 
-		logger.WithField("uploaded_to_s3", "true").Infoln("Uploaded to s3")
+		logger.WithField("uploaded_to_s3", "true").Log(logLevel, "Uploaded to s3")
 		break
 	case "sqs":
-		logger.WithField("uploaded_to_s3", "false").Infoln("Handled by original sqs sdk")
+		logger.WithField("uploaded_to_s3", "false").Log(logLevel, "Handled by original sqs sdk")
 
 		sqsInput = input
 		break
 	default:
 		errorMessage := "Unknown message destination"
-		logger.WithField("destination", destination).Errorln(errorMessage)
-		return &aws_sqs.SendMessageOutput{}, errors.SDKError{Message: errorMessage}
+		logger.WithField("destination", destination).Log(logging_constants.LOG_LEVEL_ERROR, errorMessage)
+		return nil, nil, errors.SDKError{Message: errorMessage}
 	}
 
-	return c.SQSAPI.SendMessage(sqsInput)
+	info.Destination = destination
+
+	return sqsInput, info, nil
 }
 
 func (c *AwsExtendedSQSClient) ReceiveMessage(input *aws_sqs.ReceiveMessageInput) (*aws_sqs.ReceiveMessageOutput, error) {
-	logger := c.opts.logger.WithField("method", "ReceiveMessage")
+	return c.receiveMessageWithContext(context.Background(), input, c.SQSAPI.ReceiveMessage)
+}
+
+func (c *AwsExtendedSQSClient) ReceiveMessageWithContext(ctx aws.Context, input *aws_sqs.ReceiveMessageInput, opts ...request.Option) (*aws_sqs.ReceiveMessageOutput, error) {
+	return c.receiveMessageWithContext(ctx, input, func(input *aws_sqs.ReceiveMessageInput) (*aws_sqs.ReceiveMessageOutput, error) {
+		return c.SQSAPI.ReceiveMessageWithContext(ctx, input, opts...)
+	})
+}
+
+func (c *AwsExtendedSQSClient) receiveMessageWithContext(ctx context.Context, input *aws_sqs.ReceiveMessageInput, receiveCall receiveMessageFunc) (*aws_sqs.ReceiveMessageOutput, error) {
+	var queueUrl *string
+	if input != nil {
+		queueUrl = input.QueueUrl
+	}
+
+	ctx, span := c.startMessagingSpan(ctx, tracing_constants.SPAN_RECEIVE_MESSAGE, tracing_constants.SPAN_KIND_CONSUMER, queueUrl)
+	defer span.End()
+
+	output, err := c.handleReceiveMessage(ctx, input, receiveCall)
+	if err != nil {
+		span.RecordError(err)
+	}
+
+	return output, err
+}
+
+func (c *AwsExtendedSQSClient) handleReceiveMessage(ctx context.Context, input *aws_sqs.ReceiveMessageInput, receiveCall receiveMessageFunc) (*aws_sqs.ReceiveMessageOutput, error) {
+	logger := c.opts.logger.WithField("method", sqs_configs_constants.LOG_METHOD_RECEIVE_MESSAGE)
+	logLevel := c.getLogLevel(sqs_configs_constants.LOG_METHOD_RECEIVE_MESSAGE)
 
 	if input == nil {
-		logger.Infoln("Handled by original sqs sdk")
+		logger.Log(logLevel, "Handled by original sqs sdk")
 
 		// let parent handle the error
-		return c.SQSAPI.ReceiveMessage(input)
+		return c.receiveMessage(receiveCall, input)
 	}
 
+	input = c.withTraceAttributeNames(input)
+
 	if !c.config.IsPayloadSupportEnabled() {
-		logger.Infoln("Handled by original sqs sdk")
+		logger.Log(logLevel, "Handled by original sqs sdk")
 
-		return c.SQSAPI.ReceiveMessage(input)
+		output, err := c.receiveMessage(receiveCall, input)
+		if err != nil {
+			return output, err
+		}
+
+		for _, message := range output.Messages {
+			if err := c.interceptAfterReceive(ctx, message, nil); err != nil {
+				logError(logger, "interceptAfterReceive", err)
+				return &aws_sqs.ReceiveMessageOutput{}, err
+			}
+		}
+
+		return output, nil
 	}
 
 	reservdAttributeName := sqs_configs_constants.RESERVED_ATTRIBUTE_NAME
 	legacyReservedAttributeName := sqs_configs_constants.LEGACY_RESERVED_ATTRIBUTE_NAME
+	payloadEnvelopeAttributeName := sqs_configs_constants.PAYLOAD_ENVELOPE_ATTRIBUTE_NAME
 	var updatedMessageAttributeNames []*string
 	for _, name := range input.MessageAttributeNames {
-		if *name != reservdAttributeName && *name != legacyReservedAttributeName {
+		if *name != reservdAttributeName && *name != legacyReservedAttributeName && *name != payloadEnvelopeAttributeName {
 			copied_name := *name
 			updatedMessageAttributeNames = append(updatedMessageAttributeNames, &copied_name)
 		}
 	}
 	updatedMessageAttributeNames = append(updatedMessageAttributeNames, &reservdAttributeName)
 	updatedMessageAttributeNames = append(updatedMessageAttributeNames, &legacyReservedAttributeName)
+	updatedMessageAttributeNames = append(updatedMessageAttributeNames, &payloadEnvelopeAttributeName)
 
 	updatedInput := &aws_sqs.ReceiveMessageInput{}
 	*updatedInput = *input
 	updatedInput.MessageAttributeNames = updatedMessageAttributeNames
 
-	output, err := c.SQSAPI.ReceiveMessage(updatedInput)
+	output, err := c.receiveMessage(receiveCall, updatedInput)
 	if err != nil {
-		logger.WithField("method", "ReceiveMessage").Errorf("Error: %+v\n", err)
+		logError(logger, "ReceiveMessage", err)
 
 		return output, err
 	}
@@ -163,40 +341,49 @@ func (c *AwsExtendedSQSClient) ReceiveMessage(input *aws_sqs.ReceiveMessageInput
 		modifiedMessage := &aws_sqs.Message{}
 		*modifiedMessage = *message
 
+		var s3Pointer *S3Pointer
+
 		messageAttributes := message.MessageAttributes
 		largePayloadAttributeName := getReservedAttributeNameIfPresent(messageAttributes)
 		if largePayloadAttributeName != nil {
 			loggerWithAttrs := c.opts.logger.WithFields(c.getLoggingFields(messageAttributes))
 
-			loggerWithAttrs.Infoln("Getting payload from s3")
-
-			originalPayload, err := c.payloadStore.GetOriginalPayload(*message.Body)
+			s3Pointer, err = newS3Pointer(*message.Body)
 			if err != nil {
-				loggerWithAttrs.WithField("method", "GetOriginalPayload").Errorf("Error: %+v\n", err)
+				logError(loggerWithAttrs, "newS3Pointer", err)
 
 				return &aws_sqs.ReceiveMessageOutput{}, err
 			}
 
-			modifiedMessage.Body = &originalPayload
+			// The pointer is left in the body to be resolved with a PayloadResolver
+			if !c.opts.lazyPayloadResolution {
+				resolvedMessage, err := c.resolveMessage(ctx, message, input.MessageAttributeNames, loggerWithAttrs, logLevel)
+				if err != nil {
+					return &aws_sqs.ReceiveMessageOutput{}, err
+				}
 
-			// Remove the additional attribute before returning the message to user
-			modifiedMessageAttributes := copyMessageAttributes(messageAttributes)
-			delete(modifiedMessageAttributes, sqs_configs_constants.RESERVED_ATTRIBUTE_NAME)
-			delete(modifiedMessageAttributes, sqs_configs_constants.LEGACY_RESERVED_ATTRIBUTE_NAME)
-			modifiedMessage.MessageAttributes = modifiedMessageAttributes
+				modifiedMessage.Body = resolvedMessage.Body
+				modifiedMessage.MessageAttributes = resolvedMessage.MessageAttributes
+			}
 
 			modifiedReceiptHandle, err := c.embedS3PointerInReceiptHandle(message.ReceiptHandle, message.Body)
 			if err != nil {
-				loggerWithAttrs.WithField("method", "embedS3PointerInReceiptHandle").Errorf("Error: %+v\n", err)
+				logError(loggerWithAttrs, "embedS3PointerInReceiptHandle", err)
 
 				return &aws_sqs.ReceiveMessageOutput{}, err
 			}
 
 			modifiedMessage.ReceiptHandle = modifiedReceiptHandle
+		}
+
+		if err := c.interceptAfterReceive(ctx, modifiedMessage, s3Pointer); err != nil {
+			logError(logger, "interceptAfterReceive", err)
 
-			loggerWithAttrs.Infoln("Finished getting payload from s3")
+			return &aws_sqs.ReceiveMessageOutput{}, err
 		}
 
+		c.recordMessageReceived(largePayloadAttributeName != nil)
+
 		modifiedMessages[index] = modifiedMessage
 	}
 
@@ -205,57 +392,113 @@ func (c *AwsExtendedSQSClient) ReceiveMessage(input *aws_sqs.ReceiveMessageInput
 }
 
 func (c *AwsExtendedSQSClient) DeleteMessage(input *aws_sqs.DeleteMessageInput) (*aws_sqs.DeleteMessageOutput, error) {
-	logger := c.opts.logger.WithField("method", "DeleteMessage")
+	return c.deleteMessageWithContext(context.Background(), input, c.SQSAPI.DeleteMessage)
+}
+
+func (c *AwsExtendedSQSClient) DeleteMessageWithContext(ctx aws.Context, input *aws_sqs.DeleteMessageInput, opts ...request.Option) (*aws_sqs.DeleteMessageOutput, error) {
+	return c.deleteMessageWithContext(ctx, input, func(input *aws_sqs.DeleteMessageInput) (*aws_sqs.DeleteMessageOutput, error) {
+		return c.SQSAPI.DeleteMessageWithContext(ctx, input, opts...)
+	})
+}
+
+func (c *AwsExtendedSQSClient) deleteMessageWithContext(ctx context.Context, input *aws_sqs.DeleteMessageInput, deleteCall deleteMessageFunc) (*aws_sqs.DeleteMessageOutput, error) {
+	var queueUrl *string
+	if input != nil {
+		queueUrl = input.QueueUrl
+	}
+
+	ctx, span := c.startMessagingSpan(ctx, tracing_constants.SPAN_DELETE_MESSAGE, tracing_constants.SPAN_KIND_CLIENT, queueUrl)
+	defer span.End()
+
+	output, err := c.handleDeleteMessage(ctx, input, deleteCall)
+	if err != nil {
+		span.RecordError(err)
+	}
+
+	return output, err
+}
+
+func (c *AwsExtendedSQSClient) handleDeleteMessage(ctx context.Context, input *aws_sqs.DeleteMessageInput, deleteCall deleteMessageFunc) (*aws_sqs.DeleteMessageOutput, error) {
+	logger := c.opts.logger.WithField("method", sqs_configs_constants.LOG_METHOD_DELETE_MESSAGE)
+	logLevel := c.getLogLevel(sqs_configs_constants.LOG_METHOD_DELETE_MESSAGE)
 
 	if input == nil {
-		logger.Infoln("Handled by original sqs sdk")
+		logger.Log(logLevel, "Handled by original sqs sdk")
 
 		// let parent handle the error
-		return c.SQSAPI.DeleteMessage(input)
+		return c.deleteMessage(deleteCall, input)
 	}
 
 	if !c.config.IsPayloadSupportEnabled() {
-		logger.Infoln("Handled by original sqs sdk")
+		logger.Log(logLevel, "Handled by original sqs sdk")
 
-		return c.SQSAPI.DeleteMessage(input)
+		if err := c.interceptBeforeDelete(ctx, input, nil); err != nil {
+			logError(logger, "interceptBeforeDelete", err)
+			return &aws_sqs.DeleteMessageOutput{}, err
+		}
+
+		return c.deleteMessage(deleteCall, input)
 	}
 
 	receiptHandle := input.ReceiptHandle
 	origReceiptHandle := receiptHandle
 
 	if origReceiptHandle == nil {
-		logger.Infoln("Handled by original sqs sdk")
+		logger.Log(logLevel, "Handled by original sqs sdk")
 
 		// let parent handle the error
-		return c.SQSAPI.DeleteMessage(input)
+		return c.deleteMessage(deleteCall, input)
 	}
 
 	logger = logger.WithField("receipt_handle", *input.ReceiptHandle)
 
-	if isS3ReceiptHandle(*receiptHandle) {
-		handle := getOrigReceiptHandle(*receiptHandle)
-		origReceiptHandle = &handle
+	handle, err := ParseReceiptHandle(*receiptHandle)
+	if err != nil {
+		logError(logger, "ParseReceiptHandle", err)
+		return &aws_sqs.DeleteMessageOutput{}, err
+	}
+
+	if err := c.verifyReceiptHandle(handle); err != nil {
+		logError(logger, "verifyReceiptHandle", err)
+		return &aws_sqs.DeleteMessageOutput{}, err
+	}
+	s3Pointer := handle.S3Pointer
+
+	// Deleted in the background once the message is deleted from sqs
+	var deferredPointer string
+
+	if err := c.interceptBeforeDelete(ctx, input, s3Pointer); err != nil {
+		logError(logger, "interceptBeforeDelete", err)
+		return &aws_sqs.DeleteMessageOutput{}, err
+	}
 
-		logger.Infoln("Message is sent with s3 usage")
+	if s3Pointer != nil {
+		origReceiptHandle = &handle.Original
 
-		if c.config.DoesCleanupS3Payload() {
-			logger.Infoln("Deleting message in s3")
+		logger.Log(logLevel, "Message is sent with s3 usage")
 
-			messagePointer, err := getMessagePointerFromModifiedReceiptHandle(*receiptHandle)
+		if c.shouldCleanupPayload(s3Pointer) {
+			messagePointer, err := s3Pointer.toMessagePointer()
 			if err != nil {
-				logger.WithField("method", "getMessagePointerFromModifiedReceiptHandle").Errorf("Error: %+v\n", err)
+				logError(logger, "toMessagePointer", err)
 				return &aws_sqs.DeleteMessageOutput{}, err
 			}
 
-			if err := c.payloadStore.DeleteOriginalPayload(messagePointer); err != nil {
-				logger.WithField("method", "DeleteOriginalPayload").Errorf("Error: %+v\n", err)
-				return &aws_sqs.DeleteMessageOutput{}, err
-			}
+			if c.payloadCleaner != nil {
+				deferredPointer = messagePointer
+			} else {
+				logger.Log(logLevel, "Deleting message in s3")
 
-			logger.Infoln("Deleted message in s3")
+				if err := c.payloadStore.DeleteOriginalPayloadWithContext(ctx, messagePointer); err != nil {
+					logError(logger, "DeleteOriginalPayload", err)
+					return &aws_sqs.DeleteMessageOutput{}, err
+				}
+
+				logger.Log(logLevel, "Deleted message in s3")
+			}
 		}
 	} else {
-		logger.Infoln("Message is sent without s3")
+		logger.Log(logLevel, "Message is sent without s3")
 	}
 
 	modifiedInput := &aws_sqs.DeleteMessageInput{}
@@ -263,25 +506,80 @@ func (c *AwsExtendedSQSClient) DeleteMessage(input *aws_sqs.DeleteMessageInput)
 
 	modifiedInput.ReceiptHandle = origReceiptHandle
 
-	return c.SQSAPI.DeleteMessage(modifiedInput)
+	output, err := c.deleteMessage(deleteCall, modifiedInput)
+	if err != nil || deferredPointer == "" {
+		return output, err
+	}
+
+	logger.Log(logLevel, "Enqueued message in s3 to be deleted")
+	c.payloadCleaner.Enqueue(deferredPointer)
+
+	return output, nil
+}
+
+func (c *AwsExtendedSQSClient) sendMessage(sendCall sendMessageFunc, input *aws_sqs.SendMessageInput) (*aws_sqs.SendMessageOutput, error) {
+	output, err := sendCall(input)
+	if err != nil {
+		var queueUrl *string
+		if input != nil {
+			queueUrl = input.QueueUrl
+		}
+
+		return output, newSQSError(errors_constants.OPERATION_SEND, queueUrl, err)
+	}
+
+	return output, nil
+}
+
+func (c *AwsExtendedSQSClient) receiveMessage(receiveCall receiveMessageFunc, input *aws_sqs.ReceiveMessageInput) (*aws_sqs.ReceiveMessageOutput, error) {
+	output, err := receiveCall(input)
+	if err != nil {
+		var queueUrl *string
+		if input != nil {
+			queueUrl = input.QueueUrl
+		}
+
+		return output, newSQSError(errors_constants.OPERATION_RECEIVE, queueUrl, err)
+	}
+
+	return output, nil
+}
+
+func (c *AwsExtendedSQSClient) deleteMessage(deleteCall deleteMessageFunc, input *aws_sqs.DeleteMessageInput) (*aws_sqs.DeleteMessageOutput, error) {
+	output, err := deleteCall(input)
+	if err != nil {
+		var queueUrl *string
+		if input != nil {
+			queueUrl = input.QueueUrl
+		}
+
+		return output, newSQSError(errors_constants.OPERATION_DELETE, queueUrl, err)
+	}
+
+	return output, nil
 }
 
 func (c *AwsExtendedSQSClient) checkMessageAttributes(attributes map[string]*aws_sqs.MessageAttributeValue, attributeSize int) error {
 	sizeThreshold := c.config.GetPayloadSizeThreshold()
-	if attributeSize > sizeThreshold {
+	if attributeSize > sizeThreshold && !c.config.IsAttributeOffloadEnabled() {
 		errorMessage := fmt.Sprintf("Total size of Message attributes is %s bytes which is larger than the threshold of %s Bytes. Consider including the payload in the message body instead of message attributes.", strconv.Itoa(attributeSize), strconv.Itoa(sizeThreshold))
 
 		return errors.SDKError{Message: errorMessage}
 	}
 
 	attributesLen := len(attributes)
-	if attributesLen > sqs_configs_constants.MAX_ALLOWED_ATTRIBUTES {
-		errorMessage := fmt.Sprintf("Number of message attributes [%s] exceeds the maximum allowed for large-payload messages [%s].", strconv.Itoa(attributesLen), strconv.Itoa(sqs_configs_constants.MAX_ALLOWED_ATTRIBUTES))
+	if attributesLen > sqs_configs_constants.MAX_SQS_ATTRIBUTES {
+		errorMessage := fmt.Sprintf("Number of message attributes [%s] exceeds the maximum allowed [%s].", strconv.Itoa(attributesLen), strconv.Itoa(sqs_configs_constants.MAX_SQS_ATTRIBUTES))
 
 		return errors.SDKError{Message: errorMessage}
 	}
 
 	reserved_attribute := getReservedAttributeNameIfPresent(attributes)
+	if _, ok := attributes[sqs_configs_constants.PAYLOAD_ENVELOPE_ATTRIBUTE_NAME]; ok && reserved_attribute == nil {
+		// Flags the payload envelope on receive, so it cannot be set by the user either
+		reserved_attribute = aws.String(sqs_configs_constants.PAYLOAD_ENVELOPE_ATTRIBUTE_NAME)
+	}
+
 	if reserved_attribute != nil {
 		errorMessage := fmt.Sprintf("Message attribute name %s is reserved for use by SQS extended client.", *reserved_attribute)
 
@@ -291,26 +589,59 @@ func (c *AwsExtendedSQSClient) checkMessageAttributes(attributes map[string]*aws
 	return nil
 }
 
-func (c *AwsExtendedSQSClient) getMessageDestination(input *aws_sqs.SendMessageInput, logger logrus.FieldLogger) (string, error) {
-	attributeSize := getMsgAttributesSize(input.MessageAttributes)
+// An offloaded message carries the reserved attribute as well, which leaves room for one attribute less
+func (c *AwsExtendedSQSClient) checkOffloadedMessageAttributes(attributes map[string]*aws_sqs.MessageAttributeValue) error {
+	attributesLen := len(attributes)
+	if attributesLen > sqs_configs_constants.MAX_ALLOWED_ATTRIBUTES && c.config.GetAttributeLimitFallback() != sqs_configs_constants.ATTRIBUTE_LIMIT_FALLBACK_FOLD {
+		errorMessage := fmt.Sprintf("Number of message attributes [%s] exceeds the maximum allowed for large-payload messages [%s].", strconv.Itoa(attributesLen), strconv.Itoa(sqs_configs_constants.MAX_ALLOWED_ATTRIBUTES))
+
+		return errors.SDKError{Message: errorMessage}
+	}
+
+	return nil
+}
+
+func (c *AwsExtendedSQSClient) getMessageDestination(input *aws_sqs.SendMessageInput, logger aws_extended_sqsiface.LoggerInterface) (string, error) {
+	messageSize := CalculateMessageSize(input)
+
+	// Both kinds of attributes stay in sqs when the body is offloaded
+	attributeSize := messageSize.MessageAttributes + messageSize.MessageSystemAttributes
 	if err := c.checkMessageAttributes(input.MessageAttributes, attributeSize); err != nil {
-		logger.WithField("method", "checkMessageAttributes").Errorf("Error: %+v\n", err)
+		logError(logger, "checkMessageAttributes", err)
 		return "", err
 	}
 
+	destination, err := c.getMessageDestinationBySize(messageSize, logger)
+	if err != nil {
+		return "", err
+	}
+
+	if destination == "s3" {
+		if err := c.checkOffloadedMessageAttributes(input.MessageAttributes); err != nil {
+			logError(logger, "checkOffloadedMessageAttributes", err)
+			return "", err
+		}
+	}
+
+	return destination, nil
+}
+
+func (c *AwsExtendedSQSClient) getMessageDestinationBySize(messageSize MessageSize, logger aws_extended_sqsiface.LoggerInterface) (string, error) {
 	if c.config.IsAlwaysThroughS3() {
 		return "s3", nil
 	}
 
-	bodySize := len(*input.MessageBody)
-	totalSize := attributeSize + bodySize
+	bodySize := messageSize.Body
+	totalSize := messageSize.Total()
 
-	logger.WithField("message_size", strconv.Itoa(totalSize)).Infoln("Calculated payload size")
+	logger.WithField("message_size", strconv.Itoa(totalSize)).Log(c.getLogLevel(sqs_configs_constants.LOG_METHOD_SEND_MESSAGE), "Calculated payload size")
 
 	if c.config.IsBreakSendSupportEnabled() && bodySize > c.config.GetBreakSendPayloadSizeThreshold() {
 		errorMessage := fmt.Sprintf("Total size of message is %s, exceeds the maximum allowed. Message send process is breaked.", strconv.Itoa(totalSize))
 
-		logger.WithFields(logrus.Fields{"method": "getMessageDestination", "message_size": strconv.Itoa(totalSize)}).Errorf("Error: %+v\n", errorMessage)
+		logError(logger.WithField("message_size", strconv.Itoa(totalSize)), "getMessageDestination", errorMessage)
+
+		c.opts.metricsRecorder.IncrementCounter(metrics_constants.METRIC_BREAK_SEND_REJECTIONS, map[string]string{})
 
 		return "", errors.OversizeBreakError{Message: errorMessage, Size: totalSize}
 	} else if totalSize > c.config.GetPayloadSizeThreshold() {
@@ -320,13 +651,28 @@ func (c *AwsExtendedSQSClient) getMessageDestination(input *aws_sqs.SendMessageI
 	return "sqs", nil
 }
 
-func (c *AwsExtendedSQSClient) storeMessageInS3(input *aws_sqs.SendMessageInput) (*aws_sqs.SendMessageInput, error) {
+func (c *AwsExtendedSQSClient) storeMessageInS3(ctx context.Context, input *aws_sqs.SendMessageInput) (*aws_sqs.SendMessageInput, error) {
 	messageBodySize := len(*input.MessageBody)
 
 	updatedInput := &aws_sqs.SendMessageInput{}
 	*updatedInput = *input
 
-	newMessageAttributes := copyMessageAttributes(input.MessageAttributes)
+	s3BucketName, err := c.getDestinationBucket(input, messageBodySize)
+	if err != nil {
+		return nil, errors.SDKError{Message: "Failed to route message to a bucket", Err: err}
+	}
+
+	newMessageAttributes, movedMessageAttributes := c.getOffloadedMessageAttributes(input, s3BucketName)
+
+	payload := *input.MessageBody
+	if len(movedMessageAttributes) > 0 {
+		payload, err = newPayloadEnvelope(payload, movedMessageAttributes).toJson()
+		if err != nil {
+			return nil, err
+		}
+
+		newMessageAttributes[sqs_configs_constants.PAYLOAD_ENVELOPE_ATTRIBUTE_NAME] = newPayloadEnvelopeAttribute()
+	}
 
 	messageBodySizeStr := strconv.Itoa(messageBodySize)
 	// Default use RESERVED_ATTRIBUTE_NAME
@@ -337,7 +683,7 @@ func (c *AwsExtendedSQSClient) storeMessageInS3(input *aws_sqs.SendMessageInput)
 
 	updatedInput.MessageAttributes = newMessageAttributes
 
-	messagePointer, err := c.payloadStore.StoreOriginalPayload(*input.MessageBody)
+	messagePointer, err := c.payloadStore.StoreOriginalPayloadInBucketWithContext(ctx, payload, s3BucketName)
 	if err != nil {
 		return nil, err
 	}
@@ -347,36 +693,80 @@ func (c *AwsExtendedSQSClient) storeMessageInS3(input *aws_sqs.SendMessageInput)
 	return updatedInput, nil
 }
 
+// Returns the attributes sent to sqs along with the message pointer and the ones moved into the s3 payload
+func (c *AwsExtendedSQSClient) getOffloadedMessageAttributes(input *aws_sqs.SendMessageInput, s3BucketName string) (map[string]*aws_sqs.MessageAttributeValue, map[string]*aws_sqs.MessageAttributeValue) {
+	foldOnLimit := c.config.GetAttributeLimitFallback() == sqs_configs_constants.ATTRIBUTE_LIMIT_FALLBACK_FOLD
+	if !c.config.IsAttributeOffloadEnabled() && !foldOnLimit {
+		return copyMessageAttributes(input.MessageAttributes), nil
+	}
+
+	maxAttributesLen := len(input.MessageAttributes)
+	if foldOnLimit && maxAttributesLen > sqs_configs_constants.MAX_ALLOWED_ATTRIBUTES {
+		// Room for both the reserved attribute and the envelope attribute
+		maxAttributesLen = sqs_configs_constants.MAX_SQS_ATTRIBUTES - 2
+	}
+
+	maxAttributesSize := math.MaxInt32
+	if c.config.IsAttributeOffloadEnabled() {
+		reservedAttributes := map[string]*aws_sqs.MessageAttributeValue{
+			sqs_configs_constants.RESERVED_ATTRIBUTE_NAME: {
+				DataType:    aws.String("Number"),
+				StringValue: aws.String(strconv.Itoa(len(*input.MessageBody))),
+			},
+			sqs_configs_constants.PAYLOAD_ENVELOPE_ATTRIBUTE_NAME: newPayloadEnvelopeAttribute(),
+		}
+
+		maxAttributesSize = c.config.GetPayloadSizeThreshold() -
+			getMaxMessagePointerSize(s3BucketName) -
+			CalculateMessageAttributesSize(reservedAttributes) -
+			CalculateMessageSystemAttributesSize(input.MessageSystemAttributes)
+	}
+
+	return splitMessageAttributes(input.MessageAttributes, maxAttributesSize, maxAttributesLen)
+}
+
+func (c *AwsExtendedSQSClient) getDestinationBucket(input *aws_sqs.SendMessageInput, payloadSize int) (string, error) {
+	router := c.config.GetBucketRouter()
+	if router == nil {
+		return c.config.GetS3BucketName(), nil
+	}
+
+	return router.RouteBucket(input, payloadSize)
+}
+
 func (c *AwsExtendedSQSClient) embedS3PointerInReceiptHandle(receiptHandle *string, messagePointer *string) (*string, error) {
-	s3Pointer, err := payload_store.FromJson(*messagePointer)
+	s3Pointer, err := newS3Pointer(*messagePointer)
 	if err != nil {
 		return nil, err
 	}
 
-	s3MsgBucketName := s3Pointer.S3BucketName
-	s3MsgKey := s3Pointer.S3Key
-
-	modifiedReceiptHandle := sqs_configs_constants.S3_BUCKET_NAME_MARKER + s3MsgBucketName + sqs_configs_constants.S3_BUCKET_NAME_MARKER +
-		sqs_configs_constants.S3_KEY_MARKER + s3MsgKey + sqs_configs_constants.S3_KEY_MARKER +
-		*receiptHandle
+	handle := &ReceiptHandle{S3Pointer: s3Pointer, Original: *receiptHandle}
+	if keys := c.config.GetReceiptHandleSigningKeys(); len(keys) > 0 {
+		handle.Signature = handle.sign(keys[0])
+	}
+	modifiedReceiptHandle := handle.String()
 
 	return &modifiedReceiptHandle, nil
 }
 
-func (c *AwsExtendedSQSClient) getLoggingFields(attributes map[string]*aws_sqs.MessageAttributeValue) logrus.Fields {
-	fields := logrus.Fields{}
-
-	if attributes == nil {
-		return fields
+func (c *AwsExtendedSQSClient) recordMessageSent(destination string, payloadSize int) {
+	labels := map[string]string{
+		metrics_constants.LABEL_DESTINATION: destination,
 	}
 
-	for attributeName, value := range attributes {
-		if value != nil && value.StringValue != nil {
-			fields[attributeName] = *value.StringValue
-		}
+	c.opts.metricsRecorder.IncrementCounter(metrics_constants.METRIC_MESSAGES_SENT, labels)
+	c.opts.metricsRecorder.ObserveHistogram(metrics_constants.METRIC_MESSAGE_PAYLOAD_SIZE, float64(payloadSize), labels)
+}
+
+func (c *AwsExtendedSQSClient) recordMessageReceived(throughS3 bool) {
+	destination := metrics_constants.DESTINATION_SQS
+	if throughS3 {
+		destination = metrics_constants.DESTINATION_S3
 	}
 
-	return fields
+	c.opts.metricsRecorder.IncrementCounter(metrics_constants.METRIC_MESSAGES_RECEIVED, map[string]string{
+		metrics_constants.LABEL_DESTINATION: destination,
+	})
 }
 
 func getReservedAttributeNameIfPresent(attributes map[string]*aws_sqs.MessageAttributeValue) *string {
@@ -392,61 +782,12 @@ func getReservedAttributeNameIfPresent(attributes map[string]*aws_sqs.MessageAtt
 	return &reservedAttributeName
 }
 
-func isS3ReceiptHandle(receiptHandle string) bool {
-	return strings.Contains(receiptHandle, sqs_configs_constants.S3_BUCKET_NAME_MARKER) &&
-		strings.Contains(receiptHandle, sqs_configs_constants.S3_KEY_MARKER)
-}
-
-func getOrigReceiptHandle(receiptHandle string) string {
-	firstOccurrence := strings.Index(receiptHandle, sqs_configs_constants.S3_KEY_MARKER)
-	offset := firstOccurrence + 1
-	secondOccurrence := strings.Index(receiptHandle[firstOccurrence+1:], sqs_configs_constants.S3_KEY_MARKER) + offset
-
-	return receiptHandle[secondOccurrence+len(sqs_configs_constants.S3_KEY_MARKER):]
-}
-
-func getMessagePointerFromModifiedReceiptHandle(receiptHandle string) (string, error) {
-	s3MsgBucketName := getFromReceiptHandleByMarker(receiptHandle, sqs_configs_constants.S3_BUCKET_NAME_MARKER)
-	s3MsgKey := getFromReceiptHandleByMarker(receiptHandle, sqs_configs_constants.S3_KEY_MARKER)
-
-	payloadS3Pointer := &payload_store.PayloadS3Pointer{
-		S3BucketName: s3MsgBucketName,
-		S3Key:        s3MsgKey,
-	}
-
-	return payloadS3Pointer.ToJson()
-}
-
-func getFromReceiptHandleByMarker(receiptHandle string, marker string) string {
-	firstOccurrence := strings.Index(receiptHandle, marker)
-	offset := firstOccurrence + 1
-	secondOccurrence := strings.Index(receiptHandle[offset:], marker) + offset
-
-	return receiptHandle[firstOccurrence+len(marker) : secondOccurrence]
-}
-
-func getMsgAttributesSize(attributes map[string]*aws_sqs.MessageAttributeValue) int {
-	totalMsgAttributesSize := 0
-
-	for key, value := range attributes {
-		totalMsgAttributesSize += len(key)
-
-		if value.DataType != nil {
-			totalMsgAttributesSize += len(*value.DataType)
-		}
-
-		strVal := value.StringValue
-		if strVal != nil {
-			totalMsgAttributesSize += len(*strVal)
-		}
-
-		binaryVal := value.BinaryValue
-		if binaryVal != nil {
-			totalMsgAttributesSize += len(binaryVal)
-		}
+func newSQSError(operation string, queueUrl *string, err error) error {
+	return errors.SQSError{
+		Operation: operation,
+		QueueUrl:  aws.StringValue(queueUrl),
+		Err:       err,
 	}
-
-	return totalMsgAttributesSize
 }
 
 func copyMessageAttributes(attributes map[string]*aws_sqs.MessageAttributeValue) map[string]*aws_sqs.MessageAttributeValue {
@@ -0,0 +1,465 @@
+package aws_extended_sqs_client
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	errors_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors/constants"
+	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
+	sqs_configs_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client/constants"
+	tracing_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tracing/constants"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// Code of the failed entries which could not be prepared, when the error has no code of its own
+const batchEntryErrorCode = "AwsSqsGoExtendedClientBatchEntryError"
+
+type sendMessageBatchFunc func(*aws_sqs.SendMessageBatchInput) (*aws_sqs.SendMessageBatchOutput, error)
+
+// An entry of the batch once its body is offloaded when needed
+type preparedBatchEntry struct {
+	entry    *aws_sqs.SendMessageBatchRequestEntry
+	sqsInput *aws_sqs.SendMessageInput
+	info     *SendMessageInfo
+	err      error
+}
+
+func (c *AwsExtendedSQSClient) SendMessageBatch(input *aws_sqs.SendMessageBatchInput) (*aws_sqs.SendMessageBatchOutput, error) {
+	return c.sendMessageBatchWithContext(context.Background(), input, c.SQSAPI.SendMessageBatch)
+}
+
+func (c *AwsExtendedSQSClient) SendMessageBatchWithContext(ctx aws.Context, input *aws_sqs.SendMessageBatchInput, opts ...request.Option) (*aws_sqs.SendMessageBatchOutput, error) {
+	return c.sendMessageBatchWithContext(ctx, input, func(input *aws_sqs.SendMessageBatchInput) (*aws_sqs.SendMessageBatchOutput, error) {
+		return c.SQSAPI.SendMessageBatchWithContext(ctx, input, opts...)
+	})
+}
+
+func (c *AwsExtendedSQSClient) sendMessageBatchWithContext(ctx context.Context, input *aws_sqs.SendMessageBatchInput, batchCall sendMessageBatchFunc) (*aws_sqs.SendMessageBatchOutput, error) {
+	var queueUrl *string
+	if input != nil {
+		queueUrl = input.QueueUrl
+	}
+
+	ctx, span := c.startMessagingSpan(ctx, tracing_constants.SPAN_SEND_MESSAGE_BATCH, tracing_constants.SPAN_KIND_PRODUCER, queueUrl)
+	defer span.End()
+
+	output, err := c.handleSendMessageBatch(ctx, input, batchCall)
+	if err != nil {
+		span.RecordError(err)
+	}
+
+	return output, err
+}
+
+// Every entry is handled as by SendMessage, with the oversized ones offloaded to s3 in parallel.
+// Entries which fail before being sent, i.e. on upload, are returned as failed entries of the output.
+func (c *AwsExtendedSQSClient) handleSendMessageBatch(ctx context.Context, input *aws_sqs.SendMessageBatchInput, batchCall sendMessageBatchFunc) (*aws_sqs.SendMessageBatchOutput, error) {
+	logger := c.opts.logger.WithField("method", sqs_configs_constants.LOG_METHOD_SEND_MESSAGE_BATCH)
+	logLevel := c.getLogLevel(sqs_configs_constants.LOG_METHOD_SEND_MESSAGE_BATCH)
+
+	if input == nil || len(input.Entries) == 0 {
+		logger.Log(logLevel, "Handled by original sqs sdk")
+
+		// let parent handle the error
+		return c.sendMessageBatch(batchCall, input)
+	}
+
+	preparedEntries := make([]*preparedBatchEntry, len(input.Entries))
+
+	var wg sync.WaitGroup
+	for index, entry := range input.Entries {
+		preparedEntries[index] = &preparedBatchEntry{entry: entry}
+		if entry == nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func(prepared *preparedBatchEntry) {
+			defer wg.Done()
+
+			entryLogger := logger.WithField("entry_id", aws.StringValue(prepared.entry.Id))
+			prepared.sqsInput, prepared.info, prepared.err = c.prepareSendMessage(ctx, newSendMessageInput(input.QueueUrl, prepared.entry), entryLogger, logLevel)
+		}(preparedEntries[index])
+	}
+	wg.Wait()
+
+	if c.config.IsPayloadSupportEnabled() {
+		c.fitSendMessageBatch(ctx, preparedEntries, logger, logLevel)
+	}
+
+	output := &aws_sqs.SendMessageBatchOutput{}
+	sqsInput := &aws_sqs.SendMessageBatchInput{QueueUrl: input.QueueUrl}
+
+	for _, prepared := range preparedEntries {
+		if prepared.err != nil {
+			output.Failed = append(output.Failed, newBatchResultErrorEntry(prepared.entry.Id, prepared.err))
+			continue
+		}
+
+		if prepared.sqsInput == nil {
+			// let parent handle the nil entry
+			sqsInput.Entries = append(sqsInput.Entries, prepared.entry)
+			continue
+		}
+
+		sqsInput.Entries = append(sqsInput.Entries, newSendMessageBatchRequestEntry(prepared.entry.Id, prepared.sqsInput))
+	}
+
+	if len(sqsInput.Entries) == 0 {
+		logger.Log(logLevel, "No entry left to send")
+		return output, nil
+	}
+
+	sqsOutput, err := c.sendMessageBatch(batchCall, sqsInput)
+	if err != nil {
+		logError(logger, "sendMessageBatch", err)
+	} else {
+		output.Successful = sqsOutput.Successful
+		output.Failed = append(output.Failed, sqsOutput.Failed...)
+	}
+
+	c.interceptAfterSendBatch(ctx, preparedEntries, output, err)
+
+	return output, err
+}
+
+// SQS caps the whole batch as well as each message, so entries below the threshold may still add up over it.
+// The largest entries still sent inline are offloaded one at a time until the batch fits.
+func (c *AwsExtendedSQSClient) fitSendMessageBatch(ctx context.Context, preparedEntries []*preparedBatchEntry, logger aws_extended_sqsiface.LoggerInterface, logLevel string) {
+	batchSize := 0
+	for _, prepared := range preparedEntries {
+		if prepared.err == nil && prepared.sqsInput != nil {
+			batchSize += CalculateMessageSize(prepared.sqsInput).Total()
+		}
+	}
+
+	for batchSize > sqs_configs_constants.MAX_SQS_BATCH_SIZE {
+		var largest *preparedBatchEntry
+		largestSize := 0
+		for _, prepared := range preparedEntries {
+			if prepared.err != nil || prepared.info == nil || prepared.info.Destination != "sqs" || prepared.sqsInput.MessageBody == nil {
+				continue
+			}
+
+			if size := CalculateMessageSize(prepared.sqsInput).Total(); size > largestSize {
+				largest, largestSize = prepared, size
+			}
+		}
+
+		if largest == nil {
+			// let parent handle the error
+			return
+		}
+
+		entryLogger := logger.WithField("entry_id", aws.StringValue(largest.entry.Id)).WithField("batch_size", strconv.Itoa(batchSize))
+		batchSize -= largestSize
+
+		sqsInput, s3Pointer, err := c.offloadBatchEntry(ctx, largest.sqsInput, entryLogger)
+		if err != nil {
+			largest.err = err
+			continue
+		}
+
+		largest.sqsInput = sqsInput
+		largest.info.Destination = "s3"
+		largest.info.S3Pointer = s3Pointer
+		batchSize += CalculateMessageSize(sqsInput).Total()
+
+		entryLogger.WithField("uploaded_to_s3", "true").Log(logLevel, "Uploaded to s3 to fit the batch")
+	}
+}
+
+func (c *AwsExtendedSQSClient) offloadBatchEntry(ctx context.Context, input *aws_sqs.SendMessageInput, logger aws_extended_sqsiface.LoggerInterface) (*aws_sqs.SendMessageInput, *S3Pointer, error) {
+	if err := c.checkOffloadedMessageAttributes(input.MessageAttributes); err != nil {
+		logError(logger, "checkOffloadedMessageAttributes", err)
+		return nil, nil, err
+	}
+
+	sqsInput, err := c.storeMessageInS3(ctx, input)
+	if err != nil {
+		logError(logger, "storeMessageInS3", err)
+		return nil, nil, err
+	}
+
+	s3Pointer, err := newS3Pointer(*sqsInput.MessageBody)
+	if err != nil {
+		logError(logger, "newS3Pointer", err)
+		return nil, nil, err
+	}
+
+	return sqsInput, s3Pointer, nil
+}
+
+func (c *AwsExtendedSQSClient) sendMessageBatch(batchCall sendMessageBatchFunc, input *aws_sqs.SendMessageBatchInput) (*aws_sqs.SendMessageBatchOutput, error) {
+	output, err := batchCall(input)
+	if err != nil {
+		var queueUrl *string
+		if input != nil {
+			queueUrl = input.QueueUrl
+		}
+
+		return output, newSQSError(errors_constants.OPERATION_SEND, queueUrl, err)
+	}
+
+	return output, nil
+}
+
+// Runs the after send interceptors for each sent entry with its own outcome, and records the sent ones
+func (c *AwsExtendedSQSClient) interceptAfterSendBatch(ctx context.Context, preparedEntries []*preparedBatchEntry, output *aws_sqs.SendMessageBatchOutput, batchErr error) {
+	successful := make(map[string]*aws_sqs.SendMessageBatchResultEntry, len(output.Successful))
+	for _, result := range output.Successful {
+		successful[aws.StringValue(result.Id)] = result
+	}
+
+	failed := make(map[string]*aws_sqs.BatchResultErrorEntry, len(output.Failed))
+	for _, result := range output.Failed {
+		failed[aws.StringValue(result.Id)] = result
+	}
+
+	for _, prepared := range preparedEntries {
+		if prepared.err != nil || prepared.info == nil {
+			continue
+		}
+
+		id := aws.StringValue(prepared.entry.Id)
+		entryOutput := &aws_sqs.SendMessageOutput{}
+		entryErr := batchErr
+
+		if result, ok := successful[id]; ok {
+			entryOutput = &aws_sqs.SendMessageOutput{
+				MessageId:                    result.MessageId,
+				MD5OfMessageBody:             result.MD5OfMessageBody,
+				MD5OfMessageAttributes:       result.MD5OfMessageAttributes,
+				MD5OfMessageSystemAttributes: result.MD5OfMessageSystemAttributes,
+				SequenceNumber:               result.SequenceNumber,
+			}
+
+			if c.config.IsPayloadSupportEnabled() && prepared.info.Input.MessageBody != nil {
+				c.recordMessageSent(prepared.info.Destination, len(*prepared.info.Input.MessageBody))
+			}
+		} else if result, ok := failed[id]; ok {
+			entryErr = newSQSError(errors_constants.OPERATION_SEND, prepared.info.Input.QueueUrl, awserr.New(aws.StringValue(result.Code), aws.StringValue(result.Message), nil))
+		}
+
+		for _, interceptor := range c.opts.afterSendInterceptors {
+			interceptor(ctx, prepared.info, entryOutput, entryErr)
+		}
+	}
+}
+
+func newSendMessageInput(queueUrl *string, entry *aws_sqs.SendMessageBatchRequestEntry) *aws_sqs.SendMessageInput {
+	return &aws_sqs.SendMessageInput{
+		QueueUrl:                queueUrl,
+		DelaySeconds:            entry.DelaySeconds,
+		MessageAttributes:       entry.MessageAttributes,
+		MessageBody:             entry.MessageBody,
+		MessageDeduplicationId:  entry.MessageDeduplicationId,
+		MessageGroupId:          entry.MessageGroupId,
+		MessageSystemAttributes: entry.MessageSystemAttributes,
+	}
+}
+
+func newSendMessageBatchRequestEntry(id *string, input *aws_sqs.SendMessageInput) *aws_sqs.SendMessageBatchRequestEntry {
+	return &aws_sqs.SendMessageBatchRequestEntry{
+		Id:                      id,
+		DelaySeconds:            input.DelaySeconds,
+		MessageAttributes:       input.MessageAttributes,
+		MessageBody:             input.MessageBody,
+		MessageDeduplicationId:  input.MessageDeduplicationId,
+		MessageGroupId:          input.MessageGroupId,
+		MessageSystemAttributes: input.MessageSystemAttributes,
+	}
+}
+
+func newBatchResultErrorEntry(id *string, err error) *aws_sqs.BatchResultErrorEntry {
+	code := batchEntryErrorCode
+	if codedErr, ok := err.(interface{ Code() string }); ok {
+		code = codedErr.Code()
+	}
+
+	return &aws_sqs.BatchResultErrorEntry{
+		Id:          id,
+		Code:        aws.String(code),
+		Message:     aws.String(err.Error()),
+		SenderFault: aws.Bool(true),
+	}
+}
+
+type deleteMessageBatchFunc func(*aws_sqs.DeleteMessageBatchInput) (*aws_sqs.DeleteMessageBatchOutput, error)
+
+func (c *AwsExtendedSQSClient) DeleteMessageBatch(input *aws_sqs.DeleteMessageBatchInput) (*aws_sqs.DeleteMessageBatchOutput, error) {
+	return c.deleteMessageBatchWithContext(context.Background(), input, c.SQSAPI.DeleteMessageBatch)
+}
+
+func (c *AwsExtendedSQSClient) DeleteMessageBatchWithContext(ctx aws.Context, input *aws_sqs.DeleteMessageBatchInput, opts ...request.Option) (*aws_sqs.DeleteMessageBatchOutput, error) {
+	return c.deleteMessageBatchWithContext(ctx, input, func(input *aws_sqs.DeleteMessageBatchInput) (*aws_sqs.DeleteMessageBatchOutput, error) {
+		return c.SQSAPI.DeleteMessageBatchWithContext(ctx, input, opts...)
+	})
+}
+
+func (c *AwsExtendedSQSClient) deleteMessageBatchWithContext(ctx context.Context, input *aws_sqs.DeleteMessageBatchInput, batchCall deleteMessageBatchFunc) (*aws_sqs.DeleteMessageBatchOutput, error) {
+	var queueUrl *string
+	if input != nil {
+		queueUrl = input.QueueUrl
+	}
+
+	ctx, span := c.startMessagingSpan(ctx, tracing_constants.SPAN_DELETE_MESSAGE_BATCH, tracing_constants.SPAN_KIND_CLIENT, queueUrl)
+	defer span.End()
+
+	output, err := c.handleDeleteMessageBatch(ctx, input, batchCall)
+	if err != nil {
+		span.RecordError(err)
+	}
+
+	return output, err
+}
+
+// Unlike DeleteMessage, the messages are deleted from sqs before their payloads, which are then deleted in bulk.
+// Entries whose payload fails to be deleted are moved to the failed entries of the output, as well as the ones
+// rejected by the before delete interceptors.
+func (c *AwsExtendedSQSClient) handleDeleteMessageBatch(ctx context.Context, input *aws_sqs.DeleteMessageBatchInput, batchCall deleteMessageBatchFunc) (*aws_sqs.DeleteMessageBatchOutput, error) {
+	logger := c.opts.logger.WithField("method", sqs_configs_constants.LOG_METHOD_DELETE_MESSAGE_BATCH)
+	logLevel := c.getLogLevel(sqs_configs_constants.LOG_METHOD_DELETE_MESSAGE_BATCH)
+
+	if input == nil || len(input.Entries) == 0 {
+		logger.Log(logLevel, "Handled by original sqs sdk")
+
+		// let parent handle the error
+		return c.deleteMessageBatch(batchCall, input)
+	}
+
+	output := &aws_sqs.DeleteMessageBatchOutput{}
+	sqsInput := &aws_sqs.DeleteMessageBatchInput{QueueUrl: input.QueueUrl}
+	messagePointers := map[string]string{}
+
+	for _, entry := range input.Entries {
+		if entry == nil || entry.ReceiptHandle == nil {
+			// let parent handle the error
+			sqsInput.Entries = append(sqsInput.Entries, entry)
+			continue
+		}
+
+		handle := &ReceiptHandle{Original: *entry.ReceiptHandle}
+		if c.config.IsPayloadSupportEnabled() {
+			var err error
+			handle, err = ParseReceiptHandle(*entry.ReceiptHandle)
+			if err != nil {
+				logError(logger.WithField("entry_id", aws.StringValue(entry.Id)), "ParseReceiptHandle", err)
+				output.Failed = append(output.Failed, newBatchResultErrorEntry(entry.Id, err))
+				continue
+			}
+
+			if err := c.verifyReceiptHandle(handle); err != nil {
+				logError(logger.WithField("entry_id", aws.StringValue(entry.Id)), "verifyReceiptHandle", err)
+				output.Failed = append(output.Failed, newBatchResultErrorEntry(entry.Id, err))
+				continue
+			}
+		}
+		s3Pointer := handle.S3Pointer
+
+		deleteInput := &aws_sqs.DeleteMessageInput{QueueUrl: input.QueueUrl, ReceiptHandle: entry.ReceiptHandle}
+		if err := c.interceptBeforeDelete(ctx, deleteInput, s3Pointer); err != nil {
+			logError(logger.WithField("entry_id", aws.StringValue(entry.Id)), "interceptBeforeDelete", err)
+			output.Failed = append(output.Failed, newBatchResultErrorEntry(entry.Id, err))
+			continue
+		}
+
+		if s3Pointer == nil {
+			sqsInput.Entries = append(sqsInput.Entries, entry)
+			continue
+		}
+
+		if c.shouldCleanupPayload(s3Pointer) {
+			messagePointer, err := s3Pointer.toMessagePointer()
+			if err != nil {
+				logError(logger.WithField("entry_id", aws.StringValue(entry.Id)), "toMessagePointer", err)
+				output.Failed = append(output.Failed, newBatchResultErrorEntry(entry.Id, err))
+				continue
+			}
+
+			messagePointers[aws.StringValue(entry.Id)] = messagePointer
+		}
+
+		sqsInput.Entries = append(sqsInput.Entries, &aws_sqs.DeleteMessageBatchRequestEntry{
+			Id:            entry.Id,
+			ReceiptHandle: aws.String(handle.Original),
+		})
+	}
+
+	if len(sqsInput.Entries) == 0 {
+		logger.Log(logLevel, "No entry left to delete")
+		return output, nil
+	}
+
+	sqsOutput, err := c.deleteMessageBatch(batchCall, sqsInput)
+	if err != nil {
+		logError(logger, "deleteMessageBatch", err)
+		return output, err
+	}
+
+	output.Failed = append(output.Failed, sqsOutput.Failed...)
+
+	// Only the payloads of the messages deleted from sqs are deleted
+	var deletedIds []string
+	var deletedPointers []string
+	for _, result := range sqsOutput.Successful {
+		if messagePointer, ok := messagePointers[aws.StringValue(result.Id)]; ok {
+			deletedIds = append(deletedIds, aws.StringValue(result.Id))
+			deletedPointers = append(deletedPointers, messagePointer)
+		}
+	}
+
+	if len(deletedPointers) > 0 && c.payloadCleaner != nil {
+		logger.Log(logLevel, fmt.Sprintf("Enqueued %d messages in s3 to be deleted", len(deletedPointers)))
+
+		for _, messagePointer := range deletedPointers {
+			c.payloadCleaner.Enqueue(messagePointer)
+		}
+
+		output.Successful = append(output.Successful, sqsOutput.Successful...)
+		return output, nil
+	}
+
+	payloadErrs := map[string]error{}
+	if len(deletedPointers) > 0 {
+		logger.Log(logLevel, fmt.Sprintf("Deleting %d messages in s3", len(deletedPointers)))
+
+		for index, err := range c.payloadStore.DeleteOriginalPayloadsWithContext(ctx, deletedPointers) {
+			if err != nil {
+				logError(logger.WithField("entry_id", deletedIds[index]), "DeleteOriginalPayloads", err)
+				payloadErrs[deletedIds[index]] = err
+			}
+		}
+	}
+
+	for _, result := range sqsOutput.Successful {
+		if err, ok := payloadErrs[aws.StringValue(result.Id)]; ok {
+			output.Failed = append(output.Failed, newBatchResultErrorEntry(result.Id, err))
+			continue
+		}
+
+		output.Successful = append(output.Successful, result)
+	}
+
+	return output, nil
+}
+
+func (c *AwsExtendedSQSClient) deleteMessageBatch(batchCall deleteMessageBatchFunc, input *aws_sqs.DeleteMessageBatchInput) (*aws_sqs.DeleteMessageBatchOutput, error) {
+	output, err := batchCall(input)
+	if err != nil {
+		var queueUrl *string
+		if input != nil {
+			queueUrl = input.QueueUrl
+		}
+
+		return output, newSQSError(errors_constants.OPERATION_DELETE, queueUrl, err)
+	}
+
+	return output, nil
+}
@@ -0,0 +1,37 @@
+package aws_extended_sqs_client
+
+import (
+	"time"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/internal/payload_cache"
+)
+
+type PayloadCacheOption func(*payloadCacheOptions)
+
+type payloadCacheOptions struct {
+	maxBytes  int
+	cacheOpts []payload_cache.PayloadCacheOption
+}
+
+// Time after which a cached payload is fetched again from s3, 5 minutes by default. Payloads do not expire when ttl is 0.
+func WithPayloadCacheTTL(ttl time.Duration) PayloadCacheOption {
+	return func(opts *payloadCacheOptions) {
+		opts.cacheOpts = append(opts.cacheOpts, payload_cache.WithTTL(ttl))
+	}
+}
+
+// Keeps the payloads fetched from s3 in memory, up to maxBytes in total, so that redelivered messages are resolved without
+// downloading their payloads again. The least recently used payloads are evicted first, and a payload is evicted once
+// deleted through the client. Lookups are reported as METRIC_PAYLOAD_CACHE_LOOKUPS.
+func WithPayloadCache(maxBytes int, opts ...PayloadCacheOption) AwsExtendedSQSClientOption {
+	return func(clientOpts *awsExtendedSQSClientOptions) {
+		clientOpts.payloadCache = &payloadCacheOptions{maxBytes: maxBytes}
+		for _, opt := range opts {
+			opt(clientOpts.payloadCache)
+		}
+	}
+}
+
+func (o *payloadCacheOptions) newPayloadCache() *payload_cache.PayloadCache {
+	return payload_cache.NewPayloadCache(o.maxBytes, o.cacheOpts...)
+}
@@ -0,0 +1,88 @@
+package aws_extended_sqs_client
+
+import (
+	"context"
+	"time"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/internal/payload_cleaner"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/internal/payload_store"
+)
+
+// Called once an offloaded payload could not be deleted in the background, s3Pointer is nil when the pointer could not be parsed
+type PayloadCleanupFailureHandler func(s3Pointer *S3Pointer, err error)
+
+type PayloadCleanupOption func(*payloadCleanupOptions)
+
+type payloadCleanupOptions struct {
+	cleanerOpts []payload_cleaner.PayloadCleanerOption
+}
+
+// Number of payloads deleted concurrently, 1 by default
+func WithPayloadCleanupWorkers(workers int) PayloadCleanupOption {
+	return func(opts *payloadCleanupOptions) {
+		opts.cleanerOpts = append(opts.cleanerOpts, payload_cleaner.WithWorkers(workers))
+	}
+}
+
+// Payloads waiting to be deleted, 1000 by default. Payloads of messages deleted while the queue is full are reported as failed
+func WithPayloadCleanupQueueSize(queueSize int) PayloadCleanupOption {
+	return func(opts *payloadCleanupOptions) {
+		opts.cleanerOpts = append(opts.cleanerOpts, payload_cleaner.WithQueueSize(queueSize))
+	}
+}
+
+// Attempts after the first failed deletion of a payload, 3 by default, with the backoff doubled on each one, 1 second by default
+func WithPayloadCleanupRetries(maxRetries int, backoff time.Duration) PayloadCleanupOption {
+	return func(opts *payloadCleanupOptions) {
+		opts.cleanerOpts = append(opts.cleanerOpts, payload_cleaner.WithMaxRetries(maxRetries), payload_cleaner.WithRetryBackoff(backoff))
+	}
+}
+
+// Delays the deletion of a payload after its message is deleted, so that other deliveries of the message can still resolve it
+func WithPayloadCleanupGracePeriod(gracePeriod time.Duration) PayloadCleanupOption {
+	return func(opts *payloadCleanupOptions) {
+		opts.cleanerOpts = append(opts.cleanerOpts, payload_cleaner.WithGracePeriod(gracePeriod))
+	}
+}
+
+// Called once a payload has failed all its attempts, i.e. to record it for a later cleanup. It may call Close.
+func WithPayloadCleanupFailureHandler(handler PayloadCleanupFailureHandler) PayloadCleanupOption {
+	return func(opts *payloadCleanupOptions) {
+		opts.cleanerOpts = append(opts.cleanerOpts, payload_cleaner.WithFailureHandler(func(messagePointer string, err error) {
+			s3Pointer, _ := newS3Pointer(messagePointer)
+			handler(s3Pointer, err)
+		}))
+	}
+}
+
+// Deletes the payloads in the background once their messages are deleted from sqs, instead of deleting them before the messages.
+// A failed payload deletion then leaves an orphaned object in s3 rather than failing the delete and having the message redelivered.
+// Does nothing when SetCleanupS3Payload is disabled, call Close to delete the pending payloads before exiting.
+func WithDeferredPayloadCleanup(opts ...PayloadCleanupOption) AwsExtendedSQSClientOption {
+	return func(clientOpts *awsExtendedSQSClientOptions) {
+		clientOpts.payloadCleanup = &payloadCleanupOptions{}
+		for _, opt := range opts {
+			opt(clientOpts.payloadCleanup)
+		}
+	}
+}
+
+// Deletes the payloads pending in the background right away, until ctx is done. Nothing to do without WithDeferredPayloadCleanup.
+func (c *AwsExtendedSQSClient) Close(ctx context.Context) error {
+	if c.payloadCleaner == nil {
+		return nil
+	}
+
+	return c.payloadCleaner.Close(ctx)
+}
+
+// Content addressed payloads may be shared by other messages, they are left to the lifecycle rules of the bucket
+func (c *AwsExtendedSQSClient) shouldCleanupPayload(s3Pointer *S3Pointer) bool {
+	return c.config.DoesCleanupS3Payload() && !payload_store.IsContentAddressedKey(s3Pointer.S3Key)
+}
+
+func (c *AwsExtendedSQSClient) newPayloadCleaner() *payload_cleaner.PayloadCleaner {
+	opts := append([]payload_cleaner.PayloadCleanerOption{payload_cleaner.WithLogger(c.opts.logger)}, c.opts.payloadCleanup.cleanerOpts...)
+
+	return payload_cleaner.NewPayloadCleaner(c.payloadStore, opts...)
+}
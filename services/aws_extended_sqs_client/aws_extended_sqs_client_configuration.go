@@ -1,6 +1,8 @@
 package aws_extended_sqs_client
 
 import (
+	"time"
+
 	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
 	sqs_configs_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client/constants"
 
@@ -11,24 +13,44 @@ type AwsExtendedSQSClientConfiguration struct {
 	aws_extended_sqsiface.AwsExtendedSqsClientConfigurationInterface
 	s3             aws_s3iface.S3API
 	s3BucketName   string
+	s3KeyPrefix    string
 	payloadSupport bool
 
+	bucketRouter     aws_extended_sqsiface.BucketRouterInterface
+	s3ClientResolver aws_extended_sqsiface.S3ClientResolverInterface
+
 	payloadSizeThreshold int
 	alwaysThroughS3      bool
 	cleanupS3Payload     bool
+	attributeOffload     bool
+	contentAddressed     bool
+
+	contentAddressedTouchAge time.Duration
+
+	attributeLimitFallback string
 
 	breakSendSupport              bool
 	breakSendPayloadSizeThreshold int
+
+	// The first one signs, all of them verify
+	receiptHandleSigningKeys [][]byte
 }
 
 func NewExtendedSQSClientConfiguration() *AwsExtendedSQSClientConfiguration {
 	return &AwsExtendedSQSClientConfiguration{
 		s3:                            nil,
 		s3BucketName:                  "",
+		s3KeyPrefix:                   "",
 		payloadSupport:                false,
+		bucketRouter:                  nil,
+		s3ClientResolver:              nil,
 		payloadSizeThreshold:          sqs_configs_constants.DEFAULT_MESSAGE_SIZE_THRESHOLD,
 		alwaysThroughS3:               false,
 		cleanupS3Payload:              true,
+		attributeOffload:              false,
+		contentAddressed:              false,
+		contentAddressedTouchAge:      sqs_configs_constants.DEFAULT_CONTENT_ADDRESSED_TOUCH_AGE,
+		attributeLimitFallback:        sqs_configs_constants.ATTRIBUTE_LIMIT_FALLBACK_ERROR,
 		breakSendSupport:              false,
 		breakSendPayloadSizeThreshold: sqs_configs_constants.DEFAULT_BREAK_SEND_MESSAGE_SIZE_THRESHOLD,
 	}
@@ -44,6 +66,21 @@ func (config *AwsExtendedSQSClientConfiguration) WithBreakSendSupportEnabled() {
 	config.breakSendSupport = true
 }
 
+// Chooses the destination bucket per message instead of always using the bucket given to WithPayloadSupportEnabled
+func (config *AwsExtendedSQSClientConfiguration) WithBucketRouter(router aws_extended_sqsiface.BucketRouterInterface) {
+	config.bucketRouter = router
+}
+
+// Chooses the s3 client used for a bucket, e.g. a client of another region or account
+func (config *AwsExtendedSQSClientConfiguration) WithS3ClientResolver(resolver aws_extended_sqsiface.S3ClientResolverInterface) {
+	config.s3ClientResolver = resolver
+}
+
+// Prepended to the key of each offloaded payload, e.g. "payloads/", so that the payloads can be listed apart from other objects of the bucket
+func (config *AwsExtendedSQSClientConfiguration) SetS3KeyPrefix(prefix string) {
+	config.s3KeyPrefix = prefix
+}
+
 func (config *AwsExtendedSQSClientConfiguration) SetPayloadSizeThreshold(threshold int) {
 	config.payloadSizeThreshold = threshold
 }
@@ -60,6 +97,37 @@ func (config *AwsExtendedSQSClientConfiguration) SetCleanupS3Payload(cleanupS3Pa
 	config.cleanupS3Payload = cleanupS3Payload
 }
 
+// Moves message attributes that do not fit next to the message pointer into the s3 payload,
+// instead of rejecting the message. They are restored on receive.
+func (config *AwsExtendedSQSClientConfiguration) SetAttributeOffloadEnabled(attributeOffload bool) {
+	config.attributeOffload = attributeOffload
+}
+
+// Stores each payload under a key derived from the sha256 of its content, and skips the upload when the payload is already stored,
+// i.e. when the same payload is sent to many queues. Such payloads may be shared by many messages, so they are never deleted
+// with their messages, by any client, and are left to the lifecycle rules of the bucket.
+func (config *AwsExtendedSQSClientConfiguration) SetContentAddressedPayloads(contentAddressed bool) {
+	config.contentAddressed = contentAddressed
+}
+
+// Age after which a content addressed payload sent again is copied onto itself to refresh its last modified time, 24 hours by default.
+// A lifecycle rule expiring the payloads must expire them at least this long after the message retention period of the queues.
+func (config *AwsExtendedSQSClientConfiguration) SetContentAddressedTouchAge(touchAge time.Duration) {
+	config.contentAddressedTouchAge = touchAge
+}
+
+// Decides what happens when an offloaded message has more attributes than MAX_ALLOWED_ATTRIBUTES,
+// either ATTRIBUTE_LIMIT_FALLBACK_ERROR or ATTRIBUTE_LIMIT_FALLBACK_FOLD to move attributes into the s3 payload
+func (config *AwsExtendedSQSClientConfiguration) SetAttributeLimitFallback(fallback string) {
+	config.attributeLimitFallback = fallback
+}
+
+// Signs the s3 pointer embedded in the receipt handles of offloaded messages, and rejects the deletes of receipt handles
+// whose signature does not match before touching s3. The previous keys are only used to verify, i.e. while rotating the key.
+func (config *AwsExtendedSQSClientConfiguration) SetReceiptHandleSigningKey(key []byte, previousKeys ...[]byte) {
+	config.receiptHandleSigningKeys = append([][]byte{key}, previousKeys...)
+}
+
 func (config *AwsExtendedSQSClientConfiguration) IsPayloadSupportEnabled() bool {
 	return config.payloadSupport
 }
@@ -68,6 +136,22 @@ func (config *AwsExtendedSQSClientConfiguration) IsBreakSendSupportEnabled() boo
 	return config.breakSendSupport
 }
 
+func (config *AwsExtendedSQSClientConfiguration) GetS3BucketName() string {
+	return config.s3BucketName
+}
+
+func (config *AwsExtendedSQSClientConfiguration) GetS3KeyPrefix() string {
+	return config.s3KeyPrefix
+}
+
+func (config *AwsExtendedSQSClientConfiguration) GetBucketRouter() aws_extended_sqsiface.BucketRouterInterface {
+	return config.bucketRouter
+}
+
+func (config *AwsExtendedSQSClientConfiguration) GetS3ClientResolver() aws_extended_sqsiface.S3ClientResolverInterface {
+	return config.s3ClientResolver
+}
+
 func (config *AwsExtendedSQSClientConfiguration) GetPayloadSizeThreshold() int {
 	return config.payloadSizeThreshold
 }
@@ -83,3 +167,23 @@ func (config *AwsExtendedSQSClientConfiguration) IsAlwaysThroughS3() bool {
 func (config *AwsExtendedSQSClientConfiguration) DoesCleanupS3Payload() bool {
 	return config.cleanupS3Payload
 }
+
+func (config *AwsExtendedSQSClientConfiguration) IsAttributeOffloadEnabled() bool {
+	return config.attributeOffload
+}
+
+func (config *AwsExtendedSQSClientConfiguration) IsContentAddressedPayloads() bool {
+	return config.contentAddressed
+}
+
+func (config *AwsExtendedSQSClientConfiguration) GetContentAddressedTouchAge() time.Duration {
+	return config.contentAddressedTouchAge
+}
+
+func (config *AwsExtendedSQSClientConfiguration) GetAttributeLimitFallback() string {
+	return config.attributeLimitFallback
+}
+
+func (config *AwsExtendedSQSClientConfiguration) GetReceiptHandleSigningKeys() [][]byte {
+	return config.receiptHandleSigningKeys
+}
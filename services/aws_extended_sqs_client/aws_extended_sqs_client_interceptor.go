@@ -0,0 +1,138 @@
+package aws_extended_sqs_client
+
+import (
+	"context"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/internal/payload_store"
+
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// Location of an offloaded payload in s3
+type S3Pointer struct {
+	S3BucketName string
+	S3Key        string
+}
+
+// Runs before the message is checked and offloaded. The input is a copy of the one given to SendMessage
+// which can be modified, i.e. to add message attributes, and an error aborts the send.
+type BeforeSendInterceptor func(ctx context.Context, input *aws_sqs.SendMessageInput) error
+
+type SendMessageInfo struct {
+	// Input before the body is offloaded
+	Input *aws_sqs.SendMessageInput
+	// "s3" or "sqs", as decided by the size of the message
+	Destination string
+	// Set when the body is offloaded
+	S3Pointer *S3Pointer
+}
+
+// Runs once the message is sent to sqs, err is the error of the send
+type AfterSendInterceptor func(ctx context.Context, info *SendMessageInfo, output *aws_sqs.SendMessageOutput, err error)
+
+type ReceivedMessageInfo struct {
+	// Message as returned to the caller, with the offloaded payload restored unless WithLazyPayloadResolution is set
+	Message *aws_sqs.Message
+	// Set when the payload is offloaded to s3
+	S3Pointer *S3Pointer
+}
+
+// Runs for each received message, an error fails the receive
+type AfterReceiveInterceptor func(ctx context.Context, info *ReceivedMessageInfo) error
+
+// Runs before the payload and the message are deleted, an error aborts the delete.
+// s3Pointer is set when the receipt handle is one of an offloaded message.
+type BeforeDeleteInterceptor func(ctx context.Context, input *aws_sqs.DeleteMessageInput, s3Pointer *S3Pointer) error
+
+// Interceptors of a kind run in the order they are registered
+func WithBeforeSendInterceptor(interceptors ...BeforeSendInterceptor) AwsExtendedSQSClientOption {
+	return func(opts *awsExtendedSQSClientOptions) {
+		opts.beforeSendInterceptors = append(opts.beforeSendInterceptors, interceptors...)
+	}
+}
+
+func WithAfterSendInterceptor(interceptors ...AfterSendInterceptor) AwsExtendedSQSClientOption {
+	return func(opts *awsExtendedSQSClientOptions) {
+		opts.afterSendInterceptors = append(opts.afterSendInterceptors, interceptors...)
+	}
+}
+
+func WithAfterReceiveInterceptor(interceptors ...AfterReceiveInterceptor) AwsExtendedSQSClientOption {
+	return func(opts *awsExtendedSQSClientOptions) {
+		opts.afterReceiveInterceptors = append(opts.afterReceiveInterceptors, interceptors...)
+	}
+}
+
+func WithBeforeDeleteInterceptor(interceptors ...BeforeDeleteInterceptor) AwsExtendedSQSClientOption {
+	return func(opts *awsExtendedSQSClientOptions) {
+		opts.beforeDeleteInterceptors = append(opts.beforeDeleteInterceptors, interceptors...)
+	}
+}
+
+func (c *AwsExtendedSQSClient) interceptBeforeSend(ctx context.Context, input *aws_sqs.SendMessageInput) (*aws_sqs.SendMessageInput, error) {
+	if len(c.opts.beforeSendInterceptors) == 0 {
+		return input, nil
+	}
+
+	updatedInput := &aws_sqs.SendMessageInput{}
+	*updatedInput = *input
+	updatedInput.MessageAttributes = copyMessageAttributes(input.MessageAttributes)
+
+	for _, interceptor := range c.opts.beforeSendInterceptors {
+		if err := interceptor(ctx, updatedInput); err != nil {
+			return nil, err
+		}
+	}
+
+	return updatedInput, nil
+}
+
+// Sends the message to sqs and runs the after send interceptors with the outcome
+func (c *AwsExtendedSQSClient) sendMessageAndIntercept(ctx context.Context, sendCall sendMessageFunc, sqsInput *aws_sqs.SendMessageInput, info *SendMessageInfo) (*aws_sqs.SendMessageOutput, error) {
+	output, err := c.sendMessage(sendCall, sqsInput)
+
+	for _, interceptor := range c.opts.afterSendInterceptors {
+		interceptor(ctx, info, output, err)
+	}
+
+	return output, err
+}
+
+func (c *AwsExtendedSQSClient) interceptAfterReceive(ctx context.Context, message *aws_sqs.Message, s3Pointer *S3Pointer) error {
+	for _, interceptor := range c.opts.afterReceiveInterceptors {
+		if err := interceptor(ctx, &ReceivedMessageInfo{Message: message, S3Pointer: s3Pointer}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *AwsExtendedSQSClient) interceptBeforeDelete(ctx context.Context, input *aws_sqs.DeleteMessageInput, s3Pointer *S3Pointer) error {
+	for _, interceptor := range c.opts.beforeDeleteInterceptors {
+		if err := interceptor(ctx, input, s3Pointer); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newS3Pointer(messagePointer string) (*S3Pointer, error) {
+	pointer, err := payload_store.FromJson(messagePointer)
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3Pointer{
+		S3BucketName: pointer.S3BucketName,
+		S3Key:        pointer.S3Key,
+	}, nil
+}
+
+// Message pointer of the payload, to be deleted from s3
+func (p *S3Pointer) toMessagePointer() (string, error) {
+	pointer := &payload_store.PayloadS3Pointer{S3BucketName: p.S3BucketName, S3Key: p.S3Key}
+
+	return pointer.ToJson()
+}
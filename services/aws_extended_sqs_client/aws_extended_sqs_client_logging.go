@@ -0,0 +1,97 @@
+package aws_extended_sqs_client
+
+import (
+	"fmt"
+
+	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
+	logging_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging/constants"
+
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// Returns the value to log for a message attribute, or false to leave the attribute out of the log fields
+type LoggingAttributeRedactor func(name string, value string) (string, bool)
+
+// Only the named message attributes are added to the log fields
+func WithLoggingAttributeAllowList(names ...string) AwsExtendedSQSClientOption {
+	return func(opts *awsExtendedSQSClientOptions) {
+		opts.loggingAttributeAllowList = toSet(names)
+	}
+}
+
+// The named message attributes are never added to the log fields
+func WithLoggingAttributeDenyList(names ...string) AwsExtendedSQSClientOption {
+	return func(opts *awsExtendedSQSClientOptions) {
+		opts.loggingAttributeDenyList = toSet(names)
+	}
+}
+
+// Applied to the message attributes left by the allow and deny lists
+func WithLoggingAttributeRedactor(redactor LoggingAttributeRedactor) AwsExtendedSQSClientOption {
+	return func(opts *awsExtendedSQSClientOptions) {
+		opts.loggingAttributeRedactor = redactor
+	}
+}
+
+// Level of the routine logs of a method, i.e. "Handled by original sqs sdk", see LOG_METHOD_* in sqs_configs_constants
+// and LOG_LEVEL_* in logging_constants. Errors are always logged at the error level.
+func WithLogLevel(method string, level string) AwsExtendedSQSClientOption {
+	return func(opts *awsExtendedSQSClientOptions) {
+		opts.logLevels[method] = level
+	}
+}
+
+func (c *AwsExtendedSQSClient) getLogLevel(method string) string {
+	if level, ok := c.opts.logLevels[method]; ok {
+		return level
+	}
+
+	return logging_constants.LOG_LEVEL_INFO
+}
+
+func (c *AwsExtendedSQSClient) getLoggingFields(attributes map[string]*aws_sqs.MessageAttributeValue) map[string]interface{} {
+	fields := map[string]interface{}{}
+
+	if attributes == nil {
+		return fields
+	}
+
+	for attributeName, value := range attributes {
+		if value == nil || value.StringValue == nil {
+			continue
+		}
+
+		if c.opts.loggingAttributeDenyList[attributeName] {
+			continue
+		}
+
+		if c.opts.loggingAttributeAllowList != nil && !c.opts.loggingAttributeAllowList[attributeName] {
+			continue
+		}
+
+		loggedValue := *value.StringValue
+		if c.opts.loggingAttributeRedactor != nil {
+			var ok bool
+			if loggedValue, ok = c.opts.loggingAttributeRedactor(attributeName, loggedValue); !ok {
+				continue
+			}
+		}
+
+		fields[attributeName] = loggedValue
+	}
+
+	return fields
+}
+
+func logError(logger aws_extended_sqsiface.LoggerInterface, method string, err interface{}) {
+	logger.WithField("method", method).Log(logging_constants.LOG_LEVEL_ERROR, fmt.Sprintf("Error: %+v", err))
+}
+
+func toSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+
+	return set
+}
@@ -0,0 +1,185 @@
+package aws_extended_sqs_client
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	sqs_configs_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client/constants"
+	tracing_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tracing/constants"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// Size in bytes of a message as accounted by SQS against the maximum message size.
+// Go strings are UTF-8 byte sequences, so len() already yields the UTF-8 size that SQS bills.
+type MessageSize struct {
+	Body                    int
+	MessageAttributes       int
+	MessageSystemAttributes int
+}
+
+// SQS documents message system attributes as not counting towards the message size.
+// They are still included here so that a message close to the limit is offloaded rather than rejected.
+func (s MessageSize) Total() int {
+	return s.Body + s.MessageAttributes + s.MessageSystemAttributes
+}
+
+func CalculateMessageSize(input *aws_sqs.SendMessageInput) MessageSize {
+	if input == nil {
+		return MessageSize{}
+	}
+
+	return calculateMessageSize(input.MessageBody, input.MessageAttributes, input.MessageSystemAttributes)
+}
+
+// Size the message takes in sqs once sent through the client, without sending or storing anything.
+// The trace context attributes are counted, and a message the client would offload to s3 counts its remaining
+// attributes and the longest possible message pointer in place of its body.
+func (c *AwsExtendedSQSClient) CalculateSentMessageSize(ctx context.Context, input *aws_sqs.SendMessageInput) MessageSize {
+	if input == nil {
+		return MessageSize{}
+	}
+
+	input = c.withTraceContextHeadroom(c.injectTraceContext(ctx, input))
+	messageSize := CalculateMessageSize(input)
+
+	if !c.config.IsPayloadSupportEnabled() || input.MessageBody == nil {
+		return messageSize
+	}
+
+	if !c.config.IsAlwaysThroughS3() && messageSize.Total() <= c.config.GetPayloadSizeThreshold() {
+		return messageSize
+	}
+
+	s3BucketName, err := c.getDestinationBucket(input, len(*input.MessageBody))
+	if err != nil {
+		// the message fails to be sent anyway
+		return messageSize
+	}
+
+	attributes, movedAttributes := c.getOffloadedMessageAttributes(input, s3BucketName)
+	if len(movedAttributes) > 0 {
+		attributes[sqs_configs_constants.PAYLOAD_ENVELOPE_ATTRIBUTE_NAME] = newPayloadEnvelopeAttribute()
+	}
+
+	attributes[sqs_configs_constants.RESERVED_ATTRIBUTE_NAME] = &aws_sqs.MessageAttributeValue{
+		DataType:    aws.String("Number"),
+		StringValue: aws.String(strconv.Itoa(len(*input.MessageBody))),
+	}
+
+	return MessageSize{
+		Body:                    getMaxMessagePointerSize(s3BucketName),
+		MessageAttributes:       CalculateMessageAttributesSize(attributes),
+		MessageSystemAttributes: messageSize.MessageSystemAttributes,
+	}
+}
+
+// Sets the trace context attributes the tracer did not inject, e.g. without a span in ctx, to a value as long as a traceparent
+func (c *AwsExtendedSQSClient) withTraceContextHeadroom(input *aws_sqs.SendMessageInput) *aws_sqs.SendMessageInput {
+	var missingFields []string
+	for _, field := range c.opts.tracer.Fields() {
+		if _, ok := input.MessageAttributes[field]; !ok {
+			missingFields = append(missingFields, field)
+		}
+	}
+
+	if len(missingFields) == 0 {
+		return input
+	}
+
+	updatedInput := &aws_sqs.SendMessageInput{}
+	*updatedInput = *input
+	updatedInput.MessageAttributes = copyMessageAttributes(input.MessageAttributes)
+
+	for _, field := range missingFields {
+		updatedInput.MessageAttributes[field] = &aws_sqs.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(strings.Repeat("x", tracing_constants.TRACE_CONTEXT_VALUE_SIZE)),
+		}
+	}
+
+	return updatedInput
+}
+
+func CalculateBatchEntrySize(entry *aws_sqs.SendMessageBatchRequestEntry) MessageSize {
+	if entry == nil {
+		return MessageSize{}
+	}
+
+	return calculateMessageSize(entry.MessageBody, entry.MessageAttributes, entry.MessageSystemAttributes)
+}
+
+// Each attribute counts its name, data type (including any custom type suffix) and value.
+// Binary values count their raw bytes, not their base64 representation.
+func CalculateMessageAttributesSize(attributes map[string]*aws_sqs.MessageAttributeValue) int {
+	totalMsgAttributesSize := 0
+
+	for name, value := range attributes {
+		totalMsgAttributesSize += len(name)
+
+		if value == nil {
+			continue
+		}
+
+		totalMsgAttributesSize += calculateAttributeValueSize(value.DataType, value.StringValue, value.BinaryValue, value.StringListValues, value.BinaryListValues)
+	}
+
+	return totalMsgAttributesSize
+}
+
+func CalculateMessageSystemAttributesSize(attributes map[string]*aws_sqs.MessageSystemAttributeValue) int {
+	totalMsgSystemAttributesSize := 0
+
+	for name, value := range attributes {
+		totalMsgSystemAttributesSize += len(name)
+
+		if value == nil {
+			continue
+		}
+
+		totalMsgSystemAttributesSize += calculateAttributeValueSize(value.DataType, value.StringValue, value.BinaryValue, value.StringListValues, value.BinaryListValues)
+	}
+
+	return totalMsgSystemAttributesSize
+}
+
+func calculateMessageSize(body *string, attributes map[string]*aws_sqs.MessageAttributeValue, systemAttributes map[string]*aws_sqs.MessageSystemAttributeValue) MessageSize {
+	size := MessageSize{
+		MessageAttributes:       CalculateMessageAttributesSize(attributes),
+		MessageSystemAttributes: CalculateMessageSystemAttributesSize(systemAttributes),
+	}
+
+	if body != nil {
+		size.Body = len(*body)
+	}
+
+	return size
+}
+
+func calculateAttributeValueSize(dataType *string, stringValue *string, binaryValue []byte, stringListValues []*string, binaryListValues [][]byte) int {
+	size := 0
+
+	if dataType != nil {
+		size += len(*dataType)
+	}
+
+	if stringValue != nil {
+		size += len(*stringValue)
+	}
+
+	size += len(binaryValue)
+
+	for _, value := range stringListValues {
+		if value != nil {
+			size += len(*value)
+		}
+	}
+
+	for _, value := range binaryListValues {
+		size += len(value)
+	}
+
+	return size
+}
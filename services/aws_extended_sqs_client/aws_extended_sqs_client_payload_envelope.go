@@ -0,0 +1,165 @@
+package aws_extended_sqs_client
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/internal/payload_store"
+	sqs_configs_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client/constants"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// Stored in s3 instead of the plain message body when message attributes are moved along with the body,
+// i.e. {"body":"xxx","messageAttributes":{"name":{"dataType":"String","stringValue":"xxx"}}}
+type payloadEnvelope struct {
+	Body              string                               `json:"body"`
+	MessageAttributes map[string]*payloadEnvelopeAttribute `json:"messageAttributes,omitempty"`
+}
+
+type payloadEnvelopeAttribute struct {
+	DataType         *string   `json:"dataType,omitempty"`
+	StringValue      *string   `json:"stringValue,omitempty"`
+	BinaryValue      []byte    `json:"binaryValue,omitempty"`
+	StringListValues []*string `json:"stringListValues,omitempty"`
+	BinaryListValues [][]byte  `json:"binaryListValues,omitempty"`
+}
+
+func newPayloadEnvelope(body string, attributes map[string]*aws_sqs.MessageAttributeValue) *payloadEnvelope {
+	envelope := &payloadEnvelope{
+		Body:              body,
+		MessageAttributes: make(map[string]*payloadEnvelopeAttribute),
+	}
+
+	for name, value := range attributes {
+		if value == nil {
+			continue
+		}
+
+		envelope.MessageAttributes[name] = &payloadEnvelopeAttribute{
+			DataType:         value.DataType,
+			StringValue:      value.StringValue,
+			BinaryValue:      value.BinaryValue,
+			StringListValues: value.StringListValues,
+			BinaryListValues: value.BinaryListValues,
+		}
+	}
+
+	return envelope
+}
+
+func payloadEnvelopeFromJson(envelopeStr string) (*payloadEnvelope, error) {
+	var envelope payloadEnvelope
+	if err := json.Unmarshal([]byte(envelopeStr), &envelope); err != nil {
+		return nil, err
+	}
+
+	return &envelope, nil
+}
+
+func (e *payloadEnvelope) toJson() (string, error) {
+	envelopeStr, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+
+	return string(envelopeStr), nil
+}
+
+func (e *payloadEnvelope) getMessageAttributes() map[string]*aws_sqs.MessageAttributeValue {
+	attributes := make(map[string]*aws_sqs.MessageAttributeValue)
+
+	for name, value := range e.MessageAttributes {
+		if value == nil {
+			continue
+		}
+
+		attributes[name] = &aws_sqs.MessageAttributeValue{
+			DataType:         value.DataType,
+			StringValue:      value.StringValue,
+			BinaryValue:      value.BinaryValue,
+			StringListValues: value.StringListValues,
+			BinaryListValues: value.BinaryListValues,
+		}
+	}
+
+	return attributes
+}
+
+func newPayloadEnvelopeAttribute() *aws_sqs.MessageAttributeValue {
+	return &aws_sqs.MessageAttributeValue{
+		DataType:    aws.String("String"),
+		StringValue: aws.String(sqs_configs_constants.PAYLOAD_ENVELOPE_VERSION),
+	}
+}
+
+// Size of the message pointer with the longest possible s3 key, used as headroom when deciding which attributes stay in sqs
+func getMaxMessagePointerSize(s3BucketName string) int {
+	pointer := &payload_store.PayloadS3Pointer{
+		S3BucketName: s3BucketName,
+		S3Key:        strings.Repeat("x", sqs_configs_constants.MAX_S3_KEY_LENGTH),
+	}
+
+	pointerStr, err := pointer.ToJson()
+	if err != nil {
+		return 0
+	}
+
+	return len(pointerStr)
+}
+
+// Moves the largest attributes out until the remaining ones fit into maxSize and maxLen
+func splitMessageAttributes(attributes map[string]*aws_sqs.MessageAttributeValue, maxSize int, maxLen int) (map[string]*aws_sqs.MessageAttributeValue, map[string]*aws_sqs.MessageAttributeValue) {
+	kept := copyMessageAttributes(attributes)
+	moved := make(map[string]*aws_sqs.MessageAttributeValue)
+
+	sizes := make(map[string]int)
+	names := make([]string, 0, len(attributes))
+	for name, value := range attributes {
+		sizes[name] = CalculateMessageAttributesSize(map[string]*aws_sqs.MessageAttributeValue{name: value})
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		if sizes[names[i]] != sizes[names[j]] {
+			return sizes[names[i]] > sizes[names[j]]
+		}
+
+		return names[i] < names[j]
+	})
+
+	keptSize := CalculateMessageAttributesSize(kept)
+	for _, name := range names {
+		if keptSize <= maxSize && len(kept) <= maxLen {
+			break
+		}
+
+		moved[name] = kept[name]
+		delete(kept, name)
+		keptSize -= sizes[name]
+	}
+
+	return kept, moved
+}
+
+// Follows the matching of ReceiveMessageInput.MessageAttributeNames, i.e. "All", ".*", "prefix.*" or an exact name
+func isMessageAttributeRequested(name string, requestedNames []*string) bool {
+	for _, requestedName := range requestedNames {
+		if requestedName == nil {
+			continue
+		}
+
+		switch {
+		case *requestedName == aws_sqs.QueueAttributeNameAll || *requestedName == ".*":
+			return true
+		case strings.HasSuffix(*requestedName, ".*") && strings.HasPrefix(name, strings.TrimSuffix(*requestedName, "*")):
+			return true
+		case *requestedName == name:
+			return true
+		}
+	}
+
+	return false
+}
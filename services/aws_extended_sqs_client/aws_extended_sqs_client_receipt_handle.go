@@ -0,0 +1,215 @@
+package aws_extended_sqs_client
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"strings"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors"
+	sqs_configs_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client/constants"
+)
+
+// Receipt handle of a message received through the extended client. The s3 pointer of an offloaded message is embedded
+// in front of the receipt handle given by sqs, i.e. "-..s3BucketName..-bucket-..s3BucketName..--..s3Key..-key-..s3Key..-original",
+// the same format as the java and python extended clients.
+type ReceiptHandle struct {
+	// Nil when the message is not offloaded
+	S3Pointer *S3Pointer
+	// HMAC of the s3 pointer and the original receipt handle, set when a signing key is configured
+	Signature string
+	// Receipt handle given by sqs
+	Original string
+}
+
+// Parses a receipt handle returned by ReceiveMessage, either modified or given by sqs as is.
+// The key ends at the last key marker, so that keys containing the marker text are kept whole.
+// Returns a ReceiptHandleFormatError when the markers are misplaced or a part is empty.
+func ParseReceiptHandle(receiptHandle string) (*ReceiptHandle, error) {
+	bucketNameMarker := sqs_configs_constants.S3_BUCKET_NAME_MARKER
+	keyMarker := sqs_configs_constants.S3_KEY_MARKER
+
+	switch {
+	case receiptHandle == "":
+		return nil, errors.ReceiptHandleFormatError{Message: "Empty receipt handle"}
+	case strings.HasPrefix(receiptHandle, bucketNameMarker):
+		return parseBucketNameFirst(receiptHandle)
+	case strings.HasPrefix(receiptHandle, keyMarker):
+		return parseKeyFirst(receiptHandle)
+	case containsMarker(receiptHandle):
+		return nil, errors.ReceiptHandleFormatError{Message: "S3 pointer not at the start of the receipt handle"}
+	}
+
+	return &ReceiptHandle{Original: receiptHandle}, nil
+}
+
+// Always writes the bucket name first, as the other extended clients do
+func (h *ReceiptHandle) String() string {
+	if h.S3Pointer == nil {
+		return h.Original
+	}
+
+	bucketNameMarker := sqs_configs_constants.S3_BUCKET_NAME_MARKER
+	keyMarker := sqs_configs_constants.S3_KEY_MARKER
+
+	var signature string
+	if h.Signature != "" {
+		signature = sqs_configs_constants.SIGNATURE_MARKER + h.Signature + sqs_configs_constants.SIGNATURE_MARKER
+	}
+
+	return bucketNameMarker + h.S3Pointer.S3BucketName + bucketNameMarker + keyMarker + h.S3Pointer.S3Key + keyMarker + signature + h.Original
+}
+
+// Signature of the s3 pointer and the original receipt handle, each part prefixed by its length so that they cannot be shifted
+func (h *ReceiptHandle) sign(key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	for _, part := range []string{h.S3Pointer.S3BucketName, h.S3Pointer.S3Key, h.Original} {
+		length := make([]byte, 4)
+		binary.BigEndian.PutUint32(length, uint32(len(part)))
+
+		mac.Write(length)
+		mac.Write([]byte(part))
+	}
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Whether the signature is made with one of the keys
+func (h *ReceiptHandle) verify(keys [][]byte) bool {
+	signature, err := base64.RawURLEncoding.DecodeString(h.Signature)
+	if err != nil {
+		return false
+	}
+
+	for _, key := range keys {
+		expected, _ := base64.RawURLEncoding.DecodeString(h.sign(key))
+		if hmac.Equal(signature, expected) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// i.e. "-..s3BucketName..-bucket-..s3BucketName..--..s3Key..-key-..s3Key..-original", the original receipt handle
+// being preceded by "-..signature..-signature-..signature..-" when signed
+func parseBucketNameFirst(receiptHandle string) (*ReceiptHandle, error) {
+	bucketNameMarker := sqs_configs_constants.S3_BUCKET_NAME_MARKER
+	keyMarker := sqs_configs_constants.S3_KEY_MARKER
+
+	rest := receiptHandle[len(bucketNameMarker):]
+	end := strings.Index(rest, bucketNameMarker)
+	if end < 0 {
+		return nil, errors.ReceiptHandleFormatError{Message: "Unclosed s3 bucket name marker"}
+	}
+	s3BucketName := rest[:end]
+
+	rest = rest[end+len(bucketNameMarker):]
+	if !strings.HasPrefix(rest, keyMarker) {
+		return nil, errors.ReceiptHandleFormatError{Message: "S3 key marker does not follow the s3 bucket name"}
+	}
+
+	rest = rest[len(keyMarker):]
+	end = strings.LastIndex(rest, keyMarker)
+	if end < 0 {
+		return nil, errors.ReceiptHandleFormatError{Message: "Unclosed s3 key marker"}
+	}
+
+	return newReceiptHandle(s3BucketName, rest[:end], rest[end+len(keyMarker):])
+}
+
+// i.e. "-..s3Key..-key-..s3Key..--..s3BucketName..-bucket-..s3BucketName..-original", normalized to the bucket name first
+func parseKeyFirst(receiptHandle string) (*ReceiptHandle, error) {
+	bucketNameMarker := sqs_configs_constants.S3_BUCKET_NAME_MARKER
+	keyMarker := sqs_configs_constants.S3_KEY_MARKER
+
+	rest := receiptHandle[len(keyMarker):]
+	end := strings.LastIndex(rest, keyMarker+bucketNameMarker)
+	if end < 0 {
+		return nil, errors.ReceiptHandleFormatError{Message: "S3 bucket name marker does not follow the s3 key"}
+	}
+	s3Key := rest[:end]
+
+	rest = rest[end+len(keyMarker)+len(bucketNameMarker):]
+	end = strings.Index(rest, bucketNameMarker)
+	if end < 0 {
+		return nil, errors.ReceiptHandleFormatError{Message: "Unclosed s3 bucket name marker"}
+	}
+
+	handle, err := newReceiptHandle(rest[:end], s3Key, rest[end+len(bucketNameMarker):])
+	if err != nil {
+		return nil, err
+	}
+
+	// The parts are taken apart differently once written with the bucket name first
+	if normalized, err := parseBucketNameFirst(handle.String()); err != nil || *normalized.S3Pointer != *handle.S3Pointer ||
+		normalized.Signature != handle.Signature || normalized.Original != handle.Original {
+		return nil, errors.ReceiptHandleFormatError{Message: "Ambiguous s3 pointer", Err: err}
+	}
+
+	return handle, nil
+}
+
+func newReceiptHandle(s3BucketName string, s3Key string, original string) (*ReceiptHandle, error) {
+	signatureMarker := sqs_configs_constants.SIGNATURE_MARKER
+
+	var signature string
+	if strings.HasPrefix(original, signatureMarker) {
+		rest := original[len(signatureMarker):]
+		end := strings.Index(rest, signatureMarker)
+		if end < 0 {
+			return nil, errors.ReceiptHandleFormatError{Message: "Unclosed signature marker"}
+		}
+
+		signature = rest[:end]
+		if signature == "" {
+			return nil, errors.ReceiptHandleFormatError{Message: "Empty signature"}
+		}
+
+		original = rest[end+len(signatureMarker):]
+	}
+
+	switch {
+	case s3BucketName == "":
+		return nil, errors.ReceiptHandleFormatError{Message: "Empty s3 bucket name"}
+	case s3Key == "":
+		return nil, errors.ReceiptHandleFormatError{Message: "Empty s3 key"}
+	case len(s3Key) > sqs_configs_constants.MAX_S3_KEY_LENGTH:
+		return nil, errors.ReceiptHandleFormatError{Message: "S3 key too long"}
+	case original == "":
+		return nil, errors.ReceiptHandleFormatError{Message: "Empty original receipt handle"}
+	case containsMarker(original):
+		return nil, errors.ReceiptHandleFormatError{Message: "Marker in the original receipt handle"}
+	}
+
+	return &ReceiptHandle{
+		S3Pointer: &S3Pointer{S3BucketName: s3BucketName, S3Key: s3Key},
+		Signature: signature,
+		Original:  original,
+	}, nil
+}
+
+func containsMarker(s string) bool {
+	return strings.Contains(s, sqs_configs_constants.S3_BUCKET_NAME_MARKER) ||
+		strings.Contains(s, sqs_configs_constants.S3_KEY_MARKER) ||
+		strings.Contains(s, sqs_configs_constants.SIGNATURE_MARKER)
+}
+
+// Checks the signature of a receipt handle with an s3 pointer, nothing to check without a signing key
+func (c *AwsExtendedSQSClient) verifyReceiptHandle(handle *ReceiptHandle) error {
+	keys := c.config.GetReceiptHandleSigningKeys()
+	if len(keys) == 0 || handle.S3Pointer == nil {
+		return nil
+	}
+
+	if handle.Signature == "" {
+		return errors.ReceiptHandleSignatureError{Message: "Unsigned receipt handle"}
+	}
+
+	if !handle.verify(keys) {
+		return errors.ReceiptHandleSignatureError{Message: "Invalid receipt handle signature"}
+	}
+
+	return nil
+}
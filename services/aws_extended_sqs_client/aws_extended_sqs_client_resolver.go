@@ -0,0 +1,102 @@
+package aws_extended_sqs_client
+
+import (
+	"context"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors"
+	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
+	sqs_configs_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client/constants"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// Makes ReceiveMessage return offloaded messages as they are in sqs, with the message pointer as body and the reserved attributes,
+// instead of fetching every payload from s3. Their receipt handles are still wrapped, so that DeleteMessage cleans up the payloads.
+// The payloads are fetched on demand with a PayloadResolver. A message forwarded as is with the underlying SQSAPI stays resolvable
+// by other extended clients, SendMessage of the extended client rejects its reserved attributes.
+func WithLazyPayloadResolution() AwsExtendedSQSClientOption {
+	return func(opts *awsExtendedSQSClientOptions) {
+		opts.lazyPayloadResolution = true
+	}
+}
+
+// Fetches the payload of a message received with WithLazyPayloadResolution
+type PayloadResolver struct {
+	client  *AwsExtendedSQSClient
+	message *aws_sqs.Message
+}
+
+func (c *AwsExtendedSQSClient) NewPayloadResolver(message *aws_sqs.Message) *PayloadResolver {
+	return &PayloadResolver{client: c, message: message}
+}
+
+// Whether the payload of the message is in s3, i.e. whether Resolve fetches it
+func (r *PayloadResolver) IsOffloaded() bool {
+	return r.message != nil && getReservedAttributeNameIfPresent(r.message.MessageAttributes) != nil
+}
+
+// Returns a copy of the message with the payload fetched from s3 and the message attributes moved into the payload restored,
+// as ReceiveMessage returns it without WithLazyPayloadResolution. The receipt handle is kept as is.
+// A message which is not offloaded is returned as is.
+func (r *PayloadResolver) Resolve(ctx context.Context) (*aws_sqs.Message, error) {
+	if r.message == nil {
+		return nil, errors.SDKError{Message: "Nil message"}
+	}
+
+	if !r.IsOffloaded() {
+		return r.message, nil
+	}
+
+	logger := r.client.opts.logger.WithFields(r.client.getLoggingFields(r.message.MessageAttributes)).WithField("method", "Resolve")
+	logLevel := r.client.getLogLevel(sqs_configs_constants.LOG_METHOD_RECEIVE_MESSAGE)
+
+	// Every message attribute moved into the payload is restored, the requested names of the receive being unknown
+	return r.client.resolveMessage(ctx, r.message, []*string{aws.String(aws_sqs.QueueAttributeNameAll)}, logger, logLevel)
+}
+
+// Copy of the offloaded message with its payload and without the reserved attributes,
+// keeping the message attributes of the payload envelope which are requested
+func (c *AwsExtendedSQSClient) resolveMessage(ctx context.Context, message *aws_sqs.Message, requestedAttributeNames []*string,
+	logger aws_extended_sqsiface.LoggerInterface, logLevel string) (*aws_sqs.Message, error) {
+	logger.Log(logLevel, "Getting payload from s3")
+
+	originalPayload, err := c.payloadStore.GetOriginalPayloadWithContext(ctx, *message.Body)
+	if err != nil {
+		logError(logger, "GetOriginalPayload", err)
+
+		return nil, err
+	}
+
+	// Remove the additional attribute before returning the message to user
+	modifiedMessageAttributes := copyMessageAttributes(message.MessageAttributes)
+	delete(modifiedMessageAttributes, sqs_configs_constants.RESERVED_ATTRIBUTE_NAME)
+	delete(modifiedMessageAttributes, sqs_configs_constants.LEGACY_RESERVED_ATTRIBUTE_NAME)
+	delete(modifiedMessageAttributes, sqs_configs_constants.PAYLOAD_ENVELOPE_ATTRIBUTE_NAME)
+
+	if _, ok := message.MessageAttributes[sqs_configs_constants.PAYLOAD_ENVELOPE_ATTRIBUTE_NAME]; ok {
+		envelope, err := payloadEnvelopeFromJson(originalPayload)
+		if err != nil {
+			logError(logger, "payloadEnvelopeFromJson", err)
+
+			return nil, errors.SDKError{Message: "Invalid payload envelope", Err: err}
+		}
+
+		originalPayload = envelope.Body
+
+		for name, value := range envelope.getMessageAttributes() {
+			if isMessageAttributeRequested(name, requestedAttributeNames) {
+				modifiedMessageAttributes[name] = value
+			}
+		}
+	}
+
+	resolvedMessage := &aws_sqs.Message{}
+	*resolvedMessage = *message
+	resolvedMessage.Body = &originalPayload
+	resolvedMessage.MessageAttributes = modifiedMessageAttributes
+
+	logger.Log(logLevel, "Finished getting payload from s3")
+
+	return resolvedMessage, nil
+}
@@ -0,0 +1,112 @@
+package aws_extended_sqs_client
+
+import (
+	"fmt"
+
+	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors"
+
+	aws_s3iface "github.com/aws/aws-sdk-go/service/s3/s3iface"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+)
+
+type BucketRouterFunc func(input *aws_sqs.SendMessageInput, payloadSize int) (string, error)
+
+func (f BucketRouterFunc) RouteBucket(input *aws_sqs.SendMessageInput, payloadSize int) (string, error) {
+	return f(input, payloadSize)
+}
+
+// Returns the bucket name and true when the rule applies to the message
+type BucketRoutingRule func(input *aws_sqs.SendMessageInput, payloadSize int) (string, bool)
+
+func RouteByQueueUrl(queueUrl string, s3BucketName string) BucketRoutingRule {
+	return func(input *aws_sqs.SendMessageInput, payloadSize int) (string, bool) {
+		if input.QueueUrl == nil || *input.QueueUrl != queueUrl {
+			return "", false
+		}
+
+		return s3BucketName, true
+	}
+}
+
+func RouteByMessageAttribute(attributeName string, attributeValue string, s3BucketName string) BucketRoutingRule {
+	return func(input *aws_sqs.SendMessageInput, payloadSize int) (string, bool) {
+		value, ok := input.MessageAttributes[attributeName]
+		if !ok || value == nil || value.StringValue == nil || *value.StringValue != attributeValue {
+			return "", false
+		}
+
+		return s3BucketName, true
+	}
+}
+
+func RouteByPayloadSize(minPayloadSize int, s3BucketName string) BucketRoutingRule {
+	return func(input *aws_sqs.SendMessageInput, payloadSize int) (string, bool) {
+		if payloadSize < minPayloadSize {
+			return "", false
+		}
+
+		return s3BucketName, true
+	}
+}
+
+// Applies the rules in order and falls back to the default bucket when none of them matches
+type RuleBucketRouter struct {
+	aws_extended_sqsiface.BucketRouterInterface
+	defaultS3BucketName string
+	rules               []BucketRoutingRule
+}
+
+func NewRuleBucketRouter(defaultS3BucketName string, rules ...BucketRoutingRule) *RuleBucketRouter {
+	return &RuleBucketRouter{
+		defaultS3BucketName: defaultS3BucketName,
+		rules:               rules,
+	}
+}
+
+func (r *RuleBucketRouter) RouteBucket(input *aws_sqs.SendMessageInput, payloadSize int) (string, error) {
+	for _, rule := range r.rules {
+		if s3BucketName, ok := rule(input, payloadSize); ok {
+			return s3BucketName, nil
+		}
+	}
+
+	if r.defaultS3BucketName == "" {
+		return "", errors.SDKError{Message: "No bucket matches the message and no default bucket is configured"}
+	}
+
+	return r.defaultS3BucketName, nil
+}
+
+type S3ClientResolverFunc func(s3BucketName string) (aws_s3iface.S3API, error)
+
+func (f S3ClientResolverFunc) ResolveS3Client(s3BucketName string) (aws_s3iface.S3API, error) {
+	return f(s3BucketName)
+}
+
+// Resolves the s3 client by bucket name, using the fallback client for unknown buckets
+type MapS3ClientResolver struct {
+	aws_extended_sqsiface.S3ClientResolverInterface
+	clients  map[string]aws_s3iface.S3API
+	fallback aws_s3iface.S3API
+}
+
+func NewMapS3ClientResolver(clients map[string]aws_s3iface.S3API, fallback aws_s3iface.S3API) *MapS3ClientResolver {
+	return &MapS3ClientResolver{
+		clients:  clients,
+		fallback: fallback,
+	}
+}
+
+func (r *MapS3ClientResolver) ResolveS3Client(s3BucketName string) (aws_s3iface.S3API, error) {
+	if s3Client, ok := r.clients[s3BucketName]; ok && s3Client != nil {
+		return s3Client, nil
+	}
+
+	if r.fallback == nil {
+		return nil, errors.SDKError{Message: fmt.Sprintf("No s3 client is configured for bucket %s", s3BucketName)}
+	}
+
+	return r.fallback, nil
+}
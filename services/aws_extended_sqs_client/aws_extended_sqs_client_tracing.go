@@ -0,0 +1,92 @@
+package aws_extended_sqs_client
+
+import (
+	"context"
+
+	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
+	tracing_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tracing/constants"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// Carries the trace context in message attributes, each field is stored as a String attribute
+type MessageAttributeCarrier map[string]*aws_sqs.MessageAttributeValue
+
+var _ aws_extended_sqsiface.TextMapCarrierInterface = MessageAttributeCarrier{}
+
+func (c MessageAttributeCarrier) Get(key string) string {
+	value, ok := c[key]
+	if !ok || value == nil {
+		return ""
+	}
+
+	return aws.StringValue(value.StringValue)
+}
+
+func (c MessageAttributeCarrier) Set(key string, value string) {
+	c[key] = &aws_sqs.MessageAttributeValue{
+		DataType:    aws.String("String"),
+		StringValue: aws.String(value),
+	}
+}
+
+func (c MessageAttributeCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// Returns ctx with the trace context the producer injected into the message, for the consumer to link its spans
+func (c *AwsExtendedSQSClient) ExtractMessageContext(ctx context.Context, message *aws_sqs.Message) context.Context {
+	if message == nil {
+		return ctx
+	}
+
+	return c.opts.tracer.Extract(ctx, MessageAttributeCarrier(message.MessageAttributes))
+}
+
+func (c *AwsExtendedSQSClient) injectTraceContext(ctx context.Context, input *aws_sqs.SendMessageInput) *aws_sqs.SendMessageInput {
+	if len(c.opts.tracer.Fields()) == 0 {
+		return input
+	}
+
+	carrier := MessageAttributeCarrier(copyMessageAttributes(input.MessageAttributes))
+	c.opts.tracer.Inject(ctx, carrier)
+
+	updatedInput := &aws_sqs.SendMessageInput{}
+	*updatedInput = *input
+	updatedInput.MessageAttributes = carrier
+
+	return updatedInput
+}
+
+// Asks for the trace context attributes as well, so that they can be extracted from the received messages
+func (c *AwsExtendedSQSClient) withTraceAttributeNames(input *aws_sqs.ReceiveMessageInput) *aws_sqs.ReceiveMessageInput {
+	fields := c.opts.tracer.Fields()
+	if len(fields) == 0 {
+		return input
+	}
+
+	updatedInput := &aws_sqs.ReceiveMessageInput{}
+	*updatedInput = *input
+	updatedInput.MessageAttributeNames = append([]*string{}, input.MessageAttributeNames...)
+
+	for _, field := range fields {
+		if !isMessageAttributeRequested(field, input.MessageAttributeNames) {
+			updatedInput.MessageAttributeNames = append(updatedInput.MessageAttributeNames, aws.String(field))
+		}
+	}
+
+	return updatedInput
+}
+
+func (c *AwsExtendedSQSClient) startMessagingSpan(ctx context.Context, name string, kind string, queueUrl *string) (context.Context, aws_extended_sqsiface.SpanInterface) {
+	return c.opts.tracer.StartSpan(ctx, name, kind, map[string]string{
+		tracing_constants.ATTRIBUTE_MESSAGING_SYSTEM:      tracing_constants.MESSAGING_SYSTEM_SQS,
+		tracing_constants.ATTRIBUTE_MESSAGING_DESTINATION: aws.StringValue(queueUrl),
+	})
+}
@@ -0,0 +1,111 @@
+package aws_extended_sqs_client
+
+import (
+	errors_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors/constants"
+	sqs_configs_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client/constants"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+)
+
+type changeMessageVisibilityFunc func(*aws_sqs.ChangeMessageVisibilityInput) (*aws_sqs.ChangeMessageVisibilityOutput, error)
+type changeMessageVisibilityBatchFunc func(*aws_sqs.ChangeMessageVisibilityBatchInput) (*aws_sqs.ChangeMessageVisibilityBatchOutput, error)
+
+func (c *AwsExtendedSQSClient) ChangeMessageVisibility(input *aws_sqs.ChangeMessageVisibilityInput) (*aws_sqs.ChangeMessageVisibilityOutput, error) {
+	return c.changeMessageVisibility(input, c.SQSAPI.ChangeMessageVisibility)
+}
+
+func (c *AwsExtendedSQSClient) ChangeMessageVisibilityWithContext(ctx aws.Context, input *aws_sqs.ChangeMessageVisibilityInput, opts ...request.Option) (*aws_sqs.ChangeMessageVisibilityOutput, error) {
+	return c.changeMessageVisibility(input, func(input *aws_sqs.ChangeMessageVisibilityInput) (*aws_sqs.ChangeMessageVisibilityOutput, error) {
+		return c.SQSAPI.ChangeMessageVisibilityWithContext(ctx, input, opts...)
+	})
+}
+
+// The receipt handle of a message received through s3 embeds the s3 pointer, sqs only accepts the original one
+func (c *AwsExtendedSQSClient) changeMessageVisibility(input *aws_sqs.ChangeMessageVisibilityInput, changeCall changeMessageVisibilityFunc) (*aws_sqs.ChangeMessageVisibilityOutput, error) {
+	logger := c.opts.logger.WithField("method", sqs_configs_constants.LOG_METHOD_CHANGE_MESSAGE_VISIBILITY)
+	logLevel := c.getLogLevel(sqs_configs_constants.LOG_METHOD_CHANGE_MESSAGE_VISIBILITY)
+
+	modifiedInput := input
+	if input != nil && input.ReceiptHandle != nil {
+		handle, err := ParseReceiptHandle(*input.ReceiptHandle)
+		if err != nil {
+			logError(logger, "ParseReceiptHandle", err)
+			return &aws_sqs.ChangeMessageVisibilityOutput{}, err
+		}
+
+		if handle.S3Pointer != nil {
+			logger.Log(logLevel, "Message is sent with s3 usage")
+
+			modifiedInput = &aws_sqs.ChangeMessageVisibilityInput{}
+			*modifiedInput = *input
+			modifiedInput.ReceiptHandle = aws.String(handle.Original)
+		}
+	}
+
+	output, err := changeCall(modifiedInput)
+	if err != nil {
+		var queueUrl *string
+		if input != nil {
+			queueUrl = input.QueueUrl
+		}
+
+		err = newSQSError(errors_constants.OPERATION_CHANGE_VISIBILITY, queueUrl, err)
+		logError(logger, "ChangeMessageVisibility", err)
+	}
+
+	return output, err
+}
+
+func (c *AwsExtendedSQSClient) ChangeMessageVisibilityBatch(input *aws_sqs.ChangeMessageVisibilityBatchInput) (*aws_sqs.ChangeMessageVisibilityBatchOutput, error) {
+	return c.changeMessageVisibilityBatch(input, c.SQSAPI.ChangeMessageVisibilityBatch)
+}
+
+func (c *AwsExtendedSQSClient) ChangeMessageVisibilityBatchWithContext(ctx aws.Context, input *aws_sqs.ChangeMessageVisibilityBatchInput, opts ...request.Option) (*aws_sqs.ChangeMessageVisibilityBatchOutput, error) {
+	return c.changeMessageVisibilityBatch(input, func(input *aws_sqs.ChangeMessageVisibilityBatchInput) (*aws_sqs.ChangeMessageVisibilityBatchOutput, error) {
+		return c.SQSAPI.ChangeMessageVisibilityBatchWithContext(ctx, input, opts...)
+	})
+}
+
+func (c *AwsExtendedSQSClient) changeMessageVisibilityBatch(input *aws_sqs.ChangeMessageVisibilityBatchInput, changeCall changeMessageVisibilityBatchFunc) (*aws_sqs.ChangeMessageVisibilityBatchOutput, error) {
+	logger := c.opts.logger.WithField("method", sqs_configs_constants.LOG_METHOD_CHANGE_MESSAGE_VISIBILITY)
+
+	modifiedInput := input
+	if input != nil {
+		modifiedInput = &aws_sqs.ChangeMessageVisibilityBatchInput{}
+		*modifiedInput = *input
+		modifiedInput.Entries = make([]*aws_sqs.ChangeMessageVisibilityBatchRequestEntry, len(input.Entries))
+
+		for index, entry := range input.Entries {
+			modifiedInput.Entries[index] = entry
+			if entry == nil || entry.ReceiptHandle == nil {
+				continue
+			}
+
+			// A malformed receipt handle is left to be rejected by sqs, failing only its entry
+			handle, err := ParseReceiptHandle(*entry.ReceiptHandle)
+			if err != nil || handle.S3Pointer == nil {
+				continue
+			}
+
+			modifiedEntry := &aws_sqs.ChangeMessageVisibilityBatchRequestEntry{}
+			*modifiedEntry = *entry
+			modifiedEntry.ReceiptHandle = aws.String(handle.Original)
+			modifiedInput.Entries[index] = modifiedEntry
+		}
+	}
+
+	output, err := changeCall(modifiedInput)
+	if err != nil {
+		var queueUrl *string
+		if input != nil {
+			queueUrl = input.QueueUrl
+		}
+
+		err = newSQSError(errors_constants.OPERATION_CHANGE_VISIBILITY, queueUrl, err)
+		logError(logger, "ChangeMessageVisibilityBatch", err)
+	}
+
+	return output, err
+}
@@ -1,11 +1,36 @@
 package sqs_configs_constants
 
+import "time"
+
 const (
 	RESERVED_ATTRIBUTE_NAME                   = "ExtendedPayloadSize"
 	LEGACY_RESERVED_ATTRIBUTE_NAME            = "SQSLargePayloadSize"
-	MAX_ALLOWED_ATTRIBUTES                    = 10 - 1
+	PAYLOAD_ENVELOPE_ATTRIBUTE_NAME           = "ExtendedPayloadEnvelope"
+	PAYLOAD_ENVELOPE_VERSION                  = "1"
+	MAX_SQS_ATTRIBUTES                        = 10
+	MAX_ALLOWED_ATTRIBUTES                    = MAX_SQS_ATTRIBUTES - 1
 	DEFAULT_MESSAGE_SIZE_THRESHOLD            = 262144
 	DEFAULT_BREAK_SEND_MESSAGE_SIZE_THRESHOLD = 10485760
+	MAX_SQS_BATCH_SIZE                        = 262144
 	S3_BUCKET_NAME_MARKER                     = "-..s3BucketName..-"
 	S3_KEY_MARKER                             = "-..s3Key..-"
+	SIGNATURE_MARKER                          = "-..signature..-"
+	MAX_S3_KEY_LENGTH                         = 1024
+	ATTRIBUTE_LIMIT_FALLBACK_ERROR            = "error"
+	ATTRIBUTE_LIMIT_FALLBACK_FOLD             = "fold"
+)
+
+const (
+	// A content addressed payload stored again is copied onto itself once older than this
+	DEFAULT_CONTENT_ADDRESSED_TOUCH_AGE = 24 * time.Hour
+)
+
+// Methods whose routine logs can be leveled with WithLogLevel
+const (
+	LOG_METHOD_SEND_MESSAGE              = "SendMessage"
+	LOG_METHOD_SEND_MESSAGE_BATCH        = "SendMessageBatch"
+	LOG_METHOD_RECEIVE_MESSAGE           = "ReceiveMessage"
+	LOG_METHOD_DELETE_MESSAGE            = "DeleteMessage"
+	LOG_METHOD_DELETE_MESSAGE_BATCH      = "DeleteMessageBatch"
+	LOG_METHOD_CHANGE_MESSAGE_VISIBILITY = "ChangeMessageVisibility"
 )
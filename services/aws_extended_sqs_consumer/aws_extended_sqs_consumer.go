@@ -0,0 +1,364 @@
+package aws_extended_sqs_consumer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors"
+	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging"
+	logging_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging/constants"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_acknowledger"
+	consumer_configs_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_consumer/constants"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+	aws_sqsiface "github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+)
+
+// Handles a received message, with the offloaded payload already resolved by the client.
+// The message is deleted when nil is returned, otherwise it is left in the queue to be redelivered.
+type Handler func(ctx context.Context, message *aws_sqs.Message) error
+
+// Implemented by AwsExtendedSQSClient, used to continue the trace of the producer in the handler
+type messageContextExtractor interface {
+	ExtractMessageContext(ctx context.Context, message *aws_sqs.Message) context.Context
+}
+
+// Long-polls a queue and dispatches the messages to a handler across a pool of workers.
+// client is expected to be an AwsExtendedSQSClient so that the payloads are resolved and deleted along with the messages.
+type AwsExtendedSQSConsumer struct {
+	client   aws_sqsiface.SQSAPI
+	queueUrl string
+	handler  Handler
+	opts     *awsExtendedSQSConsumerOptions
+}
+
+type awsExtendedSQSConsumerOptions struct {
+	logger                aws_extended_sqsiface.LoggerInterface
+	workers               int
+	maxNumberOfMessages   int64
+	waitTimeSeconds       int64
+	visibilityTimeout     *int64
+	messageAttributeNames []*string
+	receiveErrorBackoff   time.Duration
+	drainTimeout          time.Duration
+	heartbeatEnabled      bool
+	heartbeatOpts         []HeartbeatOption
+	acknowledger          *aws_extended_sqs_acknowledger.AwsExtendedSQSAcknowledger
+}
+
+type AwsExtendedSQSConsumerOption func(*awsExtendedSQSConsumerOptions)
+
+func newConsumerOptions() *awsExtendedSQSConsumerOptions {
+	return &awsExtendedSQSConsumerOptions{
+		logger:                logging.NewDefaultLogger(),
+		workers:               consumer_configs_constants.DEFAULT_WORKERS,
+		maxNumberOfMessages:   consumer_configs_constants.DEFAULT_MAX_NUMBER_OF_MESSAGES,
+		waitTimeSeconds:       consumer_configs_constants.DEFAULT_WAIT_TIME_SECONDS,
+		messageAttributeNames: []*string{aws.String(aws_sqs.QueueAttributeNameAll)},
+		receiveErrorBackoff:   consumer_configs_constants.DEFAULT_RECEIVE_ERROR_BACKOFF,
+		drainTimeout:          consumer_configs_constants.DEFAULT_DRAIN_TIMEOUT,
+	}
+}
+
+func WithLogger(logger aws_extended_sqsiface.LoggerInterface) AwsExtendedSQSConsumerOption {
+	return func(opts *awsExtendedSQSConsumerOptions) {
+		opts.logger = logger
+	}
+}
+
+// Number of messages handled concurrently
+func WithWorkers(workers int) AwsExtendedSQSConsumerOption {
+	return func(opts *awsExtendedSQSConsumerOptions) {
+		opts.workers = workers
+	}
+}
+
+// Most messages per receive, from 1 to 10. No more messages are received than there are idle workers,
+// so that each message is handled, and its heartbeat started, as soon as it is received.
+func WithMaxNumberOfMessages(maxNumberOfMessages int64) AwsExtendedSQSConsumerOption {
+	return func(opts *awsExtendedSQSConsumerOptions) {
+		opts.maxNumberOfMessages = maxNumberOfMessages
+	}
+}
+
+// Long polling duration of a receive, from 0 to 20
+func WithWaitTimeSeconds(waitTimeSeconds int64) AwsExtendedSQSConsumerOption {
+	return func(opts *awsExtendedSQSConsumerOptions) {
+		opts.waitTimeSeconds = waitTimeSeconds
+	}
+}
+
+// Overrides the visibility timeout of the queue for the received messages
+func WithVisibilityTimeout(visibilityTimeout int64) AwsExtendedSQSConsumerOption {
+	return func(opts *awsExtendedSQSConsumerOptions) {
+		opts.visibilityTimeout = aws.Int64(visibilityTimeout)
+	}
+}
+
+// All message attributes are received by default
+func WithMessageAttributeNames(names ...string) AwsExtendedSQSConsumerOption {
+	return func(opts *awsExtendedSQSConsumerOptions) {
+		opts.messageAttributeNames = aws.StringSlice(names)
+	}
+}
+
+// Wait before receiving again after a failed receive
+func WithReceiveErrorBackoff(backoff time.Duration) AwsExtendedSQSConsumerOption {
+	return func(opts *awsExtendedSQSConsumerOptions) {
+		opts.receiveErrorBackoff = backoff
+	}
+}
+
+// Time given to the handlers still running once the consumer is stopped, after which their context is cancelled.
+// 30 seconds by default, 0 never cancels them. Messages handled successfully are still deleted.
+func WithDrainTimeout(drainTimeout time.Duration) AwsExtendedSQSConsumerOption {
+	return func(opts *awsExtendedSQSConsumerOptions) {
+		opts.drainTimeout = drainTimeout
+	}
+}
+
+// Extends the visibility of each message while its handler is running, see StartHeartbeat
+func WithHeartbeat(opts ...HeartbeatOption) AwsExtendedSQSConsumerOption {
+	return func(consumerOpts *awsExtendedSQSConsumerOptions) {
+		consumerOpts.heartbeatEnabled = true
+		consumerOpts.heartbeatOpts = opts
+	}
+}
+
+// Deletes the handled messages in batches through the acknowledger instead of one by one, failures are logged by the acknowledger
+func WithAcknowledger(acknowledger *aws_extended_sqs_acknowledger.AwsExtendedSQSAcknowledger) AwsExtendedSQSConsumerOption {
+	return func(opts *awsExtendedSQSConsumerOptions) {
+		opts.acknowledger = acknowledger
+	}
+}
+
+func NewExtendedSQSConsumer(client aws_sqsiface.SQSAPI, queueUrl string, handler Handler, opts ...AwsExtendedSQSConsumerOption) *AwsExtendedSQSConsumer {
+	consumerOpts := newConsumerOptions()
+	for _, opt := range opts {
+		opt(consumerOpts)
+	}
+
+	return &AwsExtendedSQSConsumer{
+		client:   client,
+		queueUrl: queueUrl,
+		handler:  handler,
+		opts:     consumerOpts,
+	}
+}
+
+// Consumes the queue until ctx is done. Receiving stops right away, and messages being handled are finished
+// and deleted before Run returns, with their context cancelled once the drain timeout has passed.
+func (c *AwsExtendedSQSConsumer) Run(ctx context.Context) error {
+	logger := c.opts.logger.WithFields(map[string]interface{}{"method": "Run", "queue_url": c.queueUrl})
+
+	if c.opts.workers < 1 {
+		return errors.SDKError{Message: fmt.Sprintf("Number of workers [%d] must be at least 1.", c.opts.workers)}
+	}
+
+	if c.opts.maxNumberOfMessages < consumer_configs_constants.MIN_NUMBER_OF_MESSAGES || c.opts.maxNumberOfMessages > consumer_configs_constants.MAX_NUMBER_OF_MESSAGES {
+		return errors.SDKError{Message: fmt.Sprintf("Max number of messages [%d] must be from %d to %d.", c.opts.maxNumberOfMessages, consumer_configs_constants.MIN_NUMBER_OF_MESSAGES, consumer_configs_constants.MAX_NUMBER_OF_MESSAGES)}
+	}
+
+	// One token per idle worker, taken for each received message and given back once it is handled
+	idle := make(chan struct{}, c.opts.workers)
+	messages := make(chan *aws_sqs.Message, c.opts.workers)
+	handlerCtx, cancelHandlers := context.WithCancel(detachedContext{parent: ctx})
+	defer cancelHandlers()
+
+	var wg sync.WaitGroup
+	for i := 0; i < c.opts.workers; i++ {
+		idle <- struct{}{}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for message := range messages {
+				c.handleMessage(handlerCtx, message)
+				idle <- struct{}{}
+			}
+		}()
+	}
+
+	logger.Log(logging_constants.LOG_LEVEL_INFO, "Started consuming")
+
+	c.receiveMessages(ctx, messages, idle)
+	close(messages)
+
+	logger.Log(logging_constants.LOG_LEVEL_INFO, "Draining in-flight messages")
+	if c.opts.drainTimeout > 0 {
+		timer := time.AfterFunc(c.opts.drainTimeout, func() {
+			logger.Log(logging_constants.LOG_LEVEL_WARN, "Drain timeout reached, cancelling the handlers")
+			cancelHandlers()
+		})
+		defer timer.Stop()
+	}
+	wg.Wait()
+
+	if c.opts.acknowledger != nil {
+		if err := c.opts.acknowledger.Flush(context.Background()); err != nil {
+			logger.Log(logging_constants.LOG_LEVEL_ERROR, fmt.Sprintf("Error: %+v", err))
+		}
+	}
+
+	logger.Log(logging_constants.LOG_LEVEL_INFO, "Stopped consuming")
+
+	return nil
+}
+
+func (c *AwsExtendedSQSConsumer) receiveMessages(ctx context.Context, messages chan<- *aws_sqs.Message, idle chan struct{}) {
+	logger := c.opts.logger.WithFields(map[string]interface{}{"method": "receiveMessages", "queue_url": c.queueUrl})
+
+	for ctx.Err() == nil {
+		reserved := c.reserveWorkers(ctx, idle)
+		if reserved == 0 {
+			return
+		}
+
+		output, err := c.client.ReceiveMessageWithContext(ctx, &aws_sqs.ReceiveMessageInput{
+			QueueUrl:              aws.String(c.queueUrl),
+			MaxNumberOfMessages:   aws.Int64(reserved),
+			WaitTimeSeconds:       aws.Int64(c.opts.waitTimeSeconds),
+			VisibilityTimeout:     c.opts.visibilityTimeout,
+			MessageAttributeNames: c.opts.messageAttributeNames,
+			AttributeNames:        []*string{aws.String(aws_sqs.QueueAttributeNameAll)},
+		})
+		if err != nil {
+			releaseWorkers(idle, reserved)
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			logger.Log(logging_constants.LOG_LEVEL_ERROR, fmt.Sprintf("Error: %+v", err))
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(c.opts.receiveErrorBackoff):
+			}
+
+			continue
+		}
+
+		for _, message := range output.Messages {
+			// More messages than requested are handed to the workers as they become idle
+			if reserved == 0 {
+				select {
+				case <-ctx.Done():
+					return
+				case <-idle:
+					reserved++
+				}
+			}
+
+			reserved--
+			messages <- message
+		}
+
+		releaseWorkers(idle, reserved)
+	}
+}
+
+// Waits for an idle worker, then takes the other idle ones up to the max number of messages.
+// Returns 0 once ctx is done.
+func (c *AwsExtendedSQSConsumer) reserveWorkers(ctx context.Context, idle chan struct{}) int64 {
+	select {
+	case <-ctx.Done():
+		return 0
+	case <-idle:
+	}
+
+	reserved := int64(1)
+	for reserved < c.opts.maxNumberOfMessages {
+		select {
+		case <-idle:
+			reserved++
+		default:
+			return reserved
+		}
+	}
+
+	return reserved
+}
+
+func releaseWorkers(idle chan<- struct{}, reserved int64) {
+	for ; reserved > 0; reserved-- {
+		idle <- struct{}{}
+	}
+}
+
+func (c *AwsExtendedSQSConsumer) handleMessage(ctx context.Context, message *aws_sqs.Message) {
+	logger := c.opts.logger.WithFields(map[string]interface{}{"method": "handleMessage", "message_id": aws.StringValue(message.MessageId)})
+
+	if extractor, ok := c.client.(messageContextExtractor); ok {
+		ctx = extractor.ExtractMessageContext(ctx, message)
+	}
+
+	var heartbeat *Heartbeat
+	if c.opts.heartbeatEnabled {
+		heartbeatOpts := append([]HeartbeatOption{WithHeartbeatLogger(c.opts.logger)}, c.opts.heartbeatOpts...)
+		heartbeat = StartHeartbeat(ctx, c.client, c.queueUrl, aws.StringValue(message.ReceiptHandle), heartbeatOpts...)
+	}
+
+	err := c.callHandler(ctx, message)
+	if heartbeat != nil {
+		heartbeat.Stop()
+	}
+
+	if err != nil {
+		logger.Log(logging_constants.LOG_LEVEL_ERROR, fmt.Sprintf("Error: %+v", err))
+		return
+	}
+
+	if c.opts.acknowledger != nil {
+		c.opts.acknowledger.Acknowledge(c.queueUrl, aws.StringValue(message.ReceiptHandle))
+		return
+	}
+
+	// Deleted even when the drain timeout has cancelled the context of the handler
+	_, err = c.client.DeleteMessageWithContext(detachedContext{parent: ctx}, &aws_sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(c.queueUrl),
+		ReceiptHandle: message.ReceiptHandle,
+	})
+	if err != nil {
+		logger.Log(logging_constants.LOG_LEVEL_ERROR, fmt.Sprintf("Error: %+v", err))
+	}
+}
+
+// A panic of the handler fails the message instead of stopping the consumer
+func (c *AwsExtendedSQSConsumer) callHandler(ctx context.Context, message *aws_sqs.Message) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("Handler panicked: %v", recovered)
+		}
+	}()
+
+	return c.handler(ctx, message)
+}
+
+// Keeps the values of the parent without its cancellation, so that in-flight messages are still handled and deleted on shutdown,
+// until the drain timeout cancels the handlers
+type detachedContext struct {
+	parent context.Context
+}
+
+func (c detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (c detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (c detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
@@ -0,0 +1,23 @@
+package consumer_configs_constants
+
+import (
+	"time"
+)
+
+const (
+	DEFAULT_WORKERS                = 1
+	DEFAULT_MAX_NUMBER_OF_MESSAGES = 10
+	DEFAULT_WAIT_TIME_SECONDS      = 20
+	DEFAULT_RECEIVE_ERROR_BACKOFF  = time.Second
+	DEFAULT_DRAIN_TIMEOUT          = 30 * time.Second
+	// Range of MaxNumberOfMessages accepted by sqs
+	MIN_NUMBER_OF_MESSAGES = 1
+	MAX_NUMBER_OF_MESSAGES = 10
+)
+
+const (
+	DEFAULT_HEARTBEAT_INTERVAL           = 20 * time.Second
+	DEFAULT_HEARTBEAT_VISIBILITY_TIMEOUT = 60
+	// Longest a message can be kept invisible by sqs
+	DEFAULT_HEARTBEAT_MAX_EXTENSION = 12 * time.Hour
+)
@@ -0,0 +1,142 @@
+package aws_extended_sqs_consumer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging"
+	logging_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging/constants"
+	consumer_configs_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_consumer/constants"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+	aws_sqsiface "github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+)
+
+// Keeps a message invisible while it is being handled by periodically extending its visibility timeout
+type Heartbeat struct {
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+type heartbeatOptions struct {
+	logger            aws_extended_sqsiface.LoggerInterface
+	interval          time.Duration
+	visibilityTimeout int64
+	maxExtension      time.Duration
+}
+
+type HeartbeatOption func(*heartbeatOptions)
+
+func newHeartbeatOptions() *heartbeatOptions {
+	return &heartbeatOptions{
+		logger:            logging.NewDefaultLogger(),
+		interval:          consumer_configs_constants.DEFAULT_HEARTBEAT_INTERVAL,
+		visibilityTimeout: consumer_configs_constants.DEFAULT_HEARTBEAT_VISIBILITY_TIMEOUT,
+		maxExtension:      consumer_configs_constants.DEFAULT_HEARTBEAT_MAX_EXTENSION,
+	}
+}
+
+func WithHeartbeatLogger(logger aws_extended_sqsiface.LoggerInterface) HeartbeatOption {
+	return func(opts *heartbeatOptions) {
+		opts.logger = logger
+	}
+}
+
+// Time between two extensions, it should be well below the visibility timeout. No extension is made when it is not positive.
+func WithHeartbeatInterval(interval time.Duration) HeartbeatOption {
+	return func(opts *heartbeatOptions) {
+		opts.interval = interval
+	}
+}
+
+// Visibility timeout in seconds set on each extension, counted from the time of the extension
+func WithHeartbeatVisibilityTimeout(visibilityTimeout int64) HeartbeatOption {
+	return func(opts *heartbeatOptions) {
+		opts.visibilityTimeout = visibilityTimeout
+	}
+}
+
+// Total time the message can be kept invisible from the start of the heartbeat, the last extension is shortened to fit
+func WithHeartbeatMaxExtension(maxExtension time.Duration) HeartbeatOption {
+	return func(opts *heartbeatOptions) {
+		opts.maxExtension = maxExtension
+	}
+}
+
+// Starts extending the visibility of the message until Stop is called or ctx is done.
+// client is expected to be an AwsExtendedSQSClient so that the receipt handles of messages received through s3 are unwrapped.
+func StartHeartbeat(ctx context.Context, client aws_sqsiface.SQSAPI, queueUrl string, receiptHandle string, opts ...HeartbeatOption) *Heartbeat {
+	heartbeatOpts := newHeartbeatOptions()
+	for _, opt := range opts {
+		opt(heartbeatOpts)
+	}
+
+	h := &Heartbeat{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	go h.run(ctx, client, queueUrl, receiptHandle, heartbeatOpts)
+
+	return h
+}
+
+// Stops the extensions and waits for an extension in progress, the message keeps its current visibility timeout
+func (h *Heartbeat) Stop() {
+	h.stopOnce.Do(func() {
+		close(h.stop)
+	})
+
+	<-h.done
+}
+
+func (h *Heartbeat) run(ctx context.Context, client aws_sqsiface.SQSAPI, queueUrl string, receiptHandle string, opts *heartbeatOptions) {
+	defer close(h.done)
+
+	if opts.interval <= 0 {
+		return
+	}
+
+	logger := opts.logger.WithFields(map[string]interface{}{"method": "Heartbeat", "queue_url": queueUrl})
+
+	start := time.Now()
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		visibilityTimeout := opts.visibilityTimeout
+		if remaining := int64((opts.maxExtension - time.Since(start)) / time.Second); remaining < visibilityTimeout {
+			visibilityTimeout = remaining
+		}
+
+		if visibilityTimeout <= 0 {
+			logger.Log(logging_constants.LOG_LEVEL_WARN, "Reached the max extension, stopped extending the visibility")
+			return
+		}
+
+		_, err := client.ChangeMessageVisibilityWithContext(ctx, &aws_sqs.ChangeMessageVisibilityInput{
+			QueueUrl:          aws.String(queueUrl),
+			ReceiptHandle:     aws.String(receiptHandle),
+			VisibilityTimeout: aws.Int64(visibilityTimeout),
+		})
+		if err != nil {
+			logger.Log(logging_constants.LOG_LEVEL_ERROR, fmt.Sprintf("Error: %+v", err))
+			continue
+		}
+
+		logger.Log(logging_constants.LOG_LEVEL_DEBUG, fmt.Sprintf("Extended the visibility by %d seconds", visibilityTimeout))
+	}
+}
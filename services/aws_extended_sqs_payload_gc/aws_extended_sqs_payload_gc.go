@@ -0,0 +1,285 @@
+package aws_extended_sqs_payload_gc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors"
+	errors_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors/constants"
+	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/internal/payload_store"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging"
+	logging_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging/constants"
+	payload_gc_configs_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_payload_gc/constants"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
+	aws_s3iface "github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// Returns the message pointers still referenced, e.g. by messages in flight or in a dead-letter queue,
+// whose payloads are kept regardless of their age
+type InFlightPointersFunc func(ctx context.Context) ([]string, error)
+
+// Called with every orphan found, after its deletion outside dry-run mode
+type OrphanHandlerFunc func(orphan *Orphan)
+
+// Deletes the payloads no message references anymore, left behind by failed sends, consumers not cleaning up the payloads,
+// expired messages and messages moved to dead-letter queues. An object is an orphan once it is older than the min age.
+type AwsExtendedSQSPayloadGC struct {
+	s3           aws_s3iface.S3API
+	s3BucketName string
+	payloadStore aws_extended_sqsiface.PayloadStoreInterface
+	opts         *awsExtendedSQSPayloadGCOptions
+}
+
+type awsExtendedSQSPayloadGCOptions struct {
+	logger             aws_extended_sqsiface.LoggerInterface
+	s3KeyPrefix        string
+	wholeBucket        bool
+	minAge             time.Duration
+	dryRun             bool
+	inFlightPointers   InFlightPointersFunc
+	orphanHandler      OrphanHandlerFunc
+	maxReportedOrphans int
+}
+
+type AwsExtendedSQSPayloadGCOption func(*awsExtendedSQSPayloadGCOptions)
+
+type Orphan struct {
+	S3Key        string
+	LastModified time.Time
+	Size         int64
+	// Set when the orphan failed to be deleted
+	Err error
+}
+
+type Report struct {
+	DryRun bool
+	// Objects listed under the prefix
+	Scanned int
+	// Objects younger than the min age
+	Recent int
+	// Objects referenced by the in-flight pointers
+	InFlight int
+	// Objects found orphaned, including the ones left out of Orphans
+	Orphaned int
+	// The first orphans found, up to the max reported orphans
+	Orphans []*Orphan
+	Deleted int
+	Failed  int
+}
+
+func newPayloadGCOptions() *awsExtendedSQSPayloadGCOptions {
+	return &awsExtendedSQSPayloadGCOptions{
+		logger: logging.NewDefaultLogger(),
+		minAge: payload_gc_configs_constants.DEFAULT_MIN_AGE,
+		dryRun: payload_gc_configs_constants.DEFAULT_DRY_RUN,
+
+		maxReportedOrphans: payload_gc_configs_constants.DEFAULT_MAX_REPORTED_ORPHANS,
+	}
+}
+
+func WithLogger(logger aws_extended_sqsiface.LoggerInterface) AwsExtendedSQSPayloadGCOption {
+	return func(opts *awsExtendedSQSPayloadGCOptions) {
+		opts.logger = logger
+	}
+}
+
+// Only lists the objects under the prefix, i.e. the one given to SetS3KeyPrefix.
+// Required unless WithWholeBucket is given, as every other object of the bucket would be deleted as an orphan.
+func WithS3KeyPrefix(prefix string) AwsExtendedSQSPayloadGCOption {
+	return func(opts *awsExtendedSQSPayloadGCOptions) {
+		opts.s3KeyPrefix = prefix
+	}
+}
+
+// Lists the whole bucket when no prefix is given, for buckets holding nothing but the offloaded payloads
+func WithWholeBucket() AwsExtendedSQSPayloadGCOption {
+	return func(opts *awsExtendedSQSPayloadGCOptions) {
+		opts.wholeBucket = true
+	}
+}
+
+// Objects modified more recently are kept, 15 days by default
+func WithMinAge(minAge time.Duration) AwsExtendedSQSPayloadGCOption {
+	return func(opts *awsExtendedSQSPayloadGCOptions) {
+		opts.minAge = minAge
+	}
+}
+
+// Only reports the orphans when enabled, which is the default
+func WithDryRun(dryRun bool) AwsExtendedSQSPayloadGCOption {
+	return func(opts *awsExtendedSQSPayloadGCOptions) {
+		opts.dryRun = dryRun
+	}
+}
+
+func WithInFlightPointers(inFlightPointers InFlightPointersFunc) AwsExtendedSQSPayloadGCOption {
+	return func(opts *awsExtendedSQSPayloadGCOptions) {
+		opts.inFlightPointers = inFlightPointers
+	}
+}
+
+// Streams the orphans, e.g. to print them, which the report only keeps up to the max reported orphans
+func WithOrphanHandler(orphanHandler OrphanHandlerFunc) AwsExtendedSQSPayloadGCOption {
+	return func(opts *awsExtendedSQSPayloadGCOptions) {
+		opts.orphanHandler = orphanHandler
+	}
+}
+
+// Number of orphans kept in the report, 1000 by default. The others are only counted and given to the orphan handler.
+func WithMaxReportedOrphans(maxReportedOrphans int) AwsExtendedSQSPayloadGCOption {
+	return func(opts *awsExtendedSQSPayloadGCOptions) {
+		opts.maxReportedOrphans = maxReportedOrphans
+	}
+}
+
+func NewExtendedSQSPayloadGC(s3 aws_s3iface.S3API, s3BucketName string, opts ...AwsExtendedSQSPayloadGCOption) *AwsExtendedSQSPayloadGC {
+	gcOpts := newPayloadGCOptions()
+	for _, opt := range opts {
+		opt(gcOpts)
+	}
+
+	return &AwsExtendedSQSPayloadGC{
+		s3:           s3,
+		s3BucketName: s3BucketName,
+		payloadStore: payload_store.NewPayloadStore(s3, s3BucketName),
+		opts:         gcOpts,
+	}
+}
+
+// Lists the objects under the prefix and deletes the orphans page by page, or only reports them in dry-run mode.
+// The report is returned along with the error when listing fails, covering the pages handled so far.
+func (g *AwsExtendedSQSPayloadGC) Run(ctx context.Context) (*Report, error) {
+	logger := g.opts.logger.WithFields(map[string]interface{}{"method": "Run", "s3_bucket_name": g.s3BucketName, "s3_key_prefix": g.opts.s3KeyPrefix})
+
+	report := &Report{DryRun: g.opts.dryRun}
+
+	if g.opts.s3KeyPrefix == "" && !g.opts.wholeBucket {
+		err := errors.SDKError{Message: "No s3 key prefix, WithWholeBucket is required to collect the whole bucket"}
+		logger.Log(logging_constants.LOG_LEVEL_ERROR, fmt.Sprintf("Error: %+v", err))
+		return report, err
+	}
+
+	inFlight, err := g.getInFlightKeys(ctx)
+	if err != nil {
+		logger.Log(logging_constants.LOG_LEVEL_ERROR, fmt.Sprintf("Error: %+v", err))
+		return report, err
+	}
+
+	cutoff := time.Now().Add(-g.opts.minAge)
+
+	input := &aws_s3.ListObjectsV2Input{
+		Bucket: aws.String(g.s3BucketName),
+	}
+	if g.opts.s3KeyPrefix != "" {
+		input.Prefix = aws.String(g.opts.s3KeyPrefix)
+	}
+
+	err = g.s3.ListObjectsV2PagesWithContext(ctx, input, func(page *aws_s3.ListObjectsV2Output, lastPage bool) bool {
+		var orphans []*Orphan
+		for _, object := range page.Contents {
+			report.Scanned++
+
+			s3Key := aws.StringValue(object.Key)
+			lastModified := aws.TimeValue(object.LastModified)
+
+			switch {
+			case lastModified.After(cutoff):
+				report.Recent++
+			case inFlight[s3Key]:
+				report.InFlight++
+			default:
+				orphans = append(orphans, &Orphan{S3Key: s3Key, LastModified: lastModified, Size: aws.Int64Value(object.Size)})
+			}
+		}
+
+		if !g.opts.dryRun {
+			g.deleteOrphans(ctx, orphans, report)
+		}
+
+		for _, orphan := range orphans {
+			report.Orphaned++
+			if len(report.Orphans) < g.opts.maxReportedOrphans {
+				report.Orphans = append(report.Orphans, orphan)
+			}
+			if g.opts.orphanHandler != nil {
+				g.opts.orphanHandler(orphan)
+			}
+		}
+
+		return ctx.Err() == nil
+	})
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		err = errors.S3Error{Operation: errors_constants.OPERATION_LIST, S3BucketName: g.s3BucketName, S3Key: g.opts.s3KeyPrefix, Err: err}
+		logger.Log(logging_constants.LOG_LEVEL_ERROR, fmt.Sprintf("Error: %+v", err))
+		return report, err
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"dry_run":   report.DryRun,
+		"scanned":   report.Scanned,
+		"recent":    report.Recent,
+		"in_flight": report.InFlight,
+		"orphans":   report.Orphaned,
+		"deleted":   report.Deleted,
+		"failed":    report.Failed,
+	}).Log(logging_constants.LOG_LEVEL_INFO, "Collected orphaned payloads")
+
+	return report, nil
+}
+
+func (g *AwsExtendedSQSPayloadGC) deleteOrphans(ctx context.Context, orphans []*Orphan, report *Report) {
+	if len(orphans) == 0 {
+		return
+	}
+
+	messagePointers := make([]string, len(orphans))
+	for index, orphan := range orphans {
+		messagePointers[index] = payload_store.NewMessagePointer(g.s3BucketName, orphan.S3Key)
+	}
+
+	for index, err := range g.payloadStore.DeleteOriginalPayloadsWithContext(ctx, messagePointers) {
+		if err != nil {
+			g.opts.logger.WithFields(map[string]interface{}{"method": "deleteOrphans", "s3_key": orphans[index].S3Key}).
+				Log(logging_constants.LOG_LEVEL_ERROR, fmt.Sprintf("Error: %+v", err))
+
+			orphans[index].Err = err
+			report.Failed++
+			continue
+		}
+
+		report.Deleted++
+	}
+}
+
+// Keys of the pointers in the bucket of the gc, pointers of other buckets are ignored
+func (g *AwsExtendedSQSPayloadGC) getInFlightKeys(ctx context.Context) (map[string]bool, error) {
+	keys := map[string]bool{}
+	if g.opts.inFlightPointers == nil {
+		return keys, nil
+	}
+
+	messagePointers, err := g.opts.inFlightPointers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, messagePointer := range messagePointers {
+		pointer, err := payload_store.FromJson(messagePointer)
+		if err != nil {
+			return nil, err
+		}
+
+		if pointer.S3BucketName == g.s3BucketName {
+			keys[pointer.S3Key] = true
+		}
+	}
+
+	return keys, nil
+}
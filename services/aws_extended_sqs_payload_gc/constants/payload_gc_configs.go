@@ -0,0 +1,14 @@
+package payload_gc_configs_constants
+
+import (
+	"time"
+)
+
+const (
+	// Longer than the max retention period of sqs since the object was last modified. A pointer sent again in a new message
+	// is retained anew, so whatever sends it again refreshes the object, as the redrive and content-addressed payloads do.
+	DEFAULT_MIN_AGE = 15 * 24 * time.Hour
+	DEFAULT_DRY_RUN = true
+	// Bounds the memory of the report on large buckets
+	DEFAULT_MAX_REPORTED_ORPHANS = 1000
+)
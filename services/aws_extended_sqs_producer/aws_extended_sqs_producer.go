@@ -0,0 +1,212 @@
+package aws_extended_sqs_producer
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors"
+	errors_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors/constants"
+	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/internal/batcher"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging"
+	logging_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging/constants"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client"
+	producer_configs_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_producer/constants"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+	aws_sqsiface "github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+)
+
+// Buffers the messages per queue and sends them with SendMessageBatch once a batch is full or has lingered long enough.
+// client is expected to be an AwsExtendedSQSClient so that the oversized messages of a batch are offloaded to s3 in parallel.
+// Batches of a queue are sent concurrently, so the order of the messages across batches is not kept, except for fifo
+// queues and messages with a MessageGroupId whose batches are sent one at a time in the order they are flushed.
+type AwsExtendedSQSProducer struct {
+	client  aws_sqsiface.SQSAPI
+	opts    *awsExtendedSQSProducerOptions
+	batcher *batcher.Batcher
+}
+
+type awsExtendedSQSProducerOptions struct {
+	logger        aws_extended_sqsiface.LoggerInterface
+	maxBatchSize  int
+	maxBatchBytes int
+	linger        time.Duration
+}
+
+type AwsExtendedSQSProducerOption func(*awsExtendedSQSProducerOptions)
+
+// Implemented by AwsExtendedSQSClient, which knows whether it offloads a message and what remains in sqs then
+type sentMessageSizeCalculator interface {
+	CalculateSentMessageSize(ctx context.Context, input *aws_sqs.SendMessageInput) aws_extended_sqs_client.MessageSize
+}
+
+type bufferedMessage struct {
+	entry  *aws_sqs.SendMessageBatchRequestEntry
+	future *SendFuture
+}
+
+func newProducerOptions() *awsExtendedSQSProducerOptions {
+	return &awsExtendedSQSProducerOptions{
+		logger:        logging.NewDefaultLogger(),
+		maxBatchSize:  producer_configs_constants.DEFAULT_MAX_BATCH_SIZE,
+		maxBatchBytes: producer_configs_constants.DEFAULT_MAX_BATCH_BYTES,
+		linger:        producer_configs_constants.DEFAULT_LINGER,
+	}
+}
+
+func WithLogger(logger aws_extended_sqsiface.LoggerInterface) AwsExtendedSQSProducerOption {
+	return func(opts *awsExtendedSQSProducerOptions) {
+		opts.logger = logger
+	}
+}
+
+// Messages per batch, from 1 to 10
+func WithMaxBatchSize(maxBatchSize int) AwsExtendedSQSProducerOption {
+	return func(opts *awsExtendedSQSProducerOptions) {
+		opts.maxBatchSize = maxBatchSize
+	}
+}
+
+// Total size of the messages of a batch, a message offloaded to s3 counts as its attributes and pointer
+func WithMaxBatchBytes(maxBatchBytes int) AwsExtendedSQSProducerOption {
+	return func(opts *awsExtendedSQSProducerOptions) {
+		opts.maxBatchBytes = maxBatchBytes
+	}
+}
+
+// Longest a message waits in a batch which is not full before the batch is sent
+func WithLinger(linger time.Duration) AwsExtendedSQSProducerOption {
+	return func(opts *awsExtendedSQSProducerOptions) {
+		opts.linger = linger
+	}
+}
+
+func NewExtendedSQSProducer(client aws_sqsiface.SQSAPI, opts ...AwsExtendedSQSProducerOption) *AwsExtendedSQSProducer {
+	producerOpts := newProducerOptions()
+	for _, opt := range opts {
+		opt(producerOpts)
+	}
+
+	producer := &AwsExtendedSQSProducer{
+		client: client,
+		opts:   producerOpts,
+	}
+	producer.batcher = batcher.NewBatcher(producer.sendBatch,
+		batcher.WithMaxBatchSize(producerOpts.maxBatchSize),
+		batcher.WithMaxBatchBytes(producerOpts.maxBatchBytes),
+		batcher.WithLinger(producerOpts.linger),
+	)
+
+	return producer
+}
+
+// Buffers the message to be sent to input.QueueUrl, the future completes once its batch is sent
+func (p *AwsExtendedSQSProducer) SendMessage(input *aws_sqs.SendMessageInput) *SendFuture {
+	return p.SendMessageWithCallback(input, nil)
+}
+
+// Same as SendMessage, callback is called from the goroutine sending the batch once the future completes
+func (p *AwsExtendedSQSProducer) SendMessageWithCallback(input *aws_sqs.SendMessageInput, callback SendCallback) *SendFuture {
+	future := newSendFuture(callback)
+
+	if input == nil || input.QueueUrl == nil {
+		future.complete("", errors.SDKError{Message: "Queue url of the message is missing."})
+		return future
+	}
+
+	entry := &aws_sqs.SendMessageBatchRequestEntry{
+		DelaySeconds:            input.DelaySeconds,
+		MessageAttributes:       input.MessageAttributes,
+		MessageBody:             input.MessageBody,
+		MessageDeduplicationId:  input.MessageDeduplicationId,
+		MessageGroupId:          input.MessageGroupId,
+		MessageSystemAttributes: input.MessageSystemAttributes,
+	}
+	message := &bufferedMessage{entry: entry, future: future}
+
+	// the batches of a fifo queue are sent one at a time to keep the order of the messages
+	ordered := strings.HasSuffix(*input.QueueUrl, producer_configs_constants.FIFO_QUEUE_SUFFIX) || input.MessageGroupId != nil
+
+	if !p.batcher.Add(*input.QueueUrl, message, p.getMessageSize(input), ordered) {
+		future.complete("", errors.SDKError{Message: "Producer is closed."})
+	}
+
+	return future
+}
+
+// Sends the buffered messages right away and waits for all the batches being sent, or until ctx is done
+func (p *AwsExtendedSQSProducer) Flush(ctx context.Context) error {
+	return p.batcher.Flush(ctx)
+}
+
+// Stops accepting messages, then flushes the buffered ones. Messages sent afterwards fail right away.
+func (p *AwsExtendedSQSProducer) Close(ctx context.Context) error {
+	return p.batcher.Close(ctx)
+}
+
+func (p *AwsExtendedSQSProducer) sendBatch(queueUrl string, items []interface{}) {
+	logger := p.opts.logger.WithFields(map[string]interface{}{"method": "sendBatch", "queue_url": queueUrl})
+
+	messages := make([]*bufferedMessage, len(items))
+	input := &aws_sqs.SendMessageBatchInput{QueueUrl: aws.String(queueUrl)}
+	for index, item := range items {
+		message := item.(*bufferedMessage)
+		messages[index] = message
+
+		message.entry.Id = aws.String(strconv.Itoa(index))
+		input.Entries = append(input.Entries, message.entry)
+	}
+
+	output, err := p.client.SendMessageBatchWithContext(context.Background(), input)
+	if err != nil {
+		logger.Log(logging_constants.LOG_LEVEL_ERROR, fmt.Sprintf("Error: %+v", err))
+
+		for _, message := range messages {
+			message.future.complete("", err)
+		}
+		return
+	}
+
+	successful := make(map[string]*aws_sqs.SendMessageBatchResultEntry, len(output.Successful))
+	for _, result := range output.Successful {
+		successful[aws.StringValue(result.Id)] = result
+	}
+
+	failed := make(map[string]*aws_sqs.BatchResultErrorEntry, len(output.Failed))
+	for _, result := range output.Failed {
+		failed[aws.StringValue(result.Id)] = result
+	}
+
+	for _, message := range messages {
+		id := aws.StringValue(message.entry.Id)
+
+		if result, ok := successful[id]; ok {
+			message.future.complete(aws.StringValue(result.MessageId), nil)
+		} else if result, ok := failed[id]; ok {
+			message.future.complete("", errors.SQSError{
+				Operation: errors_constants.OPERATION_SEND,
+				QueueUrl:  queueUrl,
+				Err:       awserr.New(aws.StringValue(result.Code), aws.StringValue(result.Message), nil),
+			})
+		} else {
+			message.future.complete("", errors.SDKError{Message: fmt.Sprintf("No result for entry [%s] of the batch.", id)})
+		}
+	}
+
+	logger.Log(logging_constants.LOG_LEVEL_DEBUG, fmt.Sprintf("Sent batch of %d messages, %d failed", len(messages), len(output.Failed)))
+}
+
+// Size the message takes in the batch, as counted by the client when it may offload the message to s3
+func (p *AwsExtendedSQSProducer) getMessageSize(input *aws_sqs.SendMessageInput) int {
+	if calculator, ok := p.client.(sentMessageSizeCalculator); ok {
+		return calculator.CalculateSentMessageSize(context.Background(), input).Total()
+	}
+
+	return aws_extended_sqs_client.CalculateMessageSize(input).Total()
+}
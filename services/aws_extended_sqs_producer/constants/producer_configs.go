@@ -0,0 +1,13 @@
+package producer_configs_constants
+
+import (
+	"time"
+)
+
+const (
+	DEFAULT_MAX_BATCH_SIZE  = 10
+	DEFAULT_MAX_BATCH_BYTES = 262144
+	DEFAULT_LINGER          = 100 * time.Millisecond
+	// Batches of a queue whose url ends with this suffix are sent one at a time
+	FIFO_QUEUE_SUFFIX = ".fifo"
+)
@@ -0,0 +1,48 @@
+package aws_extended_sqs_producer
+
+import (
+	"context"
+)
+
+// Called once the message is sent, with the id of the message or the error of the send
+type SendCallback func(messageId string, err error)
+
+// Outcome of a message buffered by the producer
+type SendFuture struct {
+	done      chan struct{}
+	messageId string
+	err       error
+	callback  SendCallback
+}
+
+func newSendFuture(callback SendCallback) *SendFuture {
+	return &SendFuture{
+		done:     make(chan struct{}),
+		callback: callback,
+	}
+}
+
+// Closed once the message is sent or has failed
+func (f *SendFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+// Waits for the message to be sent and returns its id, or the error of ctx when it is done first
+func (f *SendFuture) Get(ctx context.Context) (string, error) {
+	select {
+	case <-f.done:
+		return f.messageId, f.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (f *SendFuture) complete(messageId string, err error) {
+	f.messageId = messageId
+	f.err = err
+	close(f.done)
+
+	if f.callback != nil {
+		f.callback(messageId, err)
+	}
+}
@@ -0,0 +1,402 @@
+package aws_extended_sqs_redrive
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors"
+	errors_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors/constants"
+	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/internal/payload_store"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging"
+	logging_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging/constants"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client"
+	sqs_configs_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client/constants"
+	redrive_configs_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_redrive/constants"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
+	aws_s3iface "github.com/aws/aws-sdk-go/service/s3/s3iface"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+	aws_sqsiface "github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/google/uuid"
+)
+
+// Moves messages from a queue, usually a dead-letter queue, to another one without resolving their payloads.
+// Messages are received and deleted with the underlying sqs client, so the payloads are never deleted along with
+// the messages, and a message whose payload is already gone is left in the source queue.
+type AwsExtendedSQSRedriver struct {
+	sqs  aws_sqsiface.SQSAPI
+	s3   aws_s3iface.S3API
+	opts *awsExtendedSQSRedriverOptions
+}
+
+type awsExtendedSQSRedriverOptions struct {
+	logger              aws_extended_sqsiface.LoggerInterface
+	s3ClientResolver    aws_extended_sqsiface.S3ClientResolverInterface
+	payloadMode         string
+	copyS3BucketName    string
+	copyS3KeyPrefix     string
+	deleteSourcePayload bool
+	batchSize           int64
+	waitTimeSeconds     int64
+	visibilityTimeout   int64
+	maxMessages         int
+}
+
+type AwsExtendedSQSRedriverOption func(*awsExtendedSQSRedriverOptions)
+
+type RedriveFailure struct {
+	MessageId string
+	Err       error
+}
+
+type RedriveReport struct {
+	// Messages sent to the destination and deleted from the source
+	Moved int
+	// Payloads copied for the moved messages
+	Copied int
+	// Messages left in the source queue, or sent but failed to be deleted from it
+	Failed []*RedriveFailure
+}
+
+func newRedriverOptions() *awsExtendedSQSRedriverOptions {
+	return &awsExtendedSQSRedriverOptions{
+		logger:            logging.NewDefaultLogger(),
+		payloadMode:       redrive_configs_constants.DEFAULT_PAYLOAD_MODE,
+		batchSize:         redrive_configs_constants.DEFAULT_BATCH_SIZE,
+		waitTimeSeconds:   redrive_configs_constants.DEFAULT_WAIT_TIME_SECONDS,
+		visibilityTimeout: redrive_configs_constants.DEFAULT_VISIBILITY_TIMEOUT,
+	}
+}
+
+func WithLogger(logger aws_extended_sqsiface.LoggerInterface) AwsExtendedSQSRedriverOption {
+	return func(opts *awsExtendedSQSRedriverOptions) {
+		opts.logger = logger
+	}
+}
+
+// Resolves the s3 client of each bucket, i.e. the one given to WithS3ClientResolver of the extended client.
+// The s3 client of the redriver is used for every bucket by default.
+func WithS3ClientResolver(resolver aws_extended_sqsiface.S3ClientResolverInterface) AwsExtendedSQSRedriverOption {
+	return func(opts *awsExtendedSQSRedriverOptions) {
+		opts.s3ClientResolver = resolver
+	}
+}
+
+// Keeps the payloads where they are by default. Copying them protects the moved messages from consumers of the source
+// queue cleaning up the payloads, and lets the payloads be moved to another bucket, see WithCopyDestination.
+func WithPayloadMode(payloadMode string) AwsExtendedSQSRedriverOption {
+	return func(opts *awsExtendedSQSRedriverOptions) {
+		opts.payloadMode = payloadMode
+	}
+}
+
+// Bucket and key prefix of the copied payloads in PAYLOAD_MODE_COPY, the bucket of the original payload by default
+func WithCopyDestination(s3BucketName string, s3KeyPrefix string) AwsExtendedSQSRedriverOption {
+	return func(opts *awsExtendedSQSRedriverOptions) {
+		opts.copyS3BucketName = s3BucketName
+		opts.copyS3KeyPrefix = s3KeyPrefix
+	}
+}
+
+// Deletes the original payload once its copy is sent, as no moved message references it anymore. Only applies to PAYLOAD_MODE_COPY.
+// Content addressed payloads are never deleted, as other messages may reference them.
+func WithDeleteSourcePayload(deleteSourcePayload bool) AwsExtendedSQSRedriverOption {
+	return func(opts *awsExtendedSQSRedriverOptions) {
+		opts.deleteSourcePayload = deleteSourcePayload
+	}
+}
+
+// Messages per receive, from 1 to 10
+func WithBatchSize(batchSize int64) AwsExtendedSQSRedriverOption {
+	return func(opts *awsExtendedSQSRedriverOptions) {
+		opts.batchSize = batchSize
+	}
+}
+
+// Long polling duration of a receive, the redrive stops once a receive returns no message
+func WithWaitTimeSeconds(waitTimeSeconds int64) AwsExtendedSQSRedriverOption {
+	return func(opts *awsExtendedSQSRedriverOptions) {
+		opts.waitTimeSeconds = waitTimeSeconds
+	}
+}
+
+// Seconds a received message stays invisible in the source queue, failed messages are received again afterwards
+func WithVisibilityTimeout(visibilityTimeout int64) AwsExtendedSQSRedriverOption {
+	return func(opts *awsExtendedSQSRedriverOptions) {
+		opts.visibilityTimeout = visibilityTimeout
+	}
+}
+
+// Stops after the number of messages is received, all the messages of the queue by default
+func WithMaxMessages(maxMessages int) AwsExtendedSQSRedriverOption {
+	return func(opts *awsExtendedSQSRedriverOptions) {
+		opts.maxMessages = maxMessages
+	}
+}
+
+// sqs can be the extended client, whose underlying sqs client is then used. s3 is used to check and copy the payloads
+// unless WithS3ClientResolver is given.
+func NewExtendedSQSRedriver(sqs aws_sqsiface.SQSAPI, s3 aws_s3iface.S3API, opts ...AwsExtendedSQSRedriverOption) *AwsExtendedSQSRedriver {
+	redriverOpts := newRedriverOptions()
+	for _, opt := range opts {
+		opt(redriverOpts)
+	}
+
+	if client, ok := sqs.(*aws_extended_sqs_client.AwsExtendedSQSClient); ok {
+		sqs = client.SQSAPI
+	}
+
+	return &AwsExtendedSQSRedriver{
+		sqs:  sqs,
+		s3:   s3,
+		opts: redriverOpts,
+	}
+}
+
+// Moves the messages until the source queue is drained, the max messages are received or ctx is done.
+// The report is returned along with the error when a receive fails, covering the messages handled so far.
+func (r *AwsExtendedSQSRedriver) Redrive(ctx context.Context, sourceQueueUrl string, destinationQueueUrl string) (*RedriveReport, error) {
+	logger := r.opts.logger.WithFields(map[string]interface{}{"method": "Redrive", "source_queue_url": sourceQueueUrl, "destination_queue_url": destinationQueueUrl})
+
+	if r.opts.payloadMode != redrive_configs_constants.PAYLOAD_MODE_KEEP && r.opts.payloadMode != redrive_configs_constants.PAYLOAD_MODE_COPY {
+		return &RedriveReport{}, errors.SDKError{Message: fmt.Sprintf("Payload mode [%s] is not supported.", r.opts.payloadMode)}
+	}
+
+	report := &RedriveReport{}
+	failed := map[string]bool{}
+	received := 0
+
+	for ctx.Err() == nil && (r.opts.maxMessages <= 0 || received < r.opts.maxMessages) {
+		batchSize := r.opts.batchSize
+		if remaining := int64(r.opts.maxMessages - received); r.opts.maxMessages > 0 && remaining < batchSize {
+			batchSize = remaining
+		}
+
+		output, err := r.sqs.ReceiveMessageWithContext(ctx, &aws_sqs.ReceiveMessageInput{
+			QueueUrl:              aws.String(sourceQueueUrl),
+			MaxNumberOfMessages:   aws.Int64(batchSize),
+			WaitTimeSeconds:       aws.Int64(r.opts.waitTimeSeconds),
+			VisibilityTimeout:     aws.Int64(r.opts.visibilityTimeout),
+			AttributeNames:        []*string{aws.String(aws_sqs.QueueAttributeNameAll)},
+			MessageAttributeNames: []*string{aws.String(aws_sqs.QueueAttributeNameAll)},
+		})
+		if err != nil {
+			err = errors.SQSError{Operation: errors_constants.OPERATION_RECEIVE, QueueUrl: sourceQueueUrl, Err: err}
+			logger.Log(logging_constants.LOG_LEVEL_ERROR, fmt.Sprintf("Error: %+v", err))
+			return report, err
+		}
+
+		// Stops on failed messages becoming visible again, as they would fail again
+		fresh := 0
+		for _, message := range output.Messages {
+			messageId := aws.StringValue(message.MessageId)
+			if failed[messageId] {
+				continue
+			}
+
+			fresh++
+			received++
+
+			copied, err := r.redriveMessage(ctx, sourceQueueUrl, destinationQueueUrl, message)
+			if err != nil {
+				logger.WithField("message_id", messageId).Log(logging_constants.LOG_LEVEL_ERROR, fmt.Sprintf("Error: %+v", err))
+
+				failed[messageId] = true
+				report.Failed = append(report.Failed, &RedriveFailure{MessageId: messageId, Err: err})
+				continue
+			}
+
+			report.Moved++
+			if copied {
+				report.Copied++
+			}
+		}
+
+		if fresh == 0 {
+			break
+		}
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"moved":  report.Moved,
+		"copied": report.Copied,
+		"failed": len(report.Failed),
+	}).Log(logging_constants.LOG_LEVEL_INFO, "Redrove messages")
+
+	return report, nil
+}
+
+func (r *AwsExtendedSQSRedriver) redriveMessage(ctx context.Context, sourceQueueUrl string, destinationQueueUrl string, message *aws_sqs.Message) (bool, error) {
+	input := &aws_sqs.SendMessageInput{
+		QueueUrl:    aws.String(destinationQueueUrl),
+		MessageBody: message.Body,
+	}
+	if len(message.MessageAttributes) > 0 {
+		input.MessageAttributes = message.MessageAttributes
+	}
+	if groupId, ok := message.Attributes[aws_sqs.MessageSystemAttributeNameMessageGroupId]; ok {
+		input.MessageGroupId = groupId
+	}
+	if deduplicationId, ok := message.Attributes[aws_sqs.MessageSystemAttributeNameMessageDeduplicationId]; ok {
+		input.MessageDeduplicationId = deduplicationId
+	}
+	// Keeps the moved message in the trace of the original one
+	if traceHeader, ok := message.Attributes[aws_sqs.MessageSystemAttributeNameAwstraceHeader]; ok {
+		input.MessageSystemAttributes = map[string]*aws_sqs.MessageSystemAttributeValue{
+			aws_sqs.MessageSystemAttributeNameForSendsAwstraceHeader: {DataType: aws.String("String"), StringValue: traceHeader},
+		}
+	}
+
+	var pointer *payload_store.PayloadS3Pointer
+	var copiedPointer *payload_store.PayloadS3Pointer
+	if isOffloaded(message) {
+		var err error
+		pointer, err = payload_store.FromJson(aws.StringValue(message.Body))
+		if err != nil {
+			return false, err
+		}
+
+		head, err := r.checkPayload(ctx, pointer)
+		if err != nil {
+			return false, err
+		}
+
+		switch r.opts.payloadMode {
+		case redrive_configs_constants.PAYLOAD_MODE_KEEP:
+			// The moved message is retained anew, so the payload must not look older than it to the payload gc
+			if err := r.touchPayload(ctx, pointer, head); err != nil {
+				return false, err
+			}
+		case redrive_configs_constants.PAYLOAD_MODE_COPY:
+			copiedPointer, err = r.copyPayload(ctx, pointer)
+			if err != nil {
+				return false, err
+			}
+
+			input.MessageBody = aws.String(payload_store.NewMessagePointer(copiedPointer.S3BucketName, copiedPointer.S3Key))
+		}
+	}
+
+	if _, err := r.sqs.SendMessageWithContext(ctx, input); err != nil {
+		if copiedPointer != nil {
+			r.deletePayload(ctx, copiedPointer)
+		}
+
+		return false, errors.SQSError{Operation: errors_constants.OPERATION_SEND, QueueUrl: destinationQueueUrl, Err: err}
+	}
+
+	if _, err := r.sqs.DeleteMessageWithContext(ctx, &aws_sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(sourceQueueUrl),
+		ReceiptHandle: message.ReceiptHandle,
+	}); err != nil {
+		return false, errors.SQSError{Operation: errors_constants.OPERATION_DELETE, QueueUrl: sourceQueueUrl, Err: err}
+	}
+
+	if copiedPointer != nil && r.opts.deleteSourcePayload && !payload_store.IsContentAddressedKey(pointer.S3Key) {
+		r.deletePayload(ctx, pointer)
+	}
+
+	return copiedPointer != nil, nil
+}
+
+// A payload cleaned up by a consumer of the source queue cannot be redriven
+func (r *AwsExtendedSQSRedriver) checkPayload(ctx context.Context, pointer *payload_store.PayloadS3Pointer) (*aws_s3.HeadObjectOutput, error) {
+	s3Client, err := r.getS3Client(pointer.S3BucketName)
+	if err != nil {
+		return nil, errors.S3Error{Operation: errors_constants.OPERATION_FETCH, S3BucketName: pointer.S3BucketName, S3Key: pointer.S3Key, Err: err}
+	}
+
+	head, err := s3Client.HeadObjectWithContext(ctx, &aws_s3.HeadObjectInput{
+		Bucket: aws.String(pointer.S3BucketName),
+		Key:    aws.String(pointer.S3Key),
+	})
+	if err != nil {
+		return nil, errors.S3Error{Operation: errors_constants.OPERATION_FETCH, S3BucketName: pointer.S3BucketName, S3Key: pointer.S3Key, Err: err}
+	}
+
+	return head, nil
+}
+
+// Copies the object onto itself to refresh its last modified time, keeping its content type and metadata
+func (r *AwsExtendedSQSRedriver) touchPayload(ctx context.Context, pointer *payload_store.PayloadS3Pointer, head *aws_s3.HeadObjectOutput) error {
+	s3Client, err := r.getS3Client(pointer.S3BucketName)
+	if err != nil {
+		return errors.S3Error{Operation: errors_constants.OPERATION_TOUCH, S3BucketName: pointer.S3BucketName, S3Key: pointer.S3Key, Err: err}
+	}
+
+	_, err = s3Client.CopyObjectWithContext(ctx, &aws_s3.CopyObjectInput{
+		Bucket:            aws.String(pointer.S3BucketName),
+		Key:               aws.String(pointer.S3Key),
+		CopySource:        aws.String(pointer.S3BucketName + "/" + (&url.URL{Path: pointer.S3Key}).EscapedPath()),
+		MetadataDirective: aws.String(aws_s3.MetadataDirectiveReplace),
+		ContentType:       head.ContentType,
+		Metadata:          head.Metadata,
+	})
+	if err != nil {
+		return errors.S3Error{Operation: errors_constants.OPERATION_TOUCH, S3BucketName: pointer.S3BucketName, S3Key: pointer.S3Key, Err: err}
+	}
+
+	return nil
+}
+
+func (r *AwsExtendedSQSRedriver) copyPayload(ctx context.Context, pointer *payload_store.PayloadS3Pointer) (*payload_store.PayloadS3Pointer, error) {
+	copiedPointer := &payload_store.PayloadS3Pointer{
+		S3BucketName: r.opts.copyS3BucketName,
+		S3Key:        r.opts.copyS3KeyPrefix + uuid.NewString(),
+	}
+	if copiedPointer.S3BucketName == "" {
+		copiedPointer.S3BucketName = pointer.S3BucketName
+	}
+
+	// The copy is requested from the destination bucket, which needs to be granted access to the source bucket
+	s3Client, err := r.getS3Client(copiedPointer.S3BucketName)
+	if err != nil {
+		return nil, errors.S3Error{Operation: errors_constants.OPERATION_COPY, S3BucketName: pointer.S3BucketName, S3Key: pointer.S3Key, Err: err}
+	}
+
+	_, err = s3Client.CopyObjectWithContext(ctx, &aws_s3.CopyObjectInput{
+		Bucket:     aws.String(copiedPointer.S3BucketName),
+		Key:        aws.String(copiedPointer.S3Key),
+		CopySource: aws.String(pointer.S3BucketName + "/" + (&url.URL{Path: pointer.S3Key}).EscapedPath()),
+	})
+	if err != nil {
+		return nil, errors.S3Error{Operation: errors_constants.OPERATION_COPY, S3BucketName: pointer.S3BucketName, S3Key: pointer.S3Key, Err: err}
+	}
+
+	return copiedPointer, nil
+}
+
+// Failures only leave an orphan behind, which is logged
+func (r *AwsExtendedSQSRedriver) deletePayload(ctx context.Context, pointer *payload_store.PayloadS3Pointer) {
+	s3Client, err := r.getS3Client(pointer.S3BucketName)
+	if err == nil {
+		_, err = s3Client.DeleteObjectWithContext(ctx, &aws_s3.DeleteObjectInput{
+			Bucket: aws.String(pointer.S3BucketName),
+			Key:    aws.String(pointer.S3Key),
+		})
+	}
+	if err != nil {
+		err = errors.S3Error{Operation: errors_constants.OPERATION_DELETE, S3BucketName: pointer.S3BucketName, S3Key: pointer.S3Key, Err: err}
+		r.opts.logger.WithField("method", "deletePayload").Log(logging_constants.LOG_LEVEL_ERROR, fmt.Sprintf("Error: %+v", err))
+	}
+}
+
+func (r *AwsExtendedSQSRedriver) getS3Client(s3BucketName string) (aws_s3iface.S3API, error) {
+	if r.opts.s3ClientResolver == nil {
+		return r.s3, nil
+	}
+
+	return r.opts.s3ClientResolver.ResolveS3Client(s3BucketName)
+}
+
+func isOffloaded(message *aws_sqs.Message) bool {
+	_, ok := message.MessageAttributes[sqs_configs_constants.RESERVED_ATTRIBUTE_NAME]
+	if !ok {
+		_, ok = message.MessageAttributes[sqs_configs_constants.LEGACY_RESERVED_ATTRIBUTE_NAME]
+	}
+
+	return ok
+}
@@ -0,0 +1,15 @@
+package redrive_configs_constants
+
+const (
+	// The moved message keeps pointing to the same object, which is copied onto itself so that it is as recent as the moved message
+	PAYLOAD_MODE_KEEP = "keep"
+	// The object is copied to a new key which the moved message points to
+	PAYLOAD_MODE_COPY = "copy"
+)
+
+const (
+	DEFAULT_PAYLOAD_MODE       = PAYLOAD_MODE_KEEP
+	DEFAULT_BATCH_SIZE         = 10
+	DEFAULT_WAIT_TIME_SECONDS  = 1
+	DEFAULT_VISIBILITY_TIMEOUT = 60
+)
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	std_errors "errors"
+	"testing"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/cmd/extended-sqs/inspect"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	messagePointer = "[\"software.amazon.payloadoffloading.PayloadS3Pointer\",{\"s3BucketName\":\"test-bucket\",\"s3Key\":\"test-key\"}]"
+	receiptHandle  = "-..s3BucketName..-test-bucket-..s3BucketName..--..s3Key..-test-key-..s3Key..-test-receipt-handle"
+)
+
+func Test_DecodeReference_Success_Message_Pointer(t *testing.T) {
+	reference, err := inspect.DecodeReference(" " + messagePointer + "\n")
+
+	assert.Nil(t, err)
+	assert.Equal(t, &inspect.Reference{S3BucketName: "test-bucket", S3Key: "test-key"}, reference)
+}
+
+func Test_DecodeReference_Success_Receipt_Handle(t *testing.T) {
+	reference, err := inspect.DecodeReference(receiptHandle)
+
+	assert.Nil(t, err)
+	assert.Equal(t, &inspect.Reference{S3BucketName: "test-bucket", S3Key: "test-key", ReceiptHandle: "test-receipt-handle"}, reference)
+}
+
+func Test_DecodeReference_Failed_Plain_Receipt_Handle(t *testing.T) {
+	reference, err := inspect.DecodeReference("test-receipt-handle")
+
+	assert.Nil(t, reference)
+	assert.True(t, std_errors.As(err, &errors.PointerFormatError{}))
+}
+
+func Test_DecodeReference_Failed_Invalid_Message_Pointer(t *testing.T) {
+	reference, err := inspect.DecodeReference("[\"software.amazon.payloadoffloading.PayloadS3Pointer\"]")
+
+	assert.Nil(t, reference)
+	assert.NotNil(t, err)
+}
+
+func Test_NewPeekedMessage(t *testing.T) {
+	s3Pointer := &aws_extended_sqs_client.S3Pointer{S3BucketName: "test-bucket", S3Key: "test-key"}
+	message := &aws_sqs.Message{
+		MessageId:     aws.String("test-id"),
+		ReceiptHandle: aws.String(receiptHandle),
+		Body:          aws.String("test"),
+		Attributes:    map[string]*string{aws_sqs.MessageSystemAttributeNameApproximateReceiveCount: aws.String("1")},
+		MessageAttributes: map[string]*aws_sqs.MessageAttributeValue{
+			"attr":   {DataType: aws.String("String"), StringValue: aws.String("value")},
+			"binary": {DataType: aws.String("Binary"), BinaryValue: []byte("value")},
+		},
+	}
+
+	assert.Equal(t, &inspect.PeekedMessage{
+		MessageId:     "test-id",
+		ReceiptHandle: receiptHandle,
+		S3Pointer:     s3Pointer,
+		Attributes:    map[string]string{aws_sqs.MessageSystemAttributeNameApproximateReceiveCount: "1"},
+		MessageAttributes: map[string]*inspect.PeekedAttribute{
+			"attr":   {DataType: "String", StringValue: "value"},
+			"binary": {DataType: "Binary", BinaryValue: []byte("value")},
+		},
+		Body: "test",
+	}, inspect.NewPeekedMessage(message, s3Pointer))
+}
+
+func Test_NewPeekedMessage_Not_Offloaded(t *testing.T) {
+	peeked := inspect.NewPeekedMessage(&aws_sqs.Message{
+		MessageId:     aws.String("test-id"),
+		ReceiptHandle: aws.String("test-receipt-handle"),
+		Body:          aws.String("test"),
+	}, nil)
+
+	assert.Nil(t, peeked.S3Pointer)
+	assert.Nil(t, peeked.MessageAttributes)
+	assert.Equal(t, "test", peeked.Body)
+}
@@ -0,0 +1,165 @@
+package tests
+
+import (
+	"context"
+	std_errors "errors"
+	"fmt"
+	"testing"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors"
+	errors_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors/constants"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_S3Error_Unwrap(t *testing.T) {
+	cause := awserr.New(aws_s3.ErrCodeNoSuchBucket, "The specified bucket does not exist", nil)
+	err := fmt.Errorf("wrapped: %w", errors.S3Error{
+		Operation:    errors_constants.OPERATION_STORE,
+		S3BucketName: "test-bucket",
+		S3Key:        "test-key",
+		Err:          cause,
+	})
+
+	var s3Err errors.S3Error
+	assert.True(t, std_errors.As(err, &s3Err))
+	assert.Equal(t, "test-bucket", s3Err.S3BucketName)
+	assert.Equal(t, "test-key", s3Err.S3Key)
+
+	var awsErr awserr.Error
+	assert.True(t, std_errors.As(err, &awsErr))
+	assert.Equal(t, aws_s3.ErrCodeNoSuchBucket, awsErr.Code())
+
+	assert.Contains(t, err.Error(), "Failed to store payload s3://test-bucket/test-key")
+}
+
+func Test_S3Error_Is_Operation(t *testing.T) {
+	err := errors.S3Error{Operation: errors_constants.OPERATION_FETCH, Err: fmt.Errorf("failed")}
+
+	assert.True(t, std_errors.Is(err, errors.S3Error{}))
+	assert.True(t, std_errors.Is(err, errors.S3Error{Operation: errors_constants.OPERATION_FETCH}))
+	assert.False(t, std_errors.Is(err, errors.S3Error{Operation: errors_constants.OPERATION_STORE}))
+	assert.False(t, std_errors.Is(err, errors.SQSError{}))
+}
+
+func Test_SQSError_Is_Operation(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", errors.SQSError{Operation: errors_constants.OPERATION_SEND, QueueUrl: "test-queue", Err: fmt.Errorf("failed")})
+
+	assert.True(t, std_errors.Is(err, errors.SQSError{Operation: errors_constants.OPERATION_SEND}))
+	assert.False(t, std_errors.Is(err, errors.SQSError{Operation: errors_constants.OPERATION_DELETE}))
+	assert.False(t, std_errors.Is(err, errors.S3Error{}))
+	assert.Contains(t, err.Error(), "Failed to send message on queue test-queue")
+}
+
+func Test_SQSError_Error_Change_Visibility(t *testing.T) {
+	err := errors.SQSError{Operation: errors_constants.OPERATION_CHANGE_VISIBILITY, QueueUrl: "test-queue", Err: fmt.Errorf("failed")}
+
+	assert.Equal(t, "AwsSqsGoExtendedClientSQSError - Failed to change message visibility on queue test-queue: failed", err.Error())
+}
+
+func Test_PointerFormatError_Unwrap(t *testing.T) {
+	cause := fmt.Errorf("unexpected end of JSON input")
+	err := errors.PointerFormatError{Message: "Invalid pointer format", Err: cause}
+
+	assert.True(t, std_errors.Is(err, cause))
+	assert.False(t, err.Retryable())
+}
+
+func Test_ReceiptHandleFormatError(t *testing.T) {
+	err := errors.ReceiptHandleFormatError{Message: "Unclosed s3 key marker"}
+
+	assert.Equal(t, "ReceiptHandleFormatError - Unclosed s3 key marker", err.Error())
+	assert.False(t, err.Retryable())
+	assert.False(t, errors.IsRetryable(err))
+}
+
+func Test_ReceiptHandleSignatureError(t *testing.T) {
+	err := errors.ReceiptHandleSignatureError{Message: "Invalid receipt handle signature"}
+
+	assert.Equal(t, "ReceiptHandleSignatureError - Invalid receipt handle signature", err.Error())
+	assert.False(t, errors.IsRetryable(err))
+}
+
+func Test_IsRetryable(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "nil",
+			err:      nil,
+			expected: false,
+		},
+		{
+			name:     "unknown error",
+			err:      fmt.Errorf("failed"),
+			expected: false,
+		},
+		{
+			name:     "deadline exceeded",
+			err:      context.DeadlineExceeded,
+			expected: true,
+		},
+		{
+			name:     "canceled",
+			err:      context.Canceled,
+			expected: false,
+		},
+		{
+			name:     "throttled",
+			err:      awserr.New("ThrottlingException", "Rate exceeded", nil),
+			expected: true,
+		},
+		{
+			name:     "s3 slow down",
+			err:      awserr.NewRequestFailure(awserr.New("SlowDown", "Please reduce your request rate", nil), 503, "request-id"),
+			expected: true,
+		},
+		{
+			name:     "missing bucket",
+			err:      awserr.New(aws_s3.ErrCodeNoSuchBucket, "The specified bucket does not exist", nil),
+			expected: false,
+		},
+		{
+			name:     "missing queue",
+			err:      awserr.New(aws_sqs.ErrCodeQueueDoesNotExist, "The specified queue does not exist", nil),
+			expected: false,
+		},
+		{
+			name:     "internal server error",
+			err:      awserr.NewRequestFailure(awserr.New("InternalError", "We encountered an internal error", nil), 500, "request-id"),
+			expected: true,
+		},
+		{
+			name:     "access denied",
+			err:      awserr.NewRequestFailure(awserr.New("AccessDenied", "Access Denied", nil), 403, "request-id"),
+			expected: false,
+		},
+		{
+			name:     "wrapped throttled s3 error",
+			err:      errors.S3Error{Operation: errors_constants.OPERATION_FETCH, Err: awserr.NewRequestFailure(awserr.New("SlowDown", "Please reduce your request rate", nil), 503, "request-id")},
+			expected: true,
+		},
+		{
+			name:     "wrapped sqs error with missing queue",
+			err:      errors.SQSError{Operation: errors_constants.OPERATION_SEND, Err: awserr.New(aws_sqs.ErrCodeQueueDoesNotExist, "The specified queue does not exist", nil)},
+			expected: false,
+		},
+		{
+			name:     "oversize break",
+			err:      errors.OversizeBreakError{Message: "too large"},
+			expected: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, errors.IsRetryable(testCase.err))
+		})
+	}
+}
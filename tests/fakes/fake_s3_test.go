@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/fakes"
+	fakes_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/fakes/constants"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func putObject(t *testing.T, fakeS3 *fakes.FakeS3, key string, body string) {
+	_, err := fakeS3.PutObject(&aws_s3.PutObjectInput{Bucket: aws.String(bucketName), Key: aws.String(key), Body: bytes.NewReader([]byte(body))})
+	assert.Nil(t, err)
+}
+
+func Test_FakeS3_Put_Get_Head_Delete(t *testing.T) {
+	fakeS3 := fakes.NewFakeS3(bucketName)
+	putObject(t, fakeS3, "key", "body")
+
+	getOutput, err := fakeS3.GetObject(&aws_s3.GetObjectInput{Bucket: aws.String(bucketName), Key: aws.String("key")})
+	assert.Nil(t, err)
+	body, _ := ioutil.ReadAll(getOutput.Body)
+	assert.Equal(t, "body", string(body))
+
+	headOutput, err := fakeS3.HeadObject(&aws_s3.HeadObjectInput{Bucket: aws.String(bucketName), Key: aws.String("key")})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), *headOutput.ContentLength)
+
+	_, err = fakeS3.DeleteObject(&aws_s3.DeleteObjectInput{Bucket: aws.String(bucketName), Key: aws.String("key")})
+	assert.Nil(t, err)
+
+	_, err = fakeS3.GetObject(&aws_s3.GetObjectInput{Bucket: aws.String(bucketName), Key: aws.String("key")})
+	assert.Equal(t, aws_s3.ErrCodeNoSuchKey, err.(awserr.Error).Code())
+
+	_, err = fakeS3.HeadObject(&aws_s3.HeadObjectInput{Bucket: aws.String(bucketName), Key: aws.String("key")})
+	assert.Equal(t, fakes_constants.ERR_CODE_NOT_FOUND, err.(awserr.Error).Code())
+}
+
+func Test_FakeS3_Bucket_Does_Not_Exist(t *testing.T) {
+	fakeS3 := fakes.NewFakeS3()
+
+	_, err := fakeS3.PutObject(&aws_s3.PutObjectInput{Bucket: aws.String(bucketName), Key: aws.String("key"), Body: bytes.NewReader(nil)})
+	assert.Equal(t, aws_s3.ErrCodeNoSuchBucket, err.(awserr.Error).Code())
+
+	_, err = fakeS3.CreateBucket(&aws_s3.CreateBucketInput{Bucket: aws.String(bucketName)})
+	assert.Nil(t, err)
+	putObject(t, fakeS3, "key", "body")
+}
+
+func Test_FakeS3_Delete_Objects(t *testing.T) {
+	fakeS3 := fakes.NewFakeS3(bucketName)
+	putObject(t, fakeS3, "first", "body")
+	putObject(t, fakeS3, "second", "body")
+	putObject(t, fakeS3, "third", "body")
+
+	output, err := fakeS3.DeleteObjects(&aws_s3.DeleteObjectsInput{
+		Bucket: aws.String(bucketName),
+		Delete: &aws_s3.Delete{Objects: []*aws_s3.ObjectIdentifier{{Key: aws.String("first")}, {Key: aws.String("second")}}},
+	})
+	assert.Nil(t, err)
+	assert.Len(t, output.Deleted, 2)
+	assert.Equal(t, []string{"third"}, fakeS3.Keys(bucketName))
+
+	output, err = fakeS3.DeleteObjects(&aws_s3.DeleteObjectsInput{
+		Bucket: aws.String(bucketName),
+		Delete: &aws_s3.Delete{Objects: []*aws_s3.ObjectIdentifier{{Key: aws.String("third")}}, Quiet: aws.Bool(true)},
+	})
+	assert.Nil(t, err)
+	assert.Empty(t, output.Deleted)
+	assert.Empty(t, fakeS3.Keys(bucketName))
+}
+
+func Test_FakeS3_Copy_Object(t *testing.T) {
+	fakeS3 := fakes.NewFakeS3(bucketName, "other-bucket")
+	_, err := fakeS3.PutObject(&aws_s3.PutObjectInput{
+		Bucket:   aws.String(bucketName),
+		Key:      aws.String("source key"),
+		Body:     bytes.NewReader([]byte("body")),
+		Metadata: map[string]*string{"Name": aws.String("value")},
+	})
+	assert.Nil(t, err)
+
+	_, err = fakeS3.CopyObject(&aws_s3.CopyObjectInput{
+		Bucket:     aws.String("other-bucket"),
+		Key:        aws.String("destination"),
+		CopySource: aws.String(bucketName + "/source%20key"),
+	})
+	assert.Nil(t, err)
+
+	body, ok := fakeS3.Object("other-bucket", "destination")
+	assert.True(t, ok)
+	assert.Equal(t, "body", string(body))
+
+	headOutput, err := fakeS3.HeadObject(&aws_s3.HeadObjectInput{Bucket: aws.String("other-bucket"), Key: aws.String("destination")})
+	assert.Nil(t, err)
+	assert.Equal(t, "value", *headOutput.Metadata["Name"])
+}
+
+func Test_FakeS3_List_Objects_Pages(t *testing.T) {
+	fakeS3 := fakes.NewFakeS3(bucketName)
+	for index := 0; index < 5; index++ {
+		putObject(t, fakeS3, fmt.Sprintf("payloads/%d", index), "body")
+	}
+	putObject(t, fakeS3, "other", "body")
+
+	var keys []string
+	pages := 0
+	err := fakeS3.ListObjectsV2PagesWithContext(context.Background(), &aws_s3.ListObjectsV2Input{
+		Bucket:  aws.String(bucketName),
+		Prefix:  aws.String("payloads/"),
+		MaxKeys: aws.Int64(2),
+	}, func(page *aws_s3.ListObjectsV2Output, lastPage bool) bool {
+		pages++
+		for _, object := range page.Contents {
+			keys = append(keys, *object.Key)
+		}
+		return true
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, pages)
+	assert.Equal(t, []string{"payloads/0", "payloads/1", "payloads/2", "payloads/3", "payloads/4"}, keys)
+}
+
+func Test_FakeS3_Advance_Ages_Objects(t *testing.T) {
+	fakeS3 := fakes.NewFakeS3(bucketName)
+	putObject(t, fakeS3, "key", "body")
+	fakeS3.Advance(time.Hour)
+	putObject(t, fakeS3, "newer", "body")
+
+	output, err := fakeS3.ListObjectsV2(&aws_s3.ListObjectsV2Input{Bucket: aws.String(bucketName)})
+	assert.Nil(t, err)
+	assert.True(t, output.Contents[1].LastModified.Sub(*output.Contents[0].LastModified) >= time.Hour)
+}
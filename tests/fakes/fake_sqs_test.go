@@ -0,0 +1,347 @@
+package tests
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/fakes"
+	fakes_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/fakes/constants"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client"
+
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/logging/mock"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	queueName  = "test-queue"
+	bucketName = "test-bucket"
+)
+
+type FakeSQSTestSuite struct {
+	suite.Suite
+
+	fakeSqs  *fakes.FakeSQS
+	fakeS3   *fakes.FakeS3
+	queueUrl string
+}
+
+func (s *FakeSQSTestSuite) SetupTest() {
+	s.fakeSqs = fakes.NewFakeSQS(queueName)
+	s.fakeS3 = fakes.NewFakeS3(bucketName)
+	s.queueUrl = s.fakeSqs.QueueUrl(queueName)
+}
+
+func (s *FakeSQSTestSuite) newClient(opts ...aws_extended_sqs_client.AwsExtendedSQSClientOption) *aws_extended_sqs_client.AwsExtendedSQSClient {
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	config.WithPayloadSupportEnabled(s.fakeS3, bucketName)
+
+	opts = append([]aws_extended_sqs_client.AwsExtendedSQSClientOption{aws_extended_sqs_client.WithLogger(NewMockLogger())}, opts...)
+
+	return aws_extended_sqs_client.NewExtendedSQSClient(s.fakeSqs, config, opts...)
+}
+
+func (s *FakeSQSTestSuite) createFifoQueue(contentBasedDeduplication bool) string {
+	output, err := s.fakeSqs.CreateQueue(&aws_sqs.CreateQueueInput{
+		QueueName: aws.String("test-queue.fifo"),
+		Attributes: map[string]*string{
+			aws_sqs.QueueAttributeNameFifoQueue:                 aws.String("true"),
+			aws_sqs.QueueAttributeNameContentBasedDeduplication: aws.String(strconv.FormatBool(contentBasedDeduplication)),
+		},
+	})
+	assert.Nil(s.T(), err)
+
+	return *output.QueueUrl
+}
+
+func (s *FakeSQSTestSuite) receive(visibilityTimeout int64) []*aws_sqs.Message {
+	output, err := s.fakeSqs.ReceiveMessage(&aws_sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(s.queueUrl),
+		MaxNumberOfMessages: aws.Int64(10),
+		VisibilityTimeout:   aws.Int64(visibilityTimeout),
+	})
+	assert.Nil(s.T(), err)
+
+	return output.Messages
+}
+
+func (s *FakeSQSTestSuite) Test_FakeSQS_ExtendedClient_Large_Payload_Round_Trip() {
+	client := s.newClient()
+	body := strings.Repeat("a", 300*1024)
+
+	_, err := client.SendMessage(&aws_sqs.SendMessageInput{QueueUrl: aws.String(s.queueUrl), MessageBody: aws.String(body)})
+	assert.Nil(s.T(), err)
+	assert.Len(s.T(), s.fakeS3.Keys(bucketName), 1)
+	assert.NotEqual(s.T(), body, *s.fakeSqs.Messages(s.queueUrl)[0].Body)
+
+	output, err := client.ReceiveMessage(&aws_sqs.ReceiveMessageInput{QueueUrl: aws.String(s.queueUrl)})
+	assert.Nil(s.T(), err)
+	assert.Len(s.T(), output.Messages, 1)
+	assert.Equal(s.T(), body, *output.Messages[0].Body)
+
+	_, err = client.DeleteMessage(&aws_sqs.DeleteMessageInput{QueueUrl: aws.String(s.queueUrl), ReceiptHandle: output.Messages[0].ReceiptHandle})
+	assert.Nil(s.T(), err)
+	assert.Empty(s.T(), s.fakeSqs.Messages(s.queueUrl))
+	assert.Empty(s.T(), s.fakeS3.Keys(bucketName))
+}
+
+func (s *FakeSQSTestSuite) Test_FakeSQS_ExtendedClient_Small_Payload_Not_Offloaded() {
+	client := s.newClient()
+
+	_, err := client.SendMessage(&aws_sqs.SendMessageInput{QueueUrl: aws.String(s.queueUrl), MessageBody: aws.String("small")})
+	assert.Nil(s.T(), err)
+	assert.Empty(s.T(), s.fakeS3.Keys(bucketName))
+
+	output, err := client.ReceiveMessage(&aws_sqs.ReceiveMessageInput{QueueUrl: aws.String(s.queueUrl)})
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "small", *output.Messages[0].Body)
+}
+
+func (s *FakeSQSTestSuite) Test_FakeSQS_Visibility_Timeout_Redelivers_With_New_Receipt_Handle() {
+	_, err := s.fakeSqs.SendMessage(&aws_sqs.SendMessageInput{QueueUrl: aws.String(s.queueUrl), MessageBody: aws.String("test")})
+	assert.Nil(s.T(), err)
+
+	first := s.receive(30)
+	assert.Len(s.T(), first, 1)
+	assert.Empty(s.T(), s.receive(30))
+
+	s.fakeSqs.Advance(31 * time.Second)
+
+	second := s.receive(30)
+	assert.Len(s.T(), second, 1)
+	assert.Equal(s.T(), *first[0].MessageId, *second[0].MessageId)
+	assert.NotEqual(s.T(), *first[0].ReceiptHandle, *second[0].ReceiptHandle)
+
+	_, err = s.fakeSqs.DeleteMessage(&aws_sqs.DeleteMessageInput{QueueUrl: aws.String(s.queueUrl), ReceiptHandle: first[0].ReceiptHandle})
+	assert.Equal(s.T(), aws_sqs.ErrCodeReceiptHandleIsInvalid, err.(awserr.Error).Code())
+
+	_, err = s.fakeSqs.DeleteMessage(&aws_sqs.DeleteMessageInput{QueueUrl: aws.String(s.queueUrl), ReceiptHandle: second[0].ReceiptHandle})
+	assert.Nil(s.T(), err)
+	assert.Empty(s.T(), s.fakeSqs.Messages(s.queueUrl))
+}
+
+func (s *FakeSQSTestSuite) Test_FakeSQS_Receive_Count_Attribute() {
+	_, err := s.fakeSqs.SendMessage(&aws_sqs.SendMessageInput{QueueUrl: aws.String(s.queueUrl), MessageBody: aws.String("test")})
+	assert.Nil(s.T(), err)
+
+	s.receive(0)
+	output, err := s.fakeSqs.ReceiveMessage(&aws_sqs.ReceiveMessageInput{
+		QueueUrl:       aws.String(s.queueUrl),
+		AttributeNames: []*string{aws.String(aws_sqs.MessageSystemAttributeNameApproximateReceiveCount)},
+	})
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "2", *output.Messages[0].Attributes[aws_sqs.MessageSystemAttributeNameApproximateReceiveCount])
+}
+
+func (s *FakeSQSTestSuite) Test_FakeSQS_Change_Message_Visibility() {
+	_, err := s.fakeSqs.SendMessage(&aws_sqs.SendMessageInput{QueueUrl: aws.String(s.queueUrl), MessageBody: aws.String("test")})
+	assert.Nil(s.T(), err)
+
+	messages := s.receive(30)
+
+	_, err = s.fakeSqs.ChangeMessageVisibility(&aws_sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(s.queueUrl),
+		ReceiptHandle:     messages[0].ReceiptHandle,
+		VisibilityTimeout: aws.Int64(0),
+	})
+	assert.Nil(s.T(), err)
+	assert.Len(s.T(), s.receive(30), 1)
+}
+
+func (s *FakeSQSTestSuite) Test_FakeSQS_Delay_Seconds() {
+	_, err := s.fakeSqs.SendMessage(&aws_sqs.SendMessageInput{QueueUrl: aws.String(s.queueUrl), MessageBody: aws.String("test"), DelaySeconds: aws.Int64(10)})
+	assert.Nil(s.T(), err)
+	assert.Empty(s.T(), s.receive(30))
+
+	s.fakeSqs.Advance(10 * time.Second)
+	assert.Len(s.T(), s.receive(30), 1)
+}
+
+func (s *FakeSQSTestSuite) Test_FakeSQS_Batch_Operations() {
+	sendOutput, err := s.fakeSqs.SendMessageBatch(&aws_sqs.SendMessageBatchInput{
+		QueueUrl: aws.String(s.queueUrl),
+		Entries: []*aws_sqs.SendMessageBatchRequestEntry{
+			{Id: aws.String("1"), MessageBody: aws.String("first")},
+			{Id: aws.String("2"), MessageBody: aws.String("")},
+			{Id: aws.String("3"), MessageBody: aws.String("third")},
+		},
+	})
+	assert.Nil(s.T(), err)
+	assert.Len(s.T(), sendOutput.Successful, 2)
+	assert.Len(s.T(), sendOutput.Failed, 1)
+	assert.Equal(s.T(), "2", *sendOutput.Failed[0].Id)
+
+	messages := s.receive(30)
+	assert.Len(s.T(), messages, 2)
+	assert.Equal(s.T(), "first", *messages[0].Body)
+	assert.Equal(s.T(), "third", *messages[1].Body)
+
+	deleteOutput, err := s.fakeSqs.DeleteMessageBatch(&aws_sqs.DeleteMessageBatchInput{
+		QueueUrl: aws.String(s.queueUrl),
+		Entries: []*aws_sqs.DeleteMessageBatchRequestEntry{
+			{Id: aws.String("1"), ReceiptHandle: messages[0].ReceiptHandle},
+			{Id: aws.String("2"), ReceiptHandle: aws.String("unknown")},
+		},
+	})
+	assert.Nil(s.T(), err)
+	assert.Len(s.T(), deleteOutput.Successful, 1)
+	assert.Len(s.T(), deleteOutput.Failed, 1)
+	assert.Len(s.T(), s.fakeSqs.Messages(s.queueUrl), 1)
+}
+
+func (s *FakeSQSTestSuite) Test_FakeSQS_Batch_Duplicate_Ids() {
+	_, err := s.fakeSqs.SendMessageBatch(&aws_sqs.SendMessageBatchInput{
+		QueueUrl: aws.String(s.queueUrl),
+		Entries: []*aws_sqs.SendMessageBatchRequestEntry{
+			{Id: aws.String("1"), MessageBody: aws.String("first")},
+			{Id: aws.String("1"), MessageBody: aws.String("second")},
+		},
+	})
+	assert.Equal(s.T(), aws_sqs.ErrCodeBatchEntryIdsNotDistinct, err.(awserr.Error).Code())
+}
+
+func (s *FakeSQSTestSuite) Test_FakeSQS_Message_Too_Large() {
+	_, err := s.fakeSqs.SendMessage(&aws_sqs.SendMessageInput{
+		QueueUrl:    aws.String(s.queueUrl),
+		MessageBody: aws.String(strings.Repeat("a", fakes_constants.MAX_MESSAGE_SIZE+1)),
+	})
+	assert.Equal(s.T(), fakes_constants.ERR_CODE_INVALID_PARAMETER_VALUE, err.(awserr.Error).Code())
+}
+
+func (s *FakeSQSTestSuite) Test_FakeSQS_Batch_Too_Large() {
+	// Each entry fits on its own but not the batch
+	entries := make([]*aws_sqs.SendMessageBatchRequestEntry, 2)
+	for index := range entries {
+		entries[index] = &aws_sqs.SendMessageBatchRequestEntry{
+			Id:          aws.String(strconv.Itoa(index)),
+			MessageBody: aws.String(strings.Repeat("a", fakes_constants.MAX_MESSAGE_SIZE/2+1)),
+		}
+	}
+
+	_, err := s.fakeSqs.SendMessageBatch(&aws_sqs.SendMessageBatchInput{QueueUrl: aws.String(s.queueUrl), Entries: entries})
+	assert.Equal(s.T(), aws_sqs.ErrCodeBatchRequestTooLong, err.(awserr.Error).Code())
+	assert.Empty(s.T(), s.fakeSqs.Messages(s.queueUrl))
+}
+
+func (s *FakeSQSTestSuite) Test_FakeSQS_Fifo_Message_Group_Id_Required() {
+	queueUrl := s.createFifoQueue(false)
+
+	_, err := s.fakeSqs.SendMessage(&aws_sqs.SendMessageInput{
+		QueueUrl:               aws.String(queueUrl),
+		MessageBody:            aws.String("test"),
+		MessageDeduplicationId: aws.String("1"),
+	})
+	assert.Equal(s.T(), "MissingParameter", err.(awserr.Error).Code())
+
+	_, err = s.fakeSqs.SendMessage(&aws_sqs.SendMessageInput{
+		QueueUrl:       aws.String(queueUrl),
+		MessageBody:    aws.String("test"),
+		MessageGroupId: aws.String("group"),
+	})
+	assert.Equal(s.T(), fakes_constants.ERR_CODE_INVALID_PARAMETER_VALUE, err.(awserr.Error).Code())
+}
+
+func (s *FakeSQSTestSuite) Test_FakeSQS_Fifo_Deduplication() {
+	queueUrl := s.createFifoQueue(true)
+
+	var messageIds []string
+	for _, deduplicationId := range []*string{nil, nil, aws.String("explicit")} {
+		output, err := s.fakeSqs.SendMessage(&aws_sqs.SendMessageInput{
+			QueueUrl:               aws.String(queueUrl),
+			MessageBody:            aws.String("test"),
+			MessageGroupId:         aws.String("group"),
+			MessageDeduplicationId: deduplicationId,
+		})
+		assert.Nil(s.T(), err)
+		messageIds = append(messageIds, *output.MessageId)
+	}
+
+	assert.Equal(s.T(), messageIds[0], messageIds[1])
+	assert.NotEqual(s.T(), messageIds[0], messageIds[2])
+	assert.Len(s.T(), s.fakeSqs.Messages(queueUrl), 2)
+
+	s.fakeSqs.Advance(fakes_constants.DEDUPLICATION_INTERVAL)
+
+	_, err := s.fakeSqs.SendMessage(&aws_sqs.SendMessageInput{QueueUrl: aws.String(queueUrl), MessageBody: aws.String("test"), MessageGroupId: aws.String("group")})
+	assert.Nil(s.T(), err)
+	assert.Len(s.T(), s.fakeSqs.Messages(queueUrl), 3)
+}
+
+func (s *FakeSQSTestSuite) Test_FakeSQS_Fifo_Message_Group_Locked_While_In_Flight() {
+	queueUrl := s.createFifoQueue(true)
+
+	for _, message := range []struct{ group, body string }{{"a", "a1"}, {"b", "b1"}, {"a", "a2"}} {
+		_, err := s.fakeSqs.SendMessage(&aws_sqs.SendMessageInput{
+			QueueUrl:       aws.String(queueUrl),
+			MessageBody:    aws.String(message.body),
+			MessageGroupId: aws.String(message.group),
+		})
+		assert.Nil(s.T(), err)
+	}
+
+	receive := func() []string {
+		output, err := s.fakeSqs.ReceiveMessage(&aws_sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(queueUrl),
+			MaxNumberOfMessages: aws.Int64(1),
+			AttributeNames:      []*string{aws.String(aws_sqs.MessageSystemAttributeNameSequenceNumber)},
+		})
+		assert.Nil(s.T(), err)
+
+		var bodies []string
+		for _, message := range output.Messages {
+			assert.NotEmpty(s.T(), *message.Attributes[aws_sqs.MessageSystemAttributeNameSequenceNumber])
+			bodies = append(bodies, *message.Body)
+		}
+		return bodies
+	}
+
+	assert.Equal(s.T(), []string{"a1"}, receive())
+	// a2 waits for a1, which is in flight
+	assert.Equal(s.T(), []string{"b1"}, receive())
+	assert.Empty(s.T(), receive())
+
+	s.fakeSqs.Advance(fakes_constants.DEFAULT_VISIBILITY_TIMEOUT * time.Second)
+	assert.Equal(s.T(), []string{"a1"}, receive())
+}
+
+func (s *FakeSQSTestSuite) Test_FakeSQS_Queue_Does_Not_Exist() {
+	_, err := s.fakeSqs.SendMessage(&aws_sqs.SendMessageInput{QueueUrl: aws.String("unknown"), MessageBody: aws.String("test")})
+	assert.Equal(s.T(), aws_sqs.ErrCodeQueueDoesNotExist, err.(awserr.Error).Code())
+}
+
+func (s *FakeSQSTestSuite) Test_FakeSQS_Long_Polling_Receives_Message_Sent_While_Waiting() {
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		s.fakeSqs.SendMessage(&aws_sqs.SendMessageInput{QueueUrl: aws.String(s.queueUrl), MessageBody: aws.String("test")})
+	}()
+
+	output, err := s.fakeSqs.ReceiveMessageWithContext(context.Background(), &aws_sqs.ReceiveMessageInput{
+		QueueUrl:        aws.String(s.queueUrl),
+		WaitTimeSeconds: aws.Int64(5),
+	})
+	assert.Nil(s.T(), err)
+	assert.Len(s.T(), output.Messages, 1)
+}
+
+func (s *FakeSQSTestSuite) Test_FakeSQS_Long_Polling_Stops_On_Context_Done() {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := s.fakeSqs.ReceiveMessageWithContext(ctx, &aws_sqs.ReceiveMessageInput{
+		QueueUrl:        aws.String(s.queueUrl),
+		WaitTimeSeconds: aws.Int64(20),
+	})
+	assert.Equal(s.T(), context.DeadlineExceeded, err)
+}
+
+func TestFakeSQSTestSuite(t *testing.T) {
+	suite.Run(t, new(FakeSQSTestSuite))
+}
@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/internal/batcher"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Records the batches sent, optionally holding each of them until released
+type batchRecorder struct {
+	release chan struct{}
+
+	mu          sync.Mutex
+	batches     [][]interface{}
+	inFlight    int
+	maxInFlight int
+}
+
+func (r *batchRecorder) send(key string, items []interface{}) {
+	r.mu.Lock()
+	r.inFlight++
+	if r.inFlight > r.maxInFlight {
+		r.maxInFlight = r.inFlight
+	}
+	r.batches = append(r.batches, items)
+	r.mu.Unlock()
+
+	if r.release != nil {
+		<-r.release
+	}
+
+	r.mu.Lock()
+	r.inFlight--
+	r.mu.Unlock()
+}
+
+func (r *batchRecorder) sentBatches() [][]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.batches
+}
+
+func Test_Batcher_Add_Flushed_On_Batch_Size_And_Bytes(t *testing.T) {
+	recorder := &batchRecorder{}
+	b := batcher.NewBatcher(recorder.send, batcher.WithMaxBatchSize(3), batcher.WithMaxBatchBytes(10), batcher.WithLinger(time.Hour))
+
+	b.Add("test-key", "item-1", 1, false)
+	b.Add("test-key", "item-2", 1, false)
+	b.Add("test-key", "item-3", 1, false)
+	// would take the batch over its bytes, so the previous batch is flushed first
+	b.Add("test-key", "item-4", 6, false)
+	b.Add("test-key", "item-5", 6, false)
+
+	assert.Nil(t, b.Flush(context.Background()))
+	assert.ElementsMatch(t, [][]interface{}{{"item-1", "item-2", "item-3"}, {"item-4"}, {"item-5"}}, recorder.sentBatches())
+}
+
+func Test_Batcher_Add_Flushed_On_Linger(t *testing.T) {
+	recorder := &batchRecorder{}
+	b := batcher.NewBatcher(recorder.send, batcher.WithLinger(10*time.Millisecond))
+
+	b.Add("test-key", "item-1", 0, false)
+	b.Add("other-key", "item-2", 0, false)
+
+	assert.Eventually(t, func() bool {
+		return len(recorder.sentBatches()) == 2
+	}, time.Second, 5*time.Millisecond)
+}
+
+func Test_Batcher_Add_Ordered_Batches_Sent_One_At_A_Time(t *testing.T) {
+	recorder := &batchRecorder{release: make(chan struct{})}
+	b := batcher.NewBatcher(recorder.send, batcher.WithMaxBatchSize(1))
+
+	for _, item := range []string{"item-1", "item-2", "item-3"} {
+		b.Add("test-key", item, 0, true)
+	}
+
+	for i := 0; i < 3; i++ {
+		recorder.release <- struct{}{}
+	}
+
+	assert.Nil(t, b.Flush(context.Background()))
+	assert.Equal(t, 1, recorder.maxInFlight)
+	assert.Equal(t, [][]interface{}{{"item-1"}, {"item-2"}, {"item-3"}}, recorder.sentBatches())
+}
+
+func Test_Batcher_Add_Unordered_Batches_Sent_Concurrently(t *testing.T) {
+	recorder := &batchRecorder{release: make(chan struct{})}
+	b := batcher.NewBatcher(recorder.send, batcher.WithMaxBatchSize(1))
+
+	b.Add("test-key", "item-1", 0, false)
+	b.Add("test-key", "item-2", 0, false)
+
+	assert.Eventually(t, func() bool {
+		return len(recorder.sentBatches()) == 2
+	}, time.Second, 5*time.Millisecond)
+
+	close(recorder.release)
+
+	assert.Nil(t, b.Flush(context.Background()))
+	assert.Equal(t, 2, recorder.maxInFlight)
+}
+
+func Test_Batcher_Flush_Waits_Until_Ctx_Done(t *testing.T) {
+	recorder := &batchRecorder{release: make(chan struct{})}
+	b := batcher.NewBatcher(recorder.send, batcher.WithLinger(time.Hour))
+
+	b.Add("test-key", "item-1", 0, false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, b.Flush(ctx))
+
+	close(recorder.release)
+	assert.Nil(t, b.Flush(context.Background()))
+}
+
+func Test_Batcher_Add_Rejected_Once_Closed(t *testing.T) {
+	recorder := &batchRecorder{}
+	b := batcher.NewBatcher(recorder.send, batcher.WithLinger(time.Hour))
+
+	assert.True(t, b.Add("test-key", "item-1", 0, false))
+	assert.Nil(t, b.Close(context.Background()))
+	assert.False(t, b.Add("test-key", "item-2", 0, false))
+
+	assert.Equal(t, [][]interface{}{{"item-1"}}, recorder.sentBatches())
+}
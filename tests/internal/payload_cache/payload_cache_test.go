@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/internal/payload_cache"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func Test_PayloadCache_Get_Put(t *testing.T) {
+	cache := payload_cache.NewPayloadCache(100)
+
+	_, ok := cache.Get("test-bucket", "test-key")
+	assert.False(t, ok)
+
+	cache.Put("test-bucket", "test-key", "payload")
+
+	payload, ok := cache.Get("test-bucket", "test-key")
+	assert.True(t, ok)
+	assert.Equal(t, "payload", payload)
+	assert.Equal(t, len("payload"), cache.Bytes())
+
+	// Keyed by bucket and key
+	_, ok = cache.Get("other-bucket", "test-key")
+	assert.False(t, ok)
+}
+
+func Test_PayloadCache_Put_Replaces(t *testing.T) {
+	cache := payload_cache.NewPayloadCache(100)
+
+	cache.Put("test-bucket", "test-key", "payload")
+	cache.Put("test-bucket", "test-key", "other payload")
+
+	payload, ok := cache.Get("test-bucket", "test-key")
+	assert.True(t, ok)
+	assert.Equal(t, "other payload", payload)
+	assert.Equal(t, len("other payload"), cache.Bytes())
+}
+
+func Test_PayloadCache_Put_Evicts_Least_Recently_Used(t *testing.T) {
+	cache := payload_cache.NewPayloadCache(30)
+
+	cache.Put("test-bucket", "key-1", strings.Repeat("a", 10))
+	cache.Put("test-bucket", "key-2", strings.Repeat("b", 10))
+	cache.Put("test-bucket", "key-3", strings.Repeat("c", 10))
+
+	// key-1 becomes the most recently used, key-2 is evicted first
+	_, ok := cache.Get("test-bucket", "key-1")
+	assert.True(t, ok)
+
+	cache.Put("test-bucket", "key-4", strings.Repeat("d", 15))
+
+	_, ok = cache.Get("test-bucket", "key-2")
+	assert.False(t, ok)
+	_, ok = cache.Get("test-bucket", "key-3")
+	assert.False(t, ok)
+	_, ok = cache.Get("test-bucket", "key-1")
+	assert.True(t, ok)
+	_, ok = cache.Get("test-bucket", "key-4")
+	assert.True(t, ok)
+	assert.Equal(t, 25, cache.Bytes())
+}
+
+func Test_PayloadCache_Put_Too_Large(t *testing.T) {
+	cache := payload_cache.NewPayloadCache(10)
+
+	cache.Put("test-bucket", "key-1", "small")
+	cache.Put("test-bucket", "key-2", strings.Repeat("a", 11))
+
+	_, ok := cache.Get("test-bucket", "key-2")
+	assert.False(t, ok)
+
+	// Nothing is evicted for a payload which is not cached
+	_, ok = cache.Get("test-bucket", "key-1")
+	assert.True(t, ok)
+}
+
+func Test_PayloadCache_Get_Expired(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	cache := payload_cache.NewPayloadCache(100, payload_cache.WithTTL(time.Minute), payload_cache.WithClock(clock.Now))
+
+	cache.Put("test-bucket", "test-key", "payload")
+
+	clock.now = clock.now.Add(59 * time.Second)
+	_, ok := cache.Get("test-bucket", "test-key")
+	assert.True(t, ok)
+
+	clock.now = clock.now.Add(time.Second)
+	_, ok = cache.Get("test-bucket", "test-key")
+	assert.False(t, ok)
+	assert.Equal(t, 0, cache.Bytes())
+}
+
+func Test_PayloadCache_Get_No_TTL(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	cache := payload_cache.NewPayloadCache(100, payload_cache.WithTTL(0), payload_cache.WithClock(clock.Now))
+
+	cache.Put("test-bucket", "test-key", "payload")
+
+	clock.now = clock.now.Add(24 * time.Hour)
+	_, ok := cache.Get("test-bucket", "test-key")
+	assert.True(t, ok)
+}
+
+func Test_PayloadCache_Invalidate(t *testing.T) {
+	cache := payload_cache.NewPayloadCache(100)
+
+	cache.Put("test-bucket", "test-key", "payload")
+	cache.Invalidate("test-bucket", "test-key")
+	cache.Invalidate("test-bucket", "missing-key")
+
+	_, ok := cache.Get("test-bucket", "test-key")
+	assert.False(t, ok)
+	assert.Equal(t, 0, cache.Bytes())
+}
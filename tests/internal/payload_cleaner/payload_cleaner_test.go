@@ -0,0 +1,243 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/internal/payload_cleaner"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/internal/payload_store"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/internal/payload_store/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/logging/mock"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const messagePointer = "[\"software.amazon.payloadoffloading.PayloadS3Pointer\",{\"s3BucketName\":\"test-bucket\",\"s3Key\":\"test-key\"}]"
+
+type failureRecorder struct {
+	mu       sync.Mutex
+	pointers []string
+	errs     []error
+}
+
+func (r *failureRecorder) handle(messagePointer string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pointers = append(r.pointers, messagePointer)
+	r.errs = append(r.errs, err)
+}
+
+func (r *failureRecorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.pointers)
+}
+
+// Counts the deletions from the goroutine of the worker, as the calls of the mock cannot be read concurrently
+func countDeletes(call *mock.Call) *int32 {
+	var count int32
+	call.Run(func(args mock.Arguments) {
+		atomic.AddInt32(&count, 1)
+	})
+
+	return &count
+}
+
+func newPayloadCleaner(mockS3 *MockS3, recorder *failureRecorder, opts ...payload_cleaner.PayloadCleanerOption) *payload_cleaner.PayloadCleaner {
+	opts = append([]payload_cleaner.PayloadCleanerOption{
+		payload_cleaner.WithLogger(NewMockLogger()),
+		payload_cleaner.WithRetryBackoff(time.Millisecond),
+		payload_cleaner.WithFailureHandler(recorder.handle),
+	}, opts...)
+
+	return payload_cleaner.NewPayloadCleaner(payload_store.NewPayloadStore(mockS3, "test-bucket"), opts...)
+}
+
+func Test_PayloadCleaner_Enqueue_Success(t *testing.T) {
+	mockS3 := new(MockS3)
+	recorder := &failureRecorder{}
+	cleaner := newPayloadCleaner(mockS3, recorder)
+
+	mockS3.On("DeleteObjectWithContext", mock.Anything, &aws_s3.DeleteObjectInput{
+		Bucket: aws.String("test-bucket"),
+		Key:    aws.String("test-key"),
+	}).Return(&aws_s3.DeleteObjectOutput{}, nil).Once()
+
+	cleaner.Enqueue(messagePointer)
+
+	assert.Nil(t, cleaner.Close(context.Background()))
+	mockS3.AssertExpectations(t)
+	assert.Equal(t, 0, recorder.count())
+}
+
+func Test_PayloadCleaner_Enqueue_Success_Retried(t *testing.T) {
+	mockS3 := new(MockS3)
+	recorder := &failureRecorder{}
+	cleaner := newPayloadCleaner(mockS3, recorder, payload_cleaner.WithMaxRetries(2))
+
+	mockS3.On("DeleteObjectWithContext", mock.Anything, mock.Anything).Return(
+		&aws_s3.DeleteObjectOutput{},
+		awserr.New("InternalError", "We encountered an internal error. Please try again.", nil),
+	).Once()
+	deletes := countDeletes(mockS3.On("DeleteObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.DeleteObjectOutput{}, nil).Once())
+
+	cleaner.Enqueue(messagePointer)
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(deletes) == 1
+	}, time.Second, time.Millisecond)
+	assert.Nil(t, cleaner.Close(context.Background()))
+	mockS3.AssertExpectations(t)
+	assert.Equal(t, 0, recorder.count())
+}
+
+func Test_PayloadCleaner_Enqueue_Failed_Retries_Exhausted(t *testing.T) {
+	mockS3 := new(MockS3)
+	recorder := &failureRecorder{}
+	cleaner := newPayloadCleaner(mockS3, recorder, payload_cleaner.WithMaxRetries(2))
+
+	mockS3.On("DeleteObjectWithContext", mock.Anything, mock.Anything).Return(
+		&aws_s3.DeleteObjectOutput{},
+		awserr.New("AccessDenied", "Access Denied", nil),
+	).Times(3)
+
+	cleaner.Enqueue(messagePointer)
+
+	assert.Eventually(t, func() bool {
+		return recorder.count() == 1
+	}, time.Second, time.Millisecond)
+	assert.Nil(t, cleaner.Close(context.Background()))
+	mockS3.AssertExpectations(t)
+	assert.Equal(t, messagePointer, recorder.pointers[0])
+	assert.NotNil(t, recorder.errs[0])
+}
+
+func Test_PayloadCleaner_Enqueue_Success_Grace_Period(t *testing.T) {
+	mockS3 := new(MockS3)
+	recorder := &failureRecorder{}
+	cleaner := newPayloadCleaner(mockS3, recorder, payload_cleaner.WithGracePeriod(50*time.Millisecond))
+
+	deletes := countDeletes(mockS3.On("DeleteObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.DeleteObjectOutput{}, nil).Once())
+
+	cleaner.Enqueue(messagePointer)
+
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, int32(0), atomic.LoadInt32(deletes))
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(deletes) == 1
+	}, time.Second, time.Millisecond)
+	assert.Nil(t, cleaner.Close(context.Background()))
+	mockS3.AssertExpectations(t)
+}
+
+func Test_PayloadCleaner_Enqueue_Success_Retry_Does_Not_Block_Worker(t *testing.T) {
+	mockS3 := new(MockS3)
+	recorder := &failureRecorder{}
+	cleaner := newPayloadCleaner(mockS3, recorder, payload_cleaner.WithMaxRetries(1), payload_cleaner.WithRetryBackoff(time.Hour))
+
+	mockS3.On("DeleteObjectWithContext", mock.Anything, &aws_s3.DeleteObjectInput{
+		Bucket: aws.String("test-bucket"),
+		Key:    aws.String("test-key"),
+	}).Return(&aws_s3.DeleteObjectOutput{}, awserr.New("InternalError", "We encountered an internal error. Please try again.", nil)).Once()
+	deletes := countDeletes(mockS3.On("DeleteObjectWithContext", mock.Anything, &aws_s3.DeleteObjectInput{
+		Bucket: aws.String("test-bucket"),
+		Key:    aws.String("other-key"),
+	}).Return(&aws_s3.DeleteObjectOutput{}, nil).Once())
+
+	// The single worker is free while the first payload waits for its retry
+	cleaner.Enqueue(messagePointer)
+	cleaner.Enqueue(strings.Replace(messagePointer, "test-key", "other-key", 1))
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(deletes) == 1
+	}, time.Second, time.Millisecond)
+
+	mockS3.On("DeleteObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.DeleteObjectOutput{}, nil).Once()
+	assert.Nil(t, cleaner.Close(context.Background()))
+	mockS3.AssertExpectations(t)
+	assert.Equal(t, 0, recorder.count())
+}
+
+func Test_PayloadCleaner_Failure_Handler_Calls_Close(t *testing.T) {
+	mockS3 := new(MockS3)
+
+	var cleaner *payload_cleaner.PayloadCleaner
+	closed := make(chan error, 2)
+	cleaner = payload_cleaner.NewPayloadCleaner(payload_store.NewPayloadStore(mockS3, "test-bucket"),
+		payload_cleaner.WithLogger(NewMockLogger()),
+		payload_cleaner.WithMaxRetries(0),
+		payload_cleaner.WithFailureHandler(func(messagePointer string, err error) {
+			closed <- cleaner.Close(context.Background())
+		}),
+	)
+
+	mockS3.On("DeleteObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.DeleteObjectOutput{}, awserr.New("AccessDenied", "Access Denied", nil)).Once()
+
+	// Failed by the worker, then by Enqueue on the closed cleaner
+	cleaner.Enqueue(messagePointer)
+	assert.Nil(t, <-closed)
+
+	cleaner.Enqueue(messagePointer)
+	assert.Nil(t, <-closed)
+
+	mockS3.AssertExpectations(t)
+}
+
+func Test_PayloadCleaner_Close_Success_Skips_Grace_Period(t *testing.T) {
+	mockS3 := new(MockS3)
+	recorder := &failureRecorder{}
+	cleaner := newPayloadCleaner(mockS3, recorder, payload_cleaner.WithGracePeriod(time.Hour))
+
+	mockS3.On("DeleteObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.DeleteObjectOutput{}, nil).Twice()
+
+	cleaner.Enqueue(messagePointer)
+	cleaner.Enqueue(messagePointer)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	assert.Nil(t, cleaner.Close(ctx))
+	mockS3.AssertExpectations(t)
+}
+
+func Test_PayloadCleaner_Enqueue_Failed_Queue_Full(t *testing.T) {
+	mockS3 := new(MockS3)
+	recorder := &failureRecorder{}
+	cleaner := newPayloadCleaner(mockS3, recorder, payload_cleaner.WithGracePeriod(time.Hour), payload_cleaner.WithQueueSize(1))
+
+	deletes := countDeletes(mockS3.On("DeleteObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.DeleteObjectOutput{}, nil))
+
+	// One is waiting in the worker at most, the other in the queue
+	cleaner.Enqueue(messagePointer)
+	cleaner.Enqueue(messagePointer)
+	cleaner.Enqueue(messagePointer)
+
+	assert.GreaterOrEqual(t, recorder.count(), 1)
+
+	assert.Nil(t, cleaner.Close(context.Background()))
+	assert.Equal(t, 3, recorder.count()+int(atomic.LoadInt32(deletes)))
+}
+
+func Test_PayloadCleaner_Enqueue_Failed_Closed(t *testing.T) {
+	mockS3 := new(MockS3)
+	recorder := &failureRecorder{}
+	cleaner := newPayloadCleaner(mockS3, recorder)
+
+	assert.Nil(t, cleaner.Close(context.Background()))
+
+	cleaner.Enqueue(messagePointer)
+
+	assert.Equal(t, 1, recorder.count())
+	mockS3.AssertNotCalled(t, "DeleteObjectWithContext", mock.Anything, mock.Anything)
+}
@@ -28,3 +28,32 @@ func (m *MockS3) DeleteObjectWithContext(ctx aws.Context, input *aws_s3.DeleteOb
 	args := m.Called(ctx, input)
 	return args.Get(0).(*aws_s3.DeleteObjectOutput), args.Error(1)
 }
+
+func (m *MockS3) DeleteObjectsWithContext(ctx aws.Context, input *aws_s3.DeleteObjectsInput, option ...request.Option) (*aws_s3.DeleteObjectsOutput, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(*aws_s3.DeleteObjectsOutput), args.Error(1)
+}
+
+func (m *MockS3) HeadObjectWithContext(ctx aws.Context, input *aws_s3.HeadObjectInput, option ...request.Option) (*aws_s3.HeadObjectOutput, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(*aws_s3.HeadObjectOutput), args.Error(1)
+}
+
+func (m *MockS3) CopyObjectWithContext(ctx aws.Context, input *aws_s3.CopyObjectInput, option ...request.Option) (*aws_s3.CopyObjectOutput, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(*aws_s3.CopyObjectOutput), args.Error(1)
+}
+
+// pages is the list of *aws_s3.ListObjectsV2Output given to fn until it returns false
+func (m *MockS3) ListObjectsV2PagesWithContext(ctx aws.Context, input *aws_s3.ListObjectsV2Input, fn func(*aws_s3.ListObjectsV2Output, bool) bool, option ...request.Option) error {
+	args := m.Called(ctx, input)
+
+	pages := args.Get(0).([]*aws_s3.ListObjectsV2Output)
+	for index, page := range pages {
+		if !fn(page, index == len(pages)-1) {
+			break
+		}
+	}
+
+	return args.Error(1)
+}
@@ -1,8 +1,10 @@
 package tests
 
 import (
+	std_errors "errors"
 	"testing"
 
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors"
 	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/internal/payload_store"
 
 	"github.com/stretchr/testify/assert"
@@ -19,6 +21,17 @@ func Test_PayloadPointer_ToJson_Success(t *testing.T) {
 	assert.Equal(t, "[\"software.amazon.payloadoffloading.PayloadS3Pointer\",{\"s3BucketName\":\"test-bucket\",\"s3Key\":\"test-key\"}]", json_str)
 }
 
+func Test_NewMessagePointer(t *testing.T) {
+	pointer := payload_store.PayloadS3Pointer{
+		S3BucketName: "test-bucket",
+		S3Key:        "prefix/\"quoted\" key",
+	}
+
+	json_str, err := pointer.ToJson()
+	assert.Nil(t, err)
+	assert.Equal(t, json_str, payload_store.NewMessagePointer("test-bucket", "prefix/\"quoted\" key"))
+}
+
 func Test_PayloadPointer_FromJson_Success(t *testing.T) {
 	pointerStr := "[\"software.amazon.payloadoffloading.PayloadS3Pointer\",{\"s3BucketName\":\"test-bucket\",\"s3Key\":\"test-key\"}]"
 
@@ -44,3 +57,38 @@ func Test_PayloadPointer_FromJson_Invalid_Json_Syntax(t *testing.T) {
 	assert.Nil(t, pointer)
 	assert.NotNil(t, err)
 }
+
+func Test_PayloadPointer_FromJson_Known_Variants(t *testing.T) {
+	pointerStrs := map[string]string{
+		"legacy java class":  "[\"com.amazon.sqs.javamessaging.MessageS3Pointer\",{\"s3BucketName\":\"test-bucket\",\"s3Key\":\"test-key\"}]",
+		"python separators":  "[\"software.amazon.payloadoffloading.PayloadS3Pointer\", {\"s3BucketName\": \"test-bucket\", \"s3Key\": \"test-key\"}]",
+		"fields reordered":   "[\"software.amazon.payloadoffloading.PayloadS3Pointer\",{\"s3Key\":\"test-key\",\"s3BucketName\":\"test-bucket\"}]",
+		"extra fields":       "[\"software.amazon.payloadoffloading.PayloadS3Pointer\",{\"s3BucketName\":\"test-bucket\",\"s3Key\":\"test-key\",\"size\":1}]",
+		"field name case":    "[\"software.amazon.payloadoffloading.PayloadS3Pointer\",{\"S3BucketName\":\"test-bucket\",\"S3Key\":\"test-key\"}]",
+		"surrounding spaces": "\n [\"software.amazon.payloadoffloading.PayloadS3Pointer\",{\"s3BucketName\":\"test-bucket\",\"s3Key\":\"test-key\"}] \n",
+	}
+
+	for name, pointerStr := range pointerStrs {
+		pointer, err := payload_store.FromJson(pointerStr)
+		assert.Nil(t, err, name)
+		assert.Equal(t, &payload_store.PayloadS3Pointer{S3BucketName: "test-bucket", S3Key: "test-key"}, pointer, name)
+	}
+}
+
+func Test_PayloadPointer_FromJson_Invalid_Variants(t *testing.T) {
+	pointerStrs := map[string]string{
+		"unknown class name":      "[\"com.example.PayloadS3Pointer\",{\"s3BucketName\":\"test-bucket\",\"s3Key\":\"test-key\"}]",
+		"empty class name":        "[\"\",{\"s3BucketName\":\"test-bucket\",\"s3Key\":\"test-key\"}]",
+		"class name not a string": "[{\"s3BucketName\":\"test-bucket\",\"s3Key\":\"test-key\"},{\"s3BucketName\":\"test-bucket\",\"s3Key\":\"test-key\"}]",
+		"missing s3 key":          "[\"software.amazon.payloadoffloading.PayloadS3Pointer\",{\"s3BucketName\":\"test-bucket\"}]",
+		"missing s3 bucket name":  "[\"software.amazon.payloadoffloading.PayloadS3Pointer\",{\"s3Key\":\"test-key\"}]",
+		"too many elements":       "[\"software.amazon.payloadoffloading.PayloadS3Pointer\",{\"s3BucketName\":\"test-bucket\",\"s3Key\":\"test-key\"},1]",
+		"s3 key not a string":     "[\"software.amazon.payloadoffloading.PayloadS3Pointer\",{\"s3BucketName\":\"test-bucket\",\"s3Key\":1}]",
+	}
+
+	for name, pointerStr := range pointerStrs {
+		pointer, err := payload_store.FromJson(pointerStr)
+		assert.Nil(t, pointer, name)
+		assert.True(t, std_errors.As(err, &errors.PointerFormatError{}), name)
+	}
+}
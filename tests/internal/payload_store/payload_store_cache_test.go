@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/internal/payload_cache"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/internal/payload_store"
+	metrics_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/metrics/constants"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/internal/payload_store/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/metrics/mock"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const cachedMessagePointer = "[\"software.amazon.payloadoffloading.PayloadS3Pointer\",{\"s3BucketName\":\"test-bucket\",\"s3Key\":\"test-key\"}]"
+
+func newCachedPayloadStore(mockS3 *MockS3, mockMetricsRecorder *MockMetricsRecorder) *payload_store.PayloadStore {
+	mockMetricsRecorder.On("IncrementCounter", metrics_constants.METRIC_S3_OPERATIONS, mock.Anything).Return()
+	mockMetricsRecorder.On("ObserveHistogram", metrics_constants.METRIC_S3_OPERATION_DURATION, mock.Anything, mock.Anything).Return()
+
+	return payload_store.NewPayloadStore(mockS3, "test-bucket",
+		payload_store.WithPayloadCache(payload_cache.NewPayloadCache(1024)),
+		payload_store.WithMetricsRecorder(mockMetricsRecorder),
+	)
+}
+
+func expectCacheLookup(mockMetricsRecorder *MockMetricsRecorder, result string) *mock.Call {
+	return mockMetricsRecorder.On("IncrementCounter", metrics_constants.METRIC_PAYLOAD_CACHE_LOOKUPS, map[string]string{
+		metrics_constants.LABEL_RESULT: result,
+	}).Return()
+}
+
+func expectGetObject(mockS3 *MockS3, payload string) *mock.Call {
+	return mockS3.On("GetObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.GetObjectOutput{
+		Body: ioutil.NopCloser(strings.NewReader(payload)),
+	}, nil).Once()
+}
+
+func Test_PayloadStore_GetOriginalPayload_Success_Cached(t *testing.T) {
+	mockS3 := new(MockS3)
+	mockMetricsRecorder := new(MockMetricsRecorder)
+	payloadStore := newCachedPayloadStore(mockS3, mockMetricsRecorder)
+
+	expectGetObject(mockS3, "test-body")
+	expectCacheLookup(mockMetricsRecorder, metrics_constants.RESULT_MISS).Once()
+	expectCacheLookup(mockMetricsRecorder, metrics_constants.RESULT_HIT).Twice()
+
+	for i := 0; i < 3; i++ {
+		payload, err := payloadStore.GetOriginalPayload(cachedMessagePointer)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "test-body", payload)
+	}
+
+	mockS3.AssertExpectations(t)
+	mockMetricsRecorder.AssertExpectations(t)
+}
+
+func Test_PayloadStore_GetOriginalPayload_Failed_Not_Cached(t *testing.T) {
+	mockS3 := new(MockS3)
+	mockMetricsRecorder := new(MockMetricsRecorder)
+	payloadStore := newCachedPayloadStore(mockS3, mockMetricsRecorder)
+
+	mockS3.On("GetObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.GetObjectOutput{},
+		awserr.New(aws_s3.ErrCodeNoSuchKey, "The specified key does not exist.", nil)).Twice()
+	expectCacheLookup(mockMetricsRecorder, metrics_constants.RESULT_MISS).Twice()
+
+	for i := 0; i < 2; i++ {
+		_, err := payloadStore.GetOriginalPayload(cachedMessagePointer)
+
+		assert.NotNil(t, err)
+	}
+
+	mockS3.AssertExpectations(t)
+	mockMetricsRecorder.AssertExpectations(t)
+}
+
+func Test_PayloadStore_DeleteOriginalPayload_Invalidates_Cache(t *testing.T) {
+	mockS3 := new(MockS3)
+	mockMetricsRecorder := new(MockMetricsRecorder)
+	payloadStore := newCachedPayloadStore(mockS3, mockMetricsRecorder)
+
+	expectGetObject(mockS3, "test-body")
+	expectGetObject(mockS3, "test-body")
+	mockS3.On("DeleteObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.DeleteObjectOutput{}, nil).Once()
+	expectCacheLookup(mockMetricsRecorder, metrics_constants.RESULT_MISS).Twice()
+
+	_, err := payloadStore.GetOriginalPayload(cachedMessagePointer)
+	assert.Nil(t, err)
+
+	assert.Nil(t, payloadStore.DeleteOriginalPayload(cachedMessagePointer))
+
+	_, err = payloadStore.GetOriginalPayload(cachedMessagePointer)
+	assert.Nil(t, err)
+
+	mockS3.AssertExpectations(t)
+	mockMetricsRecorder.AssertExpectations(t)
+}
+
+func Test_PayloadStore_DeleteOriginalPayloadsWithContext_Invalidates_Cache(t *testing.T) {
+	mockS3 := new(MockS3)
+	mockMetricsRecorder := new(MockMetricsRecorder)
+	payloadStore := newCachedPayloadStore(mockS3, mockMetricsRecorder)
+
+	expectGetObject(mockS3, "test-body")
+	expectGetObject(mockS3, "test-body")
+	mockS3.On("DeleteObjectsWithContext", mock.Anything, mock.Anything).Return(&aws_s3.DeleteObjectsOutput{}, nil).Once()
+	expectCacheLookup(mockMetricsRecorder, metrics_constants.RESULT_MISS).Twice()
+
+	_, err := payloadStore.GetOriginalPayload(cachedMessagePointer)
+	assert.Nil(t, err)
+
+	errs := payloadStore.DeleteOriginalPayloadsWithContext(context.Background(), []string{cachedMessagePointer})
+	assert.Equal(t, []error{nil}, errs)
+
+	_, err = payloadStore.GetOriginalPayload(cachedMessagePointer)
+	assert.Nil(t, err)
+
+	mockS3.AssertExpectations(t)
+	mockMetricsRecorder.AssertExpectations(t)
+}
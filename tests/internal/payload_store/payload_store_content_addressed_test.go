@@ -0,0 +1,176 @@
+package tests
+
+import (
+	std_errors "errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors"
+	errors_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors/constants"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/internal/payload_store"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/internal/payload_store/mock"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newContentAddressedPayloadStore(mockS3 *MockS3) *payload_store.PayloadStore {
+	return payload_store.NewPayloadStore(mockS3, "test-bucket", payload_store.WithS3KeyPrefix("payloads/"), payload_store.WithContentAddressing(24*time.Hour))
+}
+
+func matchContentAddressedKey(key *string) bool {
+	return *key == "payloads/"+payload_store.ContentAddressedKey("test-body")
+}
+
+func Test_PayloadStore_StoreOriginalPayload_Content_Addressed_Success_Uploaded(t *testing.T) {
+	mockS3 := new(MockS3)
+	payloadStore := newContentAddressedPayloadStore(mockS3)
+
+	mockS3.On("HeadObjectWithContext", mock.Anything, mock.MatchedBy(func(input *aws_s3.HeadObjectInput) bool {
+		return matchContentAddressedKey(input.Key)
+	})).Return(&aws_s3.HeadObjectOutput{}, awserr.New("NotFound", "Not Found", nil)).Once()
+	mockS3.On("PutObjectWithContext", mock.Anything, mock.MatchedBy(func(input *aws_s3.PutObjectInput) bool {
+		return matchContentAddressedKey(input.Key)
+	})).Return(&aws_s3.PutObjectOutput{}, nil).Once()
+
+	messagePointer, err := payloadStore.StoreOriginalPayload("test-body")
+
+	assert.Nil(t, err)
+	pointer, err := payload_store.FromJson(messagePointer)
+	assert.Nil(t, err)
+	assert.True(t, matchContentAddressedKey(&pointer.S3Key))
+
+	mockS3.AssertExpectations(t)
+}
+
+func Test_PayloadStore_StoreOriginalPayload_Content_Addressed_Success_Already_Stored(t *testing.T) {
+	mockS3 := new(MockS3)
+	payloadStore := newContentAddressedPayloadStore(mockS3)
+
+	mockS3.On("HeadObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.HeadObjectOutput{
+		ContentLength: aws.Int64(int64(len("test-body"))),
+		LastModified:  aws.Time(time.Now().Add(-time.Hour)),
+	}, nil).Once()
+
+	messagePointer, err := payloadStore.StoreOriginalPayload("test-body")
+
+	assert.Nil(t, err)
+	assert.Contains(t, messagePointer, payload_store.ContentAddressedKey("test-body"))
+
+	mockS3.AssertExpectations(t)
+	mockS3.AssertNotCalled(t, "PutObjectWithContext", mock.Anything, mock.Anything)
+	mockS3.AssertNotCalled(t, "CopyObjectWithContext", mock.Anything, mock.Anything)
+}
+
+func Test_PayloadStore_StoreOriginalPayload_Content_Addressed_Success_Touched(t *testing.T) {
+	mockS3 := new(MockS3)
+	payloadStore := newContentAddressedPayloadStore(mockS3)
+
+	mockS3.On("HeadObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.HeadObjectOutput{
+		ContentLength: aws.Int64(int64(len("test-body"))),
+		LastModified:  aws.Time(time.Now().Add(-48 * time.Hour)),
+		Metadata:      map[string]*string{"Owner": aws.String("producer")},
+	}, nil).Once()
+	mockS3.On("CopyObjectWithContext", mock.Anything, mock.MatchedBy(func(input *aws_s3.CopyObjectInput) bool {
+		return matchContentAddressedKey(input.Key) && *input.CopySource == "test-bucket/"+*input.Key &&
+			*input.MetadataDirective == aws_s3.MetadataDirectiveReplace && *input.Metadata["Owner"] == "producer"
+	})).Return(&aws_s3.CopyObjectOutput{}, nil).Once()
+
+	_, err := payloadStore.StoreOriginalPayload("test-body")
+
+	assert.Nil(t, err)
+	mockS3.AssertExpectations(t)
+	mockS3.AssertNotCalled(t, "PutObjectWithContext", mock.Anything, mock.Anything)
+}
+
+func Test_PayloadStore_StoreOriginalPayload_Content_Addressed_Success_Partial_Upload(t *testing.T) {
+	mockS3 := new(MockS3)
+	payloadStore := newContentAddressedPayloadStore(mockS3)
+
+	mockS3.On("HeadObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.HeadObjectOutput{
+		ContentLength: aws.Int64(4),
+		LastModified:  aws.Time(time.Now()),
+	}, nil).Once()
+	mockS3.On("PutObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.PutObjectOutput{}, nil).Once()
+
+	_, err := payloadStore.StoreOriginalPayload("test-body")
+
+	assert.Nil(t, err)
+	mockS3.AssertExpectations(t)
+}
+
+func Test_PayloadStore_StoreOriginalPayload_Content_Addressed_Failed_Check(t *testing.T) {
+	mockS3 := new(MockS3)
+	payloadStore := newContentAddressedPayloadStore(mockS3)
+
+	mockS3.On("HeadObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.HeadObjectOutput{},
+		awserr.New("Forbidden", "Forbidden", nil)).Once()
+
+	_, err := payloadStore.StoreOriginalPayload("test-body")
+
+	var s3Err errors.S3Error
+	assert.True(t, std_errors.As(err, &s3Err))
+	assert.Equal(t, errors_constants.OPERATION_CHECK, s3Err.Operation)
+	mockS3.AssertNotCalled(t, "PutObjectWithContext", mock.Anything, mock.Anything)
+}
+
+func Test_PayloadStore_StoreOriginalPayload_Content_Addressed_Failed_Touch(t *testing.T) {
+	mockS3 := new(MockS3)
+	payloadStore := newContentAddressedPayloadStore(mockS3)
+
+	mockS3.On("HeadObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.HeadObjectOutput{
+		ContentLength: aws.Int64(int64(len("test-body"))),
+		LastModified:  aws.Time(time.Now().Add(-48 * time.Hour)),
+	}, nil).Once()
+	mockS3.On("CopyObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.CopyObjectOutput{},
+		awserr.New("AccessDenied", "Access Denied", nil)).Once()
+
+	_, err := payloadStore.StoreOriginalPayload("test-body")
+
+	assert.True(t, std_errors.Is(err, errors.S3Error{Operation: errors_constants.OPERATION_TOUCH}))
+}
+
+func Test_PayloadStore_ContentAddressedKey(t *testing.T) {
+	assert.Equal(t, payload_store.ContentAddressedKey("test-body"), payload_store.ContentAddressedKey("test-body"))
+	assert.NotEqual(t, payload_store.ContentAddressedKey("test-body"), payload_store.ContentAddressedKey("other-body"))
+	assert.True(t, strings.HasPrefix(payload_store.ContentAddressedKey("test-body"), "sha256-"))
+}
+
+func Test_PayloadStore_IsContentAddressedKey(t *testing.T) {
+	hash := strings.TrimPrefix(payload_store.ContentAddressedKey("test-body"), "sha256-")
+
+	for key, expected := range map[string]bool{
+		"sha256-" + hash:                         true,
+		"payloads/sha256-" + hash:                true,
+		"payloads-sha256-" + hash:                true,
+		"sha256-" + strings.ToUpper(hash):        false,
+		"sha256-" + hash[1:]:                     false,
+		"sha256-" + hash[1:] + "g":               false,
+		"sha256-" + hash + "/other":              false,
+		"0f6d9e5a-3b1c-4c9e-9a47-6b9e0a1f2c3d":   false,
+		"payloads/0f6d9e5a-3b1c-4c9e-9a47-6b9e0": false,
+		"":                                       false,
+	} {
+		assert.Equal(t, expected, payload_store.IsContentAddressedKey(key), key)
+	}
+}
+
+func Test_PayloadStore_StoreOriginalPayload_Content_Addressed_Success_Touch_Age(t *testing.T) {
+	mockS3 := new(MockS3)
+	payloadStore := payload_store.NewPayloadStore(mockS3, "test-bucket", payload_store.WithContentAddressing(time.Hour))
+
+	mockS3.On("HeadObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.HeadObjectOutput{
+		ContentLength: aws.Int64(int64(len("test-body"))),
+		LastModified:  aws.Time(time.Now().Add(-2 * time.Hour)),
+	}, nil).Once()
+	mockS3.On("CopyObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.CopyObjectOutput{}, nil).Once()
+
+	_, err := payloadStore.StoreOriginalPayload("test-body")
+
+	assert.Nil(t, err)
+	mockS3.AssertExpectations(t)
+}
@@ -1,16 +1,21 @@
 package tests
 
 import (
+	"context"
+	std_errors "errors"
 	"fmt"
 	"io/ioutil"
 	"strings"
 	"testing"
 
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors"
 	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/internal/payload_store"
 	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/internal/payload_store/mock"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
+	aws_s3iface "github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -31,6 +36,22 @@ func Test_PayloadStore_StoreOriginalPayload_Success(t *testing.T) {
 	assert.Contains(t, pointerStr, fmt.Sprintf("\"s3BucketName\":\"%s\"", s3BucketName))
 }
 
+func Test_PayloadStore_StoreOriginalPayload_Success_S3_Key_Prefix(t *testing.T) {
+	mockS3 := new(MockS3)
+
+	mockS3.On("PutObjectWithContext", mock.Anything, mock.MatchedBy(func(input *aws_s3.PutObjectInput) bool {
+		return strings.HasPrefix(*input.Key, "payloads/")
+	})).Return(&aws_s3.PutObjectOutput{}, nil).Once()
+
+	payloadStore := payload_store.NewPayloadStore(mockS3, "test-bucket", payload_store.WithS3KeyPrefix("payloads/"))
+
+	pointerStr, err := payloadStore.StoreOriginalPayload("test-body")
+
+	assert.Nil(t, err)
+	assert.Contains(t, pointerStr, "\"s3Key\":\"payloads/")
+	mockS3.AssertExpectations(t)
+}
+
 func Test_PayloadStore_StoreOriginalPayload_Failed_S3_Error(t *testing.T) {
 	mockS3 := new(MockS3)
 
@@ -117,3 +138,84 @@ func Test_PayloadStore_DeleteOriginalPayload_Failed_S3_Error(t *testing.T) {
 
 	assert.NotNil(t, err)
 }
+
+func Test_PayloadStore_DeleteOriginalPayloadsWithContext_Success_Per_Bucket(t *testing.T) {
+	mockS3 := new(MockS3)
+
+	mockS3.On("DeleteObjectsWithContext", mock.Anything, mock.MatchedBy(func(input *aws_s3.DeleteObjectsInput) bool {
+		return *input.Bucket == "test-bucket" && len(input.Delete.Objects) == 2
+	})).Return(&aws_s3.DeleteObjectsOutput{
+		Errors: []*aws_s3.Error{{Key: aws.String("test-key-2"), Code: aws.String("AccessDenied"), Message: aws.String("Access Denied")}},
+	}, nil).Once()
+	mockS3.On("DeleteObjectsWithContext", mock.Anything, mock.MatchedBy(func(input *aws_s3.DeleteObjectsInput) bool {
+		return *input.Bucket == "other-bucket" && len(input.Delete.Objects) == 1
+	})).Return(&aws_s3.DeleteObjectsOutput{}, awserr.New(aws_s3.ErrCodeNoSuchBucket, "The specified bucket does not exist", nil)).Once()
+
+	payloadStore := payload_store.NewPayloadStore(mockS3, "test-bucket")
+
+	errs := payloadStore.DeleteOriginalPayloadsWithContext(context.Background(), []string{
+		"[\"software.amazon.payloadoffloading.PayloadS3Pointer\",{\"s3BucketName\":\"test-bucket\",\"s3Key\":\"test-key-1\"}]",
+		"[\"software.amazon.payloadoffloading.PayloadS3Pointer\",{\"s3BucketName\":\"other-bucket\",\"s3Key\":\"test-key-3\"}]",
+		"[\"software.amazon.payloadoffloading.PayloadS3Pointer\",{\"s3BucketName\":\"test-bucket\",\"s3Key\":\"test-key-2\"}]",
+		"invalid",
+	})
+
+	assert.Len(t, errs, 4)
+	assert.Nil(t, errs[0])
+
+	var s3Err errors.S3Error
+	assert.True(t, std_errors.As(errs[1], &s3Err))
+	assert.Equal(t, "test-key-3", s3Err.S3Key)
+	assert.True(t, std_errors.As(errs[2], &s3Err))
+	assert.Equal(t, "test-key-2", s3Err.S3Key)
+	assert.NotNil(t, errs[3])
+
+	mockS3.AssertExpectations(t)
+}
+
+func Test_PayloadStore_StoreOriginalPayloadInBucket_Success(t *testing.T) {
+	defaultS3 := new(MockS3)
+	euS3 := new(MockS3)
+
+	euS3.On("PutObjectWithContext", mock.Anything, mock.MatchedBy(func(input *aws_s3.PutObjectInput) bool {
+		return *input.Bucket == "eu-bucket"
+	})).Return(&aws_s3.PutObjectOutput{}, nil).Once()
+
+	resolver := &mockS3ClientResolver{clients: map[string]aws_s3iface.S3API{"eu-bucket": euS3}}
+	payloadStore := payload_store.NewPayloadStore(defaultS3, "test-bucket", payload_store.WithS3ClientResolver(resolver))
+
+	pointerStr, err := payloadStore.StoreOriginalPayloadInBucket("test-body", "eu-bucket")
+
+	euS3.AssertExpectations(t)
+	defaultS3.AssertExpectations(t)
+
+	assert.Nil(t, err)
+	assert.Contains(t, pointerStr, "\"s3BucketName\":\"eu-bucket\"")
+}
+
+func Test_PayloadStore_GetOriginalPayload_Failed_S3_Client_Not_Resolved(t *testing.T) {
+	defaultS3 := new(MockS3)
+
+	resolver := &mockS3ClientResolver{clients: map[string]aws_s3iface.S3API{}}
+	payloadStore := payload_store.NewPayloadStore(defaultS3, "test-bucket", payload_store.WithS3ClientResolver(resolver))
+
+	messagePointer := "[\"software.amazon.payloadoffloading.PayloadS3Pointer\",{\"s3BucketName\":\"unknown-bucket\",\"s3Key\":\"test-key\"}]"
+	payload, err := payloadStore.GetOriginalPayload(messagePointer)
+
+	defaultS3.AssertExpectations(t)
+
+	assert.NotNil(t, err)
+	assert.Empty(t, payload)
+}
+
+type mockS3ClientResolver struct {
+	clients map[string]aws_s3iface.S3API
+}
+
+func (r *mockS3ClientResolver) ResolveS3Client(s3BucketName string) (aws_s3iface.S3API, error) {
+	if s3Client, ok := r.clients[s3BucketName]; ok {
+		return s3Client, nil
+	}
+
+	return nil, fmt.Errorf("unknown bucket %s", s3BucketName)
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"sync"
+
+	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
+)
+
+type MockLogEntry struct {
+	Level   string
+	Message string
+	Fields  map[string]interface{}
+}
+
+// Records the logged entries, loggers derived with WithField and WithFields record into the same entries
+type MockLogger struct {
+	aws_extended_sqsiface.LoggerInterface
+	fields  map[string]interface{}
+	entries *[]*MockLogEntry
+	mu      *sync.Mutex
+}
+
+func NewMockLogger() *MockLogger {
+	return &MockLogger{
+		fields:  map[string]interface{}{},
+		entries: &[]*MockLogEntry{},
+		mu:      &sync.Mutex{},
+	}
+}
+
+func (l *MockLogger) WithField(key string, value interface{}) aws_extended_sqsiface.LoggerInterface {
+	return l.WithFields(map[string]interface{}{key: value})
+}
+
+func (l *MockLogger) WithFields(fields map[string]interface{}) aws_extended_sqsiface.LoggerInterface {
+	newFields := map[string]interface{}{}
+	for key, value := range l.fields {
+		newFields[key] = value
+	}
+	for key, value := range fields {
+		newFields[key] = value
+	}
+
+	return &MockLogger{fields: newFields, entries: l.entries, mu: l.mu}
+}
+
+func (l *MockLogger) Log(level string, message string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	*l.entries = append(*l.entries, &MockLogEntry{Level: level, Message: message, Fields: l.fields})
+}
+
+func (l *MockLogger) Entries() []*MockLogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return *l.entries
+}
+
+func (l *MockLogger) LastEntry() *MockLogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(*l.entries) == 0 {
+		return nil
+	}
+
+	return (*l.entries)[len(*l.entries)-1]
+}
@@ -0,0 +1,34 @@
+//go:build go1.21
+
+package tests
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging"
+	logging_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging/constants"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SlogLogger_Log(t *testing.T) {
+	var buffer bytes.Buffer
+	handler := slog.NewTextHandler(&buffer, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return attr
+		},
+	})
+
+	logging.NewSlogLogger(slog.New(handler)).
+		WithField("method", "SendMessage").
+		WithFields(map[string]interface{}{"uploaded_to_s3": "false", "message_size": 4}).
+		Log(logging_constants.LOG_LEVEL_DEBUG, "Handled by original sqs sdk")
+
+	assert.Equal(t, "level=DEBUG msg=\"Handled by original sqs sdk\" method=SendMessage message_size=4 uploaded_to_s3=false\n", buffer.String())
+}
@@ -0,0 +1,44 @@
+package tests
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging"
+	logging_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging/constants"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_StdLogger_Log(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := logging.NewStdLogger(log.New(&buffer, "", 0), logging_constants.LOG_LEVEL_INFO)
+
+	logger.WithField("method", "SendMessage").
+		WithFields(map[string]interface{}{"uploaded_to_s3": "false", "message_size": 4}).
+		Log(logging_constants.LOG_LEVEL_INFO, "Handled by original sqs sdk")
+
+	assert.Equal(t, "level=info msg=\"Handled by original sqs sdk\" message_size=\"4\" method=\"SendMessage\" uploaded_to_s3=\"false\"\n", buffer.String())
+}
+
+func Test_StdLogger_Log_Below_Level(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := logging.NewStdLogger(log.New(&buffer, "", 0), logging_constants.LOG_LEVEL_WARN)
+
+	logger.Log(logging_constants.LOG_LEVEL_INFO, "test")
+	assert.Empty(t, buffer.String())
+
+	logger.Log(logging_constants.LOG_LEVEL_ERROR, "test")
+	assert.Equal(t, "level=error msg=\"test\"\n", buffer.String())
+}
+
+func Test_StdLogger_WithFields_Does_Not_Modify_Parent(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := logging.NewStdLogger(log.New(&buffer, "", 0), logging_constants.LOG_LEVEL_INFO)
+
+	logger.WithField("method", "SendMessage")
+	logger.Log(logging_constants.LOG_LEVEL_INFO, "test")
+
+	assert.Equal(t, "level=info msg=\"test\"\n", buffer.String())
+}
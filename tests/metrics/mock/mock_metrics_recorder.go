@@ -0,0 +1,20 @@
+package tests
+
+import (
+	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MockMetricsRecorder struct {
+	aws_extended_sqsiface.MetricsRecorderInterface
+	mock.Mock
+}
+
+func (m *MockMetricsRecorder) IncrementCounter(name string, labels map[string]string) {
+	m.Called(name, labels)
+}
+
+func (m *MockMetricsRecorder) ObserveHistogram(name string, value float64, labels map[string]string) {
+	m.Called(name, value, labels)
+}
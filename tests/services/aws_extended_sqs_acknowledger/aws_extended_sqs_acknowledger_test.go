@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"context"
+	std_errors "errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_acknowledger"
+
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/logging/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/services/aws_extended_sqs_client/mock"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+	aws_sqsiface "github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// Deletes every entry of the batches, and records the receipt handles of each batch
+type recordingSqs struct {
+	*MockSqs
+
+	mu      sync.Mutex
+	batches [][]string
+}
+
+func (r *recordingSqs) DeleteMessageBatchWithContext(ctx aws.Context, input *aws_sqs.DeleteMessageBatchInput, option ...request.Option) (*aws_sqs.DeleteMessageBatchOutput, error) {
+	var receiptHandles []string
+	output := &aws_sqs.DeleteMessageBatchOutput{}
+	for _, entry := range input.Entries {
+		receiptHandles = append(receiptHandles, *input.QueueUrl+":"+*entry.ReceiptHandle)
+		output.Successful = append(output.Successful, &aws_sqs.DeleteMessageBatchResultEntry{Id: entry.Id})
+	}
+
+	r.mu.Lock()
+	r.batches = append(r.batches, receiptHandles)
+	r.mu.Unlock()
+
+	return output, nil
+}
+
+type AcknowledgerTestSuite struct {
+	suite.Suite
+
+	mockSqs      *MockSqs
+	recordingSqs *recordingSqs
+}
+
+func (s *AcknowledgerTestSuite) SetupTest() {
+	s.mockSqs = new(MockSqs)
+	s.recordingSqs = &recordingSqs{MockSqs: s.mockSqs}
+}
+
+func (s *AcknowledgerTestSuite) newAcknowledger(client aws_sqsiface.SQSAPI, opts ...aws_extended_sqs_acknowledger.AwsExtendedSQSAcknowledgerOption) *aws_extended_sqs_acknowledger.AwsExtendedSQSAcknowledger {
+	opts = append([]aws_extended_sqs_acknowledger.AwsExtendedSQSAcknowledgerOption{aws_extended_sqs_acknowledger.WithLogger(NewMockLogger())}, opts...)
+	return aws_extended_sqs_acknowledger.NewExtendedSQSAcknowledger(client, opts...)
+}
+
+func (s *AcknowledgerTestSuite) batches() [][]string {
+	s.recordingSqs.mu.Lock()
+	defer s.recordingSqs.mu.Unlock()
+
+	return s.recordingSqs.batches
+}
+
+func (s *AcknowledgerTestSuite) Test_Acknowledge_Success_Flushed_On_Batch_Size() {
+	acknowledger := s.newAcknowledger(s.recordingSqs, aws_extended_sqs_acknowledger.WithMaxBatchSize(2), aws_extended_sqs_acknowledger.WithFlushInterval(time.Hour))
+
+	first := acknowledger.Acknowledge("test-queue", "receipt-handle-1")
+	other := acknowledger.Acknowledge("other-queue", "receipt-handle-2")
+	second := acknowledger.Acknowledge("test-queue", "receipt-handle-3")
+
+	assert.Nil(s.T(), first.Get(context.Background()))
+	assert.Nil(s.T(), second.Get(context.Background()))
+	assert.Equal(s.T(), [][]string{{"test-queue:receipt-handle-1", "test-queue:receipt-handle-3"}}, s.batches())
+
+	select {
+	case <-other.Done():
+		assert.Fail(s.T(), "receipt handle of a batch which is not full should be buffered")
+	default:
+	}
+
+	assert.Nil(s.T(), acknowledger.Close(context.Background()))
+	assert.Nil(s.T(), other.Get(context.Background()))
+	assert.Len(s.T(), s.batches(), 2)
+}
+
+func (s *AcknowledgerTestSuite) Test_Acknowledge_Success_Flushed_On_Interval() {
+	acknowledger := s.newAcknowledger(s.recordingSqs, aws_extended_sqs_acknowledger.WithFlushInterval(10*time.Millisecond))
+
+	var mu sync.Mutex
+	called := false
+
+	future := acknowledger.AcknowledgeWithCallback("test-queue", "receipt-handle-1", func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		assert.Nil(s.T(), err)
+		called = true
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	assert.Nil(s.T(), future.Get(ctx))
+
+	mu.Lock()
+	assert.True(s.T(), called)
+	mu.Unlock()
+}
+
+func (s *AcknowledgerTestSuite) Test_Acknowledge_Failed_Entry_Failed() {
+	s.mockSqs.On("DeleteMessageBatchWithContext", mock.Anything, mock.Anything).Return(&aws_sqs.DeleteMessageBatchOutput{
+		Successful: []*aws_sqs.DeleteMessageBatchResultEntry{{Id: aws.String("0")}},
+		Failed:     []*aws_sqs.BatchResultErrorEntry{{Id: aws.String("1"), Code: aws.String("ReceiptHandleIsInvalid"), Message: aws.String("test error")}},
+	}, nil).Once()
+	acknowledger := s.newAcknowledger(s.mockSqs, aws_extended_sqs_acknowledger.WithMaxBatchSize(2))
+
+	succeeded := acknowledger.Acknowledge("test-queue", "receipt-handle-1")
+	failed := acknowledger.Acknowledge("test-queue", "receipt-handle-2")
+
+	assert.Nil(s.T(), succeeded.Get(context.Background()))
+
+	err := failed.Get(context.Background())
+	assert.True(s.T(), std_errors.Is(err, errors.SQSError{}))
+
+	var awsErr awserr.Error
+	assert.True(s.T(), std_errors.As(err, &awsErr))
+	assert.Equal(s.T(), "ReceiptHandleIsInvalid", awsErr.Code())
+}
+
+func (s *AcknowledgerTestSuite) Test_Acknowledge_Failed_Batch_Error() {
+	batchErr := std_errors.New("test error")
+	s.mockSqs.On("DeleteMessageBatchWithContext", mock.Anything, mock.Anything).Return(&aws_sqs.DeleteMessageBatchOutput{}, batchErr).Once()
+	acknowledger := s.newAcknowledger(s.mockSqs, aws_extended_sqs_acknowledger.WithMaxBatchSize(1))
+
+	err := acknowledger.Acknowledge("test-queue", "receipt-handle-1").Get(context.Background())
+
+	assert.Equal(s.T(), batchErr, err)
+}
+
+func (s *AcknowledgerTestSuite) Test_Acknowledge_Failed_Closed() {
+	acknowledger := s.newAcknowledger(s.mockSqs)
+
+	assert.Nil(s.T(), acknowledger.Close(context.Background()))
+
+	err := acknowledger.Acknowledge("test-queue", "receipt-handle-1").Get(context.Background())
+	assert.True(s.T(), std_errors.As(err, &errors.SDKError{}))
+
+	s.mockSqs.AssertNotCalled(s.T(), "DeleteMessageBatchWithContext", mock.Anything, mock.Anything)
+}
+
+func TestAcknowledger(t *testing.T) {
+	suite.Run(t, new(AcknowledgerTestSuite))
+}
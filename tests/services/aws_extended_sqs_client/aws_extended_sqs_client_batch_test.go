@@ -0,0 +1,282 @@
+package tests
+
+import (
+	"context"
+	std_errors "errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client"
+	sqs_configs_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client/constants"
+
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/internal/payload_store/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/logging/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/services/aws_extended_sqs_client/mock"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_ExtendedSqsClient_SendMessageBatch_Success_Oversized_Entries_Offloaded(t *testing.T) {
+	mockSqs := new(MockSqs)
+	mockS3 := new(MockS3)
+
+	var mu sync.Mutex
+	var afterSendIds []string
+
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	config.WithPayloadSupportEnabled(mockS3, "test-bucket")
+	client := aws_extended_sqs_client.NewExtendedSQSClient(mockSqs, config,
+		aws_extended_sqs_client.WithLogger(NewMockLogger()),
+		aws_extended_sqs_client.WithAfterSendInterceptor(func(ctx context.Context, info *aws_extended_sqs_client.SendMessageInfo, output *aws_sqs.SendMessageOutput, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			assert.Nil(t, err)
+			afterSendIds = append(afterSendIds, info.Destination+":"+*output.MessageId)
+		}),
+	)
+
+	largeBody := strings.Repeat("test", 65537)
+
+	mockS3.On("PutObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.PutObjectOutput{}, nil).Twice()
+	mockSqs.On("SendMessageBatch", mock.MatchedBy(func(input *aws_sqs.SendMessageBatchInput) bool {
+		if *input.QueueUrl != "test-queue" || len(input.Entries) != 3 {
+			return false
+		}
+
+		return *input.Entries[0].MessageBody == "test" &&
+			strings.Contains(*input.Entries[1].MessageBody, "test-bucket") &&
+			input.Entries[1].MessageAttributes[sqs_configs_constants.RESERVED_ATTRIBUTE_NAME] != nil &&
+			strings.Contains(*input.Entries[2].MessageBody, "test-bucket")
+	})).Return(&aws_sqs.SendMessageBatchOutput{
+		Successful: []*aws_sqs.SendMessageBatchResultEntry{
+			{Id: aws.String("1"), MessageId: aws.String("message-1")},
+			{Id: aws.String("2"), MessageId: aws.String("message-2")},
+			{Id: aws.String("3"), MessageId: aws.String("message-3")},
+		},
+	}, nil).Once()
+
+	input := &aws_sqs.SendMessageBatchInput{
+		QueueUrl: aws.String("test-queue"),
+		Entries: []*aws_sqs.SendMessageBatchRequestEntry{
+			{Id: aws.String("1"), MessageBody: aws.String("test")},
+			{Id: aws.String("2"), MessageBody: &largeBody},
+			{Id: aws.String("3"), MessageBody: &largeBody},
+		},
+	}
+	output, err := client.SendMessageBatch(input)
+
+	assert.Nil(t, err)
+	assert.Len(t, output.Successful, 3)
+	assert.Empty(t, output.Failed)
+	assert.Equal(t, largeBody, *input.Entries[1].MessageBody)
+	assert.ElementsMatch(t, []string{"sqs:message-1", "s3:message-2", "s3:message-3"}, afterSendIds)
+
+	mockSqs.AssertExpectations(t)
+	mockS3.AssertExpectations(t)
+}
+
+func Test_ExtendedSqsClient_SendMessageBatch_Success_Largest_Entries_Offloaded_Until_Batch_Fits(t *testing.T) {
+	mockSqs := new(MockSqs)
+	mockS3 := new(MockS3)
+
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	config.WithPayloadSupportEnabled(mockS3, "test-bucket")
+	client := aws_extended_sqs_client.NewExtendedSQSClient(mockSqs, config, aws_extended_sqs_client.WithLogger(NewMockLogger()))
+
+	// Each entry is just under the threshold, but the batch is far over the batch limit
+	body := strings.Repeat("a", sqs_configs_constants.DEFAULT_MESSAGE_SIZE_THRESHOLD-2048)
+
+	input := &aws_sqs.SendMessageBatchInput{QueueUrl: aws.String("test-queue")}
+	sqsOutput := &aws_sqs.SendMessageBatchOutput{}
+	for index := 0; index < 10; index++ {
+		id := aws.String(strconv.Itoa(index))
+		input.Entries = append(input.Entries, &aws_sqs.SendMessageBatchRequestEntry{Id: id, MessageBody: aws.String(body)})
+		sqsOutput.Successful = append(sqsOutput.Successful, &aws_sqs.SendMessageBatchResultEntry{Id: id, MessageId: id})
+	}
+
+	mockS3.On("PutObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.PutObjectOutput{}, nil).Times(9)
+	mockSqs.On("SendMessageBatch", mock.MatchedBy(func(input *aws_sqs.SendMessageBatchInput) bool {
+		batchSize := 0
+		inlineEntries := 0
+		for _, entry := range input.Entries {
+			batchSize += aws_extended_sqs_client.CalculateBatchEntrySize(entry).Total()
+			if *entry.MessageBody == body {
+				inlineEntries++
+			}
+		}
+
+		return len(input.Entries) == 10 && inlineEntries == 1 && batchSize <= sqs_configs_constants.MAX_SQS_BATCH_SIZE
+	})).Return(sqsOutput, nil).Once()
+
+	output, err := client.SendMessageBatch(input)
+
+	assert.Nil(t, err)
+	assert.Len(t, output.Successful, 10)
+	assert.Empty(t, output.Failed)
+
+	mockSqs.AssertExpectations(t)
+	mockS3.AssertExpectations(t)
+}
+
+func Test_ExtendedSqsClient_SendMessageBatch_Success_Failed_Upload_Returned_As_Failed_Entry(t *testing.T) {
+	mockSqs := new(MockSqs)
+	mockS3 := new(MockS3)
+
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	config.WithPayloadSupportEnabled(mockS3, "test-bucket")
+	client := aws_extended_sqs_client.NewExtendedSQSClient(mockSqs, config, aws_extended_sqs_client.WithLogger(NewMockLogger()))
+
+	largeBody := strings.Repeat("test", 65537)
+
+	mockS3.On("PutObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.PutObjectOutput{}, std_errors.New("test error")).Once()
+	mockSqs.On("SendMessageBatchWithContext", mock.Anything, mock.MatchedBy(func(input *aws_sqs.SendMessageBatchInput) bool {
+		return len(input.Entries) == 1 && *input.Entries[0].Id == "1"
+	})).Return(&aws_sqs.SendMessageBatchOutput{
+		Successful: []*aws_sqs.SendMessageBatchResultEntry{{Id: aws.String("1"), MessageId: aws.String("message-1")}},
+	}, nil).Once()
+
+	output, err := client.SendMessageBatchWithContext(context.Background(), &aws_sqs.SendMessageBatchInput{
+		QueueUrl: aws.String("test-queue"),
+		Entries: []*aws_sqs.SendMessageBatchRequestEntry{
+			{Id: aws.String("1"), MessageBody: aws.String("test")},
+			{Id: aws.String("2"), MessageBody: &largeBody},
+		},
+	})
+
+	assert.Nil(t, err)
+	assert.Len(t, output.Successful, 1)
+	assert.Len(t, output.Failed, 1)
+	assert.Equal(t, "2", *output.Failed[0].Id)
+	assert.Equal(t, errors.S3Error{}.Code(), *output.Failed[0].Code)
+	assert.True(t, *output.Failed[0].SenderFault)
+
+	mockSqs.AssertExpectations(t)
+}
+
+func Test_ExtendedSqsClient_SendMessageBatch_Failed_SQS_Error(t *testing.T) {
+	mockSqs := new(MockSqs)
+
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	client := aws_extended_sqs_client.NewExtendedSQSClient(mockSqs, config, aws_extended_sqs_client.WithLogger(NewMockLogger()))
+
+	mockSqs.On("SendMessageBatch", mock.Anything).Return(&aws_sqs.SendMessageBatchOutput{}, std_errors.New("test error")).Once()
+
+	_, err := client.SendMessageBatch(&aws_sqs.SendMessageBatchInput{
+		QueueUrl: aws.String("test-queue"),
+		Entries:  []*aws_sqs.SendMessageBatchRequestEntry{{Id: aws.String("1"), MessageBody: aws.String("test")}},
+	})
+
+	assert.True(t, std_errors.Is(err, errors.SQSError{}))
+}
+
+func Test_ExtendedSqsClient_DeleteMessageBatch_Success_Payloads_Deleted_In_Bulk(t *testing.T) {
+	mockSqs := new(MockSqs)
+	mockS3 := new(MockS3)
+
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	config.WithPayloadSupportEnabled(mockS3, "test-bucket")
+	config.SetCleanupS3Payload(true)
+	client := aws_extended_sqs_client.NewExtendedSQSClient(mockSqs, config, aws_extended_sqs_client.WithLogger(NewMockLogger()))
+
+	mockSqs.On("DeleteMessageBatch", &aws_sqs.DeleteMessageBatchInput{
+		QueueUrl: aws.String("test-queue"),
+		Entries: []*aws_sqs.DeleteMessageBatchRequestEntry{
+			{Id: aws.String("1"), ReceiptHandle: aws.String("test-receipt-handle")},
+			{Id: aws.String("2"), ReceiptHandle: aws.String("other-receipt-handle")},
+			{Id: aws.String("3"), ReceiptHandle: aws.String("failed-receipt-handle")},
+		},
+	}).Return(&aws_sqs.DeleteMessageBatchOutput{
+		Successful: []*aws_sqs.DeleteMessageBatchResultEntry{{Id: aws.String("1")}, {Id: aws.String("2")}},
+		Failed:     []*aws_sqs.BatchResultErrorEntry{{Id: aws.String("3"), Code: aws.String("ReceiptHandleIsInvalid")}},
+	}, nil).Once()
+	mockS3.On("DeleteObjectsWithContext", mock.Anything, mock.MatchedBy(func(input *aws_s3.DeleteObjectsInput) bool {
+		return *input.Bucket == "test-bucket" && len(input.Delete.Objects) == 1 && *input.Delete.Objects[0].Key == "test-key"
+	})).Return(&aws_s3.DeleteObjectsOutput{}, nil).Once()
+
+	output, err := client.DeleteMessageBatch(&aws_sqs.DeleteMessageBatchInput{
+		QueueUrl: aws.String("test-queue"),
+		Entries: []*aws_sqs.DeleteMessageBatchRequestEntry{
+			{Id: aws.String("1"), ReceiptHandle: aws.String(s3ReceiptHandle)},
+			{Id: aws.String("2"), ReceiptHandle: aws.String("other-receipt-handle")},
+			{Id: aws.String("3"), ReceiptHandle: aws.String("-..s3BucketName..-test-bucket-..s3BucketName..--..s3Key..-failed-key-..s3Key..-failed-receipt-handle")},
+		},
+	})
+
+	assert.Nil(t, err)
+	assert.Len(t, output.Successful, 2)
+	assert.Len(t, output.Failed, 1)
+
+	mockSqs.AssertExpectations(t)
+	mockS3.AssertExpectations(t)
+}
+
+func Test_ExtendedSqsClient_DeleteMessageBatch_Success_Failed_Payload_Delete_Returned_As_Failed_Entry(t *testing.T) {
+	mockSqs := new(MockSqs)
+	mockS3 := new(MockS3)
+
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	config.WithPayloadSupportEnabled(mockS3, "test-bucket")
+	config.SetCleanupS3Payload(true)
+	client := aws_extended_sqs_client.NewExtendedSQSClient(mockSqs, config,
+		aws_extended_sqs_client.WithLogger(NewMockLogger()),
+		aws_extended_sqs_client.WithBeforeDeleteInterceptor(func(ctx context.Context, input *aws_sqs.DeleteMessageInput, s3Pointer *aws_extended_sqs_client.S3Pointer) error {
+			if *input.ReceiptHandle == "protected-receipt-handle" {
+				return std_errors.New("not allowed")
+			}
+			return nil
+		}),
+	)
+
+	mockSqs.On("DeleteMessageBatchWithContext", mock.Anything, mock.MatchedBy(func(input *aws_sqs.DeleteMessageBatchInput) bool {
+		return len(input.Entries) == 1 && *input.Entries[0].ReceiptHandle == "test-receipt-handle"
+	})).Return(&aws_sqs.DeleteMessageBatchOutput{
+		Successful: []*aws_sqs.DeleteMessageBatchResultEntry{{Id: aws.String("1")}},
+	}, nil).Once()
+	mockS3.On("DeleteObjectsWithContext", mock.Anything, mock.Anything).Return(&aws_s3.DeleteObjectsOutput{
+		Errors: []*aws_s3.Error{{Key: aws.String("test-key"), Code: aws.String("AccessDenied")}},
+	}, nil).Once()
+
+	output, err := client.DeleteMessageBatchWithContext(context.Background(), &aws_sqs.DeleteMessageBatchInput{
+		QueueUrl: aws.String("test-queue"),
+		Entries: []*aws_sqs.DeleteMessageBatchRequestEntry{
+			{Id: aws.String("1"), ReceiptHandle: aws.String(s3ReceiptHandle)},
+			{Id: aws.String("2"), ReceiptHandle: aws.String("protected-receipt-handle")},
+		},
+	})
+
+	assert.Nil(t, err)
+	assert.Empty(t, output.Successful)
+	assert.Len(t, output.Failed, 2)
+	assert.Equal(t, "2", *output.Failed[0].Id)
+	assert.Equal(t, "1", *output.Failed[1].Id)
+	assert.Equal(t, errors.S3Error{}.Code(), *output.Failed[1].Code)
+}
+
+func Test_ExtendedSqsClient_DeleteMessageBatch_Failed_SQS_Error(t *testing.T) {
+	mockSqs := new(MockSqs)
+	mockS3 := new(MockS3)
+
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	config.WithPayloadSupportEnabled(mockS3, "test-bucket")
+	config.SetCleanupS3Payload(true)
+	client := aws_extended_sqs_client.NewExtendedSQSClient(mockSqs, config, aws_extended_sqs_client.WithLogger(NewMockLogger()))
+
+	mockSqs.On("DeleteMessageBatch", mock.Anything).Return(&aws_sqs.DeleteMessageBatchOutput{}, std_errors.New("test error")).Once()
+
+	_, err := client.DeleteMessageBatch(&aws_sqs.DeleteMessageBatchInput{
+		QueueUrl: aws.String("test-queue"),
+		Entries:  []*aws_sqs.DeleteMessageBatchRequestEntry{{Id: aws.String("1"), ReceiptHandle: aws.String(s3ReceiptHandle)}},
+	})
+
+	assert.True(t, std_errors.Is(err, errors.SQSError{}))
+	mockS3.AssertNotCalled(t, "DeleteObjectsWithContext", mock.Anything, mock.Anything)
+}
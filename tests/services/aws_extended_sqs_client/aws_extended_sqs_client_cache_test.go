@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/fakes"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client"
+
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/logging/mock"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ReceiveMessage_Payload_Cache_Redelivery(t *testing.T) {
+	fakeSqs := fakes.NewFakeSQS("cache-queue")
+	fakeS3 := fakes.NewFakeS3("cache-bucket")
+	queueUrl := fakeSqs.QueueUrl("cache-queue")
+
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	config.WithPayloadSupportEnabled(fakeS3, "cache-bucket")
+	config.SetAlwaysThroughS3(true)
+	client := aws_extended_sqs_client.NewExtendedSQSClient(fakeSqs, config,
+		aws_extended_sqs_client.WithLogger(NewMockLogger()), aws_extended_sqs_client.WithPayloadCache(1024))
+
+	_, err := client.SendMessage(&aws_sqs.SendMessageInput{QueueUrl: aws.String(queueUrl), MessageBody: aws.String("large payload")})
+	assert.Nil(t, err)
+
+	// Sent again as is once the message is deleted
+	sent := fakeSqs.Messages(queueUrl)[0]
+
+	receiveInput := &aws_sqs.ReceiveMessageInput{QueueUrl: aws.String(queueUrl), VisibilityTimeout: aws.Int64(0)}
+
+	output, err := client.ReceiveMessage(receiveInput)
+	assert.Nil(t, err)
+	assert.Len(t, output.Messages, 1)
+	assert.Equal(t, "large payload", *output.Messages[0].Body)
+
+	// Removed behind the client, the redelivery is served from the cache
+	keys := fakeS3.Keys("cache-bucket")
+	assert.Len(t, keys, 1)
+	_, err = fakeS3.DeleteObject(&aws_s3.DeleteObjectInput{Bucket: aws.String("cache-bucket"), Key: aws.String(keys[0])})
+	assert.Nil(t, err)
+
+	output, err = client.ReceiveMessage(receiveInput)
+	assert.Nil(t, err)
+	assert.Len(t, output.Messages, 1)
+	assert.Equal(t, "large payload", *output.Messages[0].Body)
+
+	// Deleting the message evicts its payload
+	_, err = client.DeleteMessage(&aws_sqs.DeleteMessageInput{QueueUrl: aws.String(queueUrl), ReceiptHandle: output.Messages[0].ReceiptHandle})
+	assert.Nil(t, err)
+
+	_, err = fakeSqs.SendMessage(&aws_sqs.SendMessageInput{
+		QueueUrl:          aws.String(queueUrl),
+		MessageBody:       sent.Body,
+		MessageAttributes: sent.MessageAttributes,
+	})
+	assert.Nil(t, err)
+
+	_, err = client.ReceiveMessage(receiveInput)
+	assert.NotNil(t, err)
+}
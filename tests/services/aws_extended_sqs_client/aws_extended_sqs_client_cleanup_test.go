@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"context"
+	std_errors "errors"
+	"testing"
+	"time"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client"
+
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/internal/payload_store/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/logging/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/services/aws_extended_sqs_client/mock"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newDeferredCleanupTestClient(mockSqs *MockSqs, mockS3 *MockS3, opts ...aws_extended_sqs_client.PayloadCleanupOption) *aws_extended_sqs_client.AwsExtendedSQSClient {
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	config.WithPayloadSupportEnabled(mockS3, "test-bucket")
+	config.SetCleanupS3Payload(true)
+
+	return aws_extended_sqs_client.NewExtendedSQSClient(mockSqs, config,
+		aws_extended_sqs_client.WithLogger(NewMockLogger()),
+		aws_extended_sqs_client.WithDeferredPayloadCleanup(opts...),
+	)
+}
+
+func Test_ExtendedSqsClient_DeleteMessage_Success_Deferred_Cleanup(t *testing.T) {
+	mockSqs := new(MockSqs)
+	mockS3 := new(MockS3)
+	client := newDeferredCleanupTestClient(mockSqs, mockS3, aws_extended_sqs_client.WithPayloadCleanupGracePeriod(time.Hour))
+
+	mockSqs.On("DeleteMessage", &aws_sqs.DeleteMessageInput{
+		QueueUrl:      aws.String("test-queue"),
+		ReceiptHandle: aws.String("test-receipt-handle"),
+	}).Return(&aws_sqs.DeleteMessageOutput{}, nil).Once()
+
+	_, err := client.DeleteMessage(&aws_sqs.DeleteMessageInput{
+		QueueUrl:      aws.String("test-queue"),
+		ReceiptHandle: aws.String(s3ReceiptHandle),
+	})
+
+	assert.Nil(t, err)
+	mockSqs.AssertExpectations(t)
+
+	// Deleted on close, within the grace period
+	mockS3.On("DeleteObjectWithContext", mock.Anything, &aws_s3.DeleteObjectInput{
+		Bucket: aws.String("test-bucket"),
+		Key:    aws.String("test-key"),
+	}).Return(&aws_s3.DeleteObjectOutput{}, nil).Once()
+
+	assert.Nil(t, client.Close(context.Background()))
+	mockS3.AssertExpectations(t)
+}
+
+func Test_ExtendedSqsClient_DeleteMessage_Success_Deferred_Cleanup_Failure_Handled(t *testing.T) {
+	mockSqs := new(MockSqs)
+	mockS3 := new(MockS3)
+
+	type failure struct {
+		s3Pointer *aws_extended_sqs_client.S3Pointer
+		err       error
+	}
+
+	failures := make(chan failure, 1)
+	client := newDeferredCleanupTestClient(mockSqs, mockS3,
+		aws_extended_sqs_client.WithPayloadCleanupRetries(1, time.Millisecond),
+		aws_extended_sqs_client.WithPayloadCleanupFailureHandler(func(s3Pointer *aws_extended_sqs_client.S3Pointer, err error) {
+			failures <- failure{s3Pointer: s3Pointer, err: err}
+		}),
+	)
+
+	mockSqs.On("DeleteMessageWithContext", mock.Anything, mock.Anything).Return(&aws_sqs.DeleteMessageOutput{}, nil).Once()
+	mockS3.On("DeleteObjectWithContext", mock.Anything, mock.Anything).Return(
+		&aws_s3.DeleteObjectOutput{},
+		awserr.New("AccessDenied", "Access Denied", nil),
+	).Twice()
+
+	_, err := client.DeleteMessageWithContext(context.Background(), &aws_sqs.DeleteMessageInput{
+		QueueUrl:      aws.String("test-queue"),
+		ReceiptHandle: aws.String(s3ReceiptHandle),
+	})
+
+	assert.Nil(t, err)
+	assert.Nil(t, client.Close(context.Background()))
+
+	// Close returns once the payload is handed to the handler, which may still be running
+	failed := <-failures
+
+	mockSqs.AssertExpectations(t)
+	mockS3.AssertExpectations(t)
+	assert.Equal(t, &aws_extended_sqs_client.S3Pointer{S3BucketName: "test-bucket", S3Key: "test-key"}, failed.s3Pointer)
+	assert.NotNil(t, failed.err)
+}
+
+func Test_ExtendedSqsClient_DeleteMessage_Failed_Deferred_Cleanup_SQS_Error(t *testing.T) {
+	mockSqs := new(MockSqs)
+	mockS3 := new(MockS3)
+	client := newDeferredCleanupTestClient(mockSqs, mockS3)
+
+	mockSqs.On("DeleteMessage", mock.Anything).Return(&aws_sqs.DeleteMessageOutput{}, std_errors.New("test error")).Once()
+
+	_, err := client.DeleteMessage(&aws_sqs.DeleteMessageInput{
+		QueueUrl:      aws.String("test-queue"),
+		ReceiptHandle: aws.String(s3ReceiptHandle),
+	})
+
+	assert.NotNil(t, err)
+	assert.Nil(t, client.Close(context.Background()))
+
+	mockSqs.AssertExpectations(t)
+	mockS3.AssertNotCalled(t, "DeleteObjectWithContext", mock.Anything, mock.Anything)
+}
+
+func Test_ExtendedSqsClient_DeleteMessageBatch_Success_Deferred_Cleanup(t *testing.T) {
+	mockSqs := new(MockSqs)
+	mockS3 := new(MockS3)
+	client := newDeferredCleanupTestClient(mockSqs, mockS3)
+
+	mockSqs.On("DeleteMessageBatch", mock.Anything).Return(&aws_sqs.DeleteMessageBatchOutput{
+		Successful: []*aws_sqs.DeleteMessageBatchResultEntry{{Id: aws.String("1")}},
+		Failed:     []*aws_sqs.BatchResultErrorEntry{{Id: aws.String("2"), Code: aws.String("ReceiptHandleIsInvalid")}},
+	}, nil).Once()
+	mockS3.On("DeleteObjectWithContext", mock.Anything, &aws_s3.DeleteObjectInput{
+		Bucket: aws.String("test-bucket"),
+		Key:    aws.String("test-key"),
+	}).Return(&aws_s3.DeleteObjectOutput{}, nil).Once()
+
+	output, err := client.DeleteMessageBatch(&aws_sqs.DeleteMessageBatchInput{
+		QueueUrl: aws.String("test-queue"),
+		Entries: []*aws_sqs.DeleteMessageBatchRequestEntry{
+			{Id: aws.String("1"), ReceiptHandle: aws.String(s3ReceiptHandle)},
+			{Id: aws.String("2"), ReceiptHandle: aws.String("-..s3BucketName..-test-bucket-..s3BucketName..--..s3Key..-failed-key-..s3Key..-failed-receipt-handle")},
+		},
+	})
+
+	assert.Nil(t, err)
+	assert.Len(t, output.Successful, 1)
+	assert.Len(t, output.Failed, 1)
+	assert.Nil(t, client.Close(context.Background()))
+
+	mockSqs.AssertExpectations(t)
+	mockS3.AssertExpectations(t)
+	mockS3.AssertNotCalled(t, "DeleteObjectsWithContext", mock.Anything, mock.Anything)
+}
+
+func Test_ExtendedSqsClient_Close_Success_Without_Deferred_Cleanup(t *testing.T) {
+	client := aws_extended_sqs_client.NewExtendedSQSClient(new(MockSqs), aws_extended_sqs_client.NewExtendedSQSClientConfiguration())
+
+	assert.Nil(t, client.Close(context.Background()))
+}
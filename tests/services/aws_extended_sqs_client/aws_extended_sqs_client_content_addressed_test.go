@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/fakes"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client"
+
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/logging/mock"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SendMessage_Content_Addressed_Fan_Out(t *testing.T) {
+	queueNames := []string{"fan-out-queue-1", "fan-out-queue-2", "fan-out-queue-3"}
+	fakeSqs := fakes.NewFakeSQS(queueNames...)
+	fakeS3 := fakes.NewFakeS3("fan-out-bucket")
+
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	config.WithPayloadSupportEnabled(fakeS3, "fan-out-bucket")
+	config.SetAlwaysThroughS3(true)
+	config.SetContentAddressedPayloads(true)
+	client := aws_extended_sqs_client.NewExtendedSQSClient(fakeSqs, config, aws_extended_sqs_client.WithLogger(NewMockLogger()))
+
+	for _, queueName := range queueNames {
+		_, err := client.SendMessage(&aws_sqs.SendMessageInput{QueueUrl: aws.String(fakeSqs.QueueUrl(queueName)), MessageBody: aws.String("large document")})
+		assert.Nil(t, err)
+	}
+
+	_, err := client.SendMessage(&aws_sqs.SendMessageInput{QueueUrl: aws.String(fakeSqs.QueueUrl(queueNames[0])), MessageBody: aws.String("other document")})
+	assert.Nil(t, err)
+
+	// Stored once per distinct payload
+	assert.Len(t, fakeS3.Keys("fan-out-bucket"), 2)
+
+	// Deleting the messages leaves the shared payloads to the lifecycle rules
+	output, err := client.ReceiveMessage(&aws_sqs.ReceiveMessageInput{QueueUrl: aws.String(fakeSqs.QueueUrl(queueNames[0])), MaxNumberOfMessages: aws.Int64(10)})
+	assert.Nil(t, err)
+	assert.Len(t, output.Messages, 2)
+
+	for _, message := range output.Messages {
+		_, err := client.DeleteMessage(&aws_sqs.DeleteMessageInput{QueueUrl: aws.String(fakeSqs.QueueUrl(queueNames[0])), ReceiptHandle: message.ReceiptHandle})
+		assert.Nil(t, err)
+	}
+
+	output, err = client.ReceiveMessage(&aws_sqs.ReceiveMessageInput{QueueUrl: aws.String(fakeSqs.QueueUrl(queueNames[1]))})
+	assert.Nil(t, err)
+	assert.Len(t, output.Messages, 1)
+	assert.Equal(t, "large document", *output.Messages[0].Body)
+
+	batchOutput, err := client.DeleteMessageBatch(&aws_sqs.DeleteMessageBatchInput{
+		QueueUrl: aws.String(fakeSqs.QueueUrl(queueNames[1])),
+		Entries:  []*aws_sqs.DeleteMessageBatchRequestEntry{{Id: aws.String("1"), ReceiptHandle: output.Messages[0].ReceiptHandle}},
+	})
+	assert.Nil(t, err)
+	assert.Empty(t, batchOutput.Failed)
+
+	output, err = client.ReceiveMessage(&aws_sqs.ReceiveMessageInput{QueueUrl: aws.String(fakeSqs.QueueUrl(queueNames[2]))})
+	assert.Nil(t, err)
+	assert.Len(t, output.Messages, 1)
+	assert.Equal(t, "large document", *output.Messages[0].Body)
+
+	assert.Len(t, fakeS3.Keys("fan-out-bucket"), 2)
+}
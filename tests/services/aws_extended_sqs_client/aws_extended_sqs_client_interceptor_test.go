@@ -0,0 +1,215 @@
+package tests
+
+import (
+	"context"
+	std_errors "errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client"
+
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/internal/payload_store/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/services/aws_extended_sqs_client/mock"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type InterceptorTestSuite struct {
+	suite.Suite
+
+	config *aws_extended_sqs_client.AwsExtendedSQSClientConfiguration
+
+	mockSqs *MockSqs
+	mockS3  *MockS3
+}
+
+func (suite *InterceptorTestSuite) SetupTest() {
+	suite.mockSqs = new(MockSqs)
+	suite.mockS3 = new(MockS3)
+
+	suite.config = aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	suite.config.WithPayloadSupportEnabled(suite.mockS3, "test-bucket")
+}
+
+func (s *InterceptorTestSuite) Test_SendMessage_Success_Before_Send_Adds_Attribute() {
+	client := aws_extended_sqs_client.NewExtendedSQSClient(s.mockSqs, s.config,
+		aws_extended_sqs_client.WithBeforeSendInterceptor(func(ctx context.Context, input *aws_sqs.SendMessageInput) error {
+			input.MessageAttributes["Tenant"] = &aws_sqs.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String("acme")}
+			return nil
+		}),
+	)
+
+	s.mockSqs.On("SendMessage", mock.MatchedBy(func(input *aws_sqs.SendMessageInput) bool {
+		return *input.MessageAttributes["Tenant"].StringValue == "acme"
+	})).Return(&aws_sqs.SendMessageOutput{MessageId: aws.String("test-message-id")}, nil).Once()
+
+	input := &aws_sqs.SendMessageInput{MessageBody: aws.String("test")}
+	_, err := client.SendMessage(input)
+
+	s.mockSqs.AssertExpectations(s.T())
+
+	assert.Nil(s.T(), err)
+	assert.Nil(s.T(), input.MessageAttributes)
+}
+
+func (s *InterceptorTestSuite) Test_SendMessage_Failed_Before_Send_Error() {
+	validationErr := std_errors.New("invalid schema")
+	client := aws_extended_sqs_client.NewExtendedSQSClient(s.mockSqs, s.config,
+		aws_extended_sqs_client.WithBeforeSendInterceptor(func(ctx context.Context, input *aws_sqs.SendMessageInput) error {
+			return validationErr
+		}),
+	)
+
+	_, err := client.SendMessage(&aws_sqs.SendMessageInput{MessageBody: aws.String("test")})
+
+	s.mockSqs.AssertNotCalled(s.T(), "SendMessage", mock.Anything)
+	assert.Equal(s.T(), validationErr, err)
+}
+
+func (s *InterceptorTestSuite) Test_SendMessage_Success_After_Send_Offloaded() {
+	var infos []*aws_extended_sqs_client.SendMessageInfo
+	client := aws_extended_sqs_client.NewExtendedSQSClient(s.mockSqs, s.config,
+		aws_extended_sqs_client.WithAfterSendInterceptor(func(ctx context.Context, info *aws_extended_sqs_client.SendMessageInfo, output *aws_sqs.SendMessageOutput, err error) {
+			assert.Nil(s.T(), err)
+			assert.Equal(s.T(), "test-message-id", *output.MessageId)
+			infos = append(infos, info)
+		}),
+	)
+
+	s.mockS3.On("PutObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.PutObjectOutput{}, nil).Once()
+	s.mockSqs.On("SendMessage", mock.Anything).Return(&aws_sqs.SendMessageOutput{MessageId: aws.String("test-message-id")}, nil).Twice()
+
+	largeBody := strings.Repeat("test", 65537)
+	_, err := client.SendMessage(&aws_sqs.SendMessageInput{MessageBody: &largeBody})
+	assert.Nil(s.T(), err)
+
+	_, err = client.SendMessage(&aws_sqs.SendMessageInput{MessageBody: aws.String("test")})
+	assert.Nil(s.T(), err)
+
+	assert.Len(s.T(), infos, 2)
+
+	assert.Equal(s.T(), "s3", infos[0].Destination)
+	assert.Equal(s.T(), largeBody, *infos[0].Input.MessageBody)
+	assert.Equal(s.T(), "test-bucket", infos[0].S3Pointer.S3BucketName)
+	assert.NotEmpty(s.T(), infos[0].S3Pointer.S3Key)
+
+	assert.Equal(s.T(), "sqs", infos[1].Destination)
+	assert.Nil(s.T(), infos[1].S3Pointer)
+}
+
+func (s *InterceptorTestSuite) Test_SendMessage_Failed_After_Send_Receives_Error() {
+	var sendErr error
+	client := aws_extended_sqs_client.NewExtendedSQSClient(s.mockSqs, s.config,
+		aws_extended_sqs_client.WithAfterSendInterceptor(func(ctx context.Context, info *aws_extended_sqs_client.SendMessageInfo, output *aws_sqs.SendMessageOutput, err error) {
+			sendErr = err
+		}),
+	)
+
+	s.mockSqs.On("SendMessage", mock.Anything).Return(&aws_sqs.SendMessageOutput{}, std_errors.New("test error")).Once()
+
+	_, err := client.SendMessage(&aws_sqs.SendMessageInput{MessageBody: aws.String("test")})
+
+	assert.NotNil(s.T(), err)
+	assert.Equal(s.T(), err, sendErr)
+}
+
+func (s *InterceptorTestSuite) Test_ReceiveMessage_Success_After_Receive_Per_Message() {
+	var infos []*aws_extended_sqs_client.ReceivedMessageInfo
+	client := aws_extended_sqs_client.NewExtendedSQSClient(s.mockSqs, s.config,
+		aws_extended_sqs_client.WithAfterReceiveInterceptor(func(ctx context.Context, info *aws_extended_sqs_client.ReceivedMessageInfo) error {
+			infos = append(infos, info)
+			return nil
+		}),
+	)
+
+	largeBody := strings.Repeat("test", 65537)
+	s.mockSqs.On("ReceiveMessage", mock.Anything).Return(&aws_sqs.ReceiveMessageOutput{
+		Messages: []*aws_sqs.Message{
+			createLargePayloadMessage("test-message-id-1", "test-bucket", "test-key", largeBody, "test-receipt-handle-1"),
+			{MessageId: aws.String("test-message-id-2"), Body: aws.String("test"), ReceiptHandle: aws.String("test-receipt-handle-2")},
+		},
+	}, nil).Once()
+	s.mockS3.On("GetObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.GetObjectOutput{
+		Body: ioutil.NopCloser(strings.NewReader(largeBody)),
+	}, nil).Once()
+
+	_, err := client.ReceiveMessage(&aws_sqs.ReceiveMessageInput{QueueUrl: aws.String("test-queue")})
+
+	assert.Nil(s.T(), err)
+	assert.Len(s.T(), infos, 2)
+
+	assert.Equal(s.T(), largeBody, *infos[0].Message.Body)
+	assert.Equal(s.T(), &aws_extended_sqs_client.S3Pointer{S3BucketName: "test-bucket", S3Key: "test-key"}, infos[0].S3Pointer)
+
+	assert.Equal(s.T(), "test", *infos[1].Message.Body)
+	assert.Nil(s.T(), infos[1].S3Pointer)
+}
+
+func (s *InterceptorTestSuite) Test_ReceiveMessage_Failed_After_Receive_Error() {
+	client := aws_extended_sqs_client.NewExtendedSQSClient(s.mockSqs, s.config,
+		aws_extended_sqs_client.WithAfterReceiveInterceptor(func(ctx context.Context, info *aws_extended_sqs_client.ReceivedMessageInfo) error {
+			return std_errors.New("audit failed")
+		}),
+	)
+
+	s.mockSqs.On("ReceiveMessage", mock.Anything).Return(&aws_sqs.ReceiveMessageOutput{
+		Messages: []*aws_sqs.Message{{Body: aws.String("test"), ReceiptHandle: aws.String("test-receipt-handle")}},
+	}, nil).Once()
+
+	_, err := client.ReceiveMessage(&aws_sqs.ReceiveMessageInput{QueueUrl: aws.String("test-queue")})
+
+	assert.NotNil(s.T(), err)
+}
+
+func (s *InterceptorTestSuite) Test_DeleteMessage_Success_Before_Delete_S3_Pointer() {
+	s.config.SetCleanupS3Payload(true)
+
+	var s3Pointer *aws_extended_sqs_client.S3Pointer
+	client := aws_extended_sqs_client.NewExtendedSQSClient(s.mockSqs, s.config,
+		aws_extended_sqs_client.WithBeforeDeleteInterceptor(func(ctx context.Context, input *aws_sqs.DeleteMessageInput, pointer *aws_extended_sqs_client.S3Pointer) error {
+			s3Pointer = pointer
+			return nil
+		}),
+	)
+
+	s.mockS3.On("DeleteObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.DeleteObjectOutput{}, nil).Once()
+	s.mockSqs.On("DeleteMessage", mock.Anything).Return(&aws_sqs.DeleteMessageOutput{}, nil).Once()
+
+	_, err := client.DeleteMessage(&aws_sqs.DeleteMessageInput{
+		QueueUrl:      aws.String("test-queue"),
+		ReceiptHandle: aws.String("-..s3BucketName..-test-bucket-..s3BucketName..--..s3Key..-test-key-..s3Key..-test-receipt-handle"),
+	})
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), &aws_extended_sqs_client.S3Pointer{S3BucketName: "test-bucket", S3Key: "test-key"}, s3Pointer)
+}
+
+func (s *InterceptorTestSuite) Test_DeleteMessage_Failed_Before_Delete_Error() {
+	s.config.SetCleanupS3Payload(true)
+
+	client := aws_extended_sqs_client.NewExtendedSQSClient(s.mockSqs, s.config,
+		aws_extended_sqs_client.WithBeforeDeleteInterceptor(func(ctx context.Context, input *aws_sqs.DeleteMessageInput, pointer *aws_extended_sqs_client.S3Pointer) error {
+			return std_errors.New("not allowed")
+		}),
+	)
+
+	_, err := client.DeleteMessage(&aws_sqs.DeleteMessageInput{
+		QueueUrl:      aws.String("test-queue"),
+		ReceiptHandle: aws.String("-..s3BucketName..-test-bucket-..s3BucketName..--..s3Key..-test-key-..s3Key..-test-receipt-handle"),
+	})
+
+	s.mockS3.AssertNotCalled(s.T(), "DeleteObjectWithContext", mock.Anything, mock.Anything)
+	s.mockSqs.AssertNotCalled(s.T(), "DeleteMessage", mock.Anything)
+	assert.NotNil(s.T(), err)
+}
+
+func TestInterceptor(t *testing.T) {
+	suite.Run(t, new(InterceptorTestSuite))
+}
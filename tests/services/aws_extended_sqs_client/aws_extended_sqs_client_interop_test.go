@@ -0,0 +1,208 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/fakes"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client"
+	sqs_configs_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client/constants"
+
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/internal/payload_store/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/logging/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/services/aws_extended_sqs_client/mock"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const interopQueueName = "interop-queue"
+
+// Message in the wire format of an extended client, see testdata/interop. The fixtures are written after the documented
+// formats of the java and python clients and are not captured from a release of them, so the receipt handles, message ids
+// and keys are placeholders. Fixtures captured from the clients should record the version of the library they came from.
+type interopFixture struct {
+	Name string
+	// Raw message in sqs
+	Message *aws_sqs.Message
+	// Receipt handle as modified by the receive of the client of the format
+	ModifiedReceiptHandle string
+	S3BucketName          string
+	S3Key                 string
+	Payload               string
+}
+
+func loadInteropFixtures(t *testing.T, pattern string) []*interopFixture {
+	files, err := filepath.Glob(filepath.Join("testdata", "interop", pattern))
+	assert.Nil(t, err)
+	assert.NotEmpty(t, files)
+
+	var fixtures []*interopFixture
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		assert.Nil(t, err)
+
+		var fileFixtures []*interopFixture
+		assert.Nil(t, json.Unmarshal(data, &fileFixtures), file)
+
+		fixtures = append(fixtures, fileFixtures...)
+	}
+
+	return fixtures
+}
+
+func newInteropClient(fakeSqs *fakes.FakeSQS, fakeS3 *fakes.FakeS3, s3BucketName string) *aws_extended_sqs_client.AwsExtendedSQSClient {
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	config.WithPayloadSupportEnabled(fakeS3, s3BucketName)
+
+	return aws_extended_sqs_client.NewExtendedSQSClient(fakeSqs, config, aws_extended_sqs_client.WithLogger(NewMockLogger()))
+}
+
+func Test_Interop_ReceiveMessage_Resolves_Foreign_Messages(t *testing.T) {
+	for _, fixture := range append(loadInteropFixtures(t, "java_*.json"), loadInteropFixtures(t, "python.json")...) {
+		t.Run(fixture.Name, func(t *testing.T) {
+			fakeSqs := fakes.NewFakeSQS(interopQueueName)
+			fakeS3 := fakes.NewFakeS3(fixture.S3BucketName)
+			queueUrl := fakeSqs.QueueUrl(interopQueueName)
+			client := newInteropClient(fakeSqs, fakeS3, fixture.S3BucketName)
+
+			_, err := fakeSqs.SendMessage(&aws_sqs.SendMessageInput{
+				QueueUrl:          aws.String(queueUrl),
+				MessageBody:       fixture.Message.Body,
+				MessageAttributes: fixture.Message.MessageAttributes,
+			})
+			assert.Nil(t, err)
+
+			_, err = fakeS3.PutObject(&aws_s3.PutObjectInput{
+				Bucket: aws.String(fixture.S3BucketName),
+				Key:    aws.String(fixture.S3Key),
+				Body:   bytes.NewReader([]byte(fixture.Payload)),
+			})
+			assert.Nil(t, err)
+
+			output, err := client.ReceiveMessage(&aws_sqs.ReceiveMessageInput{
+				QueueUrl:              aws.String(queueUrl),
+				MessageAttributeNames: []*string{aws.String("All")},
+			})
+			assert.Nil(t, err)
+			assert.Len(t, output.Messages, 1)
+
+			message := output.Messages[0]
+			assert.Equal(t, fixture.Payload, *message.Body)
+			assert.NotContains(t, message.MessageAttributes, sqs_configs_constants.RESERVED_ATTRIBUTE_NAME)
+			assert.NotContains(t, message.MessageAttributes, sqs_configs_constants.LEGACY_RESERVED_ATTRIBUTE_NAME)
+			for name, value := range fixture.Message.MessageAttributes {
+				if name != sqs_configs_constants.RESERVED_ATTRIBUTE_NAME && name != sqs_configs_constants.LEGACY_RESERVED_ATTRIBUTE_NAME {
+					assert.Equal(t, value, message.MessageAttributes[name])
+				}
+			}
+			assert.True(t, strings.HasPrefix(*message.ReceiptHandle, sqs_configs_constants.S3_BUCKET_NAME_MARKER+fixture.S3BucketName+sqs_configs_constants.S3_BUCKET_NAME_MARKER+
+				sqs_configs_constants.S3_KEY_MARKER+fixture.S3Key+sqs_configs_constants.S3_KEY_MARKER))
+
+			_, err = client.DeleteMessage(&aws_sqs.DeleteMessageInput{QueueUrl: aws.String(queueUrl), ReceiptHandle: message.ReceiptHandle})
+			assert.Nil(t, err)
+			assert.Empty(t, fakeSqs.Messages(queueUrl))
+			assert.Empty(t, fakeS3.Keys(fixture.S3BucketName))
+		})
+	}
+}
+
+func Test_Interop_DeleteMessage_Foreign_Receipt_Handles(t *testing.T) {
+	for _, fixture := range append(loadInteropFixtures(t, "java_*.json"), loadInteropFixtures(t, "python.json")...) {
+		t.Run(fixture.Name, func(t *testing.T) {
+			mockSqs := new(MockSqs)
+			mockS3 := new(MockS3)
+
+			config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+			config.WithPayloadSupportEnabled(mockS3, fixture.S3BucketName)
+			client := aws_extended_sqs_client.NewExtendedSQSClient(mockSqs, config, aws_extended_sqs_client.WithLogger(NewMockLogger()))
+
+			mockSqs.On("DeleteMessage", mock.MatchedBy(func(input *aws_sqs.DeleteMessageInput) bool {
+				return *input.ReceiptHandle == *fixture.Message.ReceiptHandle
+			})).Return(&aws_sqs.DeleteMessageOutput{}, nil).Once()
+			mockS3.On("DeleteObjectWithContext", mock.Anything, mock.MatchedBy(func(input *aws_s3.DeleteObjectInput) bool {
+				return *input.Bucket == fixture.S3BucketName && *input.Key == fixture.S3Key
+			})).Return(&aws_s3.DeleteObjectOutput{}, nil).Once()
+
+			_, err := client.DeleteMessage(&aws_sqs.DeleteMessageInput{ReceiptHandle: aws.String(fixture.ModifiedReceiptHandle)})
+			assert.Nil(t, err)
+
+			mockSqs.AssertExpectations(t)
+			mockS3.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_Interop_DeleteMessage_Key_Marker_First(t *testing.T) {
+	mockSqs := new(MockSqs)
+	mockS3 := new(MockS3)
+
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	config.WithPayloadSupportEnabled(mockS3, "interop-bucket")
+	client := aws_extended_sqs_client.NewExtendedSQSClient(mockSqs, config, aws_extended_sqs_client.WithLogger(NewMockLogger()))
+
+	receiptHandle := sqs_configs_constants.S3_KEY_MARKER + "interop-key" + sqs_configs_constants.S3_KEY_MARKER +
+		sqs_configs_constants.S3_BUCKET_NAME_MARKER + "interop-bucket" + sqs_configs_constants.S3_BUCKET_NAME_MARKER + "original"
+
+	mockSqs.On("DeleteMessage", mock.MatchedBy(func(input *aws_sqs.DeleteMessageInput) bool {
+		return *input.ReceiptHandle == "original"
+	})).Return(&aws_sqs.DeleteMessageOutput{}, nil).Once()
+	mockS3.On("DeleteObjectWithContext", mock.Anything, mock.MatchedBy(func(input *aws_s3.DeleteObjectInput) bool {
+		return *input.Bucket == "interop-bucket" && *input.Key == "interop-key"
+	})).Return(&aws_s3.DeleteObjectOutput{}, nil).Once()
+
+	_, err := client.DeleteMessage(&aws_sqs.DeleteMessageInput{ReceiptHandle: aws.String(receiptHandle)})
+	assert.Nil(t, err)
+
+	mockSqs.AssertExpectations(t)
+	mockS3.AssertExpectations(t)
+}
+
+// The pointer and the reserved attribute are sent in the format the java and python fixtures are written in
+func Test_Interop_SendMessage_Matches_Fixture(t *testing.T) {
+	for _, fixture := range loadInteropFixtures(t, "go.json") {
+		t.Run(fixture.Name, func(t *testing.T) {
+			fakeSqs := fakes.NewFakeSQS(interopQueueName)
+			fakeS3 := fakes.NewFakeS3(fixture.S3BucketName)
+			queueUrl := fakeSqs.QueueUrl(interopQueueName)
+
+			config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+			config.WithPayloadSupportEnabled(fakeS3, fixture.S3BucketName)
+			config.SetAlwaysThroughS3(true)
+			client := aws_extended_sqs_client.NewExtendedSQSClient(fakeSqs, config, aws_extended_sqs_client.WithLogger(NewMockLogger()))
+
+			messageAttributes := map[string]*aws_sqs.MessageAttributeValue{}
+			for name, value := range fixture.Message.MessageAttributes {
+				if name != sqs_configs_constants.RESERVED_ATTRIBUTE_NAME {
+					messageAttributes[name] = value
+				}
+			}
+
+			_, err := client.SendMessage(&aws_sqs.SendMessageInput{
+				QueueUrl:          aws.String(queueUrl),
+				MessageBody:       aws.String(fixture.Payload),
+				MessageAttributes: messageAttributes,
+			})
+			assert.Nil(t, err)
+
+			keys := fakeS3.Keys(fixture.S3BucketName)
+			assert.Len(t, keys, 1)
+
+			payload, _ := fakeS3.Object(fixture.S3BucketName, keys[0])
+			assert.Equal(t, fixture.Payload, string(payload))
+
+			messages := fakeSqs.Messages(queueUrl)
+			assert.Len(t, messages, 1)
+			assert.Equal(t, *fixture.Message.Body, strings.Replace(*messages[0].Body, keys[0], fixture.S3Key, 1))
+			assert.Equal(t, fixture.Message.MessageAttributes, messages[0].MessageAttributes)
+		})
+	}
+}
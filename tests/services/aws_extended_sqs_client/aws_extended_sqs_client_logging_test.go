@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	logging_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/logging/constants"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client"
+	sqs_configs_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client/constants"
+
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/internal/payload_store/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/logging/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/services/aws_extended_sqs_client/mock"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_ExtendedSqsClient_SendMessage_Logging_Attributes(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opts     []aws_extended_sqs_client.AwsExtendedSQSClientOption
+		expected map[string]interface{}
+	}{
+		{
+			name:     "all attributes by default",
+			expected: map[string]interface{}{"CustomerId": "customer-id", "Token": "secret", "Type": "order"},
+		},
+		{
+			name: "deny list",
+			opts: []aws_extended_sqs_client.AwsExtendedSQSClientOption{
+				aws_extended_sqs_client.WithLoggingAttributeDenyList("Token"),
+			},
+			expected: map[string]interface{}{"CustomerId": "customer-id", "Type": "order"},
+		},
+		{
+			name: "allow list",
+			opts: []aws_extended_sqs_client.AwsExtendedSQSClientOption{
+				aws_extended_sqs_client.WithLoggingAttributeAllowList("Type", "Token"),
+				aws_extended_sqs_client.WithLoggingAttributeDenyList("Token"),
+			},
+			expected: map[string]interface{}{"Type": "order"},
+		},
+		{
+			name: "redactor",
+			opts: []aws_extended_sqs_client.AwsExtendedSQSClientOption{
+				aws_extended_sqs_client.WithLoggingAttributeRedactor(func(name string, value string) (string, bool) {
+					switch name {
+					case "Token":
+						return "", false
+					case "CustomerId":
+						return "[REDACTED]", true
+					}
+					return value, true
+				}),
+			},
+			expected: map[string]interface{}{"CustomerId": "[REDACTED]", "Type": "order"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			mockSqs := new(MockSqs)
+			logger := NewMockLogger()
+
+			config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+			config.WithPayloadSupportEnabled(new(MockS3), "test-bucket")
+			opts := append([]aws_extended_sqs_client.AwsExtendedSQSClientOption{aws_extended_sqs_client.WithLogger(logger)}, testCase.opts...)
+			client := aws_extended_sqs_client.NewExtendedSQSClient(mockSqs, config, opts...)
+
+			mockSqs.On("SendMessage", mock.Anything).Return(&aws_sqs.SendMessageOutput{MessageId: aws.String("test-message-id")}, nil).Once()
+
+			_, err := client.SendMessage(&aws_sqs.SendMessageInput{
+				MessageBody: aws.String("test"),
+				MessageAttributes: map[string]*aws_sqs.MessageAttributeValue{
+					"CustomerId": {DataType: aws.String("String"), StringValue: aws.String("customer-id")},
+					"Token":      {DataType: aws.String("String"), StringValue: aws.String("secret")},
+					"Type":       {DataType: aws.String("String"), StringValue: aws.String("order")},
+					"Payload":    {DataType: aws.String("Binary"), BinaryValue: []byte("binary")},
+				},
+			})
+
+			assert.Nil(t, err)
+
+			entry := logger.LastEntry()
+			assert.Equal(t, "Handled by original sqs sdk", entry.Message)
+
+			for _, name := range []string{"CustomerId", "Token", "Type", "Payload"} {
+				expectedValue, ok := testCase.expected[name]
+				if ok {
+					assert.Equal(t, expectedValue, entry.Fields[name])
+				} else {
+					assert.NotContains(t, entry.Fields, name)
+				}
+			}
+		})
+	}
+}
+
+func Test_ExtendedSqsClient_SendMessage_Log_Level(t *testing.T) {
+	mockSqs := new(MockSqs)
+	logger := NewMockLogger()
+
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	config.WithPayloadSupportEnabled(new(MockS3), "test-bucket")
+	config.WithBreakSendSupportEnabled()
+	config.SetBreakSendPayloadSizeThreshold(1024)
+
+	client := aws_extended_sqs_client.NewExtendedSQSClient(mockSqs, config,
+		aws_extended_sqs_client.WithLogger(logger),
+		aws_extended_sqs_client.WithLogLevel(sqs_configs_constants.LOG_METHOD_SEND_MESSAGE, logging_constants.LOG_LEVEL_DEBUG),
+	)
+
+	mockSqs.On("SendMessage", mock.Anything).Return(&aws_sqs.SendMessageOutput{MessageId: aws.String("test-message-id")}, nil).Once()
+
+	_, err := client.SendMessage(&aws_sqs.SendMessageInput{MessageBody: aws.String("test")})
+
+	assert.Nil(t, err)
+	assert.NotEmpty(t, logger.Entries())
+	for _, entry := range logger.Entries() {
+		assert.Equal(t, logging_constants.LOG_LEVEL_DEBUG, entry.Level)
+	}
+
+	largeBody := strings.Repeat("test", 65537)
+	_, err = client.SendMessage(&aws_sqs.SendMessageInput{MessageBody: &largeBody})
+
+	assert.NotNil(t, err)
+	assert.Equal(t, logging_constants.LOG_LEVEL_ERROR, logger.LastEntry().Level)
+}
+
+func Test_ExtendedSqsClient_ReceiveMessage_Log_Level(t *testing.T) {
+	mockSqs := new(MockSqs)
+	logger := NewMockLogger()
+
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+
+	client := aws_extended_sqs_client.NewExtendedSQSClient(mockSqs, config,
+		aws_extended_sqs_client.WithLogger(logger),
+		aws_extended_sqs_client.WithLogLevel(sqs_configs_constants.LOG_METHOD_SEND_MESSAGE, logging_constants.LOG_LEVEL_WARN),
+		aws_extended_sqs_client.WithLogLevel(sqs_configs_constants.LOG_METHOD_RECEIVE_MESSAGE, logging_constants.LOG_LEVEL_DEBUG),
+	)
+
+	mockSqs.On("ReceiveMessage", mock.Anything).Return(&aws_sqs.ReceiveMessageOutput{}, nil).Once()
+
+	_, err := client.ReceiveMessage(&aws_sqs.ReceiveMessageInput{QueueUrl: aws.String("test-queue")})
+	assert.Nil(t, err)
+
+	assert.Equal(t, logging_constants.LOG_LEVEL_DEBUG, logger.LastEntry().Level)
+	assert.Equal(t, sqs_configs_constants.LOG_METHOD_RECEIVE_MESSAGE, logger.LastEntry().Fields["method"])
+}
@@ -0,0 +1,286 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client"
+	sqs_configs_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client/constants"
+
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/internal/payload_store/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/services/aws_extended_sqs_client/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/tracing/mock"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_CalculateMessageSize(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    *aws_sqs.SendMessageInput
+		expected aws_extended_sqs_client.MessageSize
+	}{
+		{
+			name:     "nil input",
+			input:    nil,
+			expected: aws_extended_sqs_client.MessageSize{},
+		},
+		{
+			name:     "nil body",
+			input:    &aws_sqs.SendMessageInput{},
+			expected: aws_extended_sqs_client.MessageSize{},
+		},
+		{
+			name:     "ascii body",
+			input:    &aws_sqs.SendMessageInput{MessageBody: aws.String("test")},
+			expected: aws_extended_sqs_client.MessageSize{Body: 4},
+		},
+		{
+			name:     "multi-byte utf-8 body",
+			input:    &aws_sqs.SendMessageInput{MessageBody: aws.String("測試ü😀")},
+			expected: aws_extended_sqs_client.MessageSize{Body: 3 + 3 + 2 + 4},
+		},
+		{
+			name: "string attribute",
+			input: &aws_sqs.SendMessageInput{
+				MessageBody: aws.String("test"),
+				MessageAttributes: map[string]*aws_sqs.MessageAttributeValue{
+					"name": {DataType: aws.String("String"), StringValue: aws.String("測試")},
+				},
+			},
+			expected: aws_extended_sqs_client.MessageSize{Body: 4, MessageAttributes: 4 + 6 + 6},
+		},
+		{
+			name: "number attribute with custom type",
+			input: &aws_sqs.SendMessageInput{
+				MessageAttributes: map[string]*aws_sqs.MessageAttributeValue{
+					"count": {DataType: aws.String("Number.int"), StringValue: aws.String("12345")},
+				},
+			},
+			expected: aws_extended_sqs_client.MessageSize{MessageAttributes: 5 + 10 + 5},
+		},
+		{
+			name: "binary attribute counts raw bytes",
+			input: &aws_sqs.SendMessageInput{
+				MessageAttributes: map[string]*aws_sqs.MessageAttributeValue{
+					"bin": {DataType: aws.String("Binary"), BinaryValue: []byte{0x00, 0xff, 0x10}},
+				},
+			},
+			expected: aws_extended_sqs_client.MessageSize{MessageAttributes: 3 + 6 + 3},
+		},
+		{
+			name: "list attributes",
+			input: &aws_sqs.SendMessageInput{
+				MessageAttributes: map[string]*aws_sqs.MessageAttributeValue{
+					"strings":  {DataType: aws.String("String"), StringListValues: []*string{aws.String("ab"), aws.String("cde")}},
+					"binaries": {DataType: aws.String("Binary"), BinaryListValues: [][]byte{{0x01}, {0x02, 0x03}}},
+				},
+			},
+			expected: aws_extended_sqs_client.MessageSize{MessageAttributes: (7 + 6 + 5) + (8 + 6 + 3)},
+		},
+		{
+			name: "nil attribute value",
+			input: &aws_sqs.SendMessageInput{
+				MessageAttributes: map[string]*aws_sqs.MessageAttributeValue{
+					"empty": nil,
+				},
+			},
+			expected: aws_extended_sqs_client.MessageSize{MessageAttributes: 5},
+		},
+		{
+			name: "system attributes",
+			input: &aws_sqs.SendMessageInput{
+				MessageBody: aws.String("test"),
+				MessageSystemAttributes: map[string]*aws_sqs.MessageSystemAttributeValue{
+					aws_sqs.MessageSystemAttributeNameForSendsAwstraceHeader: {
+						DataType:    aws.String("String"),
+						StringValue: aws.String("Root=1-5759e988-bd862e3fe1be46a994272793;Sampled=1"),
+					},
+				},
+			},
+			expected: aws_extended_sqs_client.MessageSize{Body: 4, MessageSystemAttributes: 14 + 6 + 50},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			size := aws_extended_sqs_client.CalculateMessageSize(testCase.input)
+
+			assert.Equal(t, testCase.expected, size)
+			assert.Equal(t, testCase.expected.Body+testCase.expected.MessageAttributes+testCase.expected.MessageSystemAttributes, size.Total())
+		})
+	}
+}
+
+func Test_CalculateBatchEntrySize(t *testing.T) {
+	size := aws_extended_sqs_client.CalculateBatchEntrySize(&aws_sqs.SendMessageBatchRequestEntry{
+		Id:          aws.String("entry-id-is-not-counted"),
+		MessageBody: aws.String("test"),
+		MessageAttributes: map[string]*aws_sqs.MessageAttributeValue{
+			"name": {DataType: aws.String("String"), StringValue: aws.String("value")},
+		},
+	})
+
+	assert.Equal(t, aws_extended_sqs_client.MessageSize{Body: 4, MessageAttributes: 4 + 6 + 5}, size)
+}
+
+func Test_ExtendedSqsClient_SendMessage_Destination_At_Size_Limit(t *testing.T) {
+	limit := sqs_configs_constants.DEFAULT_MESSAGE_SIZE_THRESHOLD
+	traceHeader := "Root=1-5759e988-bd862e3fe1be46a994272793;Sampled=1"
+
+	testCases := []struct {
+		name              string
+		input             *aws_sqs.SendMessageInput
+		expectedThroughS3 bool
+	}{
+		{
+			name:              "body at the limit",
+			input:             &aws_sqs.SendMessageInput{MessageBody: aws.String(strings.Repeat("x", limit))},
+			expectedThroughS3: false,
+		},
+		{
+			name:              "body one byte over the limit",
+			input:             &aws_sqs.SendMessageInput{MessageBody: aws.String(strings.Repeat("x", limit+1))},
+			expectedThroughS3: true,
+		},
+		{
+			name:              "multi-byte body at the limit",
+			input:             &aws_sqs.SendMessageInput{MessageBody: aws.String(strings.Repeat("ü", limit/2))},
+			expectedThroughS3: false,
+		},
+		{
+			name:              "multi-byte body over the limit",
+			input:             &aws_sqs.SendMessageInput{MessageBody: aws.String(strings.Repeat("ü", limit/2) + "x")},
+			expectedThroughS3: true,
+		},
+		{
+			name: "body and attributes at the limit",
+			input: &aws_sqs.SendMessageInput{
+				MessageBody: aws.String(strings.Repeat("x", limit-4-6-5)),
+				MessageAttributes: map[string]*aws_sqs.MessageAttributeValue{
+					"name": {DataType: aws.String("String"), StringValue: aws.String("value")},
+				},
+			},
+			expectedThroughS3: false,
+		},
+		{
+			name: "body and list attributes over the limit",
+			input: &aws_sqs.SendMessageInput{
+				MessageBody: aws.String(strings.Repeat("x", limit-4-6-5)),
+				MessageAttributes: map[string]*aws_sqs.MessageAttributeValue{
+					"name": {DataType: aws.String("String"), StringValue: aws.String("value"), StringListValues: []*string{aws.String("x")}},
+				},
+			},
+			expectedThroughS3: true,
+		},
+		{
+			name: "body at the limit with trace header",
+			input: &aws_sqs.SendMessageInput{
+				MessageBody: aws.String(strings.Repeat("x", limit)),
+				MessageSystemAttributes: map[string]*aws_sqs.MessageSystemAttributeValue{
+					aws_sqs.MessageSystemAttributeNameForSendsAwstraceHeader: {DataType: aws.String("String"), StringValue: &traceHeader},
+				},
+			},
+			expectedThroughS3: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			mockSqs := new(MockSqs)
+			mockS3 := new(MockS3)
+
+			config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+			config.WithPayloadSupportEnabled(mockS3, "test-bucket")
+			client := aws_extended_sqs_client.NewExtendedSQSClient(mockSqs, config)
+
+			if testCase.expectedThroughS3 {
+				mockS3.On("PutObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.PutObjectOutput{}, nil).Once()
+			}
+			mockSqs.On("SendMessage", mock.MatchedBy(func(input *aws_sqs.SendMessageInput) bool {
+				_, throughS3 := input.MessageAttributes[sqs_configs_constants.RESERVED_ATTRIBUTE_NAME]
+				return throughS3 == testCase.expectedThroughS3
+			})).Return(&aws_sqs.SendMessageOutput{MessageId: aws.String("test-message-id")}, nil).Once()
+
+			_, err := client.SendMessage(testCase.input)
+
+			mockSqs.AssertExpectations(t)
+			mockS3.AssertExpectations(t)
+
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func Test_ExtendedSqsClient_CalculateSentMessageSize(t *testing.T) {
+	reservedAttributeSize := len(sqs_configs_constants.RESERVED_ATTRIBUTE_NAME) + len("Number")
+	traceContextSize := len(TRACE_PARENT_FIELD) + len("String") + len(testTraceParent)
+
+	testCases := []struct {
+		name                      string
+		configure                 func(config *aws_extended_sqs_client.AwsExtendedSQSClientConfiguration)
+		opts                      []aws_extended_sqs_client.AwsExtendedSQSClientOption
+		body                      string
+		expectedThroughS3         bool
+		expectedMessageAttributes int
+	}{
+		{
+			name:                      "message kept in sqs",
+			configure:                 func(config *aws_extended_sqs_client.AwsExtendedSQSClientConfiguration) {},
+			body:                      "test",
+			expectedMessageAttributes: 0,
+		},
+		{
+			name:                      "trace context counted",
+			configure:                 func(config *aws_extended_sqs_client.AwsExtendedSQSClientConfiguration) {},
+			opts:                      []aws_extended_sqs_client.AwsExtendedSQSClientOption{aws_extended_sqs_client.WithTracer(&MockTracer{TraceParent: testTraceParent})},
+			body:                      "test",
+			expectedMessageAttributes: traceContextSize,
+		},
+		{
+			name: "message over the configured threshold",
+			configure: func(config *aws_extended_sqs_client.AwsExtendedSQSClientConfiguration) {
+				config.SetPayloadSizeThreshold(100)
+			},
+			body:                      strings.Repeat("x", 200),
+			expectedThroughS3:         true,
+			expectedMessageAttributes: reservedAttributeSize + len("200"),
+		},
+		{
+			name: "message always through s3",
+			configure: func(config *aws_extended_sqs_client.AwsExtendedSQSClientConfiguration) {
+				config.SetAlwaysThroughS3(true)
+			},
+			body:                      "test",
+			expectedThroughS3:         true,
+			expectedMessageAttributes: reservedAttributeSize + len("4"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+			config.WithPayloadSupportEnabled(new(MockS3), "test-bucket")
+			testCase.configure(config)
+			client := aws_extended_sqs_client.NewExtendedSQSClient(new(MockSqs), config, testCase.opts...)
+
+			input := &aws_sqs.SendMessageInput{QueueUrl: aws.String("test-queue"), MessageBody: aws.String(testCase.body)}
+			size := client.CalculateSentMessageSize(context.Background(), input)
+
+			if testCase.expectedThroughS3 {
+				// the longest possible message pointer stands for the body
+				assert.Greater(t, size.Body, sqs_configs_constants.MAX_S3_KEY_LENGTH)
+			} else {
+				assert.Equal(t, len(testCase.body), size.Body)
+			}
+			assert.Equal(t, testCase.expectedMessageAttributes, size.MessageAttributes)
+			assert.Nil(t, input.MessageAttributes)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	metrics_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/metrics/constants"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client"
+
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/internal/payload_store/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/metrics/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/services/aws_extended_sqs_client/mock"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MetricsTestSuite struct {
+	suite.Suite
+
+	config *aws_extended_sqs_client.AwsExtendedSQSClientConfiguration
+	client *aws_extended_sqs_client.AwsExtendedSQSClient
+
+	mockSqs             *MockSqs
+	mockS3              *MockS3
+	mockMetricsRecorder *MockMetricsRecorder
+}
+
+func (suite *MetricsTestSuite) SetupTest() {
+	suite.mockSqs = new(MockSqs)
+	suite.mockS3 = new(MockS3)
+	suite.mockMetricsRecorder = new(MockMetricsRecorder)
+
+	suite.config = aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	suite.config.WithPayloadSupportEnabled(suite.mockS3, "test-bucket")
+
+	suite.client = aws_extended_sqs_client.NewExtendedSQSClient(suite.mockSqs, suite.config, aws_extended_sqs_client.WithMetricsRecorder(suite.mockMetricsRecorder))
+}
+
+func (s *MetricsTestSuite) Test_SendMessage_Offloaded() {
+	largeBody := strings.Repeat("test", 65537)
+	s3Labels := map[string]string{metrics_constants.LABEL_OPERATION: "store", metrics_constants.LABEL_STATUS: metrics_constants.STATUS_SUCCESS}
+	sentLabels := map[string]string{metrics_constants.LABEL_DESTINATION: metrics_constants.DESTINATION_S3}
+
+	s.mockS3.On("PutObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.PutObjectOutput{}, nil).Once()
+	s.mockSqs.On("SendMessage", mock.Anything).Return(&aws_sqs.SendMessageOutput{MessageId: aws.String("test-message-id")}, nil).Once()
+
+	s.mockMetricsRecorder.On("IncrementCounter", metrics_constants.METRIC_S3_OPERATIONS, s3Labels).Once()
+	s.mockMetricsRecorder.On("ObserveHistogram", metrics_constants.METRIC_S3_OPERATION_DURATION, mock.AnythingOfType("float64"), s3Labels).Once()
+	s.mockMetricsRecorder.On("IncrementCounter", metrics_constants.METRIC_MESSAGES_SENT, sentLabels).Once()
+	s.mockMetricsRecorder.On("ObserveHistogram", metrics_constants.METRIC_MESSAGE_PAYLOAD_SIZE, float64(len(largeBody)), sentLabels).Once()
+
+	_, err := s.client.SendMessage(&aws_sqs.SendMessageInput{MessageBody: &largeBody})
+
+	s.mockMetricsRecorder.AssertExpectations(s.T())
+	assert.Nil(s.T(), err)
+}
+
+func (s *MetricsTestSuite) Test_SendMessage_Inline() {
+	sentLabels := map[string]string{metrics_constants.LABEL_DESTINATION: metrics_constants.DESTINATION_SQS}
+
+	s.mockSqs.On("SendMessage", mock.Anything).Return(&aws_sqs.SendMessageOutput{MessageId: aws.String("test-message-id")}, nil).Once()
+
+	s.mockMetricsRecorder.On("IncrementCounter", metrics_constants.METRIC_MESSAGES_SENT, sentLabels).Once()
+	s.mockMetricsRecorder.On("ObserveHistogram", metrics_constants.METRIC_MESSAGE_PAYLOAD_SIZE, float64(4), sentLabels).Once()
+
+	_, err := s.client.SendMessage(&aws_sqs.SendMessageInput{MessageBody: aws.String("test")})
+
+	s.mockMetricsRecorder.AssertExpectations(s.T())
+	assert.Nil(s.T(), err)
+}
+
+func (s *MetricsTestSuite) Test_SendMessage_S3_Error() {
+	largeBody := strings.Repeat("test", 65537)
+	s3Labels := map[string]string{metrics_constants.LABEL_OPERATION: "store", metrics_constants.LABEL_STATUS: metrics_constants.STATUS_ERROR}
+
+	s.mockS3.On("PutObjectWithContext", mock.Anything, mock.Anything).Return(
+		&aws_s3.PutObjectOutput{},
+		awserr.New(aws_s3.ErrCodeNoSuchBucket, "The specified bucket does not exist", nil),
+	).Once()
+
+	s.mockMetricsRecorder.On("IncrementCounter", metrics_constants.METRIC_S3_OPERATIONS, s3Labels).Once()
+	s.mockMetricsRecorder.On("ObserveHistogram", metrics_constants.METRIC_S3_OPERATION_DURATION, mock.AnythingOfType("float64"), s3Labels).Once()
+
+	_, err := s.client.SendMessage(&aws_sqs.SendMessageInput{MessageBody: &largeBody})
+
+	s.mockMetricsRecorder.AssertExpectations(s.T())
+	s.mockMetricsRecorder.AssertNotCalled(s.T(), "IncrementCounter", metrics_constants.METRIC_MESSAGES_SENT, mock.Anything)
+	assert.NotNil(s.T(), err)
+}
+
+func (s *MetricsTestSuite) Test_SendMessage_Break_Send_Rejected() {
+	s.config.WithBreakSendSupportEnabled()
+	s.config.SetBreakSendPayloadSizeThreshold(1024)
+
+	largeBody := strings.Repeat("test", 65537)
+
+	s.mockMetricsRecorder.On("IncrementCounter", metrics_constants.METRIC_BREAK_SEND_REJECTIONS, map[string]string{}).Once()
+
+	_, err := s.client.SendMessage(&aws_sqs.SendMessageInput{MessageBody: &largeBody})
+
+	s.mockMetricsRecorder.AssertExpectations(s.T())
+	assert.NotNil(s.T(), err)
+}
+
+func (s *MetricsTestSuite) Test_ReceiveMessage_Inline() {
+	s.mockSqs.On("ReceiveMessage", mock.Anything).Return(&aws_sqs.ReceiveMessageOutput{
+		Messages: []*aws_sqs.Message{{Body: aws.String("test"), ReceiptHandle: aws.String("test-receipt-handle")}},
+	}, nil).Once()
+
+	s.mockMetricsRecorder.On("IncrementCounter", metrics_constants.METRIC_MESSAGES_RECEIVED, map[string]string{metrics_constants.LABEL_DESTINATION: metrics_constants.DESTINATION_SQS}).Once()
+
+	_, err := s.client.ReceiveMessage(&aws_sqs.ReceiveMessageInput{QueueUrl: aws.String("test-queue")})
+
+	s.mockMetricsRecorder.AssertExpectations(s.T())
+	assert.Nil(s.T(), err)
+}
+
+func TestMetrics(t *testing.T) {
+	suite.Run(t, new(MetricsTestSuite))
+}
@@ -0,0 +1,211 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client"
+	sqs_configs_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client/constants"
+
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/internal/payload_store/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/services/aws_extended_sqs_client/mock"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type PayloadEnvelopeTestSuite struct {
+	suite.Suite
+
+	client *aws_extended_sqs_client.AwsExtendedSQSClient
+
+	mockSqs *MockSqs
+	mockS3  *MockS3
+}
+
+func (suite *PayloadEnvelopeTestSuite) SetupTest() {
+	suite.mockSqs = new(MockSqs)
+	suite.mockS3 = new(MockS3)
+
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	config.WithPayloadSupportEnabled(suite.mockS3, "test-bucket")
+	config.SetAttributeOffloadEnabled(true)
+
+	suite.client = aws_extended_sqs_client.NewExtendedSQSClient(suite.mockSqs, config)
+}
+
+func (s *PayloadEnvelopeTestSuite) Test_SendMessage_Success_Large_Attributes_Moved_To_S3() {
+	largeValue1 := strings.Repeat("x", sqs_configs_constants.DEFAULT_MESSAGE_SIZE_THRESHOLD/2)
+	largeValue2 := strings.Repeat("y", sqs_configs_constants.DEFAULT_MESSAGE_SIZE_THRESHOLD/2)
+
+	var storedPayload string
+	s.mockS3.On("PutObjectWithContext", mock.Anything, mock.MatchedBy(func(input *aws_s3.PutObjectInput) bool {
+		input.Body.Seek(0, io.SeekStart)
+		body, _ := ioutil.ReadAll(input.Body)
+		storedPayload = string(body)
+		return true
+	})).Return(&aws_s3.PutObjectOutput{}, nil).Once()
+
+	var sentInput *aws_sqs.SendMessageInput
+	s.mockSqs.On("SendMessage", mock.MatchedBy(func(input *aws_sqs.SendMessageInput) bool {
+		sentInput = input
+		return true
+	})).Return(&aws_sqs.SendMessageOutput{MessageId: aws.String("test-message-id")}, nil).Once()
+
+	_, err := s.client.SendMessage(&aws_sqs.SendMessageInput{
+		MessageBody: aws.String("test"),
+		MessageAttributes: map[string]*aws_sqs.MessageAttributeValue{
+			"LargeAttribute1": {DataType: aws.String("String"), StringValue: &largeValue1},
+			"LargeAttribute2": {DataType: aws.String("String"), StringValue: &largeValue2},
+			"SmallAttribute":  {DataType: aws.String("String"), StringValue: aws.String("small")},
+		},
+	})
+
+	s.mockSqs.AssertExpectations(s.T())
+	s.mockS3.AssertExpectations(s.T())
+
+	assert.Nil(s.T(), err)
+
+	assert.NotContains(s.T(), sentInput.MessageAttributes, "LargeAttribute1")
+	assert.Contains(s.T(), sentInput.MessageAttributes, "LargeAttribute2")
+	assert.Contains(s.T(), sentInput.MessageAttributes, "SmallAttribute")
+	assert.Equal(s.T(), sqs_configs_constants.PAYLOAD_ENVELOPE_VERSION, *sentInput.MessageAttributes[sqs_configs_constants.PAYLOAD_ENVELOPE_ATTRIBUTE_NAME].StringValue)
+	assert.Equal(s.T(), "4", *sentInput.MessageAttributes[sqs_configs_constants.RESERVED_ATTRIBUTE_NAME].StringValue)
+	assert.LessOrEqual(s.T(), aws_extended_sqs_client.CalculateMessageSize(sentInput).Total(), sqs_configs_constants.DEFAULT_MESSAGE_SIZE_THRESHOLD)
+
+	envelope := map[string]interface{}{}
+	assert.Nil(s.T(), json.Unmarshal([]byte(storedPayload), &envelope))
+	assert.Equal(s.T(), "test", envelope["body"])
+	assert.Contains(s.T(), envelope["messageAttributes"], "LargeAttribute1")
+	assert.NotContains(s.T(), envelope["messageAttributes"], "SmallAttribute")
+}
+
+func (s *PayloadEnvelopeTestSuite) Test_SendMessage_Success_Large_Body_Without_Envelope() {
+	largeBody := strings.Repeat("test", 65537)
+
+	s.mockS3.On("PutObjectWithContext", mock.Anything, mock.MatchedBy(func(input *aws_s3.PutObjectInput) bool {
+		input.Body.Seek(0, io.SeekStart)
+		body, _ := ioutil.ReadAll(input.Body)
+		return string(body) == largeBody
+	})).Return(&aws_s3.PutObjectOutput{}, nil).Once()
+	s.mockSqs.On("SendMessage", mock.MatchedBy(func(input *aws_sqs.SendMessageInput) bool {
+		_, ok := input.MessageAttributes[sqs_configs_constants.PAYLOAD_ENVELOPE_ATTRIBUTE_NAME]
+		return !ok
+	})).Return(&aws_sqs.SendMessageOutput{MessageId: aws.String("test-message-id")}, nil).Once()
+
+	_, err := s.client.SendMessage(&aws_sqs.SendMessageInput{
+		MessageBody: &largeBody,
+		MessageAttributes: map[string]*aws_sqs.MessageAttributeValue{
+			"SmallAttribute": {DataType: aws.String("String"), StringValue: aws.String("small")},
+		},
+	})
+
+	s.mockSqs.AssertExpectations(s.T())
+	s.mockS3.AssertExpectations(s.T())
+
+	assert.Nil(s.T(), err)
+}
+
+func (s *PayloadEnvelopeTestSuite) Test_ReceiveMessage_Success_Attributes_Restored() {
+	message := createEnvelopeMessage()
+	envelope := `{"body":"test","messageAttributes":{"LargeAttribute1":{"dataType":"String","stringValue":"large"},"Binary":{"dataType":"Binary","binaryValue":"AAE="}}}`
+
+	s.mockSqs.On("ReceiveMessage", mock.Anything).Return(&aws_sqs.ReceiveMessageOutput{
+		Messages: []*aws_sqs.Message{message},
+	}, nil).Once()
+	s.mockS3.On("GetObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.GetObjectOutput{
+		Body: ioutil.NopCloser(strings.NewReader(envelope)),
+	}, nil).Once()
+
+	output, err := s.client.ReceiveMessage(&aws_sqs.ReceiveMessageInput{
+		QueueUrl:              aws.String("test-queue"),
+		MessageAttributeNames: []*string{aws.String(aws_sqs.QueueAttributeNameAll)},
+	})
+
+	s.mockSqs.AssertExpectations(s.T())
+	s.mockS3.AssertExpectations(s.T())
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "test", *output.Messages[0].Body)
+
+	attributes := output.Messages[0].MessageAttributes
+	assert.Equal(s.T(), "large", *attributes["LargeAttribute1"].StringValue)
+	assert.Equal(s.T(), []byte{0x00, 0x01}, attributes["Binary"].BinaryValue)
+	assert.Equal(s.T(), "small", *attributes["SmallAttribute"].StringValue)
+	assert.NotContains(s.T(), attributes, sqs_configs_constants.PAYLOAD_ENVELOPE_ATTRIBUTE_NAME)
+	assert.NotContains(s.T(), attributes, sqs_configs_constants.RESERVED_ATTRIBUTE_NAME)
+}
+
+func (s *PayloadEnvelopeTestSuite) Test_ReceiveMessage_Success_Only_Requested_Attributes_Restored() {
+	message := createEnvelopeMessage()
+	envelope := `{"body":"test","messageAttributes":{"LargeAttribute1":{"dataType":"String","stringValue":"large"},"Small.Moved":{"dataType":"String","stringValue":"moved"}}}`
+
+	var receiveInput *aws_sqs.ReceiveMessageInput
+	s.mockSqs.On("ReceiveMessage", mock.MatchedBy(func(input *aws_sqs.ReceiveMessageInput) bool {
+		receiveInput = input
+		return true
+	})).Return(&aws_sqs.ReceiveMessageOutput{
+		Messages: []*aws_sqs.Message{message},
+	}, nil).Once()
+	s.mockS3.On("GetObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.GetObjectOutput{
+		Body: ioutil.NopCloser(strings.NewReader(envelope)),
+	}, nil).Once()
+
+	output, err := s.client.ReceiveMessage(&aws_sqs.ReceiveMessageInput{
+		QueueUrl:              aws.String("test-queue"),
+		MessageAttributeNames: []*string{aws.String("Small.*")},
+	})
+
+	s.mockSqs.AssertExpectations(s.T())
+	s.mockS3.AssertExpectations(s.T())
+
+	assert.Nil(s.T(), err)
+	assert.Contains(s.T(), aws.StringValueSlice(receiveInput.MessageAttributeNames), sqs_configs_constants.PAYLOAD_ENVELOPE_ATTRIBUTE_NAME)
+
+	attributes := output.Messages[0].MessageAttributes
+	assert.Equal(s.T(), "moved", *attributes["Small.Moved"].StringValue)
+	assert.NotContains(s.T(), attributes, "LargeAttribute1")
+}
+
+func (s *PayloadEnvelopeTestSuite) Test_ReceiveMessage_Failed_Invalid_Envelope() {
+	message := createEnvelopeMessage()
+
+	s.mockSqs.On("ReceiveMessage", mock.Anything).Return(&aws_sqs.ReceiveMessageOutput{
+		Messages: []*aws_sqs.Message{message},
+	}, nil).Once()
+	s.mockS3.On("GetObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.GetObjectOutput{
+		Body: ioutil.NopCloser(strings.NewReader("not an envelope")),
+	}, nil).Once()
+
+	_, err := s.client.ReceiveMessage(&aws_sqs.ReceiveMessageInput{
+		QueueUrl: aws.String("test-queue"),
+	})
+
+	assert.NotNil(s.T(), err)
+}
+
+func TestPayloadEnvelope(t *testing.T) {
+	suite.Run(t, new(PayloadEnvelopeTestSuite))
+}
+
+func createEnvelopeMessage() *aws_sqs.Message {
+	message := createLargePayloadMessage("test-message-id", "test-bucket", "test-key", "test", "test-receipt-handle")
+	message.MessageAttributes[sqs_configs_constants.PAYLOAD_ENVELOPE_ATTRIBUTE_NAME] = &aws_sqs.MessageAttributeValue{
+		DataType:    aws.String("String"),
+		StringValue: aws.String(sqs_configs_constants.PAYLOAD_ENVELOPE_VERSION),
+	}
+	message.MessageAttributes["SmallAttribute"] = &aws_sqs.MessageAttributeValue{
+		DataType:    aws.String("String"),
+		StringValue: aws.String("small"),
+	}
+
+	return message
+}
@@ -0,0 +1,53 @@
+//go:build go1.18
+// +build go1.18
+
+package tests
+
+import (
+	std_errors "errors"
+	"testing"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Run with go test -fuzz=FuzzParseReceiptHandle, the seeds are also run by go test
+func FuzzParseReceiptHandle(f *testing.F) {
+	seeds := []string{
+		"",
+		"AQEBoriginal==",
+		bucketNameMarker + "test-bucket" + bucketNameMarker + keyMarker + "test-key" + keyMarker + "AQEBoriginal==",
+		keyMarker + "test-key" + keyMarker + bucketNameMarker + "test-bucket" + bucketNameMarker + "AQEBoriginal==",
+		bucketNameMarker + "test-bucket" + bucketNameMarker + keyMarker + "a" + keyMarker + "b" + keyMarker + "AQEBoriginal==",
+		bucketNameMarker + "test-bucket" + bucketNameMarker + keyMarker + "test-key" + keyMarker + signatureMarker + "c2lnbmF0dXJl" + signatureMarker + "AQEBoriginal==",
+		bucketNameMarker + "test-bucket" + bucketNameMarker + keyMarker + "test-key" + keyMarker + signatureMarker + keyMarker + signatureMarker + "AQEBoriginal==",
+		bucketNameMarker + bucketNameMarker + keyMarker + keyMarker,
+		keyMarker + keyMarker + bucketNameMarker + bucketNameMarker,
+		bucketNameMarker + keyMarker,
+		keyMarker + bucketNameMarker + keyMarker,
+		"-..s3BucketName..",
+		"-..s3Key..-..s3Key..-",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, receiptHandle string) {
+		handle, err := aws_extended_sqs_client.ParseReceiptHandle(receiptHandle)
+		if err != nil {
+			assert.Nil(t, handle)
+			assert.True(t, std_errors.As(err, &errors.ReceiptHandleFormatError{}), "%v", err)
+			return
+		}
+
+		assert.NotEmpty(t, handle.Original)
+
+		// The written receipt handle parses back to the same parts
+		reparsed, err := aws_extended_sqs_client.ParseReceiptHandle(handle.String())
+		assert.Nil(t, err)
+		assert.Equal(t, handle, reparsed)
+		assert.Equal(t, handle.String(), reparsed.String())
+	})
+}
@@ -0,0 +1,315 @@
+package tests
+
+import (
+	std_errors "errors"
+	"strings"
+	"testing"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/fakes"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client"
+	sqs_configs_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client/constants"
+
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/internal/payload_store/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/logging/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/services/aws_extended_sqs_client/mock"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	bucketNameMarker = sqs_configs_constants.S3_BUCKET_NAME_MARKER
+	keyMarker        = sqs_configs_constants.S3_KEY_MARKER
+	signatureMarker  = sqs_configs_constants.SIGNATURE_MARKER
+)
+
+func Test_ParseReceiptHandle_Valid(t *testing.T) {
+	testCases := []struct {
+		name          string
+		receiptHandle string
+		expected      *aws_extended_sqs_client.ReceiptHandle
+	}{
+		{
+			name:          "plain",
+			receiptHandle: "AQEBoriginal==",
+			expected:      &aws_extended_sqs_client.ReceiptHandle{Original: "AQEBoriginal=="},
+		},
+		{
+			name:          "bucket name first",
+			receiptHandle: bucketNameMarker + "test-bucket" + bucketNameMarker + keyMarker + "test-key" + keyMarker + "AQEBoriginal==",
+			expected: &aws_extended_sqs_client.ReceiptHandle{
+				S3Pointer: &aws_extended_sqs_client.S3Pointer{S3BucketName: "test-bucket", S3Key: "test-key"},
+				Original:  "AQEBoriginal==",
+			},
+		},
+		{
+			name:          "key first",
+			receiptHandle: keyMarker + "test-key" + keyMarker + bucketNameMarker + "test-bucket" + bucketNameMarker + "AQEBoriginal==",
+			expected: &aws_extended_sqs_client.ReceiptHandle{
+				S3Pointer: &aws_extended_sqs_client.S3Pointer{S3BucketName: "test-bucket", S3Key: "test-key"},
+				Original:  "AQEBoriginal==",
+			},
+		},
+		{
+			name:          "signed",
+			receiptHandle: bucketNameMarker + "test-bucket" + bucketNameMarker + keyMarker + "test-key" + keyMarker + signatureMarker + "c2lnbmF0dXJl" + signatureMarker + "AQEBoriginal==",
+			expected: &aws_extended_sqs_client.ReceiptHandle{
+				S3Pointer: &aws_extended_sqs_client.S3Pointer{S3BucketName: "test-bucket", S3Key: "test-key"},
+				Signature: "c2lnbmF0dXJl",
+				Original:  "AQEBoriginal==",
+			},
+		},
+		{
+			name:          "key containing the markers",
+			receiptHandle: bucketNameMarker + "test-bucket" + bucketNameMarker + keyMarker + "a" + keyMarker + "b" + bucketNameMarker + "c" + keyMarker + "AQEBoriginal==",
+			expected: &aws_extended_sqs_client.ReceiptHandle{
+				S3Pointer: &aws_extended_sqs_client.S3Pointer{S3BucketName: "test-bucket", S3Key: "a" + keyMarker + "b" + bucketNameMarker + "c"},
+				Original:  "AQEBoriginal==",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			handle, err := aws_extended_sqs_client.ParseReceiptHandle(testCase.receiptHandle)
+			assert.Nil(t, err)
+			assert.Equal(t, testCase.expected, handle)
+		})
+	}
+}
+
+func Test_ParseReceiptHandle_Invalid(t *testing.T) {
+	testCases := map[string]string{
+		"empty":                          "",
+		"unclosed bucket name":           bucketNameMarker + "test-bucket",
+		"missing key":                    bucketNameMarker + "test-bucket" + bucketNameMarker + "AQEBoriginal==",
+		"unclosed key":                   bucketNameMarker + "test-bucket" + bucketNameMarker + keyMarker + "test-key",
+		"empty bucket name":              bucketNameMarker + bucketNameMarker + keyMarker + "test-key" + keyMarker + "AQEBoriginal==",
+		"empty key":                      bucketNameMarker + "test-bucket" + bucketNameMarker + keyMarker + keyMarker + "AQEBoriginal==",
+		"empty original":                 bucketNameMarker + "test-bucket" + bucketNameMarker + keyMarker + "test-key" + keyMarker,
+		"key too long":                   bucketNameMarker + "test-bucket" + bucketNameMarker + keyMarker + strings.Repeat("k", 1025) + keyMarker + "AQEBoriginal==",
+		"marker not at the start":        "AQEB" + bucketNameMarker + "test-bucket" + bucketNameMarker + keyMarker + "test-key" + keyMarker,
+		"bucket name marker in original": bucketNameMarker + "test-bucket" + bucketNameMarker + keyMarker + "test-key" + keyMarker + "AQEB" + bucketNameMarker,
+		"key first without bucket name":  keyMarker + "test-key" + keyMarker + "AQEBoriginal==",
+		"key first unclosed bucket name": keyMarker + "test-key" + keyMarker + bucketNameMarker + "test-bucket",
+		"unclosed signature":             bucketNameMarker + "test-bucket" + bucketNameMarker + keyMarker + "test-key" + keyMarker + signatureMarker + "AQEBoriginal==",
+		"empty signature":                bucketNameMarker + "test-bucket" + bucketNameMarker + keyMarker + "test-key" + keyMarker + signatureMarker + signatureMarker + "AQEBoriginal==",
+	}
+
+	for name, receiptHandle := range testCases {
+		t.Run(name, func(t *testing.T) {
+			handle, err := aws_extended_sqs_client.ParseReceiptHandle(receiptHandle)
+			assert.Nil(t, handle)
+			assert.True(t, std_errors.As(err, &errors.ReceiptHandleFormatError{}))
+		})
+	}
+}
+
+func Test_ReceiptHandle_String(t *testing.T) {
+	handle := &aws_extended_sqs_client.ReceiptHandle{
+		S3Pointer: &aws_extended_sqs_client.S3Pointer{S3BucketName: "test-bucket", S3Key: "test-key"},
+		Original:  "AQEBoriginal==",
+	}
+	assert.Equal(t, bucketNameMarker+"test-bucket"+bucketNameMarker+keyMarker+"test-key"+keyMarker+"AQEBoriginal==", handle.String())
+
+	handle = &aws_extended_sqs_client.ReceiptHandle{Original: "AQEBoriginal=="}
+	assert.Equal(t, "AQEBoriginal==", handle.String())
+}
+
+func Test_ReceiptHandle_String_Normalizes_Key_First(t *testing.T) {
+	handle, err := aws_extended_sqs_client.ParseReceiptHandle(keyMarker + "test-key" + keyMarker + bucketNameMarker + "test-bucket" + bucketNameMarker + "AQEBoriginal==")
+	assert.Nil(t, err)
+	assert.Equal(t, bucketNameMarker+"test-bucket"+bucketNameMarker+keyMarker+"test-key"+keyMarker+"AQEBoriginal==", handle.String())
+}
+
+func Test_DeleteMessage_Malformed_Receipt_Handle(t *testing.T) {
+	mockSqs := new(MockSqs)
+	mockS3 := new(MockS3)
+
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	config.WithPayloadSupportEnabled(mockS3, "test-bucket")
+	client := aws_extended_sqs_client.NewExtendedSQSClient(mockSqs, config, aws_extended_sqs_client.WithLogger(NewMockLogger()))
+
+	_, err := client.DeleteMessage(&aws_sqs.DeleteMessageInput{ReceiptHandle: aws.String(bucketNameMarker + "test-bucket" + keyMarker)})
+	assert.True(t, std_errors.As(err, &errors.ReceiptHandleFormatError{}))
+
+	mockSqs.AssertNotCalled(t, "DeleteMessage", mock.Anything)
+	mockS3.AssertNotCalled(t, "DeleteObjectWithContext", mock.Anything, mock.Anything)
+}
+
+func Test_DeleteMessageBatch_Malformed_Receipt_Handle(t *testing.T) {
+	mockSqs := new(MockSqs)
+	mockS3 := new(MockS3)
+
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	config.WithPayloadSupportEnabled(mockS3, "test-bucket")
+	client := aws_extended_sqs_client.NewExtendedSQSClient(mockSqs, config, aws_extended_sqs_client.WithLogger(NewMockLogger()))
+
+	mockSqs.On("DeleteMessageBatch", mock.MatchedBy(func(input *aws_sqs.DeleteMessageBatchInput) bool {
+		return len(input.Entries) == 1 && *input.Entries[0].ReceiptHandle == "AQEBoriginal=="
+	})).Return(&aws_sqs.DeleteMessageBatchOutput{
+		Successful: []*aws_sqs.DeleteMessageBatchResultEntry{{Id: aws.String("valid")}},
+	}, nil).Once()
+
+	output, err := client.DeleteMessageBatch(&aws_sqs.DeleteMessageBatchInput{
+		Entries: []*aws_sqs.DeleteMessageBatchRequestEntry{
+			{Id: aws.String("valid"), ReceiptHandle: aws.String("AQEBoriginal==")},
+			{Id: aws.String("malformed"), ReceiptHandle: aws.String(bucketNameMarker + "test-bucket")},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Len(t, output.Successful, 1)
+	assert.Len(t, output.Failed, 1)
+	assert.Equal(t, "malformed", *output.Failed[0].Id)
+
+	mockSqs.AssertExpectations(t)
+}
+
+func Test_ChangeMessageVisibility_Malformed_Receipt_Handle(t *testing.T) {
+	mockSqs := new(MockSqs)
+
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	client := aws_extended_sqs_client.NewExtendedSQSClient(mockSqs, config, aws_extended_sqs_client.WithLogger(NewMockLogger()))
+
+	_, err := client.ChangeMessageVisibility(&aws_sqs.ChangeMessageVisibilityInput{
+		ReceiptHandle:     aws.String(keyMarker + "test-key" + keyMarker + "AQEBoriginal=="),
+		VisibilityTimeout: aws.Int64(0),
+	})
+	assert.True(t, std_errors.As(err, &errors.ReceiptHandleFormatError{}))
+
+	mockSqs.AssertNotCalled(t, "ChangeMessageVisibility", mock.Anything)
+}
+
+// Sends an offloaded message and receives it with a client signing with the key
+func receiveSigned(t *testing.T, fakeSqs *fakes.FakeSQS, fakeS3 *fakes.FakeS3, key []byte) *aws_sqs.Message {
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	config.WithPayloadSupportEnabled(fakeS3, "test-bucket")
+	config.SetAlwaysThroughS3(true)
+	config.SetReceiptHandleSigningKey(key)
+	client := aws_extended_sqs_client.NewExtendedSQSClient(fakeSqs, config, aws_extended_sqs_client.WithLogger(NewMockLogger()))
+
+	queueUrl := fakeSqs.QueueUrl("test-queue")
+	_, err := client.SendMessage(&aws_sqs.SendMessageInput{QueueUrl: aws.String(queueUrl), MessageBody: aws.String("test")})
+	assert.Nil(t, err)
+
+	output, err := client.ReceiveMessage(&aws_sqs.ReceiveMessageInput{QueueUrl: aws.String(queueUrl), MaxNumberOfMessages: aws.Int64(10)})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, output.Messages)
+
+	return output.Messages[len(output.Messages)-1]
+}
+
+func newSigningClient(fakeSqs *fakes.FakeSQS, fakeS3 *fakes.FakeS3, key []byte, previousKeys ...[]byte) *aws_extended_sqs_client.AwsExtendedSQSClient {
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	config.WithPayloadSupportEnabled(fakeS3, "test-bucket")
+	if key != nil {
+		config.SetReceiptHandleSigningKey(key, previousKeys...)
+	}
+
+	return aws_extended_sqs_client.NewExtendedSQSClient(fakeSqs, config, aws_extended_sqs_client.WithLogger(NewMockLogger()))
+}
+
+func Test_Signed_Receipt_Handle_Deletes_Payload(t *testing.T) {
+	fakeSqs, fakeS3 := fakes.NewFakeSQS("test-queue"), fakes.NewFakeS3("test-bucket")
+	message := receiveSigned(t, fakeSqs, fakeS3, []byte("secret"))
+
+	handle, err := aws_extended_sqs_client.ParseReceiptHandle(*message.ReceiptHandle)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, handle.Signature)
+
+	_, err = newSigningClient(fakeSqs, fakeS3, []byte("secret")).DeleteMessage(&aws_sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(fakeSqs.QueueUrl("test-queue")),
+		ReceiptHandle: message.ReceiptHandle,
+	})
+	assert.Nil(t, err)
+	assert.Empty(t, fakeSqs.Messages(fakeSqs.QueueUrl("test-queue")))
+	assert.Empty(t, fakeS3.Keys("test-bucket"))
+}
+
+func Test_Signed_Receipt_Handle_Rejects_Tampering(t *testing.T) {
+	fakeSqs, fakeS3 := fakes.NewFakeSQS("test-queue"), fakes.NewFakeS3("test-bucket", "other-bucket")
+	message := receiveSigned(t, fakeSqs, fakeS3, []byte("secret"))
+	_, err := fakeS3.PutObject(&aws_s3.PutObjectInput{Bucket: aws.String("other-bucket"), Key: aws.String("other-key"), Body: strings.NewReader("other")})
+	assert.Nil(t, err)
+
+	handle, err := aws_extended_sqs_client.ParseReceiptHandle(*message.ReceiptHandle)
+	assert.Nil(t, err)
+
+	tamperedHandles := map[string]*aws_extended_sqs_client.ReceiptHandle{
+		"bucket name": {S3Pointer: &aws_extended_sqs_client.S3Pointer{S3BucketName: "other-bucket", S3Key: handle.S3Pointer.S3Key}, Signature: handle.Signature, Original: handle.Original},
+		"key":         {S3Pointer: &aws_extended_sqs_client.S3Pointer{S3BucketName: "other-bucket", S3Key: "other-key"}, Signature: handle.Signature, Original: handle.Original},
+		"unsigned":    {S3Pointer: &aws_extended_sqs_client.S3Pointer{S3BucketName: "other-bucket", S3Key: "other-key"}, Original: handle.Original},
+		"signature":   {S3Pointer: handle.S3Pointer, Signature: "c2lnbmF0dXJl", Original: handle.Original},
+	}
+
+	client := newSigningClient(fakeSqs, fakeS3, []byte("secret"))
+	for name, tamperedHandle := range tamperedHandles {
+		_, err := client.DeleteMessage(&aws_sqs.DeleteMessageInput{
+			QueueUrl:      aws.String(fakeSqs.QueueUrl("test-queue")),
+			ReceiptHandle: aws.String(tamperedHandle.String()),
+		})
+		assert.True(t, std_errors.As(err, &errors.ReceiptHandleSignatureError{}), name)
+	}
+
+	_, ok := fakeS3.Object("other-bucket", "other-key")
+	assert.True(t, ok)
+	assert.Len(t, fakeS3.Keys("test-bucket"), 1)
+	assert.Len(t, fakeSqs.Messages(fakeSqs.QueueUrl("test-queue")), 1)
+}
+
+func Test_Signed_Receipt_Handle_Previous_Key(t *testing.T) {
+	fakeSqs, fakeS3 := fakes.NewFakeSQS("test-queue"), fakes.NewFakeS3("test-bucket")
+	message := receiveSigned(t, fakeSqs, fakeS3, []byte("previous"))
+
+	_, err := newSigningClient(fakeSqs, fakeS3, []byte("current"), []byte("previous")).DeleteMessage(&aws_sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(fakeSqs.QueueUrl("test-queue")),
+		ReceiptHandle: message.ReceiptHandle,
+	})
+	assert.Nil(t, err)
+	assert.Empty(t, fakeS3.Keys("test-bucket"))
+}
+
+func Test_Signed_Receipt_Handle_Without_Signing_Key(t *testing.T) {
+	fakeSqs, fakeS3 := fakes.NewFakeSQS("test-queue"), fakes.NewFakeS3("test-bucket")
+	message := receiveSigned(t, fakeSqs, fakeS3, []byte("secret"))
+
+	_, err := newSigningClient(fakeSqs, fakeS3, nil).DeleteMessage(&aws_sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(fakeSqs.QueueUrl("test-queue")),
+		ReceiptHandle: message.ReceiptHandle,
+	})
+	assert.Nil(t, err)
+	assert.Empty(t, fakeS3.Keys("test-bucket"))
+}
+
+func Test_Signed_Receipt_Handle_DeleteMessageBatch(t *testing.T) {
+	fakeSqs, fakeS3 := fakes.NewFakeSQS("test-queue"), fakes.NewFakeS3("test-bucket")
+	receiveSigned(t, fakeSqs, fakeS3, []byte("secret"))
+	message := receiveSigned(t, fakeSqs, fakeS3, []byte("secret"))
+
+	handle, err := aws_extended_sqs_client.ParseReceiptHandle(*message.ReceiptHandle)
+	assert.Nil(t, err)
+	tamperedHandle := &aws_extended_sqs_client.ReceiptHandle{
+		S3Pointer: &aws_extended_sqs_client.S3Pointer{S3BucketName: "test-bucket", S3Key: "other-key"},
+		Signature: handle.Signature,
+		Original:  handle.Original,
+	}
+
+	output, err := newSigningClient(fakeSqs, fakeS3, []byte("secret")).DeleteMessageBatch(&aws_sqs.DeleteMessageBatchInput{
+		QueueUrl: aws.String(fakeSqs.QueueUrl("test-queue")),
+		Entries: []*aws_sqs.DeleteMessageBatchRequestEntry{
+			{Id: aws.String("valid"), ReceiptHandle: message.ReceiptHandle},
+			{Id: aws.String("tampered"), ReceiptHandle: aws.String(tamperedHandle.String())},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Len(t, output.Successful, 1)
+	assert.Len(t, output.Failed, 1)
+	assert.Equal(t, "tampered", *output.Failed[0].Id)
+	assert.Equal(t, "ReceiptHandleSignatureError", *output.Failed[0].Code)
+	assert.Len(t, fakeS3.Keys("test-bucket"), 1)
+}
@@ -0,0 +1,221 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/fakes"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client"
+	sqs_configs_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client/constants"
+
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/logging/mock"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	resolverQueueName    = "resolver-queue"
+	resolverS3BucketName = "resolver-bucket"
+)
+
+func newLazyResolutionClient(fakeSqs *fakes.FakeSQS, fakeS3 *fakes.FakeS3, configure func(*aws_extended_sqs_client.AwsExtendedSQSClientConfiguration)) *aws_extended_sqs_client.AwsExtendedSQSClient {
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	config.WithPayloadSupportEnabled(fakeS3, resolverS3BucketName)
+	config.SetAlwaysThroughS3(true)
+	if configure != nil {
+		configure(config)
+	}
+
+	return aws_extended_sqs_client.NewExtendedSQSClient(fakeSqs, config,
+		aws_extended_sqs_client.WithLogger(NewMockLogger()), aws_extended_sqs_client.WithLazyPayloadResolution())
+}
+
+func receiveOne(t *testing.T, client *aws_extended_sqs_client.AwsExtendedSQSClient, queueUrl string) *aws_sqs.Message {
+	output, err := client.ReceiveMessage(&aws_sqs.ReceiveMessageInput{
+		QueueUrl:              aws.String(queueUrl),
+		MessageAttributeNames: []*string{aws.String("All")},
+	})
+	assert.Nil(t, err)
+	assert.Len(t, output.Messages, 1)
+
+	return output.Messages[0]
+}
+
+func Test_ReceiveMessage_Lazy_Resolution_Keeps_Pointer(t *testing.T) {
+	fakeSqs := fakes.NewFakeSQS(resolverQueueName)
+	fakeS3 := fakes.NewFakeS3(resolverS3BucketName)
+	queueUrl := fakeSqs.QueueUrl(resolverQueueName)
+	client := newLazyResolutionClient(fakeSqs, fakeS3, nil)
+
+	_, err := client.SendMessage(&aws_sqs.SendMessageInput{QueueUrl: aws.String(queueUrl), MessageBody: aws.String("large payload")})
+	assert.Nil(t, err)
+
+	sent := fakeSqs.Messages(queueUrl)[0]
+	message := receiveOne(t, client, queueUrl)
+
+	assert.Equal(t, *sent.Body, *message.Body)
+	assert.Contains(t, message.MessageAttributes, sqs_configs_constants.RESERVED_ATTRIBUTE_NAME)
+
+	keys := fakeS3.Keys(resolverS3BucketName)
+	assert.Len(t, keys, 1)
+
+	handle, err := aws_extended_sqs_client.ParseReceiptHandle(*message.ReceiptHandle)
+	assert.Nil(t, err)
+	assert.Equal(t, &aws_extended_sqs_client.S3Pointer{S3BucketName: resolverS3BucketName, S3Key: keys[0]}, handle.S3Pointer)
+}
+
+func Test_PayloadResolver_Resolve(t *testing.T) {
+	fakeSqs := fakes.NewFakeSQS(resolverQueueName)
+	fakeS3 := fakes.NewFakeS3(resolverS3BucketName)
+	queueUrl := fakeSqs.QueueUrl(resolverQueueName)
+	client := newLazyResolutionClient(fakeSqs, fakeS3, nil)
+
+	_, err := client.SendMessage(&aws_sqs.SendMessageInput{
+		QueueUrl:    aws.String(queueUrl),
+		MessageBody: aws.String("large payload"),
+		MessageAttributes: map[string]*aws_sqs.MessageAttributeValue{
+			"attr": {DataType: aws.String("String"), StringValue: aws.String("value")},
+		},
+	})
+	assert.Nil(t, err)
+
+	message := receiveOne(t, client, queueUrl)
+	body := *message.Body
+
+	resolver := client.NewPayloadResolver(message)
+	assert.True(t, resolver.IsOffloaded())
+
+	resolved, err := resolver.Resolve(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "large payload", *resolved.Body)
+	assert.Equal(t, map[string]*aws_sqs.MessageAttributeValue{
+		"attr": {DataType: aws.String("String"), StringValue: aws.String("value")},
+	}, resolved.MessageAttributes)
+	assert.Equal(t, *message.ReceiptHandle, *resolved.ReceiptHandle)
+
+	// The received message is left untouched
+	assert.Equal(t, body, *message.Body)
+	assert.Contains(t, message.MessageAttributes, sqs_configs_constants.RESERVED_ATTRIBUTE_NAME)
+
+	_, err = client.DeleteMessage(&aws_sqs.DeleteMessageInput{QueueUrl: aws.String(queueUrl), ReceiptHandle: resolved.ReceiptHandle})
+	assert.Nil(t, err)
+	assert.Empty(t, fakeSqs.Messages(queueUrl))
+	assert.Empty(t, fakeS3.Keys(resolverS3BucketName))
+}
+
+func Test_PayloadResolver_Resolve_Envelope(t *testing.T) {
+	fakeSqs := fakes.NewFakeSQS(resolverQueueName)
+	fakeS3 := fakes.NewFakeS3(resolverS3BucketName)
+	queueUrl := fakeSqs.QueueUrl(resolverQueueName)
+	client := newLazyResolutionClient(fakeSqs, fakeS3, func(config *aws_extended_sqs_client.AwsExtendedSQSClientConfiguration) {
+		config.SetAttributeLimitFallback(sqs_configs_constants.ATTRIBUTE_LIMIT_FALLBACK_FOLD)
+	})
+
+	messageAttributes := map[string]*aws_sqs.MessageAttributeValue{}
+	for i := 0; i < 10; i++ {
+		messageAttributes[fmt.Sprintf("attr%d", i)] = &aws_sqs.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String("value")}
+	}
+
+	_, err := client.SendMessage(&aws_sqs.SendMessageInput{
+		QueueUrl:          aws.String(queueUrl),
+		MessageBody:       aws.String("large payload"),
+		MessageAttributes: messageAttributes,
+	})
+	assert.Nil(t, err)
+
+	message := receiveOne(t, client, queueUrl)
+	assert.Contains(t, message.MessageAttributes, sqs_configs_constants.PAYLOAD_ENVELOPE_ATTRIBUTE_NAME)
+
+	resolved, err := client.NewPayloadResolver(message).Resolve(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "large payload", *resolved.Body)
+	assert.Equal(t, messageAttributes, resolved.MessageAttributes)
+}
+
+func Test_PayloadResolver_Resolve_Not_Offloaded(t *testing.T) {
+	fakeSqs := fakes.NewFakeSQS(resolverQueueName)
+	fakeS3 := fakes.NewFakeS3(resolverS3BucketName)
+	queueUrl := fakeSqs.QueueUrl(resolverQueueName)
+	client := newLazyResolutionClient(fakeSqs, fakeS3, func(config *aws_extended_sqs_client.AwsExtendedSQSClientConfiguration) {
+		config.SetAlwaysThroughS3(false)
+	})
+
+	_, err := client.SendMessage(&aws_sqs.SendMessageInput{QueueUrl: aws.String(queueUrl), MessageBody: aws.String("small payload")})
+	assert.Nil(t, err)
+
+	message := receiveOne(t, client, queueUrl)
+
+	resolver := client.NewPayloadResolver(message)
+	assert.False(t, resolver.IsOffloaded())
+
+	resolved, err := resolver.Resolve(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "small payload", *resolved.Body)
+	assert.Equal(t, *message.ReceiptHandle, *resolved.ReceiptHandle)
+}
+
+func Test_PayloadResolver_Resolve_Missing_Payload(t *testing.T) {
+	fakeSqs := fakes.NewFakeSQS(resolverQueueName)
+	fakeS3 := fakes.NewFakeS3(resolverS3BucketName)
+	queueUrl := fakeSqs.QueueUrl(resolverQueueName)
+	client := newLazyResolutionClient(fakeSqs, fakeS3, nil)
+
+	_, err := client.SendMessage(&aws_sqs.SendMessageInput{QueueUrl: aws.String(queueUrl), MessageBody: aws.String("large payload")})
+	assert.Nil(t, err)
+
+	message := receiveOne(t, client, queueUrl)
+
+	// Removed after the receive, i.e. by another consumer of the same message
+	keys := fakeS3.Keys(resolverS3BucketName)
+	assert.Len(t, keys, 1)
+	_, err = client.DeleteMessage(&aws_sqs.DeleteMessageInput{QueueUrl: aws.String(queueUrl), ReceiptHandle: message.ReceiptHandle})
+	assert.Nil(t, err)
+
+	resolved, err := client.NewPayloadResolver(message).Resolve(context.Background())
+	assert.Nil(t, resolved)
+	assert.NotNil(t, err)
+}
+
+func Test_Forward_Offloaded_Message_As_Is(t *testing.T) {
+	forwardQueueName := "forward-queue"
+	fakeSqs := fakes.NewFakeSQS(resolverQueueName, forwardQueueName)
+	fakeS3 := fakes.NewFakeS3(resolverS3BucketName)
+	queueUrl := fakeSqs.QueueUrl(resolverQueueName)
+	forwardQueueUrl := fakeSqs.QueueUrl(forwardQueueName)
+	client := newLazyResolutionClient(fakeSqs, fakeS3, func(config *aws_extended_sqs_client.AwsExtendedSQSClientConfiguration) {
+		config.SetCleanupS3Payload(false)
+	})
+
+	_, err := client.SendMessage(&aws_sqs.SendMessageInput{QueueUrl: aws.String(queueUrl), MessageBody: aws.String("large payload")})
+	assert.Nil(t, err)
+
+	message := receiveOne(t, client, queueUrl)
+	forwardInput := &aws_sqs.SendMessageInput{
+		QueueUrl:          aws.String(forwardQueueUrl),
+		MessageBody:       message.Body,
+		MessageAttributes: message.MessageAttributes,
+	}
+
+	// The extended client rejects the reserved attributes
+	_, err = client.SendMessage(forwardInput)
+	assert.EqualError(t, err, fmt.Sprintf("AwsSqsGoExtendedClientSDKError - Message attribute name %s is reserved for use by SQS extended client.", sqs_configs_constants.RESERVED_ATTRIBUTE_NAME))
+	assert.Empty(t, fakeSqs.Messages(forwardQueueUrl))
+
+	_, err = fakeSqs.SendMessage(forwardInput)
+	assert.Nil(t, err)
+
+	_, err = client.DeleteMessage(&aws_sqs.DeleteMessageInput{QueueUrl: aws.String(queueUrl), ReceiptHandle: message.ReceiptHandle})
+	assert.Nil(t, err)
+
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	config.WithPayloadSupportEnabled(fakeS3, resolverS3BucketName)
+	consumer := aws_extended_sqs_client.NewExtendedSQSClient(fakeSqs, config, aws_extended_sqs_client.WithLogger(NewMockLogger()))
+
+	forwarded := receiveOne(t, consumer, forwardQueueUrl)
+	assert.Equal(t, "large payload", *forwarded.Body)
+	assert.NotContains(t, forwarded.MessageAttributes, sqs_configs_constants.RESERVED_ATTRIBUTE_NAME)
+}
@@ -0,0 +1,182 @@
+package tests
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client"
+
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/internal/payload_store/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/services/aws_extended_sqs_client/mock"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
+	aws_s3iface "github.com/aws/aws-sdk-go/service/s3/s3iface"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_RuleBucketRouter_RouteBucket_By_Queue_Url(t *testing.T) {
+	router := aws_extended_sqs_client.NewRuleBucketRouter(
+		"default-bucket",
+		aws_extended_sqs_client.RouteByQueueUrl("queue-a", "bucket-a"),
+		aws_extended_sqs_client.RouteByQueueUrl("queue-b", "bucket-b"),
+	)
+
+	s3BucketName, err := router.RouteBucket(&aws_sqs.SendMessageInput{QueueUrl: aws.String("queue-b")}, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "bucket-b", s3BucketName)
+
+	s3BucketName, err = router.RouteBucket(&aws_sqs.SendMessageInput{QueueUrl: aws.String("queue-c")}, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "default-bucket", s3BucketName)
+}
+
+func Test_RuleBucketRouter_RouteBucket_By_Message_Attribute(t *testing.T) {
+	router := aws_extended_sqs_client.NewRuleBucketRouter(
+		"default-bucket",
+		aws_extended_sqs_client.RouteByMessageAttribute("region", "eu", "eu-bucket"),
+	)
+
+	s3BucketName, err := router.RouteBucket(&aws_sqs.SendMessageInput{
+		MessageAttributes: map[string]*aws_sqs.MessageAttributeValue{
+			"region": {DataType: aws.String("String"), StringValue: aws.String("eu")},
+		},
+	}, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "eu-bucket", s3BucketName)
+
+	s3BucketName, err = router.RouteBucket(&aws_sqs.SendMessageInput{
+		MessageAttributes: map[string]*aws_sqs.MessageAttributeValue{
+			"region": {DataType: aws.String("String"), StringValue: aws.String("us")},
+		},
+	}, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "default-bucket", s3BucketName)
+}
+
+func Test_RuleBucketRouter_RouteBucket_By_Payload_Size(t *testing.T) {
+	router := aws_extended_sqs_client.NewRuleBucketRouter(
+		"default-bucket",
+		aws_extended_sqs_client.RouteByPayloadSize(1024, "large-bucket"),
+	)
+
+	s3BucketName, err := router.RouteBucket(&aws_sqs.SendMessageInput{}, 1024)
+	assert.Nil(t, err)
+	assert.Equal(t, "large-bucket", s3BucketName)
+
+	s3BucketName, err = router.RouteBucket(&aws_sqs.SendMessageInput{}, 1023)
+	assert.Nil(t, err)
+	assert.Equal(t, "default-bucket", s3BucketName)
+}
+
+func Test_RuleBucketRouter_RouteBucket_Failed_No_Default(t *testing.T) {
+	router := aws_extended_sqs_client.NewRuleBucketRouter("")
+
+	s3BucketName, err := router.RouteBucket(&aws_sqs.SendMessageInput{}, 0)
+	assert.NotNil(t, err)
+	assert.Empty(t, s3BucketName)
+}
+
+func Test_MapS3ClientResolver_ResolveS3Client(t *testing.T) {
+	euS3 := new(MockS3)
+	fallbackS3 := new(MockS3)
+
+	resolver := aws_extended_sqs_client.NewMapS3ClientResolver(map[string]aws_s3iface.S3API{
+		"eu-bucket": euS3,
+	}, fallbackS3)
+
+	s3Client, err := resolver.ResolveS3Client("eu-bucket")
+	assert.Nil(t, err)
+	assert.Same(t, euS3, s3Client)
+
+	s3Client, err = resolver.ResolveS3Client("other-bucket")
+	assert.Nil(t, err)
+	assert.Same(t, fallbackS3, s3Client)
+}
+
+func Test_MapS3ClientResolver_ResolveS3Client_Failed_No_Fallback(t *testing.T) {
+	resolver := aws_extended_sqs_client.NewMapS3ClientResolver(map[string]aws_s3iface.S3API{}, nil)
+
+	s3Client, err := resolver.ResolveS3Client("other-bucket")
+	assert.NotNil(t, err)
+	assert.Nil(t, s3Client)
+}
+
+func Test_ExtendedSqsClient_SendMessage_Success_Routed_Bucket(t *testing.T) {
+	mockSqs := new(MockSqs)
+	defaultS3 := new(MockS3)
+	euS3 := new(MockS3)
+
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	config.WithPayloadSupportEnabled(defaultS3, "default-bucket")
+	config.WithBucketRouter(aws_extended_sqs_client.NewRuleBucketRouter(
+		"default-bucket",
+		aws_extended_sqs_client.RouteByQueueUrl("eu-queue", "eu-bucket"),
+	))
+	config.WithS3ClientResolver(aws_extended_sqs_client.NewMapS3ClientResolver(map[string]aws_s3iface.S3API{
+		"eu-bucket": euS3,
+	}, defaultS3))
+
+	client := aws_extended_sqs_client.NewExtendedSQSClient(mockSqs, config)
+
+	euS3.On("PutObjectWithContext", mock.Anything, mock.MatchedBy(func(input *aws_s3.PutObjectInput) bool {
+		return *input.Bucket == "eu-bucket"
+	})).Return(&aws_s3.PutObjectOutput{}, nil).Once()
+	mockSqs.On("SendMessage", mock.MatchedBy(func(input *aws_sqs.SendMessageInput) bool {
+		return strings.Contains(*input.MessageBody, "\"s3BucketName\":\"eu-bucket\"")
+	})).Return(&aws_sqs.SendMessageOutput{MessageId: aws.String("test-message-id")}, nil).Once()
+
+	largeBody := strings.Repeat("test", 65537)
+	output, err := client.SendMessage(&aws_sqs.SendMessageInput{
+		QueueUrl:    aws.String("eu-queue"),
+		MessageBody: &largeBody,
+	})
+
+	mockSqs.AssertExpectations(t)
+	euS3.AssertExpectations(t)
+	defaultS3.AssertExpectations(t)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "test-message-id", *output.MessageId)
+}
+
+func Test_ExtendedSqsClient_ReceiveMessage_Success_Resolved_S3_Client(t *testing.T) {
+	mockSqs := new(MockSqs)
+	defaultS3 := new(MockS3)
+	euS3 := new(MockS3)
+
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	config.WithPayloadSupportEnabled(defaultS3, "default-bucket")
+	config.WithS3ClientResolver(aws_extended_sqs_client.NewMapS3ClientResolver(map[string]aws_s3iface.S3API{
+		"eu-bucket": euS3,
+	}, defaultS3))
+
+	client := aws_extended_sqs_client.NewExtendedSQSClient(mockSqs, config)
+
+	largeBody := strings.Repeat("test", 65537)
+	message := createLargePayloadMessage("test-message-id", "eu-bucket", "test-key", largeBody, "test-receipt-handle")
+
+	mockSqs.On("ReceiveMessage", mock.Anything).Return(&aws_sqs.ReceiveMessageOutput{
+		Messages: []*aws_sqs.Message{message},
+	}, nil).Once()
+	euS3.On("GetObjectWithContext", mock.Anything, mock.MatchedBy(func(input *aws_s3.GetObjectInput) bool {
+		return *input.Bucket == "eu-bucket" && *input.Key == "test-key"
+	})).Return(&aws_s3.GetObjectOutput{
+		Body: ioutil.NopCloser(strings.NewReader(largeBody)),
+	}, nil).Once()
+
+	output, err := client.ReceiveMessage(&aws_sqs.ReceiveMessageInput{
+		QueueUrl: aws.String("eu-queue"),
+	})
+
+	mockSqs.AssertExpectations(t)
+	euS3.AssertExpectations(t)
+	defaultS3.AssertExpectations(t)
+
+	assert.Nil(t, err)
+	assert.Equal(t, largeBody, *output.Messages[0].Body)
+}
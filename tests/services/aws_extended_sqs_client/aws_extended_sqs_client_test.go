@@ -1,6 +1,7 @@
 package tests
 
 import (
+	std_errors "errors"
 	"fmt"
 	"io/ioutil"
 	"strconv"
@@ -12,6 +13,7 @@ import (
 	sqs_configs_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client/constants"
 
 	errors "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors"
+	errors_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors/constants"
 
 	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/internal/payload_store/mock"
 	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/services/aws_extended_sqs_client/mock"
@@ -192,6 +194,73 @@ func (s *ExtendedSqsClientTestSuite) Test_ExtendedSqsClient_SendMessage_Failed_S
 	assert.NotNil(s.T(), err)
 }
 
+func (s *ExtendedSqsClientTestSuite) Test_ExtendedSqsClient_SendMessage_Failed_S3_Error_Typed() {
+	s.mockS3.On("PutObjectWithContext", mock.Anything, mock.Anything).Return(
+		&aws_s3.PutObjectOutput{},
+		awserr.New(aws_s3.ErrCodeNoSuchBucket, "The specified bucket does not exist", nil),
+	).Once()
+
+	_, err := s.sqsClient.SendMessage(&aws_sqs.SendMessageInput{
+		QueueUrl:    aws.String("test-queue"),
+		MessageBody: &s.LARGE_BODY,
+	})
+
+	var s3Err errors.S3Error
+	assert.True(s.T(), std_errors.As(err, &s3Err))
+	assert.Equal(s.T(), errors_constants.OPERATION_STORE, s3Err.Operation)
+	assert.Equal(s.T(), s.S3_BUCKET_NAME, s3Err.S3BucketName)
+	assert.NotEmpty(s.T(), s3Err.S3Key)
+	assert.False(s.T(), errors.IsRetryable(err))
+	assert.False(s.T(), std_errors.Is(err, errors.SQSError{}))
+}
+
+func (s *ExtendedSqsClientTestSuite) Test_ExtendedSqsClient_SendMessage_Failed_SQS_Error_Typed() {
+	s.mockSqs.On("SendMessage", mock.Anything).Return(
+		&aws_sqs.SendMessageOutput{},
+		awserr.New("ThrottlingException", "Rate exceeded", nil),
+	).Once()
+
+	_, err := s.sqsClient.SendMessage(&aws_sqs.SendMessageInput{
+		QueueUrl:    aws.String("test-queue"),
+		MessageBody: &s.BODY,
+	})
+
+	var sqsErr errors.SQSError
+	assert.True(s.T(), std_errors.As(err, &sqsErr))
+	assert.Equal(s.T(), errors_constants.OPERATION_SEND, sqsErr.Operation)
+	assert.Equal(s.T(), "test-queue", sqsErr.QueueUrl)
+	assert.True(s.T(), errors.IsRetryable(err))
+
+	var awsErr awserr.Error
+	assert.True(s.T(), std_errors.As(err, &awsErr))
+	assert.Equal(s.T(), "ThrottlingException", awsErr.Code())
+}
+
+func (s *ExtendedSqsClientTestSuite) Test_ExtendedSqsClient_ReceiveMessage_Failed_Pointer_Malformed() {
+	message := &aws_sqs.Message{
+		MessageId:     &s.MESSAGE_ID,
+		Body:          aws.String("not a pointer"),
+		ReceiptHandle: &s.RECEIPT_HANDLE,
+		MessageAttributes: map[string]*aws_sqs.MessageAttributeValue{
+			sqs_configs_constants.RESERVED_ATTRIBUTE_NAME: {DataType: aws.String("Number"), StringValue: aws.String("4")},
+		},
+	}
+
+	s.mockSqs.On("ReceiveMessage", mock.Anything).Return(&aws_sqs.ReceiveMessageOutput{
+		Messages: []*aws_sqs.Message{message},
+	}, nil).Once()
+
+	_, err := s.sqsClient.ReceiveMessage(&aws_sqs.ReceiveMessageInput{
+		QueueUrl: aws.String("test-queue"),
+	})
+
+	s.mockSqs.AssertExpectations(s.T())
+	s.mockS3.AssertExpectations(s.T())
+
+	var pointerErr errors.PointerFormatError
+	assert.True(s.T(), std_errors.As(err, &pointerErr))
+}
+
 func (s *ExtendedSqsClientTestSuite) Test_ExtendedSqsClient_SendMessage_Failed_Input_Empty() {
 	s.mockSqs.On("SendMessage", mock.Anything).Return(
 		&aws_sqs.SendMessageOutput{},
@@ -266,6 +335,90 @@ func (s *ExtendedSqsClientTestSuite) Test_ExtendedSqsClient_SendMessage_Failed_M
 	assert.NotNil(s.T(), err)
 }
 
+func (s *ExtendedSqsClientTestSuite) Test_ExtendedSqsClient_SendMessage_Success_Small_Payload_Max_Sqs_Attributes() {
+	attributes := createMessageAttributes(sqs_configs_constants.MAX_SQS_ATTRIBUTES)
+
+	s.mockSqs.On("SendMessage", mock.MatchedBy(func(input *aws_sqs.SendMessageInput) bool {
+		return len(input.MessageAttributes) == sqs_configs_constants.MAX_SQS_ATTRIBUTES
+	})).Return(&aws_sqs.SendMessageOutput{
+		MessageId: &s.MESSAGE_ID,
+	}, nil).Once()
+
+	output, err := s.sqsClient.SendMessage(&aws_sqs.SendMessageInput{
+		MessageBody:       &s.BODY,
+		MessageAttributes: attributes,
+	})
+
+	s.mockSqs.AssertExpectations(s.T())
+	s.mockS3.AssertExpectations(s.T())
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), s.MESSAGE_ID, *output.MessageId)
+}
+
+func (s *ExtendedSqsClientTestSuite) Test_ExtendedSqsClient_SendMessage_Failed_Small_Payload_Message_Attributes_Too_Many() {
+	attributes := createMessageAttributes(sqs_configs_constants.MAX_SQS_ATTRIBUTES + 1)
+
+	_, err := s.sqsClient.SendMessage(&aws_sqs.SendMessageInput{
+		MessageBody:       &s.BODY,
+		MessageAttributes: attributes,
+	})
+
+	s.mockSqs.AssertExpectations(s.T())
+	s.mockS3.AssertExpectations(s.T())
+
+	assert.NotNil(s.T(), err)
+}
+
+func (s *ExtendedSqsClientTestSuite) Test_ExtendedSqsClient_SendMessage_Success_Message_Attributes_Too_Many_Folded() {
+	s.config.SetAttributeLimitFallback(sqs_configs_constants.ATTRIBUTE_LIMIT_FALLBACK_FOLD)
+
+	attributes := createMessageAttributes(sqs_configs_constants.MAX_SQS_ATTRIBUTES)
+
+	s.mockS3.On("PutObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.PutObjectOutput{}, nil).Once()
+	s.mockSqs.On("SendMessage", mock.MatchedBy(func(input *aws_sqs.SendMessageInput) bool {
+		_, hasEnvelope := input.MessageAttributes[sqs_configs_constants.PAYLOAD_ENVELOPE_ATTRIBUTE_NAME]
+		return hasEnvelope && len(input.MessageAttributes) == sqs_configs_constants.MAX_SQS_ATTRIBUTES
+	})).Return(&aws_sqs.SendMessageOutput{
+		MessageId: &s.MESSAGE_ID,
+	}, nil).Once()
+
+	output, err := s.sqsClient.SendMessage(&aws_sqs.SendMessageInput{
+		MessageBody:       &s.LARGE_BODY,
+		MessageAttributes: attributes,
+	})
+
+	s.mockSqs.AssertExpectations(s.T())
+	s.mockS3.AssertExpectations(s.T())
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), s.MESSAGE_ID, *output.MessageId)
+}
+
+func (s *ExtendedSqsClientTestSuite) Test_ExtendedSqsClient_SendMessage_Success_Max_Allowed_Attributes_Not_Folded() {
+	s.config.SetAttributeLimitFallback(sqs_configs_constants.ATTRIBUTE_LIMIT_FALLBACK_FOLD)
+
+	attributes := createMessageAttributes(sqs_configs_constants.MAX_ALLOWED_ATTRIBUTES)
+
+	s.mockS3.On("PutObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.PutObjectOutput{}, nil).Once()
+	s.mockSqs.On("SendMessage", mock.MatchedBy(func(input *aws_sqs.SendMessageInput) bool {
+		_, hasEnvelope := input.MessageAttributes[sqs_configs_constants.PAYLOAD_ENVELOPE_ATTRIBUTE_NAME]
+		return !hasEnvelope && len(input.MessageAttributes) == sqs_configs_constants.MAX_SQS_ATTRIBUTES
+	})).Return(&aws_sqs.SendMessageOutput{
+		MessageId: &s.MESSAGE_ID,
+	}, nil).Once()
+
+	_, err := s.sqsClient.SendMessage(&aws_sqs.SendMessageInput{
+		MessageBody:       &s.LARGE_BODY,
+		MessageAttributes: attributes,
+	})
+
+	s.mockSqs.AssertExpectations(s.T())
+	s.mockS3.AssertExpectations(s.T())
+
+	assert.Nil(s.T(), err)
+}
+
 func (s *ExtendedSqsClientTestSuite) Test_ExtendedSqsClient_SendMessage_Failed_Reserved_Message_Attribute_Used() {
 	attributes := make(map[string]*aws_sqs.MessageAttributeValue)
 
@@ -285,6 +438,26 @@ func (s *ExtendedSqsClientTestSuite) Test_ExtendedSqsClient_SendMessage_Failed_R
 	assert.NotNil(s.T(), err)
 }
 
+func (s *ExtendedSqsClientTestSuite) Test_ExtendedSqsClient_SendMessage_Failed_Payload_Envelope_Attribute_Used() {
+	attributes := make(map[string]*aws_sqs.MessageAttributeValue)
+
+	attributes[sqs_configs_constants.PAYLOAD_ENVELOPE_ATTRIBUTE_NAME] = &aws_sqs.MessageAttributeValue{
+		DataType:    aws.String("String"),
+		StringValue: aws.String("test"),
+	}
+
+	_, err := s.sqsClient.SendMessage(&aws_sqs.SendMessageInput{
+		MessageBody:       &s.BODY,
+		MessageAttributes: attributes,
+	})
+
+	s.mockSqs.AssertExpectations(s.T())
+	s.mockS3.AssertExpectations(s.T())
+
+	assert.NotNil(s.T(), err)
+	assert.Contains(s.T(), err.Error(), sqs_configs_constants.PAYLOAD_ENVELOPE_ATTRIBUTE_NAME)
+}
+
 func (s *ExtendedSqsClientTestSuite) Test_ExtendedSqsClient_SendMessage_Failed_Message_Body_Breaking_Large() {
 	attributes := make(map[string]*aws_sqs.MessageAttributeValue)
 
@@ -494,3 +667,16 @@ func createLargePayloadMessage(messageId string, s3BucketName string, s3Key stri
 
 	return message
 }
+
+func createMessageAttributes(count int) map[string]*aws_sqs.MessageAttributeValue {
+	attributes := make(map[string]*aws_sqs.MessageAttributeValue)
+
+	for i := 0; i < count; i++ {
+		attributes[fmt.Sprintf("Attribute%d", i)] = &aws_sqs.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String("test"),
+		}
+	}
+
+	return attributes
+}
@@ -0,0 +1,190 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client"
+	tracing_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tracing/constants"
+
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/internal/payload_store/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/services/aws_extended_sqs_client/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/tracing/mock"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+const testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+type TracingTestSuite struct {
+	suite.Suite
+
+	config *aws_extended_sqs_client.AwsExtendedSQSClientConfiguration
+	client *aws_extended_sqs_client.AwsExtendedSQSClient
+
+	mockSqs    *MockSqs
+	mockS3     *MockS3
+	mockTracer *MockTracer
+}
+
+func (suite *TracingTestSuite) SetupTest() {
+	suite.mockSqs = new(MockSqs)
+	suite.mockS3 = new(MockS3)
+	suite.mockTracer = &MockTracer{TraceParent: testTraceParent}
+
+	suite.config = aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	suite.config.WithPayloadSupportEnabled(suite.mockS3, "test-bucket")
+
+	suite.client = aws_extended_sqs_client.NewExtendedSQSClient(suite.mockSqs, suite.config, aws_extended_sqs_client.WithTracer(suite.mockTracer))
+}
+
+func (s *TracingTestSuite) Test_SendMessage_Success_Trace_Context_Injected() {
+	input := &aws_sqs.SendMessageInput{
+		QueueUrl:    aws.String("test-queue"),
+		MessageBody: aws.String("test"),
+	}
+
+	s.mockSqs.On("SendMessage", mock.MatchedBy(func(input *aws_sqs.SendMessageInput) bool {
+		return *input.MessageAttributes[TRACE_PARENT_FIELD].StringValue == testTraceParent
+	})).Return(&aws_sqs.SendMessageOutput{MessageId: aws.String("test-message-id")}, nil).Once()
+
+	_, err := s.client.SendMessage(input)
+
+	s.mockSqs.AssertExpectations(s.T())
+
+	assert.Nil(s.T(), err)
+	assert.Nil(s.T(), input.MessageAttributes)
+
+	span := s.mockTracer.GetSpan(tracing_constants.SPAN_SEND_MESSAGE)
+	assert.NotNil(s.T(), span)
+	assert.Equal(s.T(), tracing_constants.SPAN_KIND_PRODUCER, span.Kind)
+	assert.Equal(s.T(), "test-queue", span.Attributes[tracing_constants.ATTRIBUTE_MESSAGING_DESTINATION])
+	assert.True(s.T(), span.Ended)
+	assert.Nil(s.T(), span.Err)
+}
+
+func (s *TracingTestSuite) Test_SendMessageWithContext_Success_S3_Span_In_Send_Span() {
+	largeBody := strings.Repeat("test", 65537)
+	ctx := context.Background()
+
+	s.mockS3.On("PutObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.PutObjectOutput{}, nil).Once()
+	s.mockSqs.On("SendMessageWithContext", ctx, mock.MatchedBy(func(input *aws_sqs.SendMessageInput) bool {
+		_, ok := input.MessageAttributes[TRACE_PARENT_FIELD]
+		return ok
+	})).Return(&aws_sqs.SendMessageOutput{MessageId: aws.String("test-message-id")}, nil).Once()
+
+	_, err := s.client.SendMessageWithContext(ctx, &aws_sqs.SendMessageInput{
+		QueueUrl:    aws.String("test-queue"),
+		MessageBody: &largeBody,
+	})
+
+	s.mockSqs.AssertExpectations(s.T())
+	s.mockS3.AssertExpectations(s.T())
+
+	assert.Nil(s.T(), err)
+
+	sendSpan := s.mockTracer.GetSpan(tracing_constants.SPAN_SEND_MESSAGE)
+	s3Span := s.mockTracer.GetSpan(tracing_constants.SPAN_S3_STORE)
+	assert.NotNil(s.T(), s3Span)
+	assert.Same(s.T(), sendSpan, s3Span.Parent)
+	assert.Equal(s.T(), "test-bucket", s3Span.Attributes[tracing_constants.ATTRIBUTE_S3_BUCKET])
+	assert.True(s.T(), s3Span.Ended)
+}
+
+func (s *TracingTestSuite) Test_SendMessage_Failed_Trace_Context_Exceeds_Attribute_Limit() {
+	_, err := s.client.SendMessage(&aws_sqs.SendMessageInput{
+		MessageBody:       aws.String("test"),
+		MessageAttributes: createMessageAttributes(10),
+	})
+
+	s.mockSqs.AssertNotCalled(s.T(), "SendMessage", mock.Anything)
+
+	assert.NotNil(s.T(), err)
+	assert.Equal(s.T(), err, s.mockTracer.GetSpan(tracing_constants.SPAN_SEND_MESSAGE).Err)
+}
+
+func (s *TracingTestSuite) Test_ReceiveMessage_Success_Trace_Context_Extracted() {
+	message := &aws_sqs.Message{
+		Body:          aws.String("test"),
+		ReceiptHandle: aws.String("test-receipt-handle"),
+		MessageAttributes: map[string]*aws_sqs.MessageAttributeValue{
+			TRACE_PARENT_FIELD: {DataType: aws.String("String"), StringValue: aws.String(testTraceParent)},
+		},
+	}
+
+	s.mockSqs.On("ReceiveMessage", mock.MatchedBy(func(input *aws_sqs.ReceiveMessageInput) bool {
+		return aws.StringValueSlice(input.MessageAttributeNames)[1] == TRACE_PARENT_FIELD
+	})).Return(&aws_sqs.ReceiveMessageOutput{
+		Messages: []*aws_sqs.Message{message},
+	}, nil).Once()
+
+	output, err := s.client.ReceiveMessage(&aws_sqs.ReceiveMessageInput{
+		QueueUrl:              aws.String("test-queue"),
+		MessageAttributeNames: []*string{aws.String("Attribute")},
+	})
+
+	s.mockSqs.AssertExpectations(s.T())
+
+	assert.Nil(s.T(), err)
+
+	ctx := s.client.ExtractMessageContext(context.Background(), output.Messages[0])
+	assert.Equal(s.T(), testTraceParent, TraceParentFromContext(ctx))
+
+	span := s.mockTracer.GetSpan(tracing_constants.SPAN_RECEIVE_MESSAGE)
+	assert.Equal(s.T(), tracing_constants.SPAN_KIND_CONSUMER, span.Kind)
+	assert.True(s.T(), span.Ended)
+}
+
+func (s *TracingTestSuite) Test_ReceiveMessage_Success_Trace_Context_Requested_By_All() {
+	s.mockSqs.On("ReceiveMessage", mock.MatchedBy(func(input *aws_sqs.ReceiveMessageInput) bool {
+		for _, name := range input.MessageAttributeNames {
+			if *name == TRACE_PARENT_FIELD {
+				return false
+			}
+		}
+		return true
+	})).Return(&aws_sqs.ReceiveMessageOutput{}, nil).Once()
+
+	_, err := s.client.ReceiveMessage(&aws_sqs.ReceiveMessageInput{
+		QueueUrl:              aws.String("test-queue"),
+		MessageAttributeNames: []*string{aws.String(aws_sqs.QueueAttributeNameAll)},
+	})
+
+	s.mockSqs.AssertExpectations(s.T())
+
+	assert.Nil(s.T(), err)
+}
+
+func (s *TracingTestSuite) Test_DeleteMessageWithContext_Success_S3_Span_In_Delete_Span() {
+	s.config.SetCleanupS3Payload(true)
+
+	ctx := context.Background()
+	receiptHandle := "-..s3BucketName..-test-bucket-..s3BucketName..--..s3Key..-test-key-..s3Key..-test-receipt-handle"
+
+	s.mockS3.On("DeleteObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.DeleteObjectOutput{}, nil).Once()
+	s.mockSqs.On("DeleteMessageWithContext", ctx, mock.MatchedBy(func(input *aws_sqs.DeleteMessageInput) bool {
+		return *input.ReceiptHandle == "test-receipt-handle"
+	})).Return(&aws_sqs.DeleteMessageOutput{}, nil).Once()
+
+	_, err := s.client.DeleteMessageWithContext(ctx, &aws_sqs.DeleteMessageInput{
+		QueueUrl:      aws.String("test-queue"),
+		ReceiptHandle: &receiptHandle,
+	})
+
+	s.mockSqs.AssertExpectations(s.T())
+	s.mockS3.AssertExpectations(s.T())
+
+	assert.Nil(s.T(), err)
+	assert.Same(s.T(), s.mockTracer.GetSpan(tracing_constants.SPAN_DELETE_MESSAGE), s.mockTracer.GetSpan(tracing_constants.SPAN_S3_DELETE).Parent)
+}
+
+func TestTracing(t *testing.T) {
+	suite.Run(t, new(TracingTestSuite))
+}
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"context"
+	std_errors "errors"
+	"testing"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors"
+	errors_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors/constants"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client"
+
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/internal/payload_store/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/logging/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/services/aws_extended_sqs_client/mock"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const s3ReceiptHandle = "-..s3BucketName..-test-bucket-..s3BucketName..--..s3Key..-test-key-..s3Key..-test-receipt-handle"
+
+func newVisibilityTestClient(mockSqs *MockSqs) *aws_extended_sqs_client.AwsExtendedSQSClient {
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	config.WithPayloadSupportEnabled(new(MockS3), "test-bucket")
+
+	return aws_extended_sqs_client.NewExtendedSQSClient(mockSqs, config, aws_extended_sqs_client.WithLogger(NewMockLogger()))
+}
+
+func Test_ExtendedSqsClient_ChangeMessageVisibility_Success_S3_Receipt_Handle_Unwrapped(t *testing.T) {
+	mockSqs := new(MockSqs)
+	client := newVisibilityTestClient(mockSqs)
+
+	mockSqs.On("ChangeMessageVisibility", &aws_sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String("test-queue"),
+		ReceiptHandle:     aws.String("test-receipt-handle"),
+		VisibilityTimeout: aws.Int64(60),
+	}).Return(&aws_sqs.ChangeMessageVisibilityOutput{}, nil).Once()
+
+	input := &aws_sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String("test-queue"),
+		ReceiptHandle:     aws.String(s3ReceiptHandle),
+		VisibilityTimeout: aws.Int64(60),
+	}
+	_, err := client.ChangeMessageVisibility(input)
+
+	assert.Nil(t, err)
+	assert.Equal(t, s3ReceiptHandle, *input.ReceiptHandle)
+	mockSqs.AssertExpectations(t)
+}
+
+func Test_ExtendedSqsClient_ChangeMessageVisibilityWithContext_Success_Receipt_Handle_Kept(t *testing.T) {
+	mockSqs := new(MockSqs)
+	client := newVisibilityTestClient(mockSqs)
+
+	ctx := context.WithValue(context.Background(), "key", "value")
+	mockSqs.On("ChangeMessageVisibilityWithContext", ctx, &aws_sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String("test-queue"),
+		ReceiptHandle:     aws.String("test-receipt-handle"),
+		VisibilityTimeout: aws.Int64(60),
+	}).Return(&aws_sqs.ChangeMessageVisibilityOutput{}, nil).Once()
+
+	_, err := client.ChangeMessageVisibilityWithContext(ctx, &aws_sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String("test-queue"),
+		ReceiptHandle:     aws.String("test-receipt-handle"),
+		VisibilityTimeout: aws.Int64(60),
+	})
+
+	assert.Nil(t, err)
+	mockSqs.AssertExpectations(t)
+}
+
+func Test_ExtendedSqsClient_ChangeMessageVisibility_Failed_SQS_Error(t *testing.T) {
+	mockSqs := new(MockSqs)
+	client := newVisibilityTestClient(mockSqs)
+
+	mockSqs.On("ChangeMessageVisibility", mock.Anything).Return(&aws_sqs.ChangeMessageVisibilityOutput{}, std_errors.New("test error")).Once()
+
+	_, err := client.ChangeMessageVisibility(&aws_sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String("test-queue"),
+		ReceiptHandle:     aws.String(s3ReceiptHandle),
+		VisibilityTimeout: aws.Int64(60),
+	})
+
+	assert.True(t, std_errors.Is(err, errors.SQSError{Operation: errors_constants.OPERATION_CHANGE_VISIBILITY}))
+}
+
+func Test_ExtendedSqsClient_ChangeMessageVisibilityBatch_Success_S3_Receipt_Handles_Unwrapped(t *testing.T) {
+	mockSqs := new(MockSqs)
+	client := newVisibilityTestClient(mockSqs)
+
+	mockSqs.On("ChangeMessageVisibilityBatchWithContext", mock.Anything, &aws_sqs.ChangeMessageVisibilityBatchInput{
+		QueueUrl: aws.String("test-queue"),
+		Entries: []*aws_sqs.ChangeMessageVisibilityBatchRequestEntry{
+			{Id: aws.String("1"), ReceiptHandle: aws.String("test-receipt-handle"), VisibilityTimeout: aws.Int64(60)},
+			{Id: aws.String("2"), ReceiptHandle: aws.String("other-receipt-handle"), VisibilityTimeout: aws.Int64(60)},
+		},
+	}).Return(&aws_sqs.ChangeMessageVisibilityBatchOutput{}, nil).Once()
+
+	input := &aws_sqs.ChangeMessageVisibilityBatchInput{
+		QueueUrl: aws.String("test-queue"),
+		Entries: []*aws_sqs.ChangeMessageVisibilityBatchRequestEntry{
+			{Id: aws.String("1"), ReceiptHandle: aws.String(s3ReceiptHandle), VisibilityTimeout: aws.Int64(60)},
+			{Id: aws.String("2"), ReceiptHandle: aws.String("other-receipt-handle"), VisibilityTimeout: aws.Int64(60)},
+		},
+	}
+	_, err := client.ChangeMessageVisibilityBatchWithContext(context.Background(), input)
+
+	assert.Nil(t, err)
+	assert.Equal(t, s3ReceiptHandle, *input.Entries[0].ReceiptHandle)
+	mockSqs.AssertExpectations(t)
+}
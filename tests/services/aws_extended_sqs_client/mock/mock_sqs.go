@@ -3,6 +3,8 @@ package tests
 import (
 	"github.com/stretchr/testify/mock"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
 	aws_sqsiface "github.com/aws/aws-sdk-go/service/sqs/sqsiface"
 )
@@ -26,3 +28,58 @@ func (m *MockSqs) DeleteMessage(input *aws_sqs.DeleteMessageInput) (*aws_sqs.Del
 	args := m.Called(input)
 	return args.Get(0).(*aws_sqs.DeleteMessageOutput), args.Error(1)
 }
+
+func (m *MockSqs) SendMessageWithContext(ctx aws.Context, input *aws_sqs.SendMessageInput, option ...request.Option) (*aws_sqs.SendMessageOutput, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(*aws_sqs.SendMessageOutput), args.Error(1)
+}
+
+func (m *MockSqs) ReceiveMessageWithContext(ctx aws.Context, input *aws_sqs.ReceiveMessageInput, option ...request.Option) (*aws_sqs.ReceiveMessageOutput, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(*aws_sqs.ReceiveMessageOutput), args.Error(1)
+}
+
+func (m *MockSqs) DeleteMessageWithContext(ctx aws.Context, input *aws_sqs.DeleteMessageInput, option ...request.Option) (*aws_sqs.DeleteMessageOutput, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(*aws_sqs.DeleteMessageOutput), args.Error(1)
+}
+
+func (m *MockSqs) ChangeMessageVisibility(input *aws_sqs.ChangeMessageVisibilityInput) (*aws_sqs.ChangeMessageVisibilityOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*aws_sqs.ChangeMessageVisibilityOutput), args.Error(1)
+}
+
+func (m *MockSqs) ChangeMessageVisibilityWithContext(ctx aws.Context, input *aws_sqs.ChangeMessageVisibilityInput, option ...request.Option) (*aws_sqs.ChangeMessageVisibilityOutput, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(*aws_sqs.ChangeMessageVisibilityOutput), args.Error(1)
+}
+
+func (m *MockSqs) ChangeMessageVisibilityBatch(input *aws_sqs.ChangeMessageVisibilityBatchInput) (*aws_sqs.ChangeMessageVisibilityBatchOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*aws_sqs.ChangeMessageVisibilityBatchOutput), args.Error(1)
+}
+
+func (m *MockSqs) ChangeMessageVisibilityBatchWithContext(ctx aws.Context, input *aws_sqs.ChangeMessageVisibilityBatchInput, option ...request.Option) (*aws_sqs.ChangeMessageVisibilityBatchOutput, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(*aws_sqs.ChangeMessageVisibilityBatchOutput), args.Error(1)
+}
+
+func (m *MockSqs) SendMessageBatch(input *aws_sqs.SendMessageBatchInput) (*aws_sqs.SendMessageBatchOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*aws_sqs.SendMessageBatchOutput), args.Error(1)
+}
+
+func (m *MockSqs) SendMessageBatchWithContext(ctx aws.Context, input *aws_sqs.SendMessageBatchInput, option ...request.Option) (*aws_sqs.SendMessageBatchOutput, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(*aws_sqs.SendMessageBatchOutput), args.Error(1)
+}
+
+func (m *MockSqs) DeleteMessageBatch(input *aws_sqs.DeleteMessageBatchInput) (*aws_sqs.DeleteMessageBatchOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*aws_sqs.DeleteMessageBatchOutput), args.Error(1)
+}
+
+func (m *MockSqs) DeleteMessageBatchWithContext(ctx aws.Context, input *aws_sqs.DeleteMessageBatchInput, option ...request.Option) (*aws_sqs.DeleteMessageBatchOutput, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(*aws_sqs.DeleteMessageBatchOutput), args.Error(1)
+}
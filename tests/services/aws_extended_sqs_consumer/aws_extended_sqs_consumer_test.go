@@ -0,0 +1,329 @@
+package tests
+
+import (
+	"context"
+	std_errors "errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_acknowledger"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client"
+	sqs_configs_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client/constants"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_consumer"
+
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/internal/payload_store/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/logging/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/services/aws_extended_sqs_client/mock"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type ConsumerTestSuite struct {
+	suite.Suite
+
+	client *aws_extended_sqs_client.AwsExtendedSQSClient
+
+	mockSqs    *MockSqs
+	mockS3     *MockS3
+	mockLogger *MockLogger
+}
+
+func (suite *ConsumerTestSuite) SetupTest() {
+	suite.mockSqs = new(MockSqs)
+	suite.mockS3 = new(MockS3)
+	suite.mockLogger = NewMockLogger()
+
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	config.WithPayloadSupportEnabled(suite.mockS3, "test-bucket")
+	config.SetCleanupS3Payload(true)
+
+	suite.client = aws_extended_sqs_client.NewExtendedSQSClient(suite.mockSqs, config, aws_extended_sqs_client.WithLogger(suite.mockLogger))
+}
+
+// Returns the messages on the first receive, then blocks every receive until the consumer is stopped
+func (s *ConsumerTestSuite) mockReceive(messages ...*aws_sqs.Message) {
+	s.mockSqs.On("ReceiveMessageWithContext", mock.Anything, mock.MatchedBy(func(input *aws_sqs.ReceiveMessageInput) bool {
+		return *input.QueueUrl == "test-queue"
+	})).Return(&aws_sqs.ReceiveMessageOutput{Messages: messages}, nil).Once()
+	s.mockSqs.On("ReceiveMessageWithContext", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		<-args.Get(0).(context.Context).Done()
+	}).Return(&aws_sqs.ReceiveMessageOutput{}, context.Canceled)
+}
+
+func (s *ConsumerTestSuite) runConsumer(handler aws_extended_sqs_consumer.Handler, done <-chan struct{}, opts ...aws_extended_sqs_consumer.AwsExtendedSQSConsumerOption) error {
+	opts = append([]aws_extended_sqs_consumer.AwsExtendedSQSConsumerOption{aws_extended_sqs_consumer.WithLogger(s.mockLogger)}, opts...)
+	consumer := aws_extended_sqs_consumer.NewExtendedSQSConsumer(s.client, "test-queue", handler, opts...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-done
+		cancel()
+	}()
+
+	return consumer.Run(ctx)
+}
+
+func (s *ConsumerTestSuite) Test_Run_Success_Handled_Messages_Deleted() {
+	largeBody := strings.Repeat("test", 65537)
+	s.mockReceive(
+		&aws_sqs.Message{MessageId: aws.String("message-1"), Body: aws.String("test"), ReceiptHandle: aws.String("receipt-handle-1")},
+		createLargePayloadMessage("message-2", largeBody, "receipt-handle-2"),
+	)
+	s.mockS3.On("GetObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.GetObjectOutput{
+		Body: ioutil.NopCloser(strings.NewReader(largeBody)),
+	}, nil).Once()
+	s.mockS3.On("DeleteObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.DeleteObjectOutput{}, nil).Once()
+	s.mockSqs.On("DeleteMessageWithContext", mock.Anything, mock.Anything).Return(&aws_sqs.DeleteMessageOutput{}, nil).Twice()
+
+	var mu sync.Mutex
+	bodies := map[string]string{}
+	done := make(chan struct{})
+
+	err := s.runConsumer(func(ctx context.Context, message *aws_sqs.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		bodies[*message.MessageId] = *message.Body
+		if len(bodies) == 2 {
+			close(done)
+		}
+		return nil
+	}, done, aws_extended_sqs_consumer.WithWorkers(2))
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), map[string]string{"message-1": "test", "message-2": largeBody}, bodies)
+
+	s.mockSqs.AssertExpectations(s.T())
+	s.mockS3.AssertExpectations(s.T())
+	s.mockSqs.AssertCalled(s.T(), "DeleteMessageWithContext", mock.Anything, mock.MatchedBy(func(input *aws_sqs.DeleteMessageInput) bool {
+		return *input.QueueUrl == "test-queue" && *input.ReceiptHandle == "receipt-handle-2"
+	}))
+}
+
+func (s *ConsumerTestSuite) Test_Run_Success_Failed_Messages_Left_In_Queue() {
+	s.mockReceive(
+		&aws_sqs.Message{MessageId: aws.String("message-1"), Body: aws.String("error"), ReceiptHandle: aws.String("receipt-handle-1")},
+		&aws_sqs.Message{MessageId: aws.String("message-2"), Body: aws.String("panic"), ReceiptHandle: aws.String("receipt-handle-2")},
+		&aws_sqs.Message{MessageId: aws.String("message-3"), Body: aws.String("test"), ReceiptHandle: aws.String("receipt-handle-3")},
+	)
+	s.mockSqs.On("DeleteMessageWithContext", mock.Anything, mock.Anything).Return(&aws_sqs.DeleteMessageOutput{}, nil).Once()
+
+	done := make(chan struct{})
+	err := s.runConsumer(func(ctx context.Context, message *aws_sqs.Message) error {
+		switch *message.Body {
+		case "error":
+			return std_errors.New("test error")
+		case "panic":
+			panic("test panic")
+		}
+
+		close(done)
+		return nil
+	}, done)
+
+	assert.Nil(s.T(), err)
+
+	s.mockSqs.AssertExpectations(s.T())
+	s.mockSqs.AssertCalled(s.T(), "DeleteMessageWithContext", mock.Anything, mock.MatchedBy(func(input *aws_sqs.DeleteMessageInput) bool {
+		return *input.ReceiptHandle == "receipt-handle-3"
+	}))
+}
+
+func (s *ConsumerTestSuite) Test_Run_Success_In_Flight_Messages_Drained() {
+	s.mockReceive(
+		&aws_sqs.Message{MessageId: aws.String("message-1"), Body: aws.String("test"), ReceiptHandle: aws.String("receipt-handle-1")},
+		&aws_sqs.Message{MessageId: aws.String("message-2"), Body: aws.String("test"), ReceiptHandle: aws.String("receipt-handle-2")},
+	)
+	s.mockSqs.On("DeleteMessageWithContext", mock.MatchedBy(func(ctx context.Context) bool {
+		return ctx.Err() == nil
+	}), mock.Anything).Return(&aws_sqs.DeleteMessageOutput{}, nil).Once()
+
+	started := make(chan struct{})
+	stopped := make(chan struct{})
+	handled := 0
+
+	go func() {
+		<-started
+		close(stopped)
+	}()
+
+	err := s.runConsumer(func(ctx context.Context, message *aws_sqs.Message) error {
+		close(started)
+		<-stopped
+		time.Sleep(10 * time.Millisecond)
+
+		handled++
+		return ctx.Err()
+	}, stopped)
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 1, handled)
+
+	s.mockSqs.AssertExpectations(s.T())
+}
+
+func (s *ConsumerTestSuite) Test_Run_Success_Drain_Timeout_Cancels_Handlers() {
+	s.mockReceive(&aws_sqs.Message{MessageId: aws.String("message-1"), Body: aws.String("test"), ReceiptHandle: aws.String("receipt-handle-1")})
+	s.mockSqs.On("DeleteMessageWithContext", mock.MatchedBy(func(ctx context.Context) bool {
+		return ctx.Err() == nil
+	}), mock.Anything).Return(&aws_sqs.DeleteMessageOutput{}, nil).Once()
+
+	stopped := make(chan struct{})
+	var handlerErr error
+
+	err := s.runConsumer(func(ctx context.Context, message *aws_sqs.Message) error {
+		close(stopped)
+
+		// Handled once cancelled, the message is still deleted
+		<-ctx.Done()
+		handlerErr = ctx.Err()
+		return nil
+	}, stopped, aws_extended_sqs_consumer.WithDrainTimeout(10*time.Millisecond))
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), context.Canceled, handlerErr)
+
+	s.mockSqs.AssertExpectations(s.T())
+}
+
+func (s *ConsumerTestSuite) Test_Run_Success_Receives_Only_For_Idle_Workers() {
+	s.mockSqs.On("ReceiveMessageWithContext", mock.Anything, mock.MatchedBy(func(input *aws_sqs.ReceiveMessageInput) bool {
+		return *input.MaxNumberOfMessages == 2
+	})).Return(&aws_sqs.ReceiveMessageOutput{Messages: []*aws_sqs.Message{
+		{MessageId: aws.String("message-1"), Body: aws.String("test"), ReceiptHandle: aws.String("receipt-handle-1")},
+	}}, nil).Once()
+	// The other worker is still busy with the first message
+	s.mockSqs.On("ReceiveMessageWithContext", mock.Anything, mock.MatchedBy(func(input *aws_sqs.ReceiveMessageInput) bool {
+		return *input.MaxNumberOfMessages == 1
+	})).Return(&aws_sqs.ReceiveMessageOutput{Messages: []*aws_sqs.Message{
+		{MessageId: aws.String("message-2"), Body: aws.String("test"), ReceiptHandle: aws.String("receipt-handle-2")},
+	}}, nil).Once()
+	s.mockReceive()
+	s.mockSqs.On("DeleteMessageWithContext", mock.Anything, mock.Anything).Return(&aws_sqs.DeleteMessageOutput{}, nil).Twice()
+
+	released := make(chan struct{})
+	done := make(chan struct{})
+
+	err := s.runConsumer(func(ctx context.Context, message *aws_sqs.Message) error {
+		if *message.MessageId == "message-1" {
+			<-released
+			close(done)
+			return nil
+		}
+
+		close(released)
+		return nil
+	}, done, aws_extended_sqs_consumer.WithWorkers(2), aws_extended_sqs_consumer.WithMaxNumberOfMessages(10))
+
+	assert.Nil(s.T(), err)
+
+	s.mockSqs.AssertExpectations(s.T())
+}
+
+func (s *ConsumerTestSuite) Test_Run_Success_Receive_Error_Retried() {
+	s.mockSqs.On("ReceiveMessageWithContext", mock.Anything, mock.Anything).Return(&aws_sqs.ReceiveMessageOutput{}, std_errors.New("test error")).Once()
+	s.mockReceive(&aws_sqs.Message{MessageId: aws.String("message-1"), Body: aws.String("test"), ReceiptHandle: aws.String("receipt-handle-1")})
+	s.mockSqs.On("DeleteMessageWithContext", mock.Anything, mock.Anything).Return(&aws_sqs.DeleteMessageOutput{}, nil).Once()
+
+	done := make(chan struct{})
+	err := s.runConsumer(func(ctx context.Context, message *aws_sqs.Message) error {
+		close(done)
+		return nil
+	}, done, aws_extended_sqs_consumer.WithReceiveErrorBackoff(time.Millisecond))
+
+	assert.Nil(s.T(), err)
+
+	s.mockSqs.AssertExpectations(s.T())
+}
+
+func (s *ConsumerTestSuite) Test_Run_Success_Handled_Messages_Acknowledged_In_Batch() {
+	s.mockReceive(
+		&aws_sqs.Message{MessageId: aws.String("message-1"), Body: aws.String("test"), ReceiptHandle: aws.String("receipt-handle-1")},
+		&aws_sqs.Message{MessageId: aws.String("message-2"), Body: aws.String("test"), ReceiptHandle: aws.String("receipt-handle-2")},
+	)
+	s.mockSqs.On("DeleteMessageBatchWithContext", mock.Anything, mock.MatchedBy(func(input *aws_sqs.DeleteMessageBatchInput) bool {
+		return *input.QueueUrl == "test-queue" && len(input.Entries) == 2
+	})).Return(&aws_sqs.DeleteMessageBatchOutput{
+		Successful: []*aws_sqs.DeleteMessageBatchResultEntry{{Id: aws.String("0")}, {Id: aws.String("1")}},
+	}, nil).Once()
+
+	acknowledger := aws_extended_sqs_acknowledger.NewExtendedSQSAcknowledger(s.client,
+		aws_extended_sqs_acknowledger.WithLogger(s.mockLogger),
+		aws_extended_sqs_acknowledger.WithFlushInterval(time.Hour),
+	)
+
+	var mu sync.Mutex
+	handled := 0
+	done := make(chan struct{})
+
+	err := s.runConsumer(func(ctx context.Context, message *aws_sqs.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		handled++
+		if handled == 2 {
+			close(done)
+		}
+		return nil
+	}, done, aws_extended_sqs_consumer.WithAcknowledger(acknowledger))
+
+	assert.Nil(s.T(), err)
+
+	s.mockSqs.AssertExpectations(s.T())
+	s.mockSqs.AssertNotCalled(s.T(), "DeleteMessageWithContext", mock.Anything, mock.Anything)
+}
+
+func (s *ConsumerTestSuite) Test_Run_Failed_No_Workers() {
+	consumer := aws_extended_sqs_consumer.NewExtendedSQSConsumer(s.client, "test-queue", func(ctx context.Context, message *aws_sqs.Message) error {
+		return nil
+	}, aws_extended_sqs_consumer.WithWorkers(0))
+
+	err := consumer.Run(context.Background())
+
+	assert.NotNil(s.T(), err)
+}
+
+func (s *ConsumerTestSuite) Test_Run_Failed_Max_Number_Of_Messages_Out_Of_Range() {
+	for _, maxNumberOfMessages := range []int64{0, 16} {
+		consumer := aws_extended_sqs_consumer.NewExtendedSQSConsumer(s.client, "test-queue", func(ctx context.Context, message *aws_sqs.Message) error {
+			return nil
+		}, aws_extended_sqs_consumer.WithWorkers(20), aws_extended_sqs_consumer.WithMaxNumberOfMessages(maxNumberOfMessages))
+
+		err := consumer.Run(context.Background())
+
+		assert.True(s.T(), std_errors.As(err, &errors.SDKError{}))
+	}
+
+	s.mockSqs.AssertNotCalled(s.T(), "ReceiveMessageWithContext", mock.Anything, mock.Anything)
+}
+
+func TestConsumer(t *testing.T) {
+	suite.Run(t, new(ConsumerTestSuite))
+}
+
+func createLargePayloadMessage(messageId string, body string, receiptHandle string) *aws_sqs.Message {
+	messagePointer := fmt.Sprintf("[\"software.amazon.payloadoffloading.PayloadS3Pointer\",{\"s3BucketName\":\"test-bucket\",\"s3Key\":\"%s\"}]", messageId)
+
+	return &aws_sqs.Message{
+		MessageId:     &messageId,
+		Body:          &messagePointer,
+		ReceiptHandle: &receiptHandle,
+		MessageAttributes: map[string]*aws_sqs.MessageAttributeValue{
+			sqs_configs_constants.RESERVED_ATTRIBUTE_NAME: {
+				DataType:    aws.String("Number"),
+				StringValue: aws.String(fmt.Sprint(len(body))),
+			},
+		},
+	}
+}
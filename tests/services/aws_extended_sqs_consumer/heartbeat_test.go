@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"context"
+	std_errors "errors"
+	"testing"
+	"time"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_consumer"
+
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/logging/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/services/aws_extended_sqs_client/mock"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func visibilityTimeoutOf(timeout int64) interface{} {
+	return mock.MatchedBy(func(input *aws_sqs.ChangeMessageVisibilityInput) bool {
+		return *input.QueueUrl == "test-queue" && *input.ReceiptHandle == "test-receipt-handle" && *input.VisibilityTimeout == timeout
+	})
+}
+
+func Test_Heartbeat_Success_Extended_Until_Stopped(t *testing.T) {
+	mockSqs := new(MockSqs)
+	mockSqs.On("ChangeMessageVisibilityWithContext", mock.Anything, visibilityTimeoutOf(30)).Return(&aws_sqs.ChangeMessageVisibilityOutput{}, nil)
+
+	heartbeat := aws_extended_sqs_consumer.StartHeartbeat(context.Background(), mockSqs, "test-queue", "test-receipt-handle",
+		aws_extended_sqs_consumer.WithHeartbeatLogger(NewMockLogger()),
+		aws_extended_sqs_consumer.WithHeartbeatInterval(10*time.Millisecond),
+		aws_extended_sqs_consumer.WithHeartbeatVisibilityTimeout(30),
+	)
+
+	time.Sleep(55 * time.Millisecond)
+	heartbeat.Stop()
+	heartbeat.Stop()
+
+	calls := len(mockSqs.Calls)
+	assert.GreaterOrEqual(t, calls, 2)
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, calls, len(mockSqs.Calls))
+}
+
+func Test_Heartbeat_Success_Stopped_At_Max_Extension(t *testing.T) {
+	mockSqs := new(MockSqs)
+	mockSqs.On("ChangeMessageVisibilityWithContext", mock.Anything, visibilityTimeoutOf(1)).Return(&aws_sqs.ChangeMessageVisibilityOutput{}, nil).Once()
+
+	logger := NewMockLogger()
+	heartbeat := aws_extended_sqs_consumer.StartHeartbeat(context.Background(), mockSqs, "test-queue", "test-receipt-handle",
+		aws_extended_sqs_consumer.WithHeartbeatLogger(logger),
+		aws_extended_sqs_consumer.WithHeartbeatInterval(500*time.Millisecond),
+		aws_extended_sqs_consumer.WithHeartbeatVisibilityTimeout(60),
+		aws_extended_sqs_consumer.WithHeartbeatMaxExtension(1700*time.Millisecond),
+	)
+
+	time.Sleep(1300 * time.Millisecond)
+	heartbeat.Stop()
+
+	mockSqs.AssertExpectations(t)
+	assert.Equal(t, "Reached the max extension, stopped extending the visibility", logger.LastEntry().Message)
+}
+
+func Test_Heartbeat_Success_Error_Retried(t *testing.T) {
+	mockSqs := new(MockSqs)
+	mockSqs.On("ChangeMessageVisibilityWithContext", mock.Anything, mock.Anything).Return(&aws_sqs.ChangeMessageVisibilityOutput{}, std_errors.New("test error")).Once()
+	mockSqs.On("ChangeMessageVisibilityWithContext", mock.Anything, mock.Anything).Return(&aws_sqs.ChangeMessageVisibilityOutput{}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	heartbeat := aws_extended_sqs_consumer.StartHeartbeat(ctx, mockSqs, "test-queue", "test-receipt-handle",
+		aws_extended_sqs_consumer.WithHeartbeatLogger(NewMockLogger()),
+		aws_extended_sqs_consumer.WithHeartbeatInterval(10*time.Millisecond),
+	)
+
+	time.Sleep(35 * time.Millisecond)
+	cancel()
+	heartbeat.Stop()
+
+	assert.GreaterOrEqual(t, len(mockSqs.Calls), 2)
+}
+
+func Test_Consumer_Heartbeat_Success_Extended_While_Handling(t *testing.T) {
+	mockSqs := new(MockSqs)
+	mockSqs.On("ReceiveMessageWithContext", mock.Anything, mock.Anything).Return(&aws_sqs.ReceiveMessageOutput{
+		Messages: []*aws_sqs.Message{{MessageId: aws.String("message-1"), Body: aws.String("test"), ReceiptHandle: aws.String("test-receipt-handle")}},
+	}, nil).Once()
+	mockSqs.On("ReceiveMessageWithContext", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		<-args.Get(0).(context.Context).Done()
+	}).Return(&aws_sqs.ReceiveMessageOutput{}, context.Canceled)
+	mockSqs.On("ChangeMessageVisibilityWithContext", mock.Anything, visibilityTimeoutOf(30)).Return(&aws_sqs.ChangeMessageVisibilityOutput{}, nil)
+	mockSqs.On("DeleteMessageWithContext", mock.Anything, mock.Anything).Return(&aws_sqs.DeleteMessageOutput{}, nil).Once()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	consumer := aws_extended_sqs_consumer.NewExtendedSQSConsumer(mockSqs, "test-queue", func(ctx context.Context, message *aws_sqs.Message) error {
+		time.Sleep(35 * time.Millisecond)
+		cancel()
+		return nil
+	},
+		aws_extended_sqs_consumer.WithLogger(NewMockLogger()),
+		aws_extended_sqs_consumer.WithHeartbeat(
+			aws_extended_sqs_consumer.WithHeartbeatInterval(10*time.Millisecond),
+			aws_extended_sqs_consumer.WithHeartbeatVisibilityTimeout(30),
+		),
+	)
+
+	err := consumer.Run(ctx)
+
+	assert.Nil(t, err)
+	mockSqs.AssertExpectations(t)
+}
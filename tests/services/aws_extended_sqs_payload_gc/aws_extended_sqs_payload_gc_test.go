@@ -0,0 +1,201 @@
+package tests
+
+import (
+	"context"
+	std_errors "errors"
+	"testing"
+	"time"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors"
+	errors_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors/constants"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_payload_gc"
+
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/internal/payload_store/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/logging/mock"
+
+	"github.com/aws/aws-sdk-go/aws"
+	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newObject(s3Key string, age time.Duration) *aws_s3.Object {
+	return &aws_s3.Object{
+		Key:          aws.String(s3Key),
+		LastModified: aws.Time(time.Now().Add(-age)),
+		Size:         aws.Int64(1024),
+	}
+}
+
+func mockListObjects(mockS3 *MockS3, pages ...[]*aws_s3.Object) {
+	outputs := make([]*aws_s3.ListObjectsV2Output, len(pages))
+	for index, objects := range pages {
+		outputs[index] = &aws_s3.ListObjectsV2Output{Contents: objects}
+	}
+
+	mockS3.On("ListObjectsV2PagesWithContext", mock.Anything, &aws_s3.ListObjectsV2Input{
+		Bucket: aws.String("test-bucket"),
+		Prefix: aws.String("payloads/"),
+	}).Return(outputs, nil).Once()
+}
+
+func newPayloadGC(mockS3 *MockS3, opts ...aws_extended_sqs_payload_gc.AwsExtendedSQSPayloadGCOption) *aws_extended_sqs_payload_gc.AwsExtendedSQSPayloadGC {
+	opts = append([]aws_extended_sqs_payload_gc.AwsExtendedSQSPayloadGCOption{
+		aws_extended_sqs_payload_gc.WithLogger(NewMockLogger()),
+		aws_extended_sqs_payload_gc.WithS3KeyPrefix("payloads/"),
+		aws_extended_sqs_payload_gc.WithMinAge(time.Hour),
+	}, opts...)
+
+	return aws_extended_sqs_payload_gc.NewExtendedSQSPayloadGC(mockS3, "test-bucket", opts...)
+}
+
+func Test_PayloadGC_Run_Success_Dry_Run(t *testing.T) {
+	mockS3 := new(MockS3)
+	gc := newPayloadGC(mockS3)
+
+	mockListObjects(mockS3,
+		[]*aws_s3.Object{newObject("payloads/old", 2*time.Hour), newObject("payloads/new", time.Minute)},
+		[]*aws_s3.Object{newObject("payloads/older", 48*time.Hour)},
+	)
+
+	report, err := gc.Run(context.Background())
+
+	assert.Nil(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 3, report.Scanned)
+	assert.Equal(t, 1, report.Recent)
+	assert.Len(t, report.Orphans, 2)
+	assert.Equal(t, "payloads/old", report.Orphans[0].S3Key)
+	assert.Equal(t, "payloads/older", report.Orphans[1].S3Key)
+	assert.Equal(t, 0, report.Deleted)
+
+	mockS3.AssertExpectations(t)
+	mockS3.AssertNotCalled(t, "DeleteObjectsWithContext", mock.Anything, mock.Anything)
+}
+
+func Test_PayloadGC_Run_Success_Orphans_Deleted(t *testing.T) {
+	mockS3 := new(MockS3)
+	gc := newPayloadGC(mockS3,
+		aws_extended_sqs_payload_gc.WithDryRun(false),
+		aws_extended_sqs_payload_gc.WithInFlightPointers(func(ctx context.Context) ([]string, error) {
+			return []string{
+				"[\"software.amazon.payloadoffloading.PayloadS3Pointer\",{\"s3BucketName\":\"test-bucket\",\"s3Key\":\"payloads/in-flight\"}]",
+				"[\"software.amazon.payloadoffloading.PayloadS3Pointer\",{\"s3BucketName\":\"other-bucket\",\"s3Key\":\"payloads/orphan\"}]",
+			}, nil
+		}),
+	)
+
+	mockListObjects(mockS3, []*aws_s3.Object{
+		newObject("payloads/in-flight", 2*time.Hour),
+		newObject("payloads/orphan", 2*time.Hour),
+		newObject("payloads/protected", 2*time.Hour),
+	})
+	mockS3.On("DeleteObjectsWithContext", mock.Anything, mock.MatchedBy(func(input *aws_s3.DeleteObjectsInput) bool {
+		return *input.Bucket == "test-bucket" && len(input.Delete.Objects) == 2 &&
+			*input.Delete.Objects[0].Key == "payloads/orphan" && *input.Delete.Objects[1].Key == "payloads/protected"
+	})).Return(&aws_s3.DeleteObjectsOutput{
+		Errors: []*aws_s3.Error{{Key: aws.String("payloads/protected"), Code: aws.String("AccessDenied")}},
+	}, nil).Once()
+
+	report, err := gc.Run(context.Background())
+
+	assert.Nil(t, err)
+	assert.False(t, report.DryRun)
+	assert.Equal(t, 1, report.InFlight)
+	assert.Len(t, report.Orphans, 2)
+	assert.Equal(t, 1, report.Deleted)
+	assert.Equal(t, 1, report.Failed)
+	assert.Nil(t, report.Orphans[0].Err)
+	assert.NotNil(t, report.Orphans[1].Err)
+
+	mockS3.AssertExpectations(t)
+}
+
+func Test_PayloadGC_Run_Success_Max_Reported_Orphans(t *testing.T) {
+	mockS3 := new(MockS3)
+
+	var handled []string
+	gc := newPayloadGC(mockS3,
+		aws_extended_sqs_payload_gc.WithMaxReportedOrphans(1),
+		aws_extended_sqs_payload_gc.WithOrphanHandler(func(orphan *aws_extended_sqs_payload_gc.Orphan) {
+			handled = append(handled, orphan.S3Key)
+		}),
+	)
+
+	mockListObjects(mockS3,
+		[]*aws_s3.Object{newObject("payloads/old", 2*time.Hour)},
+		[]*aws_s3.Object{newObject("payloads/older", 48*time.Hour)},
+	)
+
+	report, err := gc.Run(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, report.Orphaned)
+	assert.Len(t, report.Orphans, 1)
+	assert.Equal(t, "payloads/old", report.Orphans[0].S3Key)
+	assert.Equal(t, []string{"payloads/old", "payloads/older"}, handled)
+	mockS3.AssertExpectations(t)
+}
+
+func Test_PayloadGC_Run_Success_Whole_Bucket(t *testing.T) {
+	mockS3 := new(MockS3)
+	gc := newPayloadGC(mockS3,
+		aws_extended_sqs_payload_gc.WithS3KeyPrefix(""),
+		aws_extended_sqs_payload_gc.WithWholeBucket(),
+	)
+
+	mockS3.On("ListObjectsV2PagesWithContext", mock.Anything, &aws_s3.ListObjectsV2Input{
+		Bucket: aws.String("test-bucket"),
+	}).Return([]*aws_s3.ListObjectsV2Output{{Contents: []*aws_s3.Object{newObject("old", 2*time.Hour)}}}, nil).Once()
+
+	report, err := gc.Run(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, report.Orphaned)
+	mockS3.AssertExpectations(t)
+}
+
+func Test_PayloadGC_Run_Failed_No_Prefix(t *testing.T) {
+	mockS3 := new(MockS3)
+	gc := newPayloadGC(mockS3,
+		aws_extended_sqs_payload_gc.WithS3KeyPrefix(""),
+		aws_extended_sqs_payload_gc.WithDryRun(false),
+	)
+
+	_, err := gc.Run(context.Background())
+
+	assert.True(t, std_errors.As(err, &errors.SDKError{}))
+	mockS3.AssertNotCalled(t, "ListObjectsV2PagesWithContext", mock.Anything, mock.Anything)
+}
+
+func Test_PayloadGC_Run_Failed_In_Flight_Pointers_Error(t *testing.T) {
+	mockS3 := new(MockS3)
+	gc := newPayloadGC(mockS3,
+		aws_extended_sqs_payload_gc.WithDryRun(false),
+		aws_extended_sqs_payload_gc.WithInFlightPointers(func(ctx context.Context) ([]string, error) {
+			return nil, std_errors.New("test error")
+		}),
+	)
+
+	_, err := gc.Run(context.Background())
+
+	assert.NotNil(t, err)
+	mockS3.AssertNotCalled(t, "ListObjectsV2PagesWithContext", mock.Anything, mock.Anything)
+}
+
+func Test_PayloadGC_Run_Failed_List_Error(t *testing.T) {
+	mockS3 := new(MockS3)
+	gc := newPayloadGC(mockS3)
+
+	mockS3.On("ListObjectsV2PagesWithContext", mock.Anything, mock.Anything).Return(
+		[]*aws_s3.ListObjectsV2Output{{Contents: []*aws_s3.Object{newObject("payloads/old", 2*time.Hour)}}},
+		std_errors.New("test error"),
+	).Once()
+
+	report, err := gc.Run(context.Background())
+
+	assert.True(t, std_errors.Is(err, errors.S3Error{Operation: errors_constants.OPERATION_LIST}))
+	assert.Equal(t, 1, report.Scanned)
+	mockS3.AssertExpectations(t)
+}
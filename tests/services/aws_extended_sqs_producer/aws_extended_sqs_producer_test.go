@@ -0,0 +1,320 @@
+package tests
+
+import (
+	"context"
+	std_errors "errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_producer"
+
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/internal/payload_store/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/logging/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/services/aws_extended_sqs_client/mock"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+	aws_sqsiface "github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// Succeeds every entry of the batches with the body as message id, and records the size of the batches
+type echoSqs struct {
+	*MockSqs
+
+	mu         sync.Mutex
+	batchSizes []int
+}
+
+func (e *echoSqs) SendMessageBatchWithContext(ctx aws.Context, input *aws_sqs.SendMessageBatchInput, option ...request.Option) (*aws_sqs.SendMessageBatchOutput, error) {
+	e.mu.Lock()
+	e.batchSizes = append(e.batchSizes, len(input.Entries))
+	e.mu.Unlock()
+
+	output := &aws_sqs.SendMessageBatchOutput{}
+	for _, entry := range input.Entries {
+		output.Successful = append(output.Successful, &aws_sqs.SendMessageBatchResultEntry{Id: entry.Id, MessageId: entry.MessageBody})
+	}
+	return output, nil
+}
+
+// Holds every batch until released, and records the bodies in the order they are sent along with the most batches in flight
+type orderedSqs struct {
+	*MockSqs
+
+	release chan struct{}
+
+	mu          sync.Mutex
+	bodies      []string
+	inFlight    int
+	maxInFlight int
+}
+
+func (o *orderedSqs) SendMessageBatchWithContext(ctx aws.Context, input *aws_sqs.SendMessageBatchInput, option ...request.Option) (*aws_sqs.SendMessageBatchOutput, error) {
+	o.mu.Lock()
+	o.inFlight++
+	if o.inFlight > o.maxInFlight {
+		o.maxInFlight = o.inFlight
+	}
+	for _, entry := range input.Entries {
+		o.bodies = append(o.bodies, *entry.MessageBody)
+	}
+	o.mu.Unlock()
+
+	<-o.release
+
+	o.mu.Lock()
+	o.inFlight--
+	o.mu.Unlock()
+
+	output := &aws_sqs.SendMessageBatchOutput{}
+	for _, entry := range input.Entries {
+		output.Successful = append(output.Successful, &aws_sqs.SendMessageBatchResultEntry{Id: entry.Id, MessageId: entry.MessageBody})
+	}
+	return output, nil
+}
+
+type ProducerTestSuite struct {
+	suite.Suite
+
+	mockSqs *MockSqs
+	echoSqs *echoSqs
+}
+
+func (s *ProducerTestSuite) SetupTest() {
+	s.mockSqs = new(MockSqs)
+	s.echoSqs = &echoSqs{MockSqs: s.mockSqs}
+}
+
+func (s *ProducerTestSuite) newProducer(client aws_sqsiface.SQSAPI, opts ...aws_extended_sqs_producer.AwsExtendedSQSProducerOption) *aws_extended_sqs_producer.AwsExtendedSQSProducer {
+	opts = append([]aws_extended_sqs_producer.AwsExtendedSQSProducerOption{aws_extended_sqs_producer.WithLogger(NewMockLogger())}, opts...)
+	return aws_extended_sqs_producer.NewExtendedSQSProducer(client, opts...)
+}
+
+func (s *ProducerTestSuite) batchSizes() []int {
+	s.echoSqs.mu.Lock()
+	defer s.echoSqs.mu.Unlock()
+
+	return s.echoSqs.batchSizes
+}
+
+func newSendMessageInput(queueUrl string, body string) *aws_sqs.SendMessageInput {
+	return &aws_sqs.SendMessageInput{QueueUrl: aws.String(queueUrl), MessageBody: aws.String(body)}
+}
+
+func (s *ProducerTestSuite) Test_SendMessage_Success_Flushed_On_Batch_Size() {
+	producer := s.newProducer(s.echoSqs, aws_extended_sqs_producer.WithMaxBatchSize(2), aws_extended_sqs_producer.WithLinger(time.Hour))
+
+	futures := []*aws_extended_sqs_producer.SendFuture{
+		producer.SendMessage(newSendMessageInput("test-queue", "message-1")),
+		producer.SendMessage(newSendMessageInput("other-queue", "message-2")),
+		producer.SendMessage(newSendMessageInput("test-queue", "message-3")),
+	}
+
+	for index, expected := range []string{"message-1", "", "message-3"} {
+		if expected == "" {
+			continue
+		}
+
+		messageId, err := futures[index].Get(context.Background())
+		assert.Nil(s.T(), err)
+		assert.Equal(s.T(), expected, messageId)
+	}
+
+	select {
+	case <-futures[1].Done():
+		assert.Fail(s.T(), "message of a batch which is not full should be buffered")
+	default:
+	}
+
+	assert.Nil(s.T(), producer.Close(context.Background()))
+
+	messageId, err := futures[1].Get(context.Background())
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "message-2", messageId)
+	assert.ElementsMatch(s.T(), []int{2, 1}, s.batchSizes())
+}
+
+func (s *ProducerTestSuite) Test_SendMessage_Success_Flushed_On_Linger() {
+	producer := s.newProducer(s.echoSqs, aws_extended_sqs_producer.WithLinger(10*time.Millisecond))
+
+	var mu sync.Mutex
+	var callbackMessageId string
+
+	future := producer.SendMessageWithCallback(newSendMessageInput("test-queue", "message-1"), func(messageId string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		callbackMessageId = messageId
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	messageId, err := future.Get(ctx)
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "message-1", messageId)
+
+	mu.Lock()
+	assert.Equal(s.T(), "message-1", callbackMessageId)
+	mu.Unlock()
+}
+
+func (s *ProducerTestSuite) Test_SendMessage_Success_Flushed_On_Batch_Bytes() {
+	producer := s.newProducer(s.echoSqs, aws_extended_sqs_producer.WithMaxBatchBytes(20), aws_extended_sqs_producer.WithLinger(time.Hour))
+
+	producer.SendMessage(newSendMessageInput("test-queue", "message-1"))
+	producer.SendMessage(newSendMessageInput("test-queue", "message-2"))
+	producer.SendMessage(newSendMessageInput("test-queue", "message-3"))
+
+	assert.Nil(s.T(), producer.Flush(context.Background()))
+	assert.ElementsMatch(s.T(), []int{2, 1}, s.batchSizes())
+}
+
+func (s *ProducerTestSuite) Test_SendMessage_Success_Oversized_Message_Counted_As_Offloaded() {
+	mockS3 := new(MockS3)
+	mockS3.On("PutObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.PutObjectOutput{}, nil).Twice()
+
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	config.WithPayloadSupportEnabled(mockS3, "test-bucket")
+	client := aws_extended_sqs_client.NewExtendedSQSClient(s.echoSqs, config, aws_extended_sqs_client.WithLogger(NewMockLogger()))
+
+	producer := s.newProducer(client, aws_extended_sqs_producer.WithMaxBatchBytes(10000), aws_extended_sqs_producer.WithLinger(time.Hour))
+
+	largeBody := string(make([]byte, 300000))
+	producer.SendMessage(newSendMessageInput("test-queue", largeBody))
+	producer.SendMessage(newSendMessageInput("test-queue", largeBody))
+
+	assert.Nil(s.T(), producer.Flush(context.Background()))
+	assert.Equal(s.T(), []int{2}, s.batchSizes())
+	mockS3.AssertExpectations(s.T())
+}
+
+func (s *ProducerTestSuite) Test_SendMessage_Success_Configured_Threshold_Of_Client_Used() {
+	mockS3 := new(MockS3)
+
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	config.WithPayloadSupportEnabled(mockS3, "test-bucket")
+	config.SetPayloadSizeThreshold(300000)
+	client := aws_extended_sqs_client.NewExtendedSQSClient(s.echoSqs, config, aws_extended_sqs_client.WithLogger(NewMockLogger()))
+
+	producer := s.newProducer(client, aws_extended_sqs_producer.WithMaxBatchBytes(10000), aws_extended_sqs_producer.WithLinger(time.Hour))
+
+	// Under the threshold of the client, so kept in sqs and counted in full
+	body := string(make([]byte, 6000))
+	producer.SendMessage(newSendMessageInput("test-queue", body))
+	producer.SendMessage(newSendMessageInput("test-queue", body))
+
+	assert.Nil(s.T(), producer.Flush(context.Background()))
+	assert.Equal(s.T(), []int{1, 1}, s.batchSizes())
+	mockS3.AssertNotCalled(s.T(), "PutObjectWithContext", mock.Anything, mock.Anything)
+}
+
+func (s *ProducerTestSuite) Test_SendMessage_Success_Oversized_Message_Counted_In_Full_Without_Extended_Client() {
+	producer := s.newProducer(s.echoSqs, aws_extended_sqs_producer.WithMaxBatchBytes(10000), aws_extended_sqs_producer.WithLinger(time.Hour))
+
+	largeBody := string(make([]byte, 300000))
+	producer.SendMessage(newSendMessageInput("test-queue", largeBody))
+	producer.SendMessage(newSendMessageInput("test-queue", largeBody))
+
+	assert.Nil(s.T(), producer.Flush(context.Background()))
+	assert.Equal(s.T(), []int{1, 1}, s.batchSizes())
+}
+
+func (s *ProducerTestSuite) Test_SendMessage_Success_Ordered_Batches_Sent_One_At_A_Time() {
+	testCases := []struct {
+		name     string
+		queueUrl string
+		groupId  *string
+	}{
+		{name: "fifo queue", queueUrl: "test-queue.fifo"},
+		{name: "message group id", queueUrl: "test-queue", groupId: aws.String("test-group")},
+	}
+
+	for _, testCase := range testCases {
+		s.Run(testCase.name, func() {
+			sqs := &orderedSqs{MockSqs: s.mockSqs, release: make(chan struct{})}
+			producer := s.newProducer(sqs, aws_extended_sqs_producer.WithMaxBatchSize(1))
+
+			var futures []*aws_extended_sqs_producer.SendFuture
+			for _, body := range []string{"message-1", "message-2", "message-3"} {
+				input := newSendMessageInput(testCase.queueUrl, body)
+				input.MessageGroupId = testCase.groupId
+				futures = append(futures, producer.SendMessage(input))
+			}
+
+			for range futures {
+				sqs.release <- struct{}{}
+			}
+
+			for _, future := range futures {
+				_, err := future.Get(context.Background())
+				assert.Nil(s.T(), err)
+			}
+			assert.Nil(s.T(), producer.Close(context.Background()))
+
+			assert.Equal(s.T(), 1, sqs.maxInFlight)
+			assert.Equal(s.T(), []string{"message-1", "message-2", "message-3"}, sqs.bodies)
+		})
+	}
+}
+
+func (s *ProducerTestSuite) Test_SendMessage_Failed_Entry_Failed() {
+	s.mockSqs.On("SendMessageBatchWithContext", mock.Anything, mock.Anything).Return(&aws_sqs.SendMessageBatchOutput{
+		Successful: []*aws_sqs.SendMessageBatchResultEntry{{Id: aws.String("0"), MessageId: aws.String("message-1")}},
+		Failed:     []*aws_sqs.BatchResultErrorEntry{{Id: aws.String("1"), Code: aws.String("InvalidMessageContents"), Message: aws.String("test error")}},
+	}, nil).Once()
+	producer := s.newProducer(s.mockSqs, aws_extended_sqs_producer.WithMaxBatchSize(2))
+
+	succeeded := producer.SendMessage(newSendMessageInput("test-queue", "message-1"))
+	failed := producer.SendMessage(newSendMessageInput("test-queue", "message-2"))
+
+	messageId, err := succeeded.Get(context.Background())
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), "message-1", messageId)
+
+	_, err = failed.Get(context.Background())
+	assert.True(s.T(), std_errors.Is(err, errors.SQSError{}))
+
+	var awsErr awserr.Error
+	assert.True(s.T(), std_errors.As(err, &awsErr))
+	assert.Equal(s.T(), "InvalidMessageContents", awsErr.Code())
+}
+
+func (s *ProducerTestSuite) Test_SendMessage_Failed_Batch_Error() {
+	batchErr := std_errors.New("test error")
+	s.mockSqs.On("SendMessageBatchWithContext", mock.Anything, mock.Anything).Return(&aws_sqs.SendMessageBatchOutput{}, batchErr).Once()
+	producer := s.newProducer(s.mockSqs, aws_extended_sqs_producer.WithMaxBatchSize(1))
+
+	_, err := producer.SendMessage(newSendMessageInput("test-queue", "message-1")).Get(context.Background())
+
+	assert.Equal(s.T(), batchErr, err)
+}
+
+func (s *ProducerTestSuite) Test_SendMessage_Failed_Invalid_Input_Or_Closed() {
+	producer := s.newProducer(s.mockSqs)
+
+	_, err := producer.SendMessage(&aws_sqs.SendMessageInput{MessageBody: aws.String("message-1")}).Get(context.Background())
+	assert.True(s.T(), std_errors.As(err, &errors.SDKError{}))
+
+	assert.Nil(s.T(), producer.Close(context.Background()))
+
+	_, err = producer.SendMessage(newSendMessageInput("test-queue", "message-1")).Get(context.Background())
+	assert.True(s.T(), std_errors.As(err, &errors.SDKError{}))
+
+	s.mockSqs.AssertNotCalled(s.T(), "SendMessageBatchWithContext", mock.Anything, mock.Anything)
+}
+
+func TestProducer(t *testing.T) {
+	suite.Run(t, new(ProducerTestSuite))
+}
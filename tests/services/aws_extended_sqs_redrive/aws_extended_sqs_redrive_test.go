@@ -0,0 +1,333 @@
+package tests
+
+import (
+	"context"
+	std_errors "errors"
+	"strings"
+	"testing"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors"
+	errors_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/errors/constants"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client"
+	sqs_configs_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client/constants"
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_redrive"
+	redrive_configs_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_redrive/constants"
+
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/internal/payload_store/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/logging/mock"
+	. "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tests/services/aws_extended_sqs_client/mock"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	aws_s3 "github.com/aws/aws-sdk-go/service/s3"
+	aws_s3iface "github.com/aws/aws-sdk-go/service/s3/s3iface"
+	aws_sqs "github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	sourceQueueUrl      = "source-queue"
+	destinationQueueUrl = "destination-queue"
+	messagePointer      = "[\"software.amazon.payloadoffloading.PayloadS3Pointer\",{\"s3BucketName\":\"test-bucket\",\"s3Key\":\"test-key\"}]"
+)
+
+type RedriveTestSuite struct {
+	suite.Suite
+
+	mockSqs *MockSqs
+	mockS3  *MockS3
+}
+
+func (s *RedriveTestSuite) SetupTest() {
+	s.mockSqs = new(MockSqs)
+	s.mockS3 = new(MockS3)
+}
+
+func (s *RedriveTestSuite) newRedriver(opts ...aws_extended_sqs_redrive.AwsExtendedSQSRedriverOption) *aws_extended_sqs_redrive.AwsExtendedSQSRedriver {
+	opts = append([]aws_extended_sqs_redrive.AwsExtendedSQSRedriverOption{aws_extended_sqs_redrive.WithLogger(NewMockLogger())}, opts...)
+
+	return aws_extended_sqs_redrive.NewExtendedSQSRedriver(s.mockSqs, s.mockS3, opts...)
+}
+
+// Returns the batches in order, then an empty receive which ends the redrive
+func (s *RedriveTestSuite) mockReceive(batches ...[]*aws_sqs.Message) {
+	for _, messages := range batches {
+		s.mockSqs.On("ReceiveMessageWithContext", mock.Anything, mock.MatchedBy(func(input *aws_sqs.ReceiveMessageInput) bool {
+			return *input.QueueUrl == sourceQueueUrl
+		})).Return(&aws_sqs.ReceiveMessageOutput{Messages: messages}, nil).Once()
+	}
+
+	s.mockSqs.On("ReceiveMessageWithContext", mock.Anything, mock.Anything).Return(&aws_sqs.ReceiveMessageOutput{}, nil).Once()
+}
+
+func createOffloadedMessage(id string) *aws_sqs.Message {
+	return &aws_sqs.Message{
+		MessageId:     aws.String(id),
+		ReceiptHandle: aws.String(id + "-receipt-handle"),
+		Body:          aws.String(messagePointer),
+		MessageAttributes: map[string]*aws_sqs.MessageAttributeValue{
+			sqs_configs_constants.RESERVED_ATTRIBUTE_NAME: {DataType: aws.String("Number"), StringValue: aws.String("300000")},
+		},
+	}
+}
+
+func (s *RedriveTestSuite) Test_Redrive_Success_Payload_Kept() {
+	config := aws_extended_sqs_client.NewExtendedSQSClientConfiguration()
+	config.WithPayloadSupportEnabled(s.mockS3, "test-bucket")
+	client := aws_extended_sqs_client.NewExtendedSQSClient(s.mockSqs, config, aws_extended_sqs_client.WithLogger(NewMockLogger()))
+
+	// Given the extended client, the payloads are neither resolved nor deleted
+	redriver := aws_extended_sqs_redrive.NewExtendedSQSRedriver(client, s.mockS3, aws_extended_sqs_redrive.WithLogger(NewMockLogger()))
+
+	inline := &aws_sqs.Message{
+		MessageId:     aws.String("inline"),
+		ReceiptHandle: aws.String("inline-receipt-handle"),
+		Body:          aws.String("test"),
+		Attributes:    map[string]*string{aws_sqs.MessageSystemAttributeNameMessageGroupId: aws.String("test-group")},
+	}
+	s.mockReceive([]*aws_sqs.Message{createOffloadedMessage("offloaded"), inline})
+
+	s.mockS3.On("HeadObjectWithContext", mock.Anything, &aws_s3.HeadObjectInput{
+		Bucket: aws.String("test-bucket"),
+		Key:    aws.String("test-key"),
+	}).Return(&aws_s3.HeadObjectOutput{ContentType: aws.String("text/plain")}, nil).Once()
+	// Touched so that the payload gc does not take it for an orphan while the moved message is retained
+	s.mockS3.On("CopyObjectWithContext", mock.Anything, mock.MatchedBy(func(input *aws_s3.CopyObjectInput) bool {
+		return *input.Bucket == "test-bucket" && *input.Key == "test-key" && *input.CopySource == "test-bucket/test-key" &&
+			*input.MetadataDirective == aws_s3.MetadataDirectiveReplace && *input.ContentType == "text/plain"
+	})).Return(&aws_s3.CopyObjectOutput{}, nil).Once()
+	s.mockSqs.On("SendMessageWithContext", mock.Anything, mock.MatchedBy(func(input *aws_sqs.SendMessageInput) bool {
+		return *input.QueueUrl == destinationQueueUrl && *input.MessageBody == messagePointer &&
+			input.MessageAttributes[sqs_configs_constants.RESERVED_ATTRIBUTE_NAME] != nil
+	})).Return(&aws_sqs.SendMessageOutput{}, nil).Once()
+	s.mockSqs.On("SendMessageWithContext", mock.Anything, mock.MatchedBy(func(input *aws_sqs.SendMessageInput) bool {
+		return *input.MessageBody == "test" && *input.MessageGroupId == "test-group"
+	})).Return(&aws_sqs.SendMessageOutput{}, nil).Once()
+	s.mockSqs.On("DeleteMessageWithContext", mock.Anything, &aws_sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(sourceQueueUrl),
+		ReceiptHandle: aws.String("offloaded-receipt-handle"),
+	}).Return(&aws_sqs.DeleteMessageOutput{}, nil).Once()
+	s.mockSqs.On("DeleteMessageWithContext", mock.Anything, &aws_sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(sourceQueueUrl),
+		ReceiptHandle: aws.String("inline-receipt-handle"),
+	}).Return(&aws_sqs.DeleteMessageOutput{}, nil).Once()
+
+	report, err := redriver.Redrive(context.Background(), sourceQueueUrl, destinationQueueUrl)
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 2, report.Moved)
+	assert.Equal(s.T(), 0, report.Copied)
+	assert.Empty(s.T(), report.Failed)
+
+	s.mockSqs.AssertExpectations(s.T())
+	s.mockS3.AssertExpectations(s.T())
+	s.mockS3.AssertNotCalled(s.T(), "DeleteObjectWithContext", mock.Anything, mock.Anything)
+}
+
+func (s *RedriveTestSuite) Test_Redrive_Success_Payload_Copied() {
+	redriver := s.newRedriver(
+		aws_extended_sqs_redrive.WithPayloadMode(redrive_configs_constants.PAYLOAD_MODE_COPY),
+		aws_extended_sqs_redrive.WithCopyDestination("copy-bucket", "redriven/"),
+		aws_extended_sqs_redrive.WithDeleteSourcePayload(true),
+	)
+
+	s.mockReceive([]*aws_sqs.Message{createOffloadedMessage("offloaded")})
+
+	s.mockS3.On("HeadObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.HeadObjectOutput{}, nil).Once()
+	s.mockS3.On("CopyObjectWithContext", mock.Anything, mock.MatchedBy(func(input *aws_s3.CopyObjectInput) bool {
+		return *input.Bucket == "copy-bucket" && strings.HasPrefix(*input.Key, "redriven/") && *input.CopySource == "test-bucket/test-key"
+	})).Return(&aws_s3.CopyObjectOutput{}, nil).Once()
+	s.mockSqs.On("SendMessageWithContext", mock.Anything, mock.MatchedBy(func(input *aws_sqs.SendMessageInput) bool {
+		return strings.Contains(*input.MessageBody, "\"s3BucketName\":\"copy-bucket\",\"s3Key\":\"redriven/")
+	})).Return(&aws_sqs.SendMessageOutput{}, nil).Once()
+	s.mockSqs.On("DeleteMessageWithContext", mock.Anything, mock.Anything).Return(&aws_sqs.DeleteMessageOutput{}, nil).Once()
+	s.mockS3.On("DeleteObjectWithContext", mock.Anything, &aws_s3.DeleteObjectInput{
+		Bucket: aws.String("test-bucket"),
+		Key:    aws.String("test-key"),
+	}).Return(&aws_s3.DeleteObjectOutput{}, nil).Once()
+
+	report, err := redriver.Redrive(context.Background(), sourceQueueUrl, destinationQueueUrl)
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 1, report.Moved)
+	assert.Equal(s.T(), 1, report.Copied)
+
+	s.mockSqs.AssertExpectations(s.T())
+	s.mockS3.AssertExpectations(s.T())
+}
+
+func (s *RedriveTestSuite) Test_Redrive_Success_Payload_Copied_Content_Addressed_Source_Kept() {
+	redriver := s.newRedriver(
+		aws_extended_sqs_redrive.WithPayloadMode(redrive_configs_constants.PAYLOAD_MODE_COPY),
+		aws_extended_sqs_redrive.WithCopyDestination("copy-bucket", "redriven/"),
+		aws_extended_sqs_redrive.WithDeleteSourcePayload(true),
+	)
+
+	// Shared by other messages, it is left to the lifecycle rules of the bucket
+	s3Key := "payloads/sha256-" + strings.Repeat("ab", 32)
+	message := createOffloadedMessage("offloaded")
+	message.Body = aws.String(strings.Replace(messagePointer, "test-key", s3Key, 1))
+
+	s.mockReceive([]*aws_sqs.Message{message})
+
+	s.mockS3.On("HeadObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.HeadObjectOutput{}, nil).Once()
+	s.mockS3.On("CopyObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.CopyObjectOutput{}, nil).Once()
+	s.mockSqs.On("SendMessageWithContext", mock.Anything, mock.Anything).Return(&aws_sqs.SendMessageOutput{}, nil).Once()
+	s.mockSqs.On("DeleteMessageWithContext", mock.Anything, mock.Anything).Return(&aws_sqs.DeleteMessageOutput{}, nil).Once()
+
+	report, err := redriver.Redrive(context.Background(), sourceQueueUrl, destinationQueueUrl)
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 1, report.Copied)
+
+	s.mockSqs.AssertExpectations(s.T())
+	s.mockS3.AssertExpectations(s.T())
+	s.mockS3.AssertNotCalled(s.T(), "DeleteObjectWithContext", mock.Anything, mock.Anything)
+}
+
+func (s *RedriveTestSuite) Test_Redrive_Success_S3_Client_Resolved_Per_Bucket() {
+	copyS3 := new(MockS3)
+	redriver := s.newRedriver(
+		aws_extended_sqs_redrive.WithPayloadMode(redrive_configs_constants.PAYLOAD_MODE_COPY),
+		aws_extended_sqs_redrive.WithCopyDestination("copy-bucket", "redriven/"),
+		aws_extended_sqs_redrive.WithS3ClientResolver(aws_extended_sqs_client.NewMapS3ClientResolver(
+			map[string]aws_s3iface.S3API{"copy-bucket": copyS3},
+			s.mockS3,
+		)),
+	)
+
+	message := createOffloadedMessage("offloaded")
+	message.Attributes = map[string]*string{aws_sqs.MessageSystemAttributeNameAwstraceHeader: aws.String("Root=1-test")}
+	s.mockReceive([]*aws_sqs.Message{message})
+
+	s.mockS3.On("HeadObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.HeadObjectOutput{}, nil).Once()
+	copyS3.On("CopyObjectWithContext", mock.Anything, mock.MatchedBy(func(input *aws_s3.CopyObjectInput) bool {
+		return *input.Bucket == "copy-bucket" && *input.CopySource == "test-bucket/test-key"
+	})).Return(&aws_s3.CopyObjectOutput{}, nil).Once()
+	s.mockSqs.On("SendMessageWithContext", mock.Anything, mock.MatchedBy(func(input *aws_sqs.SendMessageInput) bool {
+		traceHeader := input.MessageSystemAttributes[aws_sqs.MessageSystemAttributeNameForSendsAwstraceHeader]
+		return traceHeader != nil && *traceHeader.StringValue == "Root=1-test"
+	})).Return(&aws_sqs.SendMessageOutput{}, nil).Once()
+	s.mockSqs.On("DeleteMessageWithContext", mock.Anything, mock.Anything).Return(&aws_sqs.DeleteMessageOutput{}, nil).Once()
+
+	report, err := redriver.Redrive(context.Background(), sourceQueueUrl, destinationQueueUrl)
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 1, report.Copied)
+
+	s.mockSqs.AssertExpectations(s.T())
+	s.mockS3.AssertExpectations(s.T())
+	copyS3.AssertExpectations(s.T())
+	s.mockS3.AssertNotCalled(s.T(), "CopyObjectWithContext", mock.Anything, mock.Anything)
+}
+
+func (s *RedriveTestSuite) Test_Redrive_Success_Max_Messages() {
+	redriver := s.newRedriver(aws_extended_sqs_redrive.WithMaxMessages(1))
+
+	s.mockSqs.On("ReceiveMessageWithContext", mock.Anything, mock.MatchedBy(func(input *aws_sqs.ReceiveMessageInput) bool {
+		return *input.MaxNumberOfMessages == 1
+	})).Return(&aws_sqs.ReceiveMessageOutput{Messages: []*aws_sqs.Message{
+		{MessageId: aws.String("inline"), ReceiptHandle: aws.String("inline-receipt-handle"), Body: aws.String("test")},
+	}}, nil).Once()
+	s.mockSqs.On("SendMessageWithContext", mock.Anything, mock.Anything).Return(&aws_sqs.SendMessageOutput{}, nil).Once()
+	s.mockSqs.On("DeleteMessageWithContext", mock.Anything, mock.Anything).Return(&aws_sqs.DeleteMessageOutput{}, nil).Once()
+
+	report, err := redriver.Redrive(context.Background(), sourceQueueUrl, destinationQueueUrl)
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 1, report.Moved)
+	s.mockSqs.AssertExpectations(s.T())
+}
+
+func (s *RedriveTestSuite) Test_Redrive_Failed_Payload_Missing() {
+	redriver := s.newRedriver()
+
+	// Received again once its visibility timeout has passed
+	s.mockReceive([]*aws_sqs.Message{createOffloadedMessage("offloaded")}, []*aws_sqs.Message{createOffloadedMessage("offloaded")})
+
+	s.mockS3.On("HeadObjectWithContext", mock.Anything, mock.Anything).Return(
+		&aws_s3.HeadObjectOutput{},
+		awserr.New("NotFound", "Not Found", nil),
+	).Once()
+
+	report, err := redriver.Redrive(context.Background(), sourceQueueUrl, destinationQueueUrl)
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 0, report.Moved)
+	assert.Len(s.T(), report.Failed, 1)
+	assert.Equal(s.T(), "offloaded", report.Failed[0].MessageId)
+	assert.True(s.T(), std_errors.Is(report.Failed[0].Err, errors.S3Error{Operation: errors_constants.OPERATION_FETCH}))
+
+	s.mockS3.AssertExpectations(s.T())
+	s.mockSqs.AssertNotCalled(s.T(), "SendMessageWithContext", mock.Anything, mock.Anything)
+	s.mockSqs.AssertNotCalled(s.T(), "DeleteMessageWithContext", mock.Anything, mock.Anything)
+}
+
+func (s *RedriveTestSuite) Test_Redrive_Failed_Send_Error_Copy_Deleted() {
+	redriver := s.newRedriver(aws_extended_sqs_redrive.WithPayloadMode(redrive_configs_constants.PAYLOAD_MODE_COPY))
+
+	s.mockReceive([]*aws_sqs.Message{createOffloadedMessage("offloaded")})
+
+	s.mockS3.On("HeadObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.HeadObjectOutput{}, nil).Once()
+	s.mockS3.On("CopyObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.CopyObjectOutput{}, nil).Once()
+	s.mockSqs.On("SendMessageWithContext", mock.Anything, mock.Anything).Return(&aws_sqs.SendMessageOutput{}, std_errors.New("test error")).Once()
+	s.mockS3.On("DeleteObjectWithContext", mock.Anything, mock.MatchedBy(func(input *aws_s3.DeleteObjectInput) bool {
+		return *input.Bucket == "test-bucket" && *input.Key != "test-key"
+	})).Return(&aws_s3.DeleteObjectOutput{}, nil).Once()
+
+	report, err := redriver.Redrive(context.Background(), sourceQueueUrl, destinationQueueUrl)
+
+	assert.Nil(s.T(), err)
+	assert.Len(s.T(), report.Failed, 1)
+	assert.True(s.T(), std_errors.Is(report.Failed[0].Err, errors.SQSError{Operation: errors_constants.OPERATION_SEND}))
+
+	s.mockSqs.AssertExpectations(s.T())
+	s.mockS3.AssertExpectations(s.T())
+	s.mockSqs.AssertNotCalled(s.T(), "DeleteMessageWithContext", mock.Anything, mock.Anything)
+}
+
+func (s *RedriveTestSuite) Test_Redrive_Failed_Payload_Not_Touched() {
+	redriver := s.newRedriver()
+
+	// Received again once its visibility timeout has passed
+	s.mockReceive([]*aws_sqs.Message{createOffloadedMessage("offloaded")}, []*aws_sqs.Message{createOffloadedMessage("offloaded")})
+
+	s.mockS3.On("HeadObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.HeadObjectOutput{}, nil).Once()
+	s.mockS3.On("CopyObjectWithContext", mock.Anything, mock.Anything).Return(&aws_s3.CopyObjectOutput{}, std_errors.New("test error")).Once()
+
+	report, err := redriver.Redrive(context.Background(), sourceQueueUrl, destinationQueueUrl)
+
+	assert.Nil(s.T(), err)
+	assert.Equal(s.T(), 0, report.Moved)
+	assert.Len(s.T(), report.Failed, 1)
+	assert.True(s.T(), std_errors.Is(report.Failed[0].Err, errors.S3Error{Operation: errors_constants.OPERATION_TOUCH}))
+
+	s.mockSqs.AssertNotCalled(s.T(), "SendMessageWithContext", mock.Anything, mock.Anything)
+	s.mockSqs.AssertNotCalled(s.T(), "DeleteMessageWithContext", mock.Anything, mock.Anything)
+}
+
+func (s *RedriveTestSuite) Test_Redrive_Failed_Receive_Error() {
+	redriver := s.newRedriver()
+
+	s.mockSqs.On("ReceiveMessageWithContext", mock.Anything, mock.Anything).Return(&aws_sqs.ReceiveMessageOutput{}, std_errors.New("test error")).Once()
+
+	_, err := redriver.Redrive(context.Background(), sourceQueueUrl, destinationQueueUrl)
+
+	assert.True(s.T(), std_errors.Is(err, errors.SQSError{Operation: errors_constants.OPERATION_RECEIVE}))
+}
+
+func (s *RedriveTestSuite) Test_Redrive_Failed_Payload_Mode_Unsupported() {
+	redriver := s.newRedriver(aws_extended_sqs_redrive.WithPayloadMode("move"))
+
+	_, err := redriver.Redrive(context.Background(), sourceQueueUrl, destinationQueueUrl)
+
+	assert.NotNil(s.T(), err)
+	s.mockSqs.AssertNotCalled(s.T(), "ReceiveMessageWithContext", mock.Anything, mock.Anything)
+}
+
+func TestRedriveTestSuite(t *testing.T) {
+	suite.Run(t, new(RedriveTestSuite))
+}
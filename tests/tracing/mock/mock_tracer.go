@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"context"
+
+	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
+)
+
+const TRACE_PARENT_FIELD = "traceparent"
+
+type spanContextKey struct{}
+type traceParentContextKey struct{}
+
+type MockSpan struct {
+	aws_extended_sqsiface.SpanInterface
+	Name       string
+	Kind       string
+	Attributes map[string]string
+	Parent     *MockSpan
+	Err        error
+	Ended      bool
+}
+
+// Records the started spans and propagates TraceParent as the trace context
+type MockTracer struct {
+	aws_extended_sqsiface.TracerInterface
+	TraceParent string
+	Spans       []*MockSpan
+}
+
+func (t *MockTracer) StartSpan(ctx context.Context, name string, kind string, attributes map[string]string) (context.Context, aws_extended_sqsiface.SpanInterface) {
+	parent, _ := ctx.Value(spanContextKey{}).(*MockSpan)
+
+	span := &MockSpan{
+		Name:       name,
+		Kind:       kind,
+		Attributes: attributes,
+		Parent:     parent,
+	}
+	t.Spans = append(t.Spans, span)
+
+	return context.WithValue(ctx, spanContextKey{}, span), span
+}
+
+func (t *MockTracer) Inject(ctx context.Context, carrier aws_extended_sqsiface.TextMapCarrierInterface) {
+	carrier.Set(TRACE_PARENT_FIELD, t.TraceParent)
+}
+
+func (t *MockTracer) Extract(ctx context.Context, carrier aws_extended_sqsiface.TextMapCarrierInterface) context.Context {
+	traceParent := carrier.Get(TRACE_PARENT_FIELD)
+	if traceParent == "" {
+		return ctx
+	}
+
+	return context.WithValue(ctx, traceParentContextKey{}, traceParent)
+}
+
+func (t *MockTracer) Fields() []string {
+	return []string{TRACE_PARENT_FIELD}
+}
+
+func (t *MockTracer) GetSpan(name string) *MockSpan {
+	for _, span := range t.Spans {
+		if span.Name == name {
+			return span
+		}
+	}
+
+	return nil
+}
+
+func (s *MockSpan) RecordError(err error) {
+	s.Err = err
+}
+
+func (s *MockSpan) End() {
+	s.Ended = true
+}
+
+func TraceParentFromContext(ctx context.Context) string {
+	traceParent, _ := ctx.Value(traceParentContextKey{}).(string)
+	return traceParent
+}
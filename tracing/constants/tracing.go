@@ -0,0 +1,36 @@
+package tracing_constants
+
+const (
+	SPAN_SEND_MESSAGE         = "sqs.SendMessage"
+	SPAN_SEND_MESSAGE_BATCH   = "sqs.SendMessageBatch"
+	SPAN_RECEIVE_MESSAGE      = "sqs.ReceiveMessage"
+	SPAN_DELETE_MESSAGE       = "sqs.DeleteMessage"
+	SPAN_DELETE_MESSAGE_BATCH = "sqs.DeleteMessageBatch"
+	SPAN_S3_STORE             = "s3.PutObject"
+	SPAN_S3_FETCH             = "s3.GetObject"
+	SPAN_S3_DELETE            = "s3.DeleteObject"
+	SPAN_S3_DELETE_OBJECTS    = "s3.DeleteObjects"
+	SPAN_S3_HEAD              = "s3.HeadObject"
+	SPAN_S3_COPY              = "s3.CopyObject"
+)
+
+const (
+	SPAN_KIND_PRODUCER = "producer"
+	SPAN_KIND_CONSUMER = "consumer"
+	SPAN_KIND_CLIENT   = "client"
+)
+
+const (
+	ATTRIBUTE_MESSAGING_SYSTEM      = "messaging.system"
+	ATTRIBUTE_MESSAGING_DESTINATION = "messaging.destination.name"
+	ATTRIBUTE_S3_BUCKET             = "aws.s3.bucket"
+	ATTRIBUTE_S3_KEY                = "aws.s3.key"
+
+	MESSAGING_SYSTEM_SQS = "aws_sqs"
+)
+
+const (
+	// Length of a W3C traceparent, counted for each trace context attribute when estimating the size of a message
+	// before it is sent, as the attributes are only injected once the span of the send is started
+	TRACE_CONTEXT_VALUE_SIZE = 55
+)
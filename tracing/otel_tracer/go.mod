@@ -0,0 +1,24 @@
+module github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tracing/otel_tracer
+
+go 1.21
+
+require (
+	github.com/aws/aws-sdk-go v1.34.34
+	github.com/shoplineapp/aws-sqs-golang-extended-client-lib v0.0.0-20261019115548-ffc1e5eb724e
+	github.com/stretchr/testify v1.9.0
+	go.opentelemetry.io/otel v1.24.0
+	go.opentelemetry.io/otel/sdk v1.24.0
+	go.opentelemetry.io/otel/trace v1.24.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/go-logr/logr v1.4.1 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/uuid v1.3.0 // indirect
+	github.com/jmespath/go-jmespath v0.4.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	go.opentelemetry.io/otel/metric v1.24.0 // indirect
+	golang.org/x/sys v0.17.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
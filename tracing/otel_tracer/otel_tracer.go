@@ -0,0 +1,94 @@
+package otel_tracer
+
+import (
+	"context"
+
+	aws_extended_sqsiface "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/interfaces"
+	tracing_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tracing/constants"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const INSTRUMENTATION_NAME = "github.com/shoplineapp/aws-sqs-golang-extended-client-lib"
+
+// Creates the spans of the client with an OpenTelemetry tracer provider.
+// The trace context is propagated with W3C traceparent and tracestate unless another propagator is given.
+type OtelTracer struct {
+	aws_extended_sqsiface.TracerInterface
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+type OtelSpan struct {
+	aws_extended_sqsiface.SpanInterface
+	span trace.Span
+}
+
+type OtelTracerOption func(*OtelTracer)
+
+func WithPropagator(propagator propagation.TextMapPropagator) OtelTracerOption {
+	return func(t *OtelTracer) {
+		t.propagator = propagator
+	}
+}
+
+func NewOtelTracer(tracerProvider trace.TracerProvider, opts ...OtelTracerOption) *OtelTracer {
+	tracer := &OtelTracer{
+		tracer:     tracerProvider.Tracer(INSTRUMENTATION_NAME),
+		propagator: propagation.TraceContext{},
+	}
+
+	for _, opt := range opts {
+		opt(tracer)
+	}
+
+	return tracer
+}
+
+func (t *OtelTracer) StartSpan(ctx context.Context, name string, kind string, attributes map[string]string) (context.Context, aws_extended_sqsiface.SpanInterface) {
+	spanAttributes := make([]attribute.KeyValue, 0, len(attributes))
+	for key, value := range attributes {
+		spanAttributes = append(spanAttributes, attribute.String(key, value))
+	}
+
+	ctx, span := t.tracer.Start(ctx, name, trace.WithSpanKind(getSpanKind(kind)), trace.WithAttributes(spanAttributes...))
+
+	return ctx, &OtelSpan{span: span}
+}
+
+func (t *OtelTracer) Inject(ctx context.Context, carrier aws_extended_sqsiface.TextMapCarrierInterface) {
+	t.propagator.Inject(ctx, carrier)
+}
+
+func (t *OtelTracer) Extract(ctx context.Context, carrier aws_extended_sqsiface.TextMapCarrierInterface) context.Context {
+	return t.propagator.Extract(ctx, carrier)
+}
+
+func (t *OtelTracer) Fields() []string {
+	return t.propagator.Fields()
+}
+
+func (s *OtelSpan) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s *OtelSpan) End() {
+	s.span.End()
+}
+
+func getSpanKind(kind string) trace.SpanKind {
+	switch kind {
+	case tracing_constants.SPAN_KIND_PRODUCER:
+		return trace.SpanKindProducer
+	case tracing_constants.SPAN_KIND_CONSUMER:
+		return trace.SpanKindConsumer
+	case tracing_constants.SPAN_KIND_CLIENT:
+		return trace.SpanKindClient
+	default:
+		return trace.SpanKindInternal
+	}
+}
@@ -0,0 +1,62 @@
+package otel_tracer
+
+import (
+	"context"
+	std_errors "errors"
+	"testing"
+
+	"github.com/shoplineapp/aws-sqs-golang-extended-client-lib/services/aws_extended_sqs_client"
+	tracing_constants "github.com/shoplineapp/aws-sqs-golang-extended-client-lib/tracing/constants"
+
+	"github.com/aws/aws-sdk-go/aws"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func Test_OtelTracer_StartSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := NewOtelTracer(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	ctx, parent := tracer.StartSpan(context.Background(), tracing_constants.SPAN_SEND_MESSAGE, tracing_constants.SPAN_KIND_PRODUCER, map[string]string{
+		tracing_constants.ATTRIBUTE_MESSAGING_DESTINATION: "test-queue",
+	})
+	_, child := tracer.StartSpan(ctx, tracing_constants.SPAN_S3_STORE, tracing_constants.SPAN_KIND_CLIENT, nil)
+	child.RecordError(std_errors.New("test error"))
+	child.End()
+	parent.End()
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+
+	assert.Equal(t, tracing_constants.SPAN_S3_STORE, spans[0].Name())
+	assert.Equal(t, trace.SpanKindClient, spans[0].SpanKind())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+
+	assert.Equal(t, tracing_constants.SPAN_SEND_MESSAGE, spans[1].Name())
+	assert.Equal(t, trace.SpanKindProducer, spans[1].SpanKind())
+	assert.Contains(t, spans[1].Attributes(), attribute.String(tracing_constants.ATTRIBUTE_MESSAGING_DESTINATION, "test-queue"))
+}
+
+func Test_OtelTracer_Inject_Extract_Message_Attributes(t *testing.T) {
+	tracer := NewOtelTracer(sdktrace.NewTracerProvider())
+
+	ctx, span := tracer.StartSpan(context.Background(), tracing_constants.SPAN_SEND_MESSAGE, tracing_constants.SPAN_KIND_PRODUCER, nil)
+	defer span.End()
+
+	carrier := aws_extended_sqs_client.MessageAttributeCarrier{}
+	tracer.Inject(ctx, carrier)
+
+	assert.Equal(t, []string{"traceparent", "tracestate"}, tracer.Fields())
+	assert.Equal(t, "String", aws.StringValue(carrier["traceparent"].DataType))
+
+	extractedCtx := tracer.Extract(context.Background(), carrier)
+
+	assert.Equal(t, trace.SpanContextFromContext(ctx).TraceID(), trace.SpanContextFromContext(extractedCtx).TraceID())
+	assert.True(t, trace.SpanContextFromContext(extractedCtx).IsRemote())
+}